@@ -0,0 +1,251 @@
+// Package archive provides a Gournal Appender that accumulates entries in
+// gzip-compressed, newline-delimited JSON segments and uploads each segment
+// to object storage, such as S3, GCS, or Azure Blob Storage, once a size or
+// time threshold is reached. This provides inexpensive long-term retention
+// for programs that do not ship their logs through a log pipeline.
+//
+// This package does not import any object storage SDK. Instead, callers
+// provide an Uploader, for example:
+//
+//	archive.UploaderFunc(func(
+//		ctx context.Context, key string, r *bytes.Reader) error {
+//
+//		_, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+//			Bucket: aws.String("logs"),
+//			Key:    aws.String(key),
+//			Body:   r,
+//		})
+//		return err
+//	})
+//
+// Segments contain gournal.Record objects that may be decoded with
+// replay.NewJSONDecoder after they are decompressed.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/replay"
+)
+
+var (
+	// SegmentSize is the number of uncompressed bytes after which the
+	// segment used by an Appender returned by New is uploaded.
+	SegmentSize = 8 * 1024 * 1024
+
+	// Interval is the amount of time after which the segment used by an
+	// Appender returned by New is uploaded.
+	Interval = 5 * time.Minute
+
+	// KeyTemplate is the text/template used by an Appender returned by New
+	// to create the object key of a segment from a KeyData.
+	KeyTemplate = `{{.Time.Format "2006/01/02"}}/{{.Host}}/` +
+		`{{.Time.Format "150405"}}-{{.Seq}}.ndjson.gz`
+)
+
+// Uploader uploads an object with the provided key and contents. The
+// contents are provided as a *bytes.Reader since some object storage SDKs
+// require an io.ReadSeeker or the length of the contents.
+type Uploader interface {
+	Upload(ctx context.Context, key string, r *bytes.Reader) error
+}
+
+// UploaderFunc is a function that implements the Uploader interface.
+type UploaderFunc func(ctx context.Context, key string, r *bytes.Reader) error
+
+// Upload calls the function.
+func (f UploaderFunc) Upload(
+	ctx context.Context, key string, r *bytes.Reader) error {
+
+	return f(ctx, key, r)
+}
+
+// KeyData is the data with which an object key is created.
+type KeyData struct {
+
+	// Time is the UTC time of the first entry in the segment.
+	Time time.Time
+
+	// Host is the name of the host reported by os.Hostname.
+	Host string
+
+	// Seq is the segment's sequence number, which starts at zero for each
+	// Appender.
+	Seq uint64
+}
+
+// Appender is an archiving Appender.
+type Appender struct {
+	u    Uploader
+	key  *template.Template
+	host string
+	size int
+
+	// mu protects the current segment
+	mu  sync.Mutex
+	seg *segment
+	seq uint64
+
+	// uploading serializes uploads so segments are uploaded in order
+	uploading sync.Mutex
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+type segment struct {
+	buf   bytes.Buffer
+	gz    *gzip.Writer
+	rec   gournal.Appender
+	start time.Time
+	size  int
+	n     int
+}
+
+func (s *segment) Write(p []byte) (int, error) {
+	s.size += len(p)
+	return s.gz.Write(p)
+}
+
+// New returns an Appender that uploads segments with u using SegmentSize,
+// Interval, and KeyTemplate.
+func New(u Uploader) (*Appender, error) {
+	return NewWithOptions(u, SegmentSize, Interval, KeyTemplate)
+}
+
+// NewWithOptions returns an Appender that uploads a segment with u once it
+// contains size uncompressed bytes or, if the interval is greater than
+// zero, each time the interval elapses. The object key of each segment is
+// created by executing the provided text/template with a KeyData. An error
+// is returned if the template cannot be parsed.
+//
+// A segment that fails to upload is reported with gournal.HandleError and
+// its entries are counted with gournal.RecordDropped. FATAL and PANIC
+// entries are uploaded immediately, along with the rest of their segment,
+// before the program exits or panics.
+func NewWithOptions(
+	u Uploader,
+	size int,
+	interval time.Duration,
+	keyTemplate string) (*Appender, error) {
+
+	key, err := template.New("key").Parse(keyTemplate)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+
+	a := &Appender{
+		u:    u,
+		key:  key,
+		host: host,
+		size: size,
+		done: make(chan struct{}),
+	}
+	if interval > 0 {
+		a.wg.Add(1)
+		go a.tick(interval)
+	}
+	return a, nil
+}
+
+func (a *Appender) tick(interval time.Duration) {
+	defer a.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := a.Flush(); err != nil {
+				gournal.HandleError(err)
+			}
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// Append adds the entry to the current segment.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.mu.Lock()
+	if a.seg == nil {
+		a.seg = &segment{start: gournal.TimeFrom(ctx).UTC()}
+		a.seg.gz = gzip.NewWriter(&a.seg.buf)
+		a.seg.rec = replay.NewRecorder(a.seg)
+	}
+	a.seg.rec.Append(ctx, lvl, fields, msg)
+	a.seg.n++
+	full := a.seg.size >= a.size
+	a.mu.Unlock()
+
+	if full || lvl <= gournal.FatalLevel {
+		if err := a.Flush(); err != nil {
+			gournal.HandleError(err)
+		}
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+// Flush uploads the current segment, if any.
+func (a *Appender) Flush() error {
+	a.uploading.Lock()
+	defer a.uploading.Unlock()
+
+	a.mu.Lock()
+	seg, seq := a.seg, a.seq
+	if seg == nil {
+		a.mu.Unlock()
+		return nil
+	}
+	a.seg = nil
+	a.seq++
+	a.mu.Unlock()
+
+	if err := a.upload(seg, seq); err != nil {
+		gournal.RecordDropped(seg.n)
+		return err
+	}
+	return nil
+}
+
+func (a *Appender) upload(seg *segment, seq uint64) error {
+	if err := seg.gz.Close(); err != nil {
+		return err
+	}
+	key := &bytes.Buffer{}
+	if err := a.key.Execute(
+		key, KeyData{Time: seg.start, Host: a.host, Seq: seq}); err != nil {
+		return err
+	}
+	return a.u.Upload(
+		context.Background(), key.String(), bytes.NewReader(seg.buf.Bytes()))
+}
+
+// Close stops the Appender's interval, if any, and uploads the current
+// segment.
+func (a *Appender) Close() error {
+	a.once.Do(func() {
+		close(a.done)
+		a.wg.Wait()
+	})
+	return a.Flush()
+}
@@ -0,0 +1,141 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/replay"
+)
+
+type object struct {
+	key  string
+	recs []*gournal.Record
+}
+
+type testUploader struct {
+	sync.Mutex
+	objects []object
+	err     error
+}
+
+func (u *testUploader) Upload(
+	ctx context.Context, key string, r *bytes.Reader) error {
+
+	if u.err != nil {
+		return u.err
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	d := replay.NewJSONDecoder(gz)
+	o := object{key: key}
+	for {
+		rec, err := d.Decode()
+		if err != nil {
+			break
+		}
+		o.recs = append(o.recs, rec)
+	}
+	u.Lock()
+	u.objects = append(u.objects, o)
+	u.Unlock()
+	return nil
+}
+
+func (u *testUploader) len() int {
+	u.Lock()
+	defer u.Unlock()
+	return len(u.objects)
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(),
+		time.Date(2017, 10, 1, 12, 30, 15, 0, time.UTC))
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestArchiveAppenderSize(t *testing.T) {
+	u := &testUploader{}
+	a, err := NewWithOptions(u, 200, 0, KeyTemplate)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+
+	gournal.WithField("size", 1).Info(ctx, "Hello %s", "Bob")
+	assert.Equal(t, 0, u.len())
+	gournal.Info(ctx, "Hello Alice")
+	gournal.Info(ctx, "Hello Mary")
+	assert.Equal(t, 1, u.len())
+	gournal.Info(ctx, "Hello Kim")
+	assert.NoError(t, a.Close())
+
+	host, _ := os.Hostname()
+	if assert.Len(t, u.objects, 2) {
+		assert.Equal(
+			t, "2017/10/01/"+host+"/123015-0.ndjson.gz", u.objects[0].key)
+		assert.Equal(
+			t, "2017/10/01/"+host+"/123015-1.ndjson.gz", u.objects[1].key)
+		if assert.Len(t, u.objects[0].recs, 3) {
+			rec := u.objects[0].recs[0]
+			assert.Equal(t, "Hello Bob", rec.Message)
+			assert.Equal(t, json.Number("1"), rec.Fields["size"])
+		}
+		if assert.Len(t, u.objects[1].recs, 1) {
+			assert.Equal(t, "Hello Kim", u.objects[1].recs[0].Message)
+		}
+	}
+}
+
+func TestArchiveAppenderInterval(t *testing.T) {
+	u := &testUploader{}
+	a, err := NewWithOptions(
+		u, SegmentSize, 10*time.Millisecond, "{{.Seq}}")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+
+	gournal.Info(newContext(a), "Hello Bob")
+	for i := 0; i < 100 && u.len() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if assert.Equal(t, 1, u.len()) {
+		assert.Equal(t, "0", u.objects[0].key)
+	}
+}
+
+func TestArchiveAppenderUploadError(t *testing.T) {
+	u := &testUploader{err: errors.New("denied")}
+	a, err := NewWithOptions(u, SegmentSize, 0, "{{.Seq}}")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	gournal.Info(ctx, "Hello Alice")
+
+	dropped := gournal.DroppedCount()
+	assert.EqualError(t, a.Close(), "denied")
+	assert.Equal(
+		t, dropped+2, gournal.DroppedCount())
+}
+
+func TestArchiveAppenderBadTemplate(t *testing.T) {
+	_, err := NewWithOptions(&testUploader{}, SegmentSize, 0, "{{.Seq")
+	assert.Error(t, err)
+}
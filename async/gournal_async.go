@@ -0,0 +1,56 @@
+// Package async provides a Gournal Appender that delivers entries to
+// another Appender from a pool of worker goroutines so slow backends do not
+// block the goroutines that emit entries.
+//
+// Entries are delivered with a Context created by gournal.WithTime, so
+// Appenders that use gournal.TimeFrom record when an entry was appended
+// rather than when a worker delivered it.
+//
+// The returned Appenders must be closed with Close, or drained with Flush,
+// before a program exits to ensure queued entries are delivered.
+package async
+
+import "github.com/akutz/gournal"
+
+// The overflow policies determine what happens when an entry is appended
+// to a full queue.
+const (
+	// DropNewest drops the entry being appended.
+	DropNewest = gournal.OverflowDropNewest
+
+	// DropOldest drops the oldest queued entry to make room for the entry
+	// being appended.
+	DropOldest = gournal.OverflowDropOldest
+
+	// Block blocks the caller until there is room in the queue.
+	Block = gournal.OverflowBlock
+)
+
+var (
+	// QueueSize is the number of entries that may be queued by an Appender
+	// returned by New.
+	QueueSize = 1024
+
+	// Workers is the number of workers used by an Appender returned by New.
+	Workers = 1
+
+	// Overflow is the overflow policy used by an Appender returned by New.
+	Overflow = DropNewest
+)
+
+// New returns an asynchronous Appender that delivers entries to next using
+// QueueSize, Workers, and Overflow.
+func New(next gournal.Appender) *gournal.AsyncAppender {
+	return NewWithOptions(next, QueueSize, Workers, Overflow)
+}
+
+// NewWithOptions returns an asynchronous Appender that delivers entries to
+// next with a queue of the provided size, the provided number of workers,
+// and the provided overflow policy.
+func NewWithOptions(
+	next gournal.Appender,
+	size, workers int,
+	overflow gournal.OverflowPolicy) *gournal.AsyncAppender {
+
+	return gournal.NewAsyncAppenderWithOptions(next, size, workers, overflow)
+}
@@ -0,0 +1,129 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type gatedAppender struct {
+	gate    chan struct{}
+	started chan struct{}
+	sync.Mutex
+	msgs  []string
+	times []time.Time
+}
+
+func (a *gatedAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+	select {
+	case a.started <- struct{}{}:
+	default:
+	}
+	<-a.gate
+	a.Lock()
+	a.msgs = append(a.msgs, msg)
+	a.times = append(a.times, gournal.TimeFrom(ctx))
+	a.Unlock()
+}
+
+func runOverflowTest(
+	t *testing.T, overflow gournal.OverflowPolicy, exp ...string) {
+
+	next := &gatedAppender{
+		gate:    make(chan struct{}),
+		started: make(chan struct{}, 1),
+	}
+	a := NewWithOptions(next, 2, 1, overflow)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	// wait for the worker to take the first entry off of the queue
+	gournal.Error(ctx, "one")
+	<-next.started
+
+	gournal.Error(ctx, "two")
+	gournal.Error(ctx, "three")
+	if overflow == Block {
+		go func() {
+			time.Sleep(time.Millisecond * 10)
+			close(next.gate)
+		}()
+	}
+	gournal.Error(ctx, "four")
+	if overflow != Block {
+		close(next.gate)
+	}
+
+	assert.NoError(t, a.Close())
+	assert.Equal(t, exp, next.msgs)
+}
+
+func TestDropNewest(t *testing.T) {
+	runOverflowTest(t, DropNewest, "one", "two", "three")
+}
+
+func TestDropOldest(t *testing.T) {
+	runOverflowTest(t, DropOldest, "one", "three", "four")
+}
+
+func TestBlock(t *testing.T) {
+	runOverflowTest(t, Block, "one", "two", "three", "four")
+}
+
+func TestNew(t *testing.T) {
+	next := &gatedAppender{gate: make(chan struct{})}
+	close(next.gate)
+	a := New(next)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	gournal.Error(ctx, "one")
+	a.Flush()
+	assert.Equal(t, []string{"one"}, next.msgs)
+	assert.NoError(t, a.Close())
+}
+
+func TestTime(t *testing.T) {
+	defer func(c func() time.Time) { gournal.Clock = c }(gournal.Clock)
+	var clockMu sync.Mutex
+	now := time.Unix(0, 0).UTC()
+	gournal.Clock = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return now
+	}
+
+	for _, overflow := range []gournal.OverflowPolicy{
+		DropNewest, DropOldest, Block} {
+
+		// the worker is delayed until after the clock moves, so entries
+		// are delivered with the time they were appended
+		next := &gatedAppender{
+			gate:    make(chan struct{}),
+			started: make(chan struct{}, 1),
+		}
+		a := NewWithOptions(next, 2, 1, overflow)
+		ctx := context.WithValue(
+			context.Background(), gournal.AppenderKey(), a)
+
+		clockMu.Lock()
+		then := now
+		clockMu.Unlock()
+		gournal.Error(ctx, "one")
+		gournal.Error(ctx, "two")
+
+		clockMu.Lock()
+		now = now.Add(time.Hour)
+		clockMu.Unlock()
+		close(next.gate)
+
+		assert.NoError(t, a.Close())
+		assert.Equal(t, []time.Time{then, then}, next.times)
+	}
+}
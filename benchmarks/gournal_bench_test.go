@@ -8,37 +8,19 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"github.com/uber-go/zap"
-	//gaetest "google.golang.org/appengine/aetest"
-	//gae "google.golang.org/appengine/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/akutz/gournal"
-	//ggae "github.com/akutz/gournal/gae"
+	ggae "github.com/akutz/gournal/gae"
 	glogrus "github.com/akutz/gournal/logrus"
 	glog "github.com/akutz/gournal/stdlib"
 	gzap "github.com/akutz/gournal/zap"
 )
 
-var gaeCtx context.Context
-
 func TestMain(m *testing.M) {
 	gournal.DefaultLevel = gournal.DebugLevel
-
-	/*var (
-		err  error
-		done func()
-	)
-
-	if gaeCtx, done, err = gaetest.NewContext(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}*/
-	gaeCtx = context.Background()
-
-	ec := m.Run()
-
-	//done()
-	os.Exit(ec)
+	os.Exit(m.Run())
 }
 
 func BenchmarkNativeStdLibWithoutFields(b *testing.B) {
@@ -66,7 +48,10 @@ func BenchmarkNativeLogrusWithoutFields(b *testing.B) {
 }
 
 func BenchmarkNativeZapWithoutFields(b *testing.B) {
-	l := zap.New(zap.NewJSONEncoder(), zap.Output(os.Stderr))
+	l := zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(os.Stderr),
+		zapcore.DebugLevel))
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
 		for pb.Next() {
@@ -75,15 +60,6 @@ func BenchmarkNativeZapWithoutFields(b *testing.B) {
 	})
 }
 
-/*func BenchmarkNativeGAEWithoutFields(b *testing.B) {
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		for pb.Next() {
-			gae.Infof(gaeCtx, "Run Barry, run.")
-		}
-	})
-}*/
-
 func BenchmarkGournalStdLibWithoutFields(b *testing.B) {
 	benchmarkWithoutFields(
 		b, glog.NewWithOptions(os.Stderr, "", log.LstdFlags))
@@ -96,12 +72,13 @@ func BenchmarkGournalLogrusWithoutFields(b *testing.B) {
 
 func BenchmarkGournalZapWithoutFields(b *testing.B) {
 	benchmarkWithoutFields(b, gzap.NewWithOptions(
-		zap.NewJSONEncoder(), zap.Output(os.Stderr)))
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		os.Stderr))
 }
 
-/*func BenchmarkGournalGAEWithoutFields(b *testing.B) {
-	benchmarkWithoutFields(b, ggae.New())
-}*/
+func BenchmarkGournalGAEWithoutFields(b *testing.B) {
+	benchmarkWithoutFields(b, ggae.NewWithOptions(os.Stderr, ""))
+}
 
 func BenchmarkGournalStdLibWithFields(b *testing.B) {
 	benchmarkWithFields(
@@ -115,20 +92,15 @@ func BenchmarkGournalLogrusWithFields(b *testing.B) {
 
 func BenchmarkGournalZapWithFields(b *testing.B) {
 	benchmarkWithFields(b, gzap.NewWithOptions(
-		zap.NewJSONEncoder(), zap.Output(os.Stderr)))
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		os.Stderr))
 }
 
-/*func BenchmarkGournalGAEWithFields(b *testing.B) {
-	benchmarkWithFields(b, ggae.New())
-}*/
+func BenchmarkGournalGAEWithFields(b *testing.B) {
+	benchmarkWithFields(b, ggae.NewWithOptions(os.Stderr, ""))
+}
 
 func newContext(a gournal.Appender) context.Context {
-	/*var ctx context.Context
-	if a == ggae.New() {
-		ctx = gaeCtx
-	} else {
-		ctx = context.Background()
-	}*/
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
 	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
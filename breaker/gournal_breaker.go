@@ -0,0 +1,163 @@
+// Package breaker provides a Gournal Appender that wraps another Appender
+// with a circuit breaker. Once the wrapped Appender fails to deliver a
+// number of consecutive entries, the circuit opens and entries are routed
+// to a fallback Appender, or dropped, until the wrapped Appender recovers.
+// This prevents a dead backend from consuming the goroutines that emit
+// entries.
+//
+// Delivery failures are detected with gournal.TryAppend, so only Appenders
+// that implement gournal.ErrorAppender trip the circuit.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// Threshold is the number of consecutive failures that open the circuit
+	// of an Appender returned by New.
+	Threshold = 5
+
+	// Cooldown is the amount of time the circuit of an Appender returned by
+	// New remains open before it is half-opened.
+	Cooldown = 30 * time.Second
+
+	// ErrOpen is the error returned by the TryAppend function of an
+	// Appender without a fallback when its circuit is open.
+	ErrOpen = errors.New("breaker: circuit is open")
+)
+
+// New returns an Appender that wraps next with a circuit breaker using
+// Threshold and Cooldown.
+func New(next, fallback gournal.Appender) gournal.Appender {
+	return NewWithOptions(next, fallback, Threshold, Cooldown)
+}
+
+// NewWithOptions returns an Appender that wraps next with a circuit breaker.
+// The circuit opens after threshold consecutive failures to deliver an
+// entry, each of which is reported with gournal.HandleError. While the
+// circuit is open, entries are delivered to the fallback Appender or, if it
+// is nil, dropped and counted with gournal.RecordDropped.
+//
+// Once the cooldown elapses the circuit is half-opened, and the next entry
+// is sent to next as a trial. If the trial succeeds the circuit is closed,
+// otherwise it opens again for another cooldown.
+//
+// The returned Appender also implements gournal.ErrorAppender so that
+// entries it is unable to deliver may be handled by another wrapper.
+func NewWithOptions(
+	next, fallback gournal.Appender,
+	threshold int,
+	cooldown time.Duration) gournal.Appender {
+
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &appender{
+		next:      next,
+		fallback:  fallback,
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+type state uint8
+
+const (
+	closed state = iota
+	open
+	halfOpen
+)
+
+type appender struct {
+	next      gournal.Appender
+	fallback  gournal.Appender
+	threshold int
+	cooldown  time.Duration
+
+	sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	switch err := a.TryAppend(ctx, lvl, fields, msg); err {
+	case nil:
+	case ErrOpen:
+		gournal.RecordDropped(1)
+	default:
+		gournal.HandleError(err)
+	}
+}
+
+// TryAppend is like Append, except an entry that is delivered to neither
+// the wrapped Appender nor a fallback Appender results in an error rather
+// than being reported. ErrOpen is returned if the circuit is open.
+func (a *appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	if !a.allow() {
+		if a.fallback == nil {
+			return ErrOpen
+		}
+		a.fallback.Append(ctx, lvl, fields, msg)
+		return nil
+	}
+
+	err := gournal.TryAppend(a.next, ctx, lvl, fields, msg)
+	a.record(err)
+	if err != nil && a.fallback != nil {
+		gournal.HandleError(err)
+		a.fallback.Append(ctx, lvl, fields, msg)
+		return nil
+	}
+	return err
+}
+
+// allow returns a flag indicating whether an entry may be sent to the
+// wrapped Appender. Only one trial entry is allowed while half-open.
+func (a *appender) allow() bool {
+	a.Lock()
+	defer a.Unlock()
+	switch a.state {
+	case open:
+		if time.Since(a.openedAt) < a.cooldown {
+			return false
+		}
+		a.state = halfOpen
+		return true
+	case halfOpen:
+		return false
+	}
+	return true
+}
+
+// record updates the state of the circuit with the result of an attempt.
+func (a *appender) record(err error) {
+	a.Lock()
+	defer a.Unlock()
+	if err == nil {
+		a.state = closed
+		a.failures = 0
+		return
+	}
+	a.failures++
+	if a.state == halfOpen || a.failures >= a.threshold {
+		a.state = open
+		a.openedAt = time.Now()
+	}
+}
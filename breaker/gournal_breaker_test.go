@@ -0,0 +1,82 @@
+package breaker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type failingWriter struct {
+	err error
+	buf bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.buf.Write(p)
+}
+
+func TestBreaker(t *testing.T) {
+	handled := 0
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { handled++ }
+
+	next := &failingWriter{err: errors.New("down")}
+	fallback := &bytes.Buffer{}
+	ctx := context.WithValue(
+		context.Background(),
+		gournal.AppenderKey(),
+		NewWithOptions(
+			gournal.NewAppenderWithOptions(next),
+			gournal.NewAppenderWithOptions(fallback),
+			2, time.Millisecond*50))
+
+	gournal.Error(ctx, "one")
+	gournal.Error(ctx, "two")
+	gournal.Error(ctx, "three")
+	assert.Equal(t, 2, handled)
+	assert.Equal(
+		t, "[ERROR] one\n[ERROR] two\n[ERROR] three\n", fallback.String())
+
+	// the circuit half-opens and the trial fails
+	time.Sleep(time.Millisecond * 60)
+	gournal.Error(ctx, "four")
+	assert.Equal(t, 3, handled)
+
+	// the circuit half-opens and the trial succeeds
+	next.err = nil
+	gournal.Error(ctx, "five")
+	time.Sleep(time.Millisecond * 60)
+	gournal.Error(ctx, "six")
+	gournal.Error(ctx, "seven")
+	assert.Equal(t, "[ERROR] six\n[ERROR] seven\n", next.buf.String())
+	assert.Equal(t, 3, handled)
+}
+
+func TestBreakerDrops(t *testing.T) {
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) {}
+
+	next := &failingWriter{err: errors.New("down")}
+	ctx := context.WithValue(
+		context.Background(),
+		gournal.AppenderKey(),
+		NewWithOptions(
+			gournal.NewAppenderWithOptions(next), nil, 1, time.Hour))
+
+	dropped := gournal.DroppedCount()
+	gournal.Error(ctx, "one")
+	gournal.Error(ctx, "two")
+	assert.Equal(
+		t, dropped+1, gournal.DroppedCount())
+}
@@ -0,0 +1,186 @@
+// Package buffered provides a Gournal Appender that formats entries like
+// the Appender returned by gournal.NewAppenderWithOptions but buffers the
+// formatted output, writing it to the underlying io.Writer when a byte
+// threshold is reached or an interval elapses. This amortizes the cost of
+// expensive writers such as network connections or bulk upload APIs.
+//
+// To buffer entries as Records rather than as formatted output, use
+// gournal.NewBatcher with a gournal.BatchAppender.
+//
+// The returned Appenders must be closed with Close, or drained with Flush,
+// before a program exits to ensure buffered entries are written.
+package buffered
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// DefaultSize is the byte threshold used by an Appender returned by
+	// New.
+	DefaultSize = 64 * 1024
+
+	// DefaultInterval is the flush interval used by an Appender returned by
+	// New.
+	DefaultInterval = time.Second
+
+	// DefaultMaxRecords is the record threshold used by an Appender
+	// returned by New. A threshold of zero disables it.
+	DefaultMaxRecords = 0
+)
+
+// Appender is a buffered Appender.
+type Appender struct {
+	w        *writer
+	buffered gournal.Appender
+	direct   gournal.Appender
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// New returns an Appender that writes to w using DefaultSize,
+// DefaultMaxRecords, and DefaultInterval.
+func New(w io.Writer) *Appender {
+	return NewWithMaxRecords(
+		w, DefaultSize, DefaultMaxRecords, DefaultInterval)
+}
+
+// NewWithOptions returns an Appender that buffers formatted entries and
+// writes them to w once size bytes are buffered or, if the interval is
+// greater than zero, each time the interval elapses. Entries are never
+// split across writes.
+//
+// FATAL and PANIC entries are written immediately, after any buffered
+// entries.
+func NewWithOptions(
+	w io.Writer, size int, interval time.Duration) *Appender {
+
+	return NewWithMaxRecords(w, size, 0, interval)
+}
+
+// NewWithMaxRecords returns an Appender like one returned by
+// NewWithOptions that also writes the buffered entries once maxRecords
+// entries are buffered, so latency is bounded when entries are small. A
+// maxRecords of zero disables the record threshold.
+func NewWithMaxRecords(
+	w io.Writer,
+	size, maxRecords int,
+	interval time.Duration) *Appender {
+
+	bw := &writer{w: w, size: size, maxRecords: maxRecords}
+	a := &Appender{
+		w:        bw,
+		buffered: gournal.NewAppenderWithOptions(bw),
+		direct:   gournal.NewAppenderWithOptions(w),
+		done:     make(chan struct{}),
+	}
+	if interval > 0 {
+		a.wg.Add(1)
+		go a.tick(interval)
+	}
+	return a
+}
+
+func (a *Appender) tick(interval time.Duration) {
+	defer a.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			a.Flush()
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// Append buffers the formatted entry.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if lvl <= gournal.FatalLevel {
+		// the lock is held so buffered entries are not written between
+		// the flushed entries and this one
+		a.w.Lock()
+		defer a.w.Unlock()
+		if err := a.w.flushLocked(); err != nil {
+			gournal.HandleError(err)
+		}
+		a.direct.Append(ctx, lvl, fields, msg)
+		return
+	}
+	a.buffered.Append(ctx, lvl, fields, msg)
+}
+
+// Flush writes any buffered entries. Errors are reported with
+// gournal.HandleError.
+func (a *Appender) Flush() {
+	if err := a.w.flush(); err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+// Close stops the Appender's interval, if any, and writes any buffered
+// entries.
+func (a *Appender) Close() error {
+	a.once.Do(func() {
+		close(a.done)
+		a.wg.Wait()
+	})
+	return a.w.flush()
+}
+
+// writer buffers whole entries, each of which is written to it with a
+// single call to Write.
+type writer struct {
+	sync.Mutex
+	w          io.Writer
+	size       int
+	maxRecords int
+	buf        bytes.Buffer
+
+	// records is the number of buffered entries
+	records int
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	w.buf.Write(p)
+	w.records++
+	if w.buf.Len() >= w.size ||
+		(w.maxRecords > 0 && w.records >= w.maxRecords) {
+		if err := w.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *writer) flush() error {
+	w.Lock()
+	defer w.Unlock()
+	return w.flushLocked()
+}
+
+func (w *writer) flushLocked() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.buf.Bytes())
+	w.buf.Reset()
+	w.records = 0
+	return err
+}
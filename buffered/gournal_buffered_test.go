@@ -0,0 +1,95 @@
+package buffered
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type countingWriter struct {
+	sync.Mutex
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func (w *countingWriter) String() string {
+	w.Lock()
+	defer w.Unlock()
+	return w.Buffer.String()
+}
+
+func TestSize(t *testing.T) {
+	w := &countingWriter{}
+	a := NewWithOptions(w, 20, 0)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	gournal.Error(ctx, "one")
+	assert.Equal(t, "", w.String())
+	gournal.Error(ctx, "two")
+	assert.Equal(t, "[ERROR] one\n[ERROR] two\n", w.String())
+	assert.Equal(t, 1, w.writes)
+
+	gournal.Error(ctx, "three")
+	assert.NoError(t, a.Close())
+	assert.Equal(t, "[ERROR] one\n[ERROR] two\n[ERROR] three\n", w.String())
+	assert.Equal(t, 2, w.writes)
+}
+
+func TestMaxRecords(t *testing.T) {
+	w := &countingWriter{}
+	a := NewWithMaxRecords(w, 1024, 2, 0)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	gournal.Error(ctx, "one")
+	assert.Equal(t, "", w.String())
+	gournal.Error(ctx, "two")
+	assert.Equal(t, "[ERROR] one\n[ERROR] two\n", w.String())
+	assert.Equal(t, 1, w.writes)
+
+	gournal.Error(ctx, "three")
+	assert.Equal(t, 1, w.writes)
+	gournal.Error(ctx, "four")
+	assert.Equal(t, 2, w.writes)
+	assert.NoError(t, a.Close())
+	assert.Equal(t, 2, w.writes)
+}
+
+func TestInterval(t *testing.T) {
+	w := &countingWriter{}
+	a := NewWithOptions(w, 1024, time.Millisecond)
+	defer a.Close()
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	gournal.Error(ctx, "one")
+	for i := 0; i < 1000 && w.String() == ""; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, "[ERROR] one\n", w.String())
+}
+
+func TestPanicIsWrittenImmediately(t *testing.T) {
+	w := &countingWriter{}
+	a := New(w)
+	defer a.Close()
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	gournal.Error(ctx, "one")
+	func() {
+		defer func() { recover() }()
+		gournal.Panic(ctx, "two")
+	}()
+	assert.Equal(t, "[ERROR] one\n[PANIC] two\n", w.String())
+}
@@ -0,0 +1,218 @@
+// Package cbor provides a Gournal Formatter that encodes entries as CBOR
+// (RFC 7049) maps, and a Decoder that reads them back. CBOR records are
+// smaller and cheaper to produce than JSON records, which makes them
+// well-suited for high-volume agents that ship entries over the network or
+// store them in files.
+//
+// A record is a map with the same keys as a JSON Record: "time", "level",
+// "msg", and "fields". The time is encoded as an RFC 3339 date/time string
+// with tag 0 and the level as its name. Field values that are not
+// booleans, numbers, strings, byte slices, times, slices, or maps are
+// converted to the value of their JSON representation, and values that
+// cannot be marshaled to JSON are written as strings.
+//
+// Records are self-delimiting, so a stream of them may be written without
+// framing, for example with the socket package's Raw framing, and decoded
+// with a Decoder, which also implements the replay package's Decoder
+// interface.
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// major types
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// Formatter is a CBOR Formatter.
+type Formatter struct{}
+
+// Format writes the Record to the buffer.
+func (Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	n := 2
+	if !rec.Time.IsZero() {
+		n++
+	}
+	if len(rec.Fields) > 0 {
+		n++
+	}
+	writeHeader(buf, majorMap, uint64(n))
+	if !rec.Time.IsZero() {
+		writeString(buf, "time")
+		writeTime(buf, rec.Time)
+	}
+	writeString(buf, "level")
+	writeString(buf, rec.Level.String())
+	writeString(buf, "msg")
+	writeString(buf, rec.Message)
+	if len(rec.Fields) > 0 {
+		writeString(buf, "fields")
+		writeHeader(buf, majorMap, uint64(len(rec.Fields)))
+		for _, k := range gournal.SortKeys(rec.Fields) {
+			writeString(buf, k)
+			writeValue(buf, rec.Fields[k])
+		}
+	}
+	return nil
+}
+
+func writeValue(buf *bytes.Buffer, v interface{}) {
+	switch tv := v.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+	case bool:
+		if tv {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+	case int:
+		writeInt(buf, int64(tv))
+	case int8:
+		writeInt(buf, int64(tv))
+	case int16:
+		writeInt(buf, int64(tv))
+	case int32:
+		writeInt(buf, int64(tv))
+	case int64:
+		writeInt(buf, tv)
+	case uint:
+		writeHeader(buf, majorUint, uint64(tv))
+	case uint8:
+		writeHeader(buf, majorUint, uint64(tv))
+	case uint16:
+		writeHeader(buf, majorUint, uint64(tv))
+	case uint32:
+		writeHeader(buf, majorUint, uint64(tv))
+	case uint64:
+		writeHeader(buf, majorUint, tv)
+	case float32:
+		buf.WriteByte(0xfa)
+		writeBE(buf, uint64(math.Float32bits(tv)), 4)
+	case float64:
+		buf.WriteByte(0xfb)
+		writeBE(buf, math.Float64bits(tv), 8)
+	case string:
+		writeString(buf, tv)
+	case []byte:
+		writeHeader(buf, majorBytes, uint64(len(tv)))
+		buf.Write(tv)
+	case time.Time:
+		writeTime(buf, tv)
+	case error:
+		writeString(buf, tv.Error())
+	case []interface{}:
+		writeHeader(buf, majorArray, uint64(len(tv)))
+		for _, e := range tv {
+			writeValue(buf, e)
+		}
+	case map[string]interface{}:
+		writeHeader(buf, majorMap, uint64(len(tv)))
+		for _, k := range gournal.SortKeys(tv) {
+			writeString(buf, k)
+			writeValue(buf, tv[k])
+		}
+	default:
+		writeValue(buf, jsonValue(v))
+	}
+}
+
+// jsonValue returns the value of the JSON representation of v, or the
+// string representation of v if it cannot be marshaled to JSON.
+func jsonValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var jv interface{}
+	if err := d.Decode(&jv); err != nil {
+		return fmt.Sprint(v)
+	}
+	return jsonNumbers(jv)
+}
+
+// jsonNumbers replaces the json.Number values in v with integers or
+// floats.
+func jsonNumbers(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case json.Number:
+		if i, err := tv.Int64(); err == nil {
+			return i
+		}
+		f, _ := tv.Float64()
+		return f
+	case []interface{}:
+		for i, e := range tv {
+			tv[i] = jsonNumbers(e)
+		}
+	case map[string]interface{}:
+		for k, e := range tv {
+			tv[k] = jsonNumbers(e)
+		}
+	}
+	return v
+}
+
+func writeInt(buf *bytes.Buffer, i int64) {
+	if i >= 0 {
+		writeHeader(buf, majorUint, uint64(i))
+		return
+	}
+	writeHeader(buf, majorNegInt, uint64(^i))
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeHeader(buf, majorText, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+// writeTime writes the time as a standard date/time string.
+func writeTime(buf *bytes.Buffer, t time.Time) {
+	writeHeader(buf, majorTag, 0)
+	writeString(buf, t.Format(time.RFC3339Nano))
+}
+
+// writeHeader writes the initial byte of a data item of the major type with
+// the argument n, followed by n if it does not fit in the initial byte.
+func writeHeader(buf *bytes.Buffer, major byte, n uint64) {
+	switch {
+	case n < 24:
+		buf.WriteByte(major<<5 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(major<<5 | 24)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(major<<5 | 25)
+		writeBE(buf, n, 2)
+	case n <= math.MaxUint32:
+		buf.WriteByte(major<<5 | 26)
+		writeBE(buf, n, 4)
+	default:
+		buf.WriteByte(major<<5 | 27)
+		writeBE(buf, n, 8)
+	}
+}
+
+func writeBE(buf *bytes.Buffer, u uint64, n int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], u)
+	buf.Write(b[8-n:])
+}
@@ -0,0 +1,314 @@
+package cbor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// ErrInvalid is returned by a Decoder when the stream does not contain a
+// valid CBOR record.
+var ErrInvalid = errors.New("cbor: invalid record")
+
+// errBreak is returned by decode when it reads the break stop code that
+// ends an indefinite-length item.
+var errBreak = errors.New("cbor: unexpected break")
+
+// Decoder decodes Records written by a Formatter from a stream.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder returns a Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode returns the next Record from the stream or io.EOF when there are
+// no more Records. Integer field values are decoded as int64 or uint64
+// values, maps as map[string]interface{} values, arrays as []interface{}
+// values, and date/times as time.Time values.
+func (d *Decoder) Decode() (*gournal.Record, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	v, err := decode(d.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == errBreak {
+		err = ErrInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalid
+	}
+
+	rec := &gournal.Record{}
+	if rec.Time, ok = m["time"].(time.Time); !ok && m["time"] != nil {
+		return nil, ErrInvalid
+	}
+	lvl, _ := m["level"].(string)
+	rec.Level = gournal.ParseLevel(lvl)
+	rec.Message, _ = m["msg"].(string)
+	if f, ok := m["fields"]; ok {
+		if rec.Fields, ok = f.(map[string]interface{}); !ok {
+			return nil, ErrInvalid
+		}
+	}
+	return rec, nil
+}
+
+// decode reads a CBOR data item.
+func decode(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	major, info := c>>5, c&0x1f
+
+	if major == majorSimple {
+		return decodeSimple(r, info)
+	}
+	if info == 31 {
+		return decodeIndefinite(r, major)
+	}
+	n, err := readArg(r, info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case majorUint:
+		if n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+		return n, nil
+	case majorNegInt:
+		if n <= math.MaxInt64 {
+			return int64(^n), nil
+		}
+		return -1 - float64(n), nil
+	case majorBytes:
+		return readN(r, n)
+	case majorText:
+		buf, err := readN(r, n)
+		return string(buf), err
+	case majorArray:
+		a := make([]interface{}, 0, capacity(n))
+		for i := uint64(0); i < n; i++ {
+			v, err := decode(r)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case majorMap:
+		m := make(map[string]interface{}, capacity(n))
+		for i := uint64(0); i < n; i++ {
+			if err := decodePair(r, m); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+
+	// tags other than standard and epoch date/times are ignored
+	v, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n {
+	case 0:
+		s, ok := v.(string)
+		if !ok {
+			return nil, ErrInvalid
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, ErrInvalid
+		}
+		return t, nil
+	case 1:
+		switch tv := v.(type) {
+		case int64:
+			return time.Unix(tv, 0).UTC(), nil
+		case float64:
+			sec, frac := math.Modf(tv)
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+		}
+		return nil, ErrInvalid
+	}
+	return v, nil
+}
+
+// decodeSimple decodes a simple value or float with the additional
+// information.
+func decodeSimple(r *bufio.Reader, info byte) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		u, err := readUint(r, 2)
+		return halfFloat(uint16(u)), err
+	case 26:
+		u, err := readUint(r, 4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 27:
+		u, err := readUint(r, 8)
+		return math.Float64frombits(u), err
+	case 31:
+		return nil, errBreak
+	}
+	return nil, ErrInvalid
+}
+
+// decodeIndefinite decodes an indefinite-length item of the major type.
+func decodeIndefinite(r *bufio.Reader, major byte) (interface{}, error) {
+	switch major {
+	case majorBytes, majorText:
+		var buf []byte
+		for {
+			v, err := decode(r)
+			if err == errBreak {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			switch tv := v.(type) {
+			case []byte:
+				buf = append(buf, tv...)
+			case string:
+				buf = append(buf, tv...)
+			default:
+				return nil, ErrInvalid
+			}
+		}
+		if major == majorText {
+			return string(buf), nil
+		}
+		return buf, nil
+	case majorArray:
+		a := []interface{}{}
+		for {
+			v, err := decode(r)
+			if err == errBreak {
+				return a, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	case majorMap:
+		m := map[string]interface{}{}
+		for {
+			if err := decodePair(r, m); err == errBreak {
+				return m, nil
+			} else if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, ErrInvalid
+}
+
+// decodePair decodes a key/value pair into the map. Keys that are not
+// strings are converted to their string representation.
+func decodePair(r *bufio.Reader, m map[string]interface{}) error {
+	k, err := decode(r)
+	if err != nil {
+		return err
+	}
+	ks, ok := k.(string)
+	if !ok {
+		ks = fmt.Sprint(k)
+	}
+	v, err := decode(r)
+	if err == errBreak {
+		return ErrInvalid
+	}
+	if err != nil {
+		return err
+	}
+	m[ks] = v
+	return nil
+}
+
+// readArg reads the argument of a data item with the additional
+// information.
+func readArg(r *bufio.Reader, info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return readUint(r, 1<<(info-24))
+	}
+	return 0, ErrInvalid
+}
+
+func readUint(r *bufio.Reader, size int) (uint64, error) {
+	buf, err := readN(r, uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, b := range buf {
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+func readN(r *bufio.Reader, n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, ErrInvalid
+	}
+	buf := make([]byte, n)
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+// capacity returns the capacity with which to allocate an item with n
+// elements, limiting it so a malformed length does not exhaust memory.
+func capacity(n uint64) int {
+	if n > 1024 {
+		return 1024
+	}
+	return int(n)
+}
+
+// halfFloat returns the value of an IEEE 754 half-precision float.
+func halfFloat(h uint16) float64 {
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
@@ -0,0 +1,182 @@
+package cbor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/channel"
+	"github.com/akutz/gournal/replay"
+)
+
+func TestFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, Formatter{}.Format(buf, &gournal.Record{
+		Time:    time.Unix(1, 0).UTC(),
+		Level:   gournal.InfoLevel,
+		Message: "Hi",
+		Fields:  map[string]interface{}{"n": -1},
+	}))
+	exp := []byte{0xa4, 0x64, 't', 'i', 'm', 'e', 0xc0, 0x74}
+	exp = append(exp, "1970-01-01T00:00:01Z"...)
+	exp = append(exp,
+		0x65, 'l', 'e', 'v', 'e', 'l', 0x64, 'I', 'N', 'F', 'O',
+		0x63, 'm', 's', 'g', 0x62, 'H', 'i',
+		0x66, 'f', 'i', 'e', 'l', 'd', 's', 0xa1, 0x61, 'n', 0x20)
+	assert.Equal(t, exp, buf.Bytes())
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestRoundTrip(t *testing.T) {
+	when := time.Date(2017, 10, 1, 12, 0, 0, 123, time.UTC)
+	buf := &bytes.Buffer{}
+	f := Formatter{}
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    when,
+		Level:   gournal.WarnLevel,
+		Message: "Hello Bob",
+		Fields: map[string]interface{}{
+			"nil":     nil,
+			"bool":    false,
+			"int8":    int8(-100),
+			"int16":   int16(-1000),
+			"int32":   int32(-100000),
+			"int64":   int64(math.MinInt64),
+			"uint8":   uint8(200),
+			"uint16":  uint16(60000),
+			"uint32":  uint32(4000000000),
+			"uint64":  uint64(math.MaxUint64),
+			"float32": float32(1.5),
+			"float64": 2.5,
+			"string":  string(bytes.Repeat([]byte{'a'}, 300)),
+			"bytes":   []byte{1, 2},
+			"time":    time.Date(1900, 1, 1, 0, 0, 0, 1, time.UTC),
+			"error":   errors.New("EOF"),
+			"array":   []interface{}{"a", 1},
+			"map":     map[string]interface{}{"k": "v"},
+			"struct":  point{1, 2},
+			"dur":     time.Second,
+			"chan":    make(chan int),
+		},
+	}))
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Level:   gournal.ErrorLevel,
+		Message: "Hello Alice",
+	}))
+
+	d := NewDecoder(buf)
+	rec, err := d.Decode()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, when, rec.Time)
+	assert.Equal(t, gournal.WarnLevel, rec.Level)
+	assert.Equal(t, "Hello Bob", rec.Message)
+	assert.Regexp(t, "^0x[0-9a-f]+$", rec.Fields["chan"])
+	delete(rec.Fields, "chan")
+	assert.Equal(t, map[string]interface{}{
+		"nil":     nil,
+		"bool":    false,
+		"int8":    int64(-100),
+		"int16":   int64(-1000),
+		"int32":   int64(-100000),
+		"int64":   int64(math.MinInt64),
+		"uint8":   int64(200),
+		"uint16":  int64(60000),
+		"uint32":  int64(4000000000),
+		"uint64":  uint64(math.MaxUint64),
+		"float32": 1.5,
+		"float64": 2.5,
+		"string":  string(bytes.Repeat([]byte{'a'}, 300)),
+		"bytes":   []byte{1, 2},
+		"time":    time.Date(1900, 1, 1, 0, 0, 0, 1, time.UTC),
+		"error":   "EOF",
+		"array":   []interface{}{"a", int64(1)},
+		"map":     map[string]interface{}{"k": "v"},
+		"struct":  map[string]interface{}{"X": int64(1), "Y": int64(2)},
+		"dur":     int64(time.Second),
+	}, rec.Fields)
+
+	rec, err = d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, &gournal.Record{
+		Level:   gournal.ErrorLevel,
+		Message: "Hello Alice",
+	}, rec)
+
+	_, err = d.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecodeForeign(t *testing.T) {
+	// an indefinite-length map with an epoch date/time, a chunked string,
+	// an indefinite-length array, and half-precision floats
+	b := []byte{0xbf,
+		0x64, 't', 'i', 'm', 'e', 0xc1, 0x1a, 0x59, 0xd0, 0xd8, 0xc0,
+		0x65, 'l', 'e', 'v', 'e', 'l', 0x64, 'W', 'A', 'R', 'N',
+		0x63, 'm', 's', 'g', 0x7f, 0x62, 'H', 'e', 0x61, 'y', 0xff,
+		0x66, 'f', 'i', 'e', 'l', 'd', 's', 0xa2,
+		0x01, 0x9f, 0xf9, 0x3e, 0x00, 0xf9, 0xc4, 0x00, 0xff,
+		0x61, 'u', 0xf7,
+		0xff}
+	rec, err := NewDecoder(bytes.NewReader(b)).Decode()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, &gournal.Record{
+		Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:   gournal.WarnLevel,
+		Message: "Hey",
+		Fields: map[string]interface{}{
+			"1": []interface{}{1.5, -4.0},
+			"u": nil,
+		},
+	}, rec)
+}
+
+func TestDecodeError(t *testing.T) {
+	for _, b := range [][]byte{
+		{0xfc},
+		{0x01},
+		{0xff},
+		{0xa1, 0x64, 't', 'i', 'm', 'e', 0x01},
+		{0xa1, 0x64, 't', 'i', 'm', 'e', 0xc0, 0x61, 'x'},
+		{0xa1, 0x66, 'f', 'i', 'e', 'l', 'd', 's', 0x01},
+	} {
+		_, err := NewDecoder(bytes.NewReader(b)).Decode()
+		assert.Equal(t, ErrInvalid, err)
+	}
+	_, err := NewDecoder(bytes.NewReader([]byte{0xa2, 0x61})).Decode()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReplay(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(),
+		gournal.NewAppenderWithFormatter(buf, Formatter{}))
+	ctx = gournal.WithTime(ctx, time.Unix(0, 1).UTC())
+	gournal.WithField("size", 1).Info(ctx, "Hello Bob")
+
+	ch := make(chan gournal.Record, 1)
+	n, err := replay.Replay(nil, NewDecoder(buf), channel.New(ch))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, gournal.Record{
+		Time:    time.Unix(0, 1).UTC(),
+		Level:   gournal.InfoLevel,
+		Message: "Hello Bob",
+		Fields:  map[string]interface{}{"size": int64(1)},
+	}, <-ch)
+}
@@ -0,0 +1,210 @@
+// Package cef provides a Gournal Formatter that formats entries in the
+// ArcSight Common Event Format (CEF), so security teams can ingest
+// application logs directly into their SIEM:
+//
+//	CEF:0|Acme|Storage|1.0|volume.attach|Hello Bob|3|rt=1506859200000 suser=bob
+//
+// CEF messages are usually delivered over syslog, for example with the
+// socket package:
+//
+//	a, err := socket.NewWithOptions(
+//		"tcp", "siem.example.com:514", nil,
+//		cef.NewFormatter(cef.Header{
+//			Vendor:  "Acme",
+//			Product: "Storage",
+//			Version: "1.0",
+//		}),
+//		socket.Newline, socket.BufferSize)
+package cef
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/gournal"
+)
+
+// Header contains the header fields of CEF messages that are the same for
+// every entry.
+type Header struct {
+
+	// Vendor is the Device Vendor, ex. "Acme".
+	Vendor string
+
+	// Product is the Device Product, ex. "Storage".
+	Product string
+
+	// Version is the Device Version, ex. "1.0".
+	Version string
+}
+
+var (
+	// SignatureKey is the name of the field whose value is the Signature
+	// ID of the CEF messages written by a Formatter returned by
+	// NewFormatter. The entry's level is used if the field is missing.
+	SignatureKey = "event"
+
+	// Extensions maps the names of fields to the CEF extension keys, ex.
+	// "user" to "suser", with which they are written by a Formatter
+	// returned by NewFormatter. Other fields are written with their own
+	// names, without the characters that are not valid in extension keys.
+	Extensions map[string]string
+)
+
+// Formatter is a CEF Formatter.
+type Formatter struct {
+	hdr    Header
+	sigKey string
+	ext    map[string]string
+}
+
+// New returns an Appender that writes CEF messages to w with a Formatter
+// returned by NewFormatter.
+func New(w io.Writer, hdr Header) gournal.Appender {
+	return gournal.NewAppenderWithFormatter(w, NewFormatter(hdr))
+}
+
+// NewFormatter returns a Formatter that writes CEF messages with the
+// provided Header using SignatureKey and Extensions.
+func NewFormatter(hdr Header) *Formatter {
+	return NewFormatterWithOptions(hdr, SignatureKey, Extensions)
+}
+
+// NewFormatterWithOptions returns a Formatter that writes CEF messages with
+// the provided Header. The Signature ID of each message is the value of the
+// field named by sigKey, or the entry's level if it is missing, and the
+// Name is the entry's message. The Severity is derived from the level.
+//
+// The entry's time is written as the "rt" extension, and its fields, other
+// than the one named by sigKey, as extensions with the keys they are mapped
+// to by ext or with their own names, in the order returned by
+// gournal.SortKeys.
+func NewFormatterWithOptions(
+	hdr Header, sigKey string, ext map[string]string) *Formatter {
+
+	f := &Formatter{
+		hdr:    hdr,
+		sigKey: sigKey,
+		ext:    make(map[string]string, len(ext)),
+	}
+	for k, v := range ext {
+		f.ext[k] = v
+	}
+	return f
+}
+
+// Format writes the Record to the buffer.
+func (f *Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	sig := strings.ToLower(rec.Level.String())
+	if v, ok := rec.Fields[f.sigKey]; ok {
+		sig = fmt.Sprint(v)
+	}
+
+	buf.WriteString("CEF:0|")
+	for _, s := range []string{
+		f.hdr.Vendor, f.hdr.Product, f.hdr.Version, sig, rec.Message,
+	} {
+		writeHeader(buf, s)
+		buf.WriteByte('|')
+	}
+	buf.WriteString(strconv.Itoa(Severity(rec.Level)))
+	buf.WriteByte('|')
+
+	type ext struct{ k, v string }
+	exts := make([]ext, 0, len(rec.Fields)+1)
+	if !rec.Time.IsZero() {
+		exts = append(exts, ext{"rt", strconv.FormatInt(
+			rec.Time.UnixNano()/1e6, 10)})
+	}
+	for _, k := range gournal.SortKeys(rec.Fields) {
+		if k == f.sigKey {
+			continue
+		}
+		v := rec.Fields[k]
+		if mk, ok := f.ext[k]; ok {
+			k = mk
+		}
+		if k = extensionKey(k); k == "" {
+			continue
+		}
+		exts = append(exts, ext{k, fmt.Sprint(v)})
+	}
+	for i, e := range exts {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(e.k)
+		buf.WriteByte('=')
+		writeExtension(buf, e.v)
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// Severity returns the CEF severity, from 0 to 10, of the level, or the
+// one returned by gournal.SeverityMapper for the "cef" backend.
+func Severity(lvl gournal.Level) int {
+	if n, ok := gournal.MapSeverityInt("cef", lvl); ok {
+		return n
+	}
+	switch lvl {
+	case gournal.PanicLevel:
+		return 10
+	case gournal.FatalLevel:
+		return 9
+	case gournal.ErrorLevel:
+		return 7
+	case gournal.WarnLevel:
+		return 5
+	case gournal.InfoLevel:
+		return 3
+	}
+	return 1
+}
+
+// writeHeader writes a header field, escaping backslashes and pipes and
+// replacing line breaks, which are not allowed, with spaces.
+func writeHeader(buf *bytes.Buffer, s string) {
+	for _, c := range s {
+		switch c {
+		case '\\', '|':
+			buf.WriteByte('\\')
+		case '\r', '\n':
+			c = ' '
+		}
+		buf.WriteRune(c)
+	}
+}
+
+// writeExtension writes an extension value, escaping backslashes, equals
+// signs, and line breaks.
+func writeExtension(buf *bytes.Buffer, s string) {
+	for _, c := range s {
+		switch c {
+		case '\\', '=':
+			buf.WriteByte('\\')
+		case '\n':
+			buf.WriteString(`\n`)
+			continue
+		case '\r':
+			buf.WriteString(`\r`)
+			continue
+		}
+		buf.WriteRune(c)
+	}
+}
+
+// extensionKey returns the key without the characters that are not valid
+// in extension keys, which are letters, digits, and underscores.
+func extensionKey(k string) string {
+	return strings.Map(func(c rune) rune {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			c >= '0' && c <= '9' {
+			return c
+		}
+		return -1
+	}, k)
+}
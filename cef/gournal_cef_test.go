@@ -0,0 +1,47 @@
+package cef
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := NewFormatterWithOptions(Header{
+		Vendor:  "Acme|Corp",
+		Product: `Storage\Svc`,
+		Version: "1.0",
+	}, "event", map[string]string{"user": "suser"})
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    time.Unix(1506859200, 0),
+		Level:   gournal.WarnLevel,
+		Message: "Hello | Bob",
+		Fields: map[string]interface{}{
+			"event":    "volume.attach",
+			"user":     "bob",
+			"query":    "a=b\\c\nd",
+			"http.url": "/v1",
+			"---":      "dropped",
+		},
+	}))
+	assert.Equal(t,
+		`CEF:0|Acme\|Corp|Storage\\Svc|1.0|volume.attach|Hello \| Bob|5|`+
+			`rt=1506859200000 httpurl=/v1 query=a\=b\\c\nd suser=bob`+"\n",
+		buf.String())
+}
+
+func TestNew(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := New(buf, Header{Vendor: "Acme", Product: "Storage", Version: "1"})
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	ctx = gournal.WithTime(ctx, time.Unix(0, 0))
+	gournal.Error(ctx, "Hello Bob")
+	assert.Equal(t, "CEF:0|Acme|Storage|1|error|Hello Bob|7|rt=0\n",
+		buf.String())
+}
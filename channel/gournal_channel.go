@@ -0,0 +1,88 @@
+// Package channel provides a Gournal Appender that publishes entries as
+// gournal.Record values to a channel. This allows applications to build
+// in-process pipelines, user interfaces, or tests without implementing the
+// Appender interface.
+//
+// Like the Appender returned by replay.NewRecorder, the Appenders in this
+// package do not exit the program or panic when they receive FATAL or PANIC
+// entries.
+package channel
+
+import (
+	"context"
+
+	"github.com/akutz/gournal"
+)
+
+// Overflow is the overflow policy used by an Appender returned by New.
+var Overflow = gournal.OverflowDropNewest
+
+// New returns an Appender that publishes Records to the provided channel
+// using Overflow.
+func New(ch chan gournal.Record) gournal.Appender {
+	return NewWithOptions(ch, Overflow)
+}
+
+// NewWithOptions returns an Appender that publishes Records to the provided
+// channel. The overflow policy determines what happens when the channel is
+// full. Dropped Records are counted with gournal.RecordDropped.
+//
+// The channel must not be closed while the Appender is in use.
+func NewWithOptions(
+	ch chan gournal.Record,
+	overflow gournal.OverflowPolicy) gournal.Appender {
+
+	return &appender{ch: ch, overflow: overflow}
+}
+
+type appender struct {
+	ch       chan gournal.Record
+	overflow gournal.OverflowPolicy
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	rec := gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	}
+
+	// the fields are copied since the Record outlives the call
+	if len(fields) > 0 {
+		rec.Fields = make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			rec.Fields[k] = v
+		}
+	}
+
+	switch a.overflow {
+	case gournal.OverflowBlock:
+		a.ch <- rec
+	case gournal.OverflowDropOldest:
+		for {
+			select {
+			case a.ch <- rec:
+				return
+			default:
+			}
+			select {
+			case <-a.ch:
+				gournal.RecordDropped(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case a.ch <- rec:
+		default:
+			gournal.RecordDropped(1)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package channel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func newTestContext(a gournal.Appender) context.Context {
+	return context.WithValue(context.Background(), gournal.AppenderKey(), a)
+}
+
+func messages(ch chan gournal.Record) []string {
+	var msgs []string
+	for {
+		select {
+		case rec := <-ch:
+			msgs = append(msgs, rec.Message)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestAppender(t *testing.T) {
+	ch := make(chan gournal.Record, 2)
+	ctx := newTestContext(New(ch))
+
+	tm := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	gournal.WithField("size", 1).Error(gournal.WithTime(ctx, tm), "one")
+	rec := <-ch
+	assert.Equal(t, gournal.Record{
+		Time:    tm,
+		Level:   gournal.ErrorLevel,
+		Message: "one",
+		Fields:  map[string]interface{}{"size": 1},
+	}, rec)
+
+	gournal.Error(ctx, "two")
+	gournal.Error(ctx, "three")
+	gournal.Error(ctx, "four")
+	assert.Equal(t, []string{"two", "three"}, messages(ch))
+}
+
+func TestDropOldest(t *testing.T) {
+	ch := make(chan gournal.Record, 2)
+	ctx := newTestContext(NewWithOptions(ch, gournal.OverflowDropOldest))
+
+	gournal.Error(ctx, "one")
+	gournal.Error(ctx, "two")
+	gournal.Error(ctx, "three")
+	assert.Equal(t, []string{"two", "three"}, messages(ch))
+}
+
+func TestBlock(t *testing.T) {
+	ch := make(chan gournal.Record)
+	ctx := newTestContext(NewWithOptions(ch, gournal.OverflowBlock))
+
+	go gournal.Error(ctx, "one")
+	assert.Equal(t, "one", (<-ch).Message)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	timeKeys  = []string{"time", "ts", "@timestamp", "timestamp"}
+	levelKeys = []string{"level", "lvl", "severity", "log.level"}
+	msgKeys   = []string{"msg", "message"}
+)
+
+// recordFieldsKey is the key of the fields of a JSON gournal.Record.
+const recordFieldsKey = "fields"
+
+// entry is a structured log entry parsed from a line of input.
+type entry struct {
+	time   string
+	level  gournal.Level
+	msg    string
+	fields map[string]string
+}
+
+// parseEntry parses a line of NDJSON or logfmt into an entry. A flag is
+// returned indicating whether the line could be parsed.
+func parseEntry(line []byte) (*entry, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil, false
+	}
+
+	var fields map[string]string
+	if line[0] == '{' {
+		var m map[string]interface{}
+		if err := json.Unmarshal(line, &m); err != nil {
+			return nil, false
+		}
+
+		// the fields of JSON gournal.Records, such as those written by
+		// replay.NewRecorder and encrypt.New, are nested under "fields"
+		if nested, ok := m[recordFieldsKey].(map[string]interface{}); ok {
+			delete(m, recordFieldsKey)
+			for k, v := range nested {
+				if _, ok := m[k]; !ok {
+					m[k] = v
+				}
+			}
+		}
+
+		fields = make(map[string]string, len(m))
+		for k, v := range m {
+			switch tv := v.(type) {
+			case string:
+				fields[k] = tv
+			default:
+				buf, _ := json.Marshal(tv)
+				fields[k] = string(buf)
+			}
+		}
+	} else {
+		var ok bool
+		if fields, ok = parseLogfmt(string(line)); !ok {
+			return nil, false
+		}
+	}
+
+	e := &entry{fields: fields}
+	e.time = take(fields, timeKeys)
+	e.level = gournal.ParseLevel(take(fields, levelKeys))
+	e.msg = take(fields, msgKeys)
+	return e, true
+}
+
+// recordEntry returns an entry for a Record decoded from a binary stream.
+func recordEntry(rec *gournal.Record) *entry {
+	e := &entry{
+		level:  rec.Level,
+		msg:    rec.Message,
+		fields: make(map[string]string, len(rec.Fields)),
+	}
+	if !rec.Time.IsZero() {
+		e.time = rec.Time.Format(time.RFC3339Nano)
+	}
+	for k, v := range rec.Fields {
+		switch tv := v.(type) {
+		case string:
+			e.fields[k] = tv
+		case time.Time:
+			e.fields[k] = tv.Format(time.RFC3339Nano)
+		default:
+			buf, err := json.Marshal(tv)
+			if err != nil {
+				e.fields[k] = fmt.Sprint(tv)
+			} else {
+				e.fields[k] = string(buf)
+			}
+		}
+	}
+	return e
+}
+
+func take(fields map[string]string, keys []string) string {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok {
+			delete(fields, k)
+			return v
+		}
+	}
+	return ""
+}
+
+// parseLogfmt parses a line of key=value pairs where values may be quoted.
+func parseLogfmt(s string) (map[string]string, bool) {
+	fields := map[string]string{}
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			break
+		}
+		i := strings.IndexAny(s, "= ")
+		if i <= 0 || s[i] != '=' {
+			return nil, false
+		}
+		key := s[:i]
+		s = s[i+1:]
+
+		var val string
+		if strings.HasPrefix(s, `"`) {
+			j := 1
+			for ; j < len(s); j++ {
+				if s[j] == '\\' {
+					j++
+				} else if s[j] == '"' {
+					break
+				}
+			}
+			if j >= len(s) {
+				return nil, false
+			}
+			if _, err := fmt.Sscanf(s[:j+1], "%q", &val); err != nil {
+				return nil, false
+			}
+			s = s[j+1:]
+		} else {
+			j := strings.IndexFunc(s, unicode.IsSpace)
+			if j < 0 {
+				j = len(s)
+			}
+			val, s = s[:j], s[j:]
+		}
+		fields[key] = val
+	}
+	return fields, len(fields) > 0
+}
+
+// filter is a field expression of the form key=value or key!=value.
+type filter struct {
+	key    string
+	value  string
+	negate bool
+}
+
+func parseFilter(s string) (filter, error) {
+	if i := strings.Index(s, "!="); i > 0 {
+		return filter{key: s[:i], value: s[i+2:], negate: true}, nil
+	}
+	if i := strings.Index(s, "="); i > 0 {
+		return filter{key: s[:i], value: s[i+1:]}, nil
+	}
+	return filter{}, fmt.Errorf("invalid filter: %s", s)
+}
+
+func (f filter) match(e *entry) bool {
+	v, ok := e.fields[f.key]
+	if !ok {
+		switch f.key {
+		case "msg":
+			v, ok = e.msg, true
+		case "level":
+			v, ok = e.level.String(), true
+		}
+	}
+	matched := ok && strings.EqualFold(v, f.value)
+	return matched != f.negate
+}
+
+var levelColors = map[gournal.Level]string{
+	gournal.PanicLevel: "\x1b[35m",
+	gournal.FatalLevel: "\x1b[35m",
+	gournal.ErrorLevel: "\x1b[31m",
+	gournal.WarnLevel:  "\x1b[33m",
+	gournal.InfoLevel:  "\x1b[36m",
+	gournal.DebugLevel: "\x1b[37m",
+}
+
+// render writes the entry in a human-readable console format.
+func (e *entry) render(buf *bytes.Buffer, color bool) {
+	if e.time != "" {
+		buf.WriteString(e.time)
+		buf.WriteByte(' ')
+	}
+	lvl := fmt.Sprintf("%-7s", e.level)
+	if color {
+		buf.WriteString(levelColors[e.level])
+		buf.WriteString(lvl)
+		buf.WriteString("\x1b[0m")
+	} else {
+		buf.WriteString(lvl)
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(e.msg)
+
+	keys := make([]string, 0, len(e.fields))
+	for k := range e.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteByte(' ')
+		if color {
+			buf.WriteString("\x1b[2m" + k + "=\x1b[0m")
+		} else {
+			buf.WriteString(k + "=")
+		}
+		v := e.fields[k]
+		if strings.ContainsAny(v, " \t\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		buf.WriteString(v)
+	}
+	buf.WriteByte('\n')
+}
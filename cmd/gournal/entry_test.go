@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/encrypt"
+	"github.com/akutz/gournal/msgpack"
+)
+
+func TestParseEntryJSON(t *testing.T) {
+	e, ok := parseEntry([]byte(
+		`{"level":"warn","msg":"Hello Bob","size":1,"location":"Austin"}`))
+	assert.True(t, ok)
+	assert.Equal(t, gournal.WarnLevel, e.level)
+	assert.Equal(t, "Hello Bob", e.msg)
+	assert.Equal(t, map[string]string{"size": "1", "location": "Austin"},
+		e.fields)
+}
+
+func TestParseEntryLogfmt(t *testing.T) {
+	e, ok := parseEntry([]byte(
+		`time=2017-10-01T00:00:00Z level=info msg="Hello \"Bob\"" size=1`))
+	assert.True(t, ok)
+	assert.Equal(t, "2017-10-01T00:00:00Z", e.time)
+	assert.Equal(t, gournal.InfoLevel, e.level)
+	assert.Equal(t, `Hello "Bob"`, e.msg)
+	assert.Equal(t, map[string]string{"size": "1"}, e.fields)
+
+	_, ok = parseEntry([]byte("just some text"))
+	assert.False(t, ok)
+}
+
+func TestProcess(t *testing.T) {
+	in := strings.Join([]string{
+		`{"level":"debug","msg":"Hello Bob"}`,
+		`level=info msg="Hello Alice" location=Austin`,
+		`level=error msg="Hello Mary" location=Boston`,
+		`not structured`,
+	}, "\n")
+
+	f, _ := parseFilter("location!=Boston")
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	err := process(
+		strings.NewReader(in), w, gournal.InfoLevel, filters{f}, false, nil)
+	w.Flush()
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"INFO    Hello Alice location=Austin\nnot structured\n",
+		buf.String())
+}
+
+func TestProcessLineTooLong(t *testing.T) {
+	in := `level=info msg="Hello Bob"` + "\n" +
+		strings.Repeat("x", 2*1024*1024) + "\n" +
+		`level=info msg="Hello Alice"` + "\n"
+
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	err := process(
+		strings.NewReader(in), w, gournal.InfoLevel, nil, false, nil)
+	w.Flush()
+
+	assert.Equal(t, bufio.ErrTooLong, err)
+	assert.Equal(t, "INFO    Hello Bob\n", buf.String())
+}
+
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestProcessFlush(t *testing.T) {
+	pr, pw := io.Pipe()
+	out := make(chanWriter, 1)
+	w := bufio.NewWriter(out)
+	done := make(chan error)
+	go func() {
+		done <- process(
+			&flushReader{pr, w}, w, gournal.InfoLevel, nil, false, nil)
+	}()
+
+	// each entry is written while the next line is awaited
+	for _, name := range []string{"Bob", "Alice"} {
+		pw.Write([]byte(`level=info msg="Hello ` + name + `"` + "\n"))
+		select {
+		case s := <-out:
+			assert.Equal(t, "INFO    Hello "+name+"\n", s)
+		case <-time.After(5 * time.Second):
+			t.Fatal("entry was not written before reading the next line")
+		}
+	}
+	pw.Close()
+	assert.NoError(t, <-done)
+}
+
+func TestProcessDecrypt(t *testing.T) {
+	key := make([]byte, 32)
+	in := &bytes.Buffer{}
+	ew, _ := encrypt.NewWriter(in, key)
+	ew.Write([]byte(`{"level":"info","msg":"Hello Bob"}` + "\n"))
+	in.WriteString("not encrypted\n")
+
+	dec, _ := encrypt.NewDecrypter(key)
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	assert.NoError(t, process(in, w, gournal.InfoLevel, nil, false, dec))
+	w.Flush()
+
+	assert.Equal(t, "INFO    Hello Bob\nnot encrypted\n", buf.String())
+}
+
+func TestProcessDecryptRecords(t *testing.T) {
+	key := make([]byte, 32)
+	in := &bytes.Buffer{}
+	a, err := encrypt.New(in, key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = gournal.WithTime(ctx, time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC))
+	gournal.WithFields(map[string]interface{}{
+		"location": "Austin",
+		"size":     1,
+	}).Info(ctx, "Hello Alice")
+
+	dec, _ := encrypt.NewDecrypter(key)
+	f, _ := parseFilter("location=Austin")
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	assert.NoError(
+		t, process(in, w, gournal.InfoLevel, filters{f}, false, dec))
+	w.Flush()
+
+	assert.Equal(t,
+		"2017-10-01T00:00:00Z INFO    Hello Alice location=Austin size=1\n",
+		buf.String())
+}
+
+func TestProcessRecords(t *testing.T) {
+	in := &bytes.Buffer{}
+	for _, rec := range []*gournal.Record{
+		{Level: gournal.DebugLevel, Message: "Hello Bob"},
+		{
+			Time:    time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC),
+			Level:   gournal.InfoLevel,
+			Message: "Hello Alice",
+			Fields: map[string]interface{}{
+				"location": "Austin",
+				"size":     1,
+			},
+		},
+	} {
+		msgpack.Formatter{}.Format(in, rec)
+	}
+	in.WriteByte(0xc1)
+
+	buf := &bytes.Buffer{}
+	w := bufio.NewWriter(buf)
+	err := processRecords(
+		msgpack.NewDecoder(in), w, gournal.InfoLevel, nil, false)
+	w.Flush()
+
+	assert.Equal(t, msgpack.ErrInvalid, err)
+	assert.Equal(
+		t,
+		"2017-10-01T00:00:00Z INFO    Hello Alice location=Austin size=1\n",
+		buf.String())
+}
@@ -0,0 +1,247 @@
+// Command gournal reads structured log entries formatted as NDJSON or logfmt
+// from stdin or the provided files, filters them by level and field
+// expressions, and re-renders them in a human-readable console format.
+//
+// Usage:
+//
+//	gournal [-level LEVEL] [-filter KEY=VALUE]... [-color MODE] [-key FILE]
+//	        [-input FORMAT] [FILE]...
+//
+// The -key flag specifies a file that contains a hex-encoded key used to
+// decrypt entries written by the encrypt package.
+//
+// The -input flag specifies the format of the input: "text" for NDJSON or
+// logfmt lines, or "msgpack" or "cbor" for binary records written by the
+// msgpack and cbor packages.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/cbor"
+	"github.com/akutz/gournal/encrypt"
+	"github.com/akutz/gournal/msgpack"
+	"github.com/akutz/gournal/replay"
+)
+
+type filters []filter
+
+func (f *filters) String() string {
+	return fmt.Sprint(*f)
+}
+
+func (f *filters) Set(s string) error {
+	v, err := parseFilter(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, v)
+	return nil
+}
+
+func main() {
+	var (
+		flt   filters
+		level = flag.String(
+			"level", "debug", "the least severe level to display")
+		color = flag.String(
+			"color", "auto", "colorize output: auto, always, or never")
+		keyFile = flag.String(
+			"key", "", "a file with a hex-encoded key to decrypt entries")
+		input = flag.String(
+			"input", "text", "the input format: text, msgpack, or cbor")
+	)
+	flag.Var(&flt, "filter",
+		"display entries where KEY=VALUE or KEY!=VALUE; may be repeated")
+	flag.Parse()
+
+	minLvl := gournal.ParseLevel(*level)
+	if minLvl == gournal.UnknownLevel {
+		fmt.Fprintf(os.Stderr, "gournal: invalid level: %s\n", *level)
+		os.Exit(2)
+	}
+
+	useColor := false
+	switch strings.ToLower(*color) {
+	case "always":
+		useColor = true
+	case "auto":
+		useColor = isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""
+	}
+
+	var newDecoder func(io.Reader) replay.Decoder
+	switch strings.ToLower(*input) {
+	case "text":
+	case "msgpack":
+		newDecoder = func(r io.Reader) replay.Decoder {
+			return msgpack.NewDecoder(r)
+		}
+	case "cbor":
+		newDecoder = func(r io.Reader) replay.Decoder {
+			return cbor.NewDecoder(r)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "gournal: invalid input: %s\n", *input)
+		os.Exit(2)
+	}
+
+	var dec *encrypt.Decrypter
+	if *keyFile != "" {
+		buf, err := ioutil.ReadFile(*keyFile)
+		var key []byte
+		if err == nil {
+			key, err = encrypt.ParseKey(string(buf))
+		}
+		if err == nil {
+			dec, err = encrypt.NewDecrypter(key)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gournal: invalid key: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	run := func(r io.Reader) error {
+		r = &flushReader{r, out}
+		if newDecoder != nil {
+			return processRecords(
+				newDecoder(r), out, minLvl, flt, useColor)
+		}
+		return process(r, out, minLvl, flt, useColor, dec)
+	}
+
+	if flag.NArg() == 0 {
+		if err := run(os.Stdin); err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "gournal: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err == nil {
+			err = run(f)
+			f.Close()
+		}
+		if err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "gournal: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// process renders the lines read from the Reader. The error that stopped
+// reading, such as bufio.ErrTooLong for a line longer than 1MiB, is
+// returned.
+func process(
+	r io.Reader,
+	w *bufio.Writer,
+	minLvl gournal.Level,
+	flt filters,
+	color bool,
+	dec *encrypt.Decrypter) error {
+
+	var (
+		buf     bytes.Buffer
+		scanner = bufio.NewScanner(r)
+	)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if dec != nil {
+			// lines that cannot be decrypted are treated as plain text
+			if p, err := dec.Decrypt(line); err == nil {
+				line = p
+			}
+		}
+		e, ok := parseEntry(line)
+		if !ok {
+			// lines that are not structured are passed through untouched
+			w.Write(bytes.TrimRight(line, "\n"))
+			w.WriteByte('\n')
+			continue
+		}
+		emit(w, &buf, e, minLvl, flt, color)
+	}
+	return scanner.Err()
+}
+
+// processRecords renders the Records read from the Decoder. The first
+// decoding error other than io.EOF is returned.
+func processRecords(
+	d replay.Decoder,
+	w *bufio.Writer,
+	minLvl gournal.Level,
+	flt filters,
+	color bool) error {
+
+	var buf bytes.Buffer
+	for {
+		rec, err := d.Decode()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		emit(w, &buf, recordEntry(rec), minLvl, flt, color)
+	}
+}
+
+// emit renders the entry if it is at least as severe as minLvl and matches
+// every filter.
+func emit(
+	w *bufio.Writer,
+	buf *bytes.Buffer,
+	e *entry,
+	minLvl gournal.Level,
+	flt filters,
+	color bool) {
+
+	if e.level > minLvl {
+		return
+	}
+	for _, f := range flt {
+		if !f.match(e) {
+			return
+		}
+	}
+	buf.Reset()
+	e.render(buf, color)
+	w.Write(buf.Bytes())
+}
+
+// flushReader flushes the output before each read, so the entries rendered
+// so far are written before the program waits for more input, for example
+// when following a file with "tail -f", while they remain buffered when
+// the input is read in bulk.
+type flushReader struct {
+	r io.Reader
+	w *bufio.Writer
+}
+
+func (r *flushReader) Read(p []byte) (int, error) {
+	if err := r.w.Flush(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
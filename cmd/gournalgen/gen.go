@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxStructDepth is the depth of nested structs beyond which structs are
+// logged as values, the same as gournal.WithStruct.
+const maxStructDepth = 8
+
+// event is a struct type for which a logging function is generated.
+type event struct {
+	name   string
+	fields []eventField
+
+	// hasLogFields is true if the type already has a LogFields function,
+	// in which case one is not generated
+	hasLogFields bool
+}
+
+// eventField is a field of an event.
+type eventField struct {
+	key string
+
+	// expr is the expression that selects the field from the event, ex.
+	// "ev.Audit.Actor"
+	expr string
+
+	// conds are the conditions that must be true for the field to be
+	// logged, such as that a pointer to the struct that contains it is not
+	// nil, or that the field does not have its zero value if it is tagged
+	// with "omitempty"
+	conds []string
+}
+
+// pkgInfo is the syntax of the package's struct types and functions.
+type pkgInfo struct {
+	structs map[string]*ast.StructType
+
+	// methods are the names of the functions of each type
+	methods map[string]map[string]bool
+}
+
+// isValue returns true if the type is logged as a value instead of having
+// its fields added, the same as gournal.WithStruct.
+func (p *pkgInfo) isValue(name string) bool {
+	m := p.methods[name]
+	return m["String"] || m["Error"] || m["MarshalJSON"] || m["MarshalText"]
+}
+
+// parsePackage returns the name of the package in the directory and the
+// events with the provided type names, in the same order.
+func parsePackage(dir string, types []string) (string, []*event, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("%s: expected one package, found %d",
+			dir, len(pkgs))
+	}
+
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+
+	info := &pkgInfo{
+		structs: map[string]*ast.StructType{},
+		methods: map[string]map[string]bool{},
+	}
+	for _, f := range pkg.Files {
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					if st, ok := ts.Type.(*ast.StructType); ok {
+						info.structs[ts.Name.Name] = st
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					continue
+				}
+				recv := recvName(d.Recv.List[0].Type)
+				if info.methods[recv] == nil {
+					info.methods[recv] = map[string]bool{}
+				}
+				info.methods[recv][d.Name.Name] = true
+			}
+		}
+	}
+
+	events := make([]*event, 0, len(types))
+	for _, name := range types {
+		st, ok := info.structs[name]
+		if !ok {
+			return "", nil, fmt.Errorf("%s: struct type not found", name)
+		}
+		ev := &event{
+			name:         name,
+			hasLogFields: info.methods[name]["LogFields"],
+		}
+		if err := info.addFields(ev, st, "ev", "", nil, 0); err != nil {
+			return "", nil, fmt.Errorf("%s: %v", name, err)
+		}
+		events = append(events, ev)
+	}
+	return pkg.Name, events, nil
+}
+
+// addFields adds the fields of the struct, selected with the expression,
+// to the event the same way as gournal.WithStruct, with their keys
+// prefixed by the prefix and logged only if the conditions are true.
+func (p *pkgInfo) addFields(
+	ev *event,
+	st *ast.StructType,
+	expr, prefix string,
+	conds []string,
+	depth int) error {
+
+	for _, f := range st.Fields.List {
+		tag := ""
+		if f.Tag != nil {
+			s, _ := strconv.Unquote(f.Tag.Value)
+			stag := reflect.StructTag(s)
+			var ok bool
+			if tag, ok = stag.Lookup("log"); !ok {
+				tag = stag.Get("json")
+			}
+		}
+		if tag == "-" {
+			continue
+		}
+		key, opts := tag, ""
+		if i := strings.IndexByte(tag, ','); i >= 0 {
+			key, opts = tag[:i], ","+tag[i+1:]+","
+		}
+
+		if len(f.Names) == 0 {
+			name := recvName(f.Type)
+			if key == "" {
+				if _, ok := f.Type.(*ast.Ident); !ok {
+					return fmt.Errorf("%s: embedded pointers and types "+
+						"of other packages are not supported", name)
+				}
+				est, ok := p.structs[name]
+				if !ok {
+					continue
+				}
+				if err := p.addFields(ev, est,
+					expr+"."+name, prefix, conds, depth); err != nil {
+					return err
+				}
+				continue
+			}
+			if ast.IsExported(name) {
+				p.addField(ev, f.Type,
+					expr+"."+name, prefix+key, opts, conds, depth)
+			}
+			continue
+		}
+
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			k := key
+			if k == "" {
+				k = n.Name
+			}
+			p.addField(ev, f.Type,
+				expr+"."+n.Name, prefix+k, opts, conds, depth)
+		}
+	}
+	return nil
+}
+
+// addField adds the field of the provided type, or the fields of it if it
+// is a nested struct, to the event.
+func (p *pkgInfo) addField(
+	ev *event,
+	typ ast.Expr,
+	expr, key, opts string,
+	conds []string,
+	depth int) {
+
+	omitEmpty := strings.Contains(opts, ",omitempty,")
+	if omitEmpty {
+		if c := nonZeroCondition(expr, typ); c != "" {
+			conds = append(conds[:len(conds):len(conds)], c)
+		}
+	}
+	if strings.Contains(opts, ",redact,") {
+		ev.fields = append(ev.fields, eventField{
+			key: key, expr: "gournal.RedactedValue", conds: conds})
+		return
+	}
+
+	ptr := false
+	styp := typ
+	if se, ok := typ.(*ast.StarExpr); ok {
+		ptr, styp = true, se.X
+	}
+	if id, ok := styp.(*ast.Ident); ok && depth < maxStructDepth {
+		if st, ok := p.structs[id.Name]; ok && !p.isValue(id.Name) {
+			nested := conds
+			if ptr && !omitEmpty {
+				nested = append(conds[:len(conds):len(conds)],
+					expr+" != nil")
+
+				// a nil pointer is logged as a value unless it is omitted
+				ev.fields = append(ev.fields, eventField{
+					key:  key,
+					expr: expr,
+					conds: append(conds[:len(conds):len(conds)],
+						expr+" == nil"),
+				})
+			}
+			p.addFields(ev, st, expr, key+".", nested, depth+1)
+			return
+		}
+	}
+	ev.fields = append(ev.fields, eventField{
+		key: key, expr: expr, conds: conds})
+}
+
+// nonZeroCondition returns the condition that is false when the expression
+// of the provided type has its zero value or is empty, or an empty string
+// if the condition cannot be determined from the type's syntax, in which
+// case the field is never omitted.
+func nonZeroCondition(expr string, typ ast.Expr) string {
+	switch t := typ.(type) {
+	case *ast.StarExpr, *ast.InterfaceType, *ast.FuncType, *ast.ChanType:
+		return expr + " != nil"
+	case *ast.MapType:
+		return "len(" + expr + ") > 0"
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "len(" + expr + ") > 0"
+		}
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return expr + ` != ""`
+		case "bool":
+			return expr
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+			"float32", "float64", "complex64", "complex128",
+			"byte", "rune":
+			return expr + " != 0"
+		}
+	}
+	return ""
+}
+
+// recvName returns the name of the possibly pointer or package-qualified
+// type.
+func recvName(typ ast.Expr) string {
+	switch t := typ.(type) {
+	case *ast.StarExpr:
+		return recvName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// generate returns the formatted source of the logging functions of the
+// events.
+func generate(pkg string, args []string, events []*event) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf,
+		"// Code generated by \"gournalgen %s\"; DO NOT EDIT.\n\n",
+		strings.Join(args, " "))
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	buf.WriteString("import (\n\t\"context\"\n\n")
+	buf.WriteString("\t\"github.com/akutz/gournal\"\n)\n")
+
+	for _, ev := range events {
+		fmt.Fprintf(buf, `
+// Log%[1]s emits the %[1]s event with gournal.Event.
+func Log%[1]s(ctx context.Context, ev %[1]s) {
+	gournal.Event(ctx, ev)
+}
+`, ev.name)
+
+		if ev.hasLogFields {
+			continue
+		}
+
+		fmt.Fprintf(buf, `
+// LogFields returns the fields of the %s event.
+func (ev %[1]s) LogFields() map[string]interface{} {
+	m := map[string]interface{}{
+`, ev.name)
+		var conditional []eventField
+		for _, f := range ev.fields {
+			if len(f.conds) > 0 {
+				conditional = append(conditional, f)
+				continue
+			}
+			fmt.Fprintf(buf, "\t\t%q: %s,\n", f.key, f.expr)
+		}
+		buf.WriteString("\t}\n")
+		for _, f := range conditional {
+			fmt.Fprintf(buf, "\tif %s {\n\t\tm[%q] = %s\n\t}\n",
+				strings.Join(f.conds, " && "), f.key, f.expr)
+		}
+		buf.WriteString("\treturn m\n}\n")
+	}
+	return format.Source(buf.Bytes())
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSource = `package events
+
+type Audit struct {
+	Actor string ` + "`json:\"actor\"`" + `
+}
+
+type Node struct {
+	ID    string
+	Token string ` + "`log:\"token,redact\"`" + `
+}
+
+type VolumeAttached struct {
+	Audit
+	VolumeID string ` + "`log:\"volume_id\" json:\"volumeId\"`" + `
+	Size     int    ` + "`json:\"size,omitempty\"`" + `
+	Node     *Node  ` + "`log:\"node\"`" + `
+	Source   Node   ` + "`log:\"source\"`" + `
+	Tags     []string ` + "`json:\",omitempty\"`" + `
+	Secret   string ` + "`json:\"-\"`" + `
+	Host     string
+	private  string
+}
+
+type UserLoggedIn struct {
+	User string
+}
+
+func (e *UserLoggedIn) LogFields() map[string]interface{} {
+	return nil
+}
+`
+
+const testOutput = "// Code generated by \"gournalgen -type " +
+	"VolumeAttached,UserLoggedIn\"; DO NOT EDIT.\n" + `
+package events
+
+import (
+	"context"
+
+	"github.com/akutz/gournal"
+)
+
+// LogVolumeAttached emits the VolumeAttached event with gournal.Event.
+func LogVolumeAttached(ctx context.Context, ev VolumeAttached) {
+	gournal.Event(ctx, ev)
+}
+
+// LogFields returns the fields of the VolumeAttached event.
+func (ev VolumeAttached) LogFields() map[string]interface{} {
+	m := map[string]interface{}{
+		"actor":        ev.Audit.Actor,
+		"volume_id":    ev.VolumeID,
+		"source.ID":    ev.Source.ID,
+		"source.token": gournal.RedactedValue,
+		"Host":         ev.Host,
+	}
+	if ev.Size != 0 {
+		m["size"] = ev.Size
+	}
+	if ev.Node == nil {
+		m["node"] = ev.Node
+	}
+	if ev.Node != nil {
+		m["node.ID"] = ev.Node.ID
+	}
+	if ev.Node != nil {
+		m["node.token"] = gournal.RedactedValue
+	}
+	if len(ev.Tags) > 0 {
+		m["Tags"] = ev.Tags
+	}
+	return m
+}
+
+// LogUserLoggedIn emits the UserLoggedIn event with gournal.Event.
+func LogUserLoggedIn(ctx context.Context, ev UserLoggedIn) {
+	gournal.Event(ctx, ev)
+}
+`
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gournalgen")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	if !assert.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "events.go"), []byte(testSource), 0644)) {
+		t.FailNow()
+	}
+
+	pkg, events, err := parsePackage(
+		dir, []string{"VolumeAttached", "UserLoggedIn"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	src, err := generate(
+		pkg, []string{"-type", "VolumeAttached,UserLoggedIn"}, events)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, testOutput, string(src))
+
+	_, _, err = parsePackage(dir, []string{"VolumeDetached"})
+	assert.EqualError(t, err, "VolumeDetached: struct type not found")
+}
@@ -0,0 +1,72 @@
+// Command gournalgen generates strongly-typed logging functions for event
+// structs so the names and types of their fields are checked at compile
+// time. It is intended to be run with go generate:
+//
+//	//go:generate gournalgen -type VolumeAttached,UserLoggedIn
+//
+// Usage:
+//
+//	gournalgen -type TYPE[,TYPE]... [-output FILE] [DIR]
+//
+// For each type, the struct's package in DIR, or the current directory,
+// gets a function that emits the event with gournal.Event:
+//
+//	func LogVolumeAttached(ctx context.Context, ev VolumeAttached)
+//
+// and, unless the type already has one, a LogFields function that returns
+// the event's fields the same way gournal.Event would obtain them with
+// reflection, as described by gournal.WithStruct. The functions are
+// written to the output file, which defaults to TYPE_gournal.go in DIR,
+// where TYPE is the first type in lower case.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		types = flag.String(
+			"type", "", "a comma-separated list of event struct types")
+		output = flag.String(
+			"output", "", "the output file; defaults to TYPE_gournal.go")
+	)
+	flag.Parse()
+
+	if *types == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*types, ",")
+
+	pkg, events, err := parsePackage(dir, names)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gournalgen: %v\n", err)
+		os.Exit(1)
+	}
+	src, err := generate(pkg, os.Args[1:], events)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gournalgen: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := *output
+	if path == "" {
+		path = filepath.Join(
+			dir, strings.ToLower(names[0])+"_gournal.go")
+	}
+	if err := ioutil.WriteFile(path, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "gournalgen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,155 @@
+// Package compress provides a Gournal Appender that writes formatted
+// entries to an io.Writer as length-prefixed, gzip-compressed frames. This
+// reduces the bandwidth used by agents that ship logs over constrained
+// links.
+//
+// Each frame is a four-byte, big-endian length followed by that many bytes
+// of gzip-compressed data. Frames may be decoded with NewReader.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal/buffered"
+)
+
+var (
+	// FrameSize is the number of uncompressed bytes that are buffered by an
+	// Appender returned by New before a frame is written.
+	FrameSize = 64 * 1024
+
+	// Interval is the amount of time after which an Appender returned by
+	// New writes a frame of the entries buffered so far.
+	Interval = time.Second
+)
+
+// New returns an Appender that writes compressed frames to w using
+// FrameSize, Interval, and gzip.DefaultCompression.
+func New(w io.Writer) *buffered.Appender {
+	return NewWithOptions(w, FrameSize, Interval, gzip.DefaultCompression)
+}
+
+// NewWithOptions returns an Appender that buffers formatted entries and
+// writes them to w as a compressed frame once size uncompressed bytes are
+// buffered or, if the interval is greater than zero, each time the interval
+// elapses. FATAL and PANIC entries are written immediately.
+//
+// The returned Appender must be closed to write the final frame.
+func NewWithOptions(
+	w io.Writer,
+	size int,
+	interval time.Duration,
+	level int) *buffered.Appender {
+
+	return buffered.NewWithOptions(
+		NewWriterLevel(w, level), size, interval)
+}
+
+// NewWriter returns an io.Writer that writes the data of each call to Write
+// to w as a single frame compressed with gzip.DefaultCompression.
+func NewWriter(w io.Writer) io.Writer {
+	return NewWriterLevel(w, gzip.DefaultCompression)
+}
+
+// NewWriterLevel is like NewWriter but uses the provided compression level.
+// Invalid levels are replaced with gzip.DefaultCompression.
+func NewWriterLevel(w io.Writer, level int) io.Writer {
+	zw, err := gzip.NewWriterLevel(nil, level)
+	if err != nil {
+		zw = gzip.NewWriter(nil)
+	}
+	return &writer{w: w, zw: zw}
+}
+
+type writer struct {
+	sync.Mutex
+	w   io.Writer
+	zw  *gzip.Writer
+	buf bytes.Buffer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	// reserve space for the length prefix
+	w.buf.Reset()
+	w.buf.Write([]byte{0, 0, 0, 0})
+
+	w.zw.Reset(&w.buf)
+	if _, err := w.zw.Write(p); err != nil {
+		return 0, err
+	}
+	if err := w.zw.Close(); err != nil {
+		return 0, err
+	}
+
+	frame := w.buf.Bytes()
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-4))
+	if _, err := w.w.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// NewReader returns an io.Reader that decompresses the frames read from r.
+func NewReader(r io.Reader) io.Reader {
+	return &reader{r: r}
+}
+
+type reader struct {
+	r    io.Reader
+	zr   *gzip.Reader
+	hdr  [4]byte
+	data bytes.Buffer
+	cur  io.Reader
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for {
+		if r.cur != nil {
+			n, err := r.cur.Read(p)
+			if err != io.EOF {
+				return n, err
+			}
+			r.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// next reads the next frame.
+func (r *reader) next() error {
+	if _, err := io.ReadFull(r.r, r.hdr[:]); err != nil {
+		return err
+	}
+	n := int64(binary.BigEndian.Uint32(r.hdr[:]))
+	r.data.Reset()
+	if _, err := io.CopyN(&r.data, r.r, n); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	var err error
+	if r.zr == nil {
+		r.zr, err = gzip.NewReader(&r.data)
+	} else {
+		err = r.zr.Reset(&r.data)
+	}
+	if err != nil {
+		return err
+	}
+	r.cur = r.zr
+	return nil
+}
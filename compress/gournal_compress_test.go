@@ -0,0 +1,51 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestAppender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := NewWithOptions(buf, 4096, 0, gzip.BestCompression)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	for i := 0; i < 100; i++ {
+		gournal.Error(ctx, "Hello Bob")
+	}
+	assert.Zero(t, buf.Len())
+	assert.NoError(t, a.Close())
+
+	// the entries are written as a single, compressed frame
+	n := binary.BigEndian.Uint32(buf.Bytes())
+	assert.Equal(t, buf.Len()-4, int(n))
+	assert.True(t, buf.Len() < 100)
+
+	out, err := ioutil.ReadAll(NewReader(buf))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("[ERROR] Hello Bob\n", 100), string(out))
+}
+
+func TestReaderMultipleFrames(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+	w.Write([]byte("Hello "))
+	w.Write([]byte(""))
+	w.Write([]byte("Bob"))
+
+	out, err := ioutil.ReadAll(NewReader(buf))
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Bob", string(out))
+
+	_, err = ioutil.ReadAll(NewReader(bytes.NewReader([]byte{0, 0, 0, 9})))
+	assert.Error(t, err)
+}
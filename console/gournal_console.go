@@ -0,0 +1,181 @@
+// Package console provides a Gournal Formatter for terminals that colors
+// level tags and aligns entries in columns:
+//
+//	12:00:00.000 INFO  Hello Bob                                size=1
+//	12:00:01.000 ERROR Goodbye Bob                              error=EOF
+//
+// The logger name and caller of entries, if any, are written in columns
+// between the level and the message, formatted with gournal.FormatName and
+// gournal.FormatCaller and padded to gournal.NameWidth and
+// gournal.CallerWidth.
+//
+// Color is disabled automatically when the output is not a terminal or the
+// NO_COLOR environment variable is set. On Windows, virtual terminal
+// processing is enabled so the consoles of Windows 10 and later render the
+// colors.
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/akutz/gournal"
+)
+
+// Palette maps levels to ANSI SGR parameters, ex. "31" for red or "1;31"
+// for bold red. Levels without an entry are not colored.
+type Palette map[gournal.Level]string
+
+var (
+	// DefaultPalette is the Palette used by a Formatter returned by
+	// NewFormatter.
+	DefaultPalette = Palette{
+		gournal.DebugLevel: "90",
+		gournal.InfoLevel:  "36",
+		gournal.WarnLevel:  "33",
+		gournal.ErrorLevel: "31",
+		gournal.FatalLevel: "35",
+		gournal.PanicLevel: "1;31",
+	}
+
+	// TimeFormat is the format of the times written by a Formatter returned
+	// by NewFormatter.
+	TimeFormat = "15:04:05.000"
+
+	// MessageWidth is the width to which a Formatter returned by
+	// NewFormatter pads messages so fields are aligned.
+	MessageWidth = 40
+)
+
+// Formatter is a console Formatter.
+type Formatter struct {
+	color      bool
+	palette    Palette
+	timeFormat string
+	msgWidth   int
+}
+
+// New returns an Appender that writes entries to w with a Formatter
+// returned by NewFormatter.
+func New(w io.Writer) gournal.Appender {
+	return gournal.NewAppenderWithFormatter(w, NewFormatter(w))
+}
+
+// NewFormatter returns a Formatter for entries written to w using
+// DefaultPalette, TimeFormat, and MessageWidth. Color is enabled if
+// ColorEnabled(w) is true.
+func NewFormatter(w io.Writer) *Formatter {
+	return NewFormatterWithOptions(
+		ColorEnabled(w), DefaultPalette, TimeFormat, MessageWidth)
+}
+
+// NewFormatterWithOptions returns a Formatter that colors level tags with
+// the provided Palette if color is true, writes times with the provided
+// format, or omits them if it is empty, and pads messages to msgWidth so
+// fields are aligned.
+func NewFormatterWithOptions(
+	color bool,
+	palette Palette,
+	timeFormat string,
+	msgWidth int) *Formatter {
+
+	return &Formatter{
+		color:      color,
+		palette:    palette,
+		timeFormat: timeFormat,
+		msgWidth:   msgWidth,
+	}
+}
+
+// ColorEnabled returns true if the NO_COLOR environment variable is not set
+// and w is an *os.File that refers to a terminal able to render colors.
+func ColorEnabled(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok || !terminal.IsTerminal(int(f.Fd())) {
+		return false
+	}
+	return enableVirtualTerminal(f)
+}
+
+// Format writes the Record to the buffer.
+func (f *Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	if f.timeFormat != "" {
+		buf.WriteString(rec.Time.Format(f.timeFormat))
+		buf.WriteByte(' ')
+	}
+
+	sgr := ""
+	if f.color {
+		sgr = f.palette[rec.Level]
+	}
+	colorize(buf, sgr, fmt.Sprintf("%-5s", rec.Level.String()))
+	buf.WriteByte(' ')
+	if rec.Name != "" {
+		writeColumn(
+			buf, gournal.FormatName(rec.Name), gournal.NameWidth)
+	}
+	if rec.Caller != nil {
+		writeColumn(
+			buf, gournal.FormatCaller(rec.Caller), gournal.CallerWidth)
+	}
+	buf.WriteString(rec.Message)
+
+	if len(rec.Fields) > 0 {
+		if pad := f.msgWidth - len([]rune(rec.Message)); pad > 0 {
+			buf.WriteString(strings.Repeat(" ", pad))
+		}
+		for _, k := range gournal.SortKeys(rec.Fields) {
+			buf.WriteByte(' ')
+			colorize(buf, sgr, k)
+			buf.WriteByte('=')
+			writeValue(buf, fmt.Sprint(rec.Fields[k]))
+		}
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// writeColumn writes s padded to width, followed by a space.
+func writeColumn(buf *bytes.Buffer, s string, width int) {
+	buf.WriteString(s)
+	if pad := width - len([]rune(s)); pad > 0 {
+		buf.WriteString(strings.Repeat(" ", pad))
+	}
+	buf.WriteByte(' ')
+}
+
+// colorize writes s wrapped in the escape sequences for the provided SGR
+// parameters, or as-is if they are empty.
+func colorize(buf *bytes.Buffer, sgr, s string) {
+	if sgr == "" {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString("\x1b[")
+	buf.WriteString(sgr)
+	buf.WriteByte('m')
+	buf.WriteString(s)
+	buf.WriteString("\x1b[0m")
+}
+
+// writeValue writes the value, quoted if it is empty or contains spaces,
+// quotation marks, or non-printable characters.
+func writeValue(buf *bytes.Buffer, v string) {
+	if v == "" || strings.IndexFunc(v, func(r rune) bool {
+		return r <= ' ' || r == '"' || !unicode.IsPrint(r)
+	}) >= 0 {
+		buf.WriteString(strconv.Quote(v))
+		return
+	}
+	buf.WriteString(v)
+}
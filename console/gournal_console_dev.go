@@ -0,0 +1,211 @@
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// StackKey is the name of the field rendered by a DevFormatter as a
+	// stack trace, such as the one added by gournal.Recover.
+	StackKey = "stack"
+
+	// InlineWidth is the maximum length of the values a DevFormatter
+	// returned by NewDevFormatter renders on the same line as the message.
+	InlineWidth = 40
+)
+
+// DevFormatter is a Formatter for developers' terminals. Each entry begins
+// with a line containing a short timestamp, the level, the message, the
+// fields with short, scalar values, and the source location of the log
+// function call. Large and nested fields are rendered below the line as
+// indented JSON, and stack traces are indented under the entry:
+//
+//	12:00:00.000 ERROR recovered from panic panic=oops  (main.go:42)
+//	    config={
+//	      "retries": 3
+//	    }
+//	    stack=
+//	      goroutine 1 [running]:
+//	      ...
+//
+// The logger name, if any, precedes the message. The source location is the
+// entry's caller, formatted with gournal.FormatCaller, if it was captured
+// with gournal.CaptureCaller. Otherwise it is only available when the
+// Formatter is used by an Appender that formats entries on the goroutine
+// that emitted them, so it is not written for entries delivered by
+// asynchronous Appenders.
+type DevFormatter struct {
+	color       bool
+	palette     Palette
+	timeFormat  string
+	inlineWidth int
+	source      bool
+}
+
+// NewDev returns an Appender that writes entries to w with a DevFormatter
+// returned by NewDevFormatter.
+func NewDev(w io.Writer) gournal.Appender {
+	return gournal.NewAppenderWithFormatter(w, NewDevFormatter(w))
+}
+
+// NewDevFormatter returns a DevFormatter for entries written to w using
+// DefaultPalette, TimeFormat, and InlineWidth that writes source locations.
+// Color is enabled if ColorEnabled(w) is true.
+func NewDevFormatter(w io.Writer) *DevFormatter {
+	return NewDevFormatterWithOptions(
+		ColorEnabled(w), DefaultPalette, TimeFormat, InlineWidth, true)
+}
+
+// NewDevFormatterWithOptions returns a DevFormatter that colors levels with
+// the provided Palette if color is true, writes times with the provided
+// format, renders values longer than inlineWidth below the entry's first
+// line, and writes source locations if source is true.
+func NewDevFormatterWithOptions(
+	color bool,
+	palette Palette,
+	timeFormat string,
+	inlineWidth int,
+	source bool) *DevFormatter {
+
+	return &DevFormatter{
+		color:       color,
+		palette:     palette,
+		timeFormat:  timeFormat,
+		inlineWidth: inlineWidth,
+		source:      source,
+	}
+}
+
+// Format writes the Record to the buffer.
+func (f *DevFormatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	if f.timeFormat != "" {
+		buf.WriteString(rec.Time.Format(f.timeFormat))
+		buf.WriteByte(' ')
+	}
+	sgr := ""
+	if f.color {
+		sgr = f.palette[rec.Level]
+	}
+	colorize(buf, sgr, fmt.Sprintf("%-5s", rec.Level.String()))
+	buf.WriteByte(' ')
+	if rec.Name != "" {
+		writeColumn(
+			buf, gournal.FormatName(rec.Name), gournal.NameWidth)
+	}
+	buf.WriteString(rec.Message)
+
+	var block []string
+	for _, k := range gournal.SortKeys(rec.Fields) {
+		if k == StackKey {
+			continue
+		}
+		v, multiline := f.render(rec.Fields[k])
+		if multiline {
+			block = append(block, k, v)
+			continue
+		}
+		buf.WriteByte(' ')
+		colorize(buf, sgr, k)
+		buf.WriteByte('=')
+		buf.WriteString(v)
+	}
+
+	if f.source && rec.Caller != nil {
+		buf.WriteString("  (")
+		buf.WriteString(gournal.FormatCaller(rec.Caller))
+		buf.WriteByte(')')
+	} else if f.source {
+		if file, line, ok := caller(); ok {
+			buf.WriteString("  (")
+			buf.WriteString(filepath.Base(file))
+			buf.WriteByte(':')
+			buf.WriteString(strconv.Itoa(line))
+			buf.WriteByte(')')
+		}
+	}
+	buf.WriteByte('\n')
+
+	for i := 0; i < len(block); i += 2 {
+		buf.WriteString("    ")
+		colorize(buf, sgr, block[i])
+		buf.WriteByte('=')
+		buf.WriteString(indent(block[i+1], "    "))
+		buf.WriteByte('\n')
+	}
+	if v, ok := rec.Fields[StackKey]; ok {
+		buf.WriteString("    ")
+		colorize(buf, sgr, StackKey)
+		buf.WriteString("=\n      ")
+		buf.WriteString(indent(
+			strings.TrimRight(fmt.Sprint(v), "\n"), "      "))
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+// render returns the value as a string and whether it is rendered on its
+// own lines. Nested values are rendered as JSON, indented if they are
+// rendered on their own lines, and other values rendered on the first line
+// are quoted if necessary.
+func (f *DevFormatter) render(v interface{}) (string, bool) {
+	if v != nil {
+		switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			if _, ok := v.(fmt.Stringer); ok {
+				break
+			}
+			if _, ok := v.(error); ok {
+				break
+			}
+			buf, err := json.Marshal(v)
+			if err != nil {
+				break
+			}
+			if len(buf) <= f.inlineWidth {
+				return string(buf), false
+			}
+			buf, _ = json.MarshalIndent(v, "", "  ")
+			return string(buf), true
+		}
+	}
+	s := fmt.Sprint(v)
+	if strings.Contains(s, "\n") || len(s) > f.inlineWidth {
+		return s, true
+	}
+	buf := &bytes.Buffer{}
+	writeValue(buf, s)
+	return buf.String(), false
+}
+
+// indent indents every line of s after the first with the prefix.
+func indent(s, prefix string) string {
+	return strings.Replace(s, "\n", "\n"+prefix, -1)
+}
+
+// caller returns the source location of the first function on the stack
+// outside of Gournal, or of the first function in a test file.
+func caller() (string, int, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		fr, more := frames.Next()
+		if !strings.HasPrefix(fr.Function, "github.com/akutz/gournal") ||
+			strings.HasSuffix(fr.File, "_test.go") {
+			return fr.File, fr.Line, fr.Function != ""
+		}
+		if !more {
+			return "", 0, false
+		}
+	}
+}
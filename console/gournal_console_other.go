@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package console
+
+import "os"
+
+// enableVirtualTerminal returns true since terminals other than the
+// Windows console process escape sequences.
+func enableVirtualTerminal(f *os.File) bool {
+	return true
+}
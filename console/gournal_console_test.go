@@ -0,0 +1,135 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+var testRecord = &gournal.Record{
+	Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+	Level:   gournal.InfoLevel,
+	Message: "Hello Bob",
+	Fields:  map[string]interface{}{"size": 1, "name": "Bob Smith"},
+}
+
+func TestFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := NewFormatterWithOptions(false, DefaultPalette, TimeFormat, 12)
+	assert.NoError(t, f.Format(buf, testRecord))
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    testRecord.Time,
+		Level:   gournal.ErrorLevel,
+		Message: "Goodbye",
+	}))
+	assert.Equal(t,
+		"12:00:00.000 INFO  Hello Bob    name=\"Bob Smith\" size=1\n"+
+			"12:00:00.000 ERROR Goodbye\n",
+		buf.String())
+}
+
+func TestFormatterColor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := NewFormatterWithOptions(
+		true, Palette{gournal.InfoLevel: "32"}, "", 0)
+	assert.NoError(t, f.Format(buf, testRecord))
+	assert.Equal(t,
+		"\x1b[32mINFO \x1b[0m Hello Bob "+
+			"\x1b[32mname\x1b[0m=\"Bob Smith\" \x1b[32msize\x1b[0m=1\n",
+		buf.String())
+
+	buf.Reset()
+	testRecord.Level = gournal.WarnLevel
+	defer func() { testRecord.Level = gournal.InfoLevel }()
+	assert.NoError(t, f.Format(buf, testRecord))
+	assert.Equal(t,
+		"WARN  Hello Bob name=\"Bob Smith\" size=1\n", buf.String())
+}
+
+func TestColorEnabled(t *testing.T) {
+	assert.False(t, ColorEnabled(&bytes.Buffer{}))
+
+	f, err := ioutil.TempFile("", "gournal-console")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	assert.False(t, ColorEnabled(f))
+}
+
+func TestDevFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := NewDevFormatterWithOptions(false, DefaultPalette, TimeFormat, 20, false)
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    testRecord.Time,
+		Level:   gournal.ErrorLevel,
+		Message: "recovered from panic",
+		Fields: map[string]interface{}{
+			"panic": "oh no",
+			"size":  1,
+			"tags":  []string{"a"},
+			"config": map[string]interface{}{
+				"retries": 3,
+				"timeout": "30s",
+			},
+			"query": "SELECT * FROM volumes WHERE id = 1",
+			"stack": "goroutine 1 [running]:\nmain.main()\n",
+		},
+	}))
+	assert.Equal(t,
+		"12:00:00.000 ERROR recovered from panic panic=\"oh no\" size=1 "+
+			`tags=["a"]`+"\n"+
+			"    config={\n"+
+			"      \"retries\": 3,\n"+
+			"      \"timeout\": \"30s\"\n"+
+			"    }\n"+
+			"    query=SELECT * FROM volumes WHERE id = 1\n"+
+			"    stack=\n"+
+			"      goroutine 1 [running]:\n"+
+			"      main.main()\n",
+		buf.String())
+}
+
+func TestDevFormatterSource(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := gournal.NewAppenderWithFormatter(
+		buf, NewDevFormatterWithOptions(false, nil, "", 40, true))
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	gournal.Error(ctx, "Hello Bob")
+	assert.Regexp(t,
+		`^ERROR Hello Bob  \(gournal_console_test\.go:\d+\)\n$`,
+		buf.String())
+}
+
+func TestFormatterNameCaller(t *testing.T) {
+	defer func() { gournal.NameWidth, gournal.CallerWidth = 0, 0 }()
+	gournal.NameWidth, gournal.CallerWidth = 4, 14
+
+	buf := &bytes.Buffer{}
+	f := NewFormatterWithOptions(false, DefaultPalette, "", 0)
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Level:   gournal.InfoLevel,
+		Message: "Hello Bob",
+		Name:    "db",
+		Caller:  &gournal.Caller{File: "/src/app/main.go", Line: 12},
+	}))
+	assert.Equal(t, "INFO  db   app/main.go:12 Hello Bob\n", buf.String())
+
+	buf.Reset()
+	f2 := NewDevFormatterWithOptions(false, nil, "", 40, true)
+	assert.NoError(t, f2.Format(buf, &gournal.Record{
+		Level:   gournal.InfoLevel,
+		Message: "Hello Bob",
+		Name:    "database",
+		Caller:  &gournal.Caller{File: "/src/app/main.go", Line: 12},
+	}))
+	assert.Equal(t, "INFO  …ase Hello Bob  (app/main.go:12)\n", buf.String())
+}
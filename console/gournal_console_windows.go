@@ -0,0 +1,23 @@
+package console
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal enables the processing of escape sequences by the
+// console and returns false if it cannot be enabled.
+func enableVirtualTerminal(f *os.File) bool {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return false
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return true
+	}
+	err := windows.SetConsoleMode(
+		h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	return err == nil
+}
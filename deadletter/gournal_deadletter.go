@@ -0,0 +1,99 @@
+// Package deadletter provides a Gournal Appender that captures the entries
+// another Appender is unable to deliver, for example once a retry or
+// breaker Appender gives up, so they may be re-driven later.
+//
+// By default, undeliverable entries are written to a local file as
+// newline-delimited JSON Records that may be re-driven with Redrive or
+// decoded with the replay package.
+package deadletter
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/replay"
+	"github.com/akutz/gournal/transform"
+)
+
+var (
+	// ErrorKey is the key of the field that records why an entry could not
+	// be delivered.
+	ErrorKey = "dead_letter_error"
+
+	// TimeKey is the key of the field that records when an entry could not
+	// be delivered.
+	TimeKey = "dead_letter_time"
+)
+
+// New returns an Appender that delivers entries to next and appends the
+// entries it is unable to deliver to the file at the provided path, which
+// is created if it does not exist.
+func New(next gournal.Appender, path string) (gournal.Appender, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithOptions(next, replay.NewRecorder(f)), nil
+}
+
+// NewWithOptions returns an Appender that delivers entries to next and
+// delivers the entries next is unable to deliver to the dead-letter sink.
+// Failures are detected with gournal.TryAppend.
+//
+// Entries delivered to the sink include the ErrorKey and TimeKey fields,
+// which describe the failure.
+func NewWithOptions(next, sink gournal.Appender) gournal.Appender {
+	return &appender{next: next, sink: sink}
+}
+
+type appender struct {
+	next gournal.Appender
+	sink gournal.Appender
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	err := gournal.TryAppend(a.next, ctx, lvl, fields, msg)
+	if err == nil {
+		return
+	}
+
+	// the fields may be shared with the caller, so they are copied
+	m := make(map[string]interface{}, len(fields)+2)
+	for k, v := range fields {
+		m[k] = v
+	}
+	m[ErrorKey] = err.Error()
+	m[TimeKey] = gournal.Clock().UTC().Format(time.RFC3339Nano)
+	a.sink.Append(ctx, lvl, m, msg)
+}
+
+// Redrive delivers the entries in the dead-letter file at the provided path
+// to the Appender using the replay package. The ErrorKey and TimeKey fields
+// are removed from each entry. The number of entries delivered is returned
+// along with the first error other than io.EOF encountered while reading
+// the file.
+//
+// The file is not modified. Callers that re-drive entries into an Appender
+// returned by New should use a different path or remove the file first.
+func Redrive(
+	ctx context.Context,
+	path string,
+	a gournal.Appender) (int, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return replay.Replay(
+		ctx,
+		replay.NewJSONDecoder(f),
+		transform.New(a, transform.Drop(ErrorKey, TimeKey)))
+}
@@ -0,0 +1,83 @@
+package deadletter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/breaker"
+	"github.com/akutz/gournal/replay"
+)
+
+type failingWriter struct {
+	err error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestDeadLetter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gournal-deadletter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dead.ndjson")
+
+	next := breaker.NewWithOptions(
+		gournal.NewAppenderWithOptions(
+			&failingWriter{errors.New("network down")}),
+		nil, 1, time.Hour)
+	a, err := New(next, path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	fields := map[string]interface{}{"size": 1}
+	gournal.WithFields(fields).Error(ctx, "one")
+	gournal.Error(ctx, "two")
+	assert.Len(t, fields, 1)
+
+	buf, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Contains(t, string(buf), `"dead_letter_error":"network down"`)
+	assert.Contains(t, string(buf), `"dead_letter_error":"`+
+		breaker.ErrOpen.Error()+`"`)
+
+	out := &bytes.Buffer{}
+	n, err := Redrive(nil, path, gournal.NewAppenderWithOptions(out))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "[ERROR] one map[size:1]\n[ERROR] two\n", out.String())
+}
+
+func TestDeadLetterUnmarshalableFields(t *testing.T) {
+	when := time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
+	defer func() { gournal.Clock = time.Now }()
+	gournal.Clock = func() time.Time { return when }
+
+	buf := &bytes.Buffer{}
+	a := NewWithOptions(
+		gournal.NewAppenderWithOptions(
+			&failingWriter{errors.New("network down")}),
+		replay.NewRecorder(buf))
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	gournal.WithField("ch", make(chan int)).Error(ctx, "one")
+	assert.Contains(t, buf.String(), `"msg":"one"`)
+	assert.Contains(t, buf.String(), `"ch":"0x`)
+	assert.Contains(t, buf.String(),
+		`"dead_letter_time":"2017-10-01T12:00:00Z"`)
+}
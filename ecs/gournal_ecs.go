@@ -0,0 +1,144 @@
+// Package ecs provides a Gournal Formatter that formats entries as Elastic
+// Common Schema (ECS) JSON documents, so Elastic ingest pipelines need no
+// processors to index them:
+//
+//	{"@timestamp":"2017-10-01T12:00:00Z","log.level":"error",
+//	 "message":"Hello Bob","ecs.version":"1.6.0",
+//	 "error":{"message":"EOF"},"labels":{"size":"1"}}
+//
+// Well-known fields, such as the error added by WithError and the trace IDs
+// added by the propagation package, are relocated to their ECS fields. The
+// remaining fields are written as labels.
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// Version is the version of ECS to which the documents written by a
+	// Formatter conform.
+	Version = "1.6.0"
+
+	// Fields maps the names of well-known fields to the ECS fields to which
+	// a Formatter returned by NewFormatter relocates them. The names of ECS
+	// fields are dotted paths, ex. "error.message".
+	Fields = map[string]string{
+		gournal.ErrorKey:     "error.message",
+		"stack":              "error.stack_trace",
+		"trace_id":           "trace.id",
+		"span_id":            "span.id",
+		gournal.RequestIDKey: "http.request.id",
+	}
+)
+
+// Formatter is an ECS Formatter.
+type Formatter struct {
+	fields map[string]string
+}
+
+// New returns an Appender that writes ECS documents to w with a Formatter
+// returned by NewFormatter.
+func New(w io.Writer) gournal.Appender {
+	return gournal.NewAppenderWithFormatter(w, NewFormatter())
+}
+
+// NewFormatter returns a Formatter that relocates well-known fields using
+// Fields.
+func NewFormatter() *Formatter {
+	return NewFormatterWithOptions(Fields)
+}
+
+// NewFormatterWithOptions returns a Formatter that writes each entry as a
+// newline-delimited ECS document. The fields of an entry that are named in
+// the provided map are written as the ECS fields to which they are mapped,
+// and the remaining fields are written as labels formatted with fmt.Sprint,
+// since ECS labels are keywords.
+func NewFormatterWithOptions(fields map[string]string) *Formatter {
+	f := &Formatter{fields: make(map[string]string, len(fields))}
+	for k, v := range fields {
+		f.fields[k] = v
+	}
+	return f
+}
+
+// Format writes the Record to the buffer.
+func (f *Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	doc := map[string]interface{}{}
+	var labels gournal.OrderedFields
+	for k, v := range rec.Fields {
+		if path, ok := f.fields[k]; ok {
+			if _, err := json.Marshal(v); err != nil {
+				v = fmt.Sprint(v)
+			}
+			set(doc, strings.Split(path, "."), v)
+			continue
+		}
+		if labels == nil {
+			labels = gournal.OrderedFields{}
+		}
+		labels[strings.Replace(k, ".", "_", -1)] = fmt.Sprint(v)
+	}
+	if labels != nil {
+		doc["labels"] = labels
+	}
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	// the ECS logging specification requires the first three keys to be
+	// @timestamp, log.level, and message
+	buf.WriteString(`{"@timestamp":`)
+	enc.Encode(rec.Time.UTC().Format(time.RFC3339Nano))
+	buf.Truncate(buf.Len() - 1)
+	buf.WriteString(`,"log.level":`)
+	enc.Encode(strings.ToLower(rec.Level.String()))
+	buf.Truncate(buf.Len() - 1)
+	buf.WriteString(`,"message":`)
+	enc.Encode(rec.Message)
+	buf.Truncate(buf.Len() - 1)
+	buf.WriteString(`,"ecs.version":`)
+	enc.Encode(Version)
+	buf.Truncate(buf.Len() - 1)
+
+	keys := make([]string, 0, len(doc))
+	for k := range doc {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		buf.WriteByte(',')
+		enc.Encode(k)
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(doc[k]); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// set sets the value at the path in the document, creating the objects
+// along the path as needed. Values that conflict with an existing value
+// replace it.
+func set(doc map[string]interface{}, path []string, v interface{}) {
+	for _, k := range path[:len(path)-1] {
+		child, ok := doc[k].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			doc[k] = child
+		}
+		doc = child
+	}
+	doc[path[len(path)-1]] = v
+}
@@ -0,0 +1,53 @@
+package ecs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, NewFormatter().Format(buf, &gournal.Record{
+		Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:   gournal.ErrorLevel,
+		Message: "Hello <Bob>",
+		Fields: map[string]interface{}{
+			"error":     "EOF",
+			"stack":     "main.main()",
+			"trace_id":  "abc",
+			"span_id":   "def",
+			"size":      1,
+			"http.port": 80,
+		},
+	}))
+	assert.Equal(t,
+		`{"@timestamp":"2017-10-01T12:00:00Z","log.level":"error",`+
+			`"message":"Hello <Bob>","ecs.version":"1.6.0",`+
+			`"error":{"message":"EOF","stack_trace":"main.main()"},`+
+			`"labels":{"http_port":"80","size":"1"},`+
+			`"span":{"id":"def"},"trace":{"id":"abc"}}`+"\n",
+		buf.String())
+
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+}
+
+func TestNew(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := New(buf)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	ctx = gournal.WithTime(ctx, time.Unix(0, 0))
+	gournal.WithField("ch", make(chan int)).Error(ctx, "Hello Bob")
+	assert.Regexp(t,
+		`^{"@timestamp":"1970-01-01T00:00:00Z","log.level":"error",`+
+			`"message":"Hello Bob","ecs.version":"1.6.0",`+
+			`"labels":{"ch":"0x[0-9a-f]+"}}\n$`,
+		buf.String())
+}
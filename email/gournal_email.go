@@ -0,0 +1,393 @@
+// Package email provides a Gournal Appender that emails critical entries
+// for environments without chat or paging integrations. FATAL and PANIC
+// entries are emailed immediately, and ERROR entries are batched into
+// digests.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// Level is the least severe level of the entries emailed by an Appender
+	// returned by New.
+	Level = gournal.ErrorLevel
+
+	// Subject is the text/template with which an Appender returned by New
+	// renders the subject of an email. It is executed with a Data object.
+	Subject = "[{{.Level}}] {{.Host}}: {{(index .Records 0).Message}}" +
+		"{{if gt .Count 1}} (+{{.Count}} entries){{end}}"
+
+	// Body is the text/template with which an Appender returned by New
+	// renders the body of an email. It is executed with a Data object.
+	Body = `{{range .Records}}{{.Time.Format "2006-01-02T15:04:05.000Z07:00"}}` +
+		` [{{.Level}}] {{.Message}}{{range $k, $v := .Fields}}` +
+		` {{$k}}={{$v}}{{end}}
+{{end}}{{if .Omitted}}
+{{.Omitted}} entries were omitted.
+{{end}}`
+
+	// DigestInterval is the amount of time after which an Appender returned
+	// by New emails the digest of the entries buffered so far.
+	DigestInterval = 10 * time.Minute
+
+	// DigestSize is the number of entries after which an Appender returned
+	// by New emails a digest.
+	DigestSize = 100
+
+	// Limit is the number of digests an Appender returned by New emails per
+	// Period. The entries of digests beyond the limit are omitted.
+	Limit = 6
+
+	// Period is the amount of time over which Limit applies.
+	Period = time.Hour
+
+	// Timeout is the maximum amount of time to wait for a connection to the
+	// SMTP server.
+	Timeout = 10 * time.Second
+)
+
+// Server is an SMTP server.
+type Server struct {
+
+	// Addr is the host and port of the server, ex. "smtp.example.com:587".
+	Addr string
+
+	// TLSConfig is the configuration of TLS connections to the server. If
+	// it is nil, STARTTLS is used if the server supports it with the
+	// default configuration. Otherwise the server is required to support
+	// STARTTLS.
+	TLSConfig *tls.Config
+
+	// ImplicitTLS indicates whether the connection to the server uses TLS
+	// from the start, as on port 465, rather than STARTTLS.
+	ImplicitTLS bool
+
+	// Auth, if not nil, authenticates with the server, ex. smtp.PlainAuth.
+	Auth smtp.Auth
+}
+
+// Data is the data with which the subject and body templates are executed.
+type Data struct {
+
+	// Host is the name of the host reported by the kernel.
+	Host string
+
+	// Level is the level of the most severe entry.
+	Level gournal.Level
+
+	// Records are the emailed entries, oldest first.
+	Records []gournal.Record
+
+	// Count is the number of Records.
+	Count int
+
+	// Omitted is the number of entries that were not emailed because of
+	// the Appender's limit since the last email.
+	Omitted int
+}
+
+// ErrNoSTARTTLS is returned when a Server has a TLSConfig but the SMTP
+// server does not support STARTTLS.
+var ErrNoSTARTTLS = errors.New("email: server does not support STARTTLS")
+
+// Appender is an email Appender.
+type Appender struct {
+	next    gournal.Appender
+	srv     Server
+	from    string
+	to      []string
+	lvl     gournal.Level
+	subject *template.Template
+	body    *template.Template
+	batcher *gournal.Batcher
+	host    string
+
+	// mu protects the rate limiter's state
+	mu      sync.Mutex
+	limit   float64
+	period  time.Duration
+	tokens  float64
+	last    time.Time
+	omitted int
+}
+
+// now is replaced by tests.
+var now = time.Now
+
+// New returns an Appender that emails entries from the provided address to
+// the provided addresses in front of next using Level, Subject, Body,
+// DigestInterval, DigestSize, Limit, and Period.
+func New(
+	next gournal.Appender, srv Server, from string, to ...string) *Appender {
+
+	a, err := NewWithOptions(
+		next, srv, from, to, Level, Subject, Body,
+		DigestInterval, DigestSize, Limit, Period)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// NewWithOptions returns an Appender that delivers every entry to next and
+// emails the entries at or above the provided level from the provided
+// address to the provided addresses with srv. If next is nil, entries are
+// only emailed.
+//
+// FATAL and PANIC entries are emailed immediately, after the digest of any
+// buffered entries, before they are delivered to next, which is expected to
+// exit or panic. Other entries are buffered and emailed as a digest once
+// digestSize entries are buffered or, if digestInterval is greater than
+// zero, each time the interval elapses. The subject and body of each email
+// are rendered by executing the provided text/templates with a Data object.
+//
+// To protect against floods of email, at most limit digests are emailed per
+// period. The entries of digests beyond the limit are omitted, counted with
+// gournal.RecordDropped, and reported in the next email. Errors are
+// reported with gournal.HandleError.
+//
+// The returned Appender must be closed to email the final digest.
+func NewWithOptions(
+	next gournal.Appender,
+	srv Server,
+	from string,
+	to []string,
+	lvl gournal.Level,
+	subject, body string,
+	digestInterval time.Duration,
+	digestSize int,
+	limit int,
+	period time.Duration) (*Appender, error) {
+
+	st, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return nil, err
+	}
+	bt, err := template.New("body").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		next = gournal.Discard
+	}
+	host, _ := os.Hostname()
+	a := &Appender{
+		next:    next,
+		srv:     srv,
+		from:    from,
+		to:      to,
+		lvl:     lvl,
+		subject: st,
+		body:    bt,
+		host:    host,
+		limit:   float64(limit),
+		period:  period,
+		tokens:  float64(limit),
+		last:    now(),
+	}
+	a.batcher = gournal.NewBatcher(
+		digester{a}, digestSize, digestInterval)
+	return a, nil
+}
+
+// Append emails the entry or adds it to the digest if it is severe enough,
+// and then delivers it to the next Appender.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	switch {
+	case lvl <= gournal.FatalLevel:
+		a.batcher.Flush()
+		a.send([]gournal.Record{{
+			Time:    gournal.TimeFrom(ctx),
+			Level:   lvl,
+			Message: msg,
+			Fields:  fields,
+			Name:    gournal.NameFrom(ctx),
+			Caller:  gournal.CallerFrom(ctx),
+		}}, true)
+	case lvl <= a.lvl:
+		a.batcher.Append(ctx, lvl, fields, msg)
+	}
+	a.next.Append(ctx, lvl, fields, msg)
+}
+
+// Flush emails the digest of any buffered entries.
+func (a *Appender) Flush() {
+	a.batcher.Flush()
+}
+
+// Close emails the digest of any buffered entries.
+func (a *Appender) Close() error {
+	return a.batcher.Close()
+}
+
+// digester implements gournal.BatchAppender for an Appender's Batcher
+// without exporting AppendBatch from the Appender.
+type digester struct {
+	a *Appender
+}
+
+func (d digester) AppendBatch(recs []gournal.Record) {
+	d.a.send(recs, false)
+}
+
+// send emails the Records. Unless force is true, the Records are omitted if
+// the limit does not allow them to be emailed.
+func (a *Appender) send(recs []gournal.Record, force bool) {
+	data := &Data{Host: a.host, Level: gournal.DebugLevel}
+	if !a.allow(len(recs), force, data) {
+		gournal.RecordDropped(len(recs))
+		return
+	}
+	data.Records = recs
+	data.Count = len(recs)
+	for _, rec := range recs {
+		if rec.Level < data.Level {
+			data.Level = rec.Level
+		}
+	}
+
+	msg, err := a.message(data)
+	if err == nil {
+		err = a.srv.send(a.from, a.to, msg)
+	}
+	if err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+// allow reports whether the limit allows n entries to be emailed, or
+// records that they were omitted if it does not. If it does, the number of
+// entries that were omitted since the last email is stored in data.
+func (a *Appender) allow(n int, force bool, data *Data) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.period > 0 {
+		t := now()
+		a.tokens += a.limit * float64(t.Sub(a.last)) / float64(a.period)
+		if a.tokens > a.limit {
+			a.tokens = a.limit
+		}
+		a.last = t
+		if a.tokens < 1 && !force {
+			a.omitted += n
+			return false
+		}
+		a.tokens--
+	}
+	data.Omitted = a.omitted
+	a.omitted = 0
+	return true
+}
+
+// message returns the email message for the data.
+func (a *Appender) message(data *Data) ([]byte, error) {
+	subject := &bytes.Buffer{}
+	if err := a.subject.Execute(subject, data); err != nil {
+		return nil, err
+	}
+	body := &bytes.Buffer{}
+	if err := a.body.Execute(body, data); err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "From: %s\r\n", a.from)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(a.to, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode(
+		"utf-8", strings.Join(strings.Fields(subject.String()), " ")))
+	fmt.Fprintf(buf, "Date: %s\r\n", now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.Replace(
+		strings.Replace(body.String(), "\r\n", "\n", -1), "\n", "\r\n", -1))
+	return buf.Bytes(), nil
+}
+
+// send emails the message from the provided address to the provided
+// addresses.
+func (s *Server) send(from string, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	cfg := &tls.Config{ServerName: host}
+	if s.TLSConfig != nil {
+		cfg = s.TLSConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = host
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: Timeout}
+	var conn net.Conn
+	if s.ImplicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.Addr, cfg)
+	} else {
+		conn, err = dialer.Dial("tcp", s.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !s.ImplicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(cfg); err != nil {
+				return err
+			}
+		} else if s.TLSConfig != nil {
+			return ErrNoSTARTTLS
+		}
+	}
+	if s.Auth != nil {
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
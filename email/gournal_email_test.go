@@ -0,0 +1,185 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+// testServer is an SMTP server that records the messages sent to it.
+type testServer struct {
+	sync.Mutex
+	l    net.Listener
+	msgs []string
+	rcpt [][]string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	s := &testServer{l: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+
+	var rcpt []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt = append(rcpt, strings.TrimSpace(line[8:]))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 Go ahead")
+			var msg []string
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				msg = append(msg, line)
+			}
+			s.Lock()
+			s.msgs = append(s.msgs, strings.Join(msg, ""))
+			s.rcpt = append(s.rcpt, rcpt)
+			s.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *testServer) messages() []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string(nil), s.msgs...)
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := context.WithValue(
+		context.Background(), gournal.LevelKey(), gournal.DebugLevel)
+	ctx = gournal.WithTime(ctx, time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC))
+	return context.WithValue(ctx, gournal.AppenderKey(), a)
+}
+
+func TestEmailDigest(t *testing.T) {
+	s := newTestServer(t)
+	defer s.l.Close()
+
+	a, err := NewWithOptions(
+		nil, Server{Addr: s.l.Addr().String()},
+		"app@example.com", []string{"ops@example.com", "dev@example.com"},
+		gournal.ErrorLevel, "{{.Level}}: {{.Count}} entries", Body,
+		0, 2, 0, 0)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	gournal.Warn(ctx, "Hello Bob")
+	gournal.WithField("size", 1).Error(ctx, "Hello Alice")
+	assert.Len(t, s.messages(), 0)
+	gournal.Error(ctx, "Hello Mary")
+	gournal.Error(ctx, "Hello Carl")
+	assert.NoError(t, a.Close())
+
+	msgs := s.messages()
+	if !assert.Len(t, msgs, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, [][]string{
+		{"<ops@example.com>", "<dev@example.com>"},
+		{"<ops@example.com>", "<dev@example.com>"},
+	}, s.rcpt)
+	assert.Contains(t, msgs[0], "From: app@example.com\r\n")
+	assert.Contains(t, msgs[0], "To: ops@example.com, dev@example.com\r\n")
+	assert.Contains(t, msgs[0], "Subject: ERROR: 2 entries\r\n")
+	assert.Contains(t, msgs[0], "\r\n\r\n"+
+		"2017-10-01T12:00:00.000Z [ERROR] Hello Alice size=1\r\n"+
+		"2017-10-01T12:00:00.000Z [ERROR] Hello Mary\r\n")
+	assert.NotContains(t, msgs[0], "Hello Bob")
+	assert.Contains(t, msgs[1], "Subject: ERROR: 1 entries\r\n")
+	assert.Contains(t, msgs[1], "Hello Carl")
+}
+
+func TestEmailLimit(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	t0 := time.Now()
+	now = func() time.Time { return t0 }
+
+	s := newTestServer(t)
+	defer s.l.Close()
+
+	dropped := gournal.DroppedCount()
+	a, err := NewWithOptions(
+		nil, Server{Addr: s.l.Addr().String()},
+		"app@example.com", []string{"ops@example.com"},
+		gournal.ErrorLevel, Subject, Body, 0, 1, 1, time.Hour)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	gournal.Error(ctx, "Hello Bob")
+	gournal.Error(ctx, "Hello Alice")
+	gournal.Error(ctx, "Hello Mary")
+	assert.Equal(t, dropped+2,
+		gournal.DroppedCount())
+	gournal.Panic(ctx, "Goodbye Carl")
+	assert.NoError(t, a.Close())
+
+	msgs := s.messages()
+	if !assert.Len(t, msgs, 2) {
+		t.FailNow()
+	}
+	assert.Contains(t, msgs[0], "Subject: [ERROR] ")
+	assert.Contains(t, msgs[0], "Hello Bob")
+	assert.Contains(t, msgs[1], "Subject: [PANIC] ")
+	assert.Contains(t, msgs[1], ": Goodbye Carl\r\n")
+	assert.Contains(t, msgs[1], "\r\n2 entries were omitted.\r\n")
+}
+
+func TestEmailNoSTARTTLS(t *testing.T) {
+	s := newTestServer(t)
+	defer s.l.Close()
+
+	srv := &Server{Addr: s.l.Addr().String(), TLSConfig: &tls.Config{}}
+	err := srv.send("app@example.com", []string{"ops@example.com"}, nil)
+	assert.Equal(t, ErrNoSTARTTLS, err)
+}
@@ -0,0 +1,147 @@
+// Package encrypt provides an io.Writer and a Gournal Appender that encrypt
+// each formatted entry with AES-GCM to protect logs at rest.
+//
+// Every entry is written as a single line of base64-encoded text that
+// contains a random nonce followed by the sealed entry. The lines may be
+// decrypted with Decrypt or viewed with the gournal command's -key flag.
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/replay"
+)
+
+// ErrMalformed is returned by Decrypt when a line is too short to contain
+// an encrypted entry.
+var ErrMalformed = errors.New("encrypt: malformed line")
+
+// New returns an Appender that writes each entry to w as an encrypted,
+// newline-delimited JSON Record. The key must be 16, 24, or 32 bytes long to
+// select AES-128, AES-192, or AES-256.
+func New(w io.Writer, key []byte) (gournal.Appender, error) {
+	ew, err := NewWriter(w, key)
+	if err != nil {
+		return nil, err
+	}
+	return replay.NewRecorder(ew), nil
+}
+
+// NewWriter returns an io.Writer that encrypts the data of each call to
+// Write and writes it to w as a single line. Appenders that write each entry
+// with a single call to Write, such as those returned by
+// gournal.NewAppenderWithOptions and replay.NewRecorder, may use the
+// returned writer to encrypt entries individually.
+func NewWriter(w io.Writer, key []byte) (io.Writer, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{w: w, aead: aead}, nil
+}
+
+// Decrypt returns the data of an entry from a line written by a writer
+// returned by NewWriter using the same key. Surrounding whitespace is
+// ignored. Use a Decrypter to decrypt many lines with the same key.
+func Decrypt(key, line []byte) ([]byte, error) {
+	d, err := NewDecrypter(key)
+	if err != nil {
+		return nil, err
+	}
+	return d.Decrypt(line)
+}
+
+// Decrypter decrypts lines written by writers returned by NewWriter using
+// the same key. It is safe for concurrent use.
+type Decrypter struct {
+	aead cipher.AEAD
+}
+
+// NewDecrypter returns a Decrypter for the provided key, which must be 16,
+// 24, or 32 bytes long.
+func NewDecrypter(key []byte) (*Decrypter, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Decrypter{aead}, nil
+}
+
+// Decrypt returns the data of an entry from the line. Surrounding
+// whitespace is ignored.
+func (d *Decrypter) Decrypt(line []byte) ([]byte, error) {
+	return decrypt(d.aead, line)
+}
+
+// ParseKey decodes a hex-encoded key.
+func ParseKey(s string) ([]byte, error) {
+	return hex.DecodeString(string(bytes.TrimSpace([]byte(s))))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func decrypt(aead cipher.AEAD, line []byte) ([]byte, error) {
+	line = bytes.TrimSpace(line)
+	buf := make([]byte, base64.StdEncoding.DecodedLen(len(line)))
+	n, err := base64.StdEncoding.Decode(buf, line)
+	if err != nil {
+		return nil, err
+	}
+	buf = buf[:n]
+	ns := aead.NonceSize()
+	if len(buf) < ns+aead.Overhead() {
+		return nil, ErrMalformed
+	}
+	return aead.Open(nil, buf[:ns], buf[ns:], nil)
+}
+
+type writer struct {
+	sync.Mutex
+	w    io.Writer
+	aead cipher.AEAD
+	buf  []byte
+	line []byte
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	ns := w.aead.NonceSize()
+	if cap(w.buf) < ns {
+		w.buf = make([]byte, ns)
+	}
+	w.buf = w.buf[:ns]
+	if _, err := io.ReadFull(rand.Reader, w.buf); err != nil {
+		return 0, err
+	}
+	w.buf = w.aead.Seal(w.buf, w.buf[:ns], p, nil)
+
+	n := base64.StdEncoding.EncodedLen(len(w.buf))
+	if cap(w.line) < n+1 {
+		w.line = make([]byte, n+1)
+	}
+	w.line = w.line[:n+1]
+	base64.StdEncoding.Encode(w.line, w.buf)
+	w.line[n] = '\n'
+
+	if _, err := w.w.Write(w.line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
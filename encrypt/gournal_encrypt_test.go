@@ -0,0 +1,90 @@
+package encrypt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+var testKey, _ = ParseKey(
+	"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+
+func TestWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, testKey)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(),
+		gournal.NewAppenderWithOptions(w))
+
+	gournal.Error(ctx, "Hello Bob")
+	gournal.Error(ctx, "Hello Mary")
+	assert.NotContains(t, buf.String(), "Hello")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if !assert.Len(t, lines, 2) {
+		t.FailNow()
+	}
+	p, err := Decrypt(testKey, lines[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "[ERROR] Hello Mary\n", string(p))
+
+	_, err = Decrypt(testKey[:16], lines[1])
+	assert.Error(t, err)
+	_, err = Decrypt(testKey, []byte("AAAA"))
+	assert.Equal(t, ErrMalformed, err)
+}
+
+func TestAppender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a, err := New(buf, testKey)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	gournal.WithField("size", 1).Error(ctx, "Hello Bob")
+
+	line, _ := bufio.NewReader(buf).ReadBytes('\n')
+	p, err := Decrypt(testKey, line)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var rec gournal.Record
+	assert.NoError(t, json.Unmarshal(p, &rec))
+	assert.Equal(t, "Hello Bob", rec.Message)
+	assert.Equal(t, gournal.ErrorLevel, rec.Level)
+	assert.EqualValues(t, 1, rec.Fields["size"])
+}
+
+func TestDecrypter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, _ := NewWriter(buf, testKey)
+	w.Write([]byte("Hello Bob"))
+	w.Write([]byte("Hello Mary"))
+
+	d, err := NewDecrypter(testKey)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	r := bufio.NewReader(buf)
+	for _, exp := range []string{"Hello Bob", "Hello Mary"} {
+		line, _ := r.ReadBytes('\n')
+		p, err := d.Decrypt(line)
+		assert.NoError(t, err)
+		assert.Equal(t, exp, string(p))
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, []byte("short"))
+	assert.Error(t, err)
+	_, err = NewDecrypter([]byte("short"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Package execlog streams the output of child processes into Gournal.
+package execlog
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// StdoutLevel is the level at which a process's stdout is logged.
+	StdoutLevel = gournal.InfoLevel
+
+	// StderrLevel is the level at which a process's stderr is logged.
+	StderrLevel = gournal.WarnLevel
+)
+
+// Run starts the provided command, logs each line of its stdout and stderr,
+// and waits for it to exit. Every entry is tagged with the command and its
+// pid, and the process's start and exit status are logged as well.
+//
+// The command's Stdout and Stderr fields must be nil.
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	wait, err := Start(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	return wait()
+}
+
+// Start starts the provided command and logs each line of its stdout and
+// stderr. The returned function must be called to wait for the process to
+// exit and its output to be drained.
+//
+// The command's Stdout and Stderr fields must be nil.
+func Start(ctx context.Context, cmd *exec.Cmd) (func() error, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	cmdStr := strings.Join(cmd.Args, " ")
+	if err := cmd.Start(); err != nil {
+		gournal.WithFields(map[string]interface{}{
+			"cmd":            cmdStr,
+			gournal.ErrorKey: err.Error(),
+		}).Error(ctx, "process failed to start")
+		return nil, err
+	}
+
+	ctx, pop := gournal.PushFields(ctx, map[string]interface{}{
+		"cmd": cmdStr,
+		"pid": cmd.Process.Pid,
+	})
+	gournal.Info(ctx, "process started")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go stream(ctx, &wg, stdout, StdoutLevel)
+	go stream(ctx, &wg, stderr, StderrLevel)
+
+	return func() error {
+		defer pop()
+
+		// all output must be read before calling Wait
+		wg.Wait()
+		err := cmd.Wait()
+
+		code := -1
+		if cmd.ProcessState != nil {
+			code = exitCode(cmd.ProcessState)
+		}
+		entry := gournal.WithField("exitCode", code)
+		if err != nil {
+			entry.WithError(err).Error(ctx, "process exited")
+		} else {
+			entry.Info(ctx, "process exited")
+		}
+		return err
+	}, nil
+}
+
+// exitCode returns the exit code of the process, or -1 if it was killed by
+// a signal. The code is obtained from the process's wait status, such as a
+// syscall.WaitStatus, since os.ProcessState.ExitCode requires Go 1.12.
+func exitCode(ps *os.ProcessState) int {
+	ws, ok := ps.Sys().(interface {
+		ExitStatus() int
+	})
+	if !ok {
+		if ps.Success() {
+			return 0
+		}
+		return -1
+	}
+	return ws.ExitStatus()
+}
+
+func stream(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	r io.Reader,
+	lvl gournal.Level) {
+
+	defer wg.Done()
+	w := gournal.Writer(ctx, lvl)
+	io.Copy(w, r)
+	w.Close()
+}
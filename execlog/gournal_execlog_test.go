@@ -0,0 +1,62 @@
+package execlog
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestRun(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(
+		ctx, gournal.AppenderKey(), gournal.NewAppenderWithOptions(buf))
+
+	cmd := exec.Command("sh", "-c", "echo Hello Bob; echo Hello Mary >&2")
+	assert.NoError(t, Run(ctx, cmd))
+
+	out := buf.String()
+	assert.Contains(t, out, "[INFO] process started")
+	assert.Contains(t, out, "[INFO] Hello Bob")
+	assert.Contains(t, out, "[WARN] Hello Mary")
+	assert.Contains(t, out, "[INFO] process exited map[cmd:sh -c")
+	assert.Contains(t, out, "exitCode:0")
+}
+
+func TestRunFailure(t *testing.T) {
+	buf := &syncBuffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(
+		ctx, gournal.AppenderKey(), gournal.NewAppenderWithOptions(buf))
+
+	assert.Error(t, Run(ctx, exec.Command("sh", "-c", "exit 3")))
+	assert.Contains(t, buf.String(), "[ERROR] process exited")
+	assert.Contains(t, buf.String(), "exitCode:3")
+}
+
+// syncBuffer guards a buffer since a process's stdout and stderr are logged
+// from different goroutines.
+type syncBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
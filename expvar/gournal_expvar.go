@@ -0,0 +1,48 @@
+// Package expvar publishes Gournal's logging activity counters via expvar,
+// so existing debug endpoints expose logging health without additional
+// wiring. The counters are published when the package is imported:
+//
+//	import _ "github.com/akutz/gournal/expvar"
+//
+// The following variables are published:
+//
+//	gournal.entries        the number of entries emitted, by level
+//	gournal.appendErrors   the number of errors reported with HandleError
+//	gournal.dropped        the number of entries recorded with RecordDropped
+//
+// Importing this package, like importing expvar, registers the
+// /debug/vars handler with http.DefaultServeMux. Variables that are already
+// published, for example by another copy of this package, are not
+// published again.
+package expvar
+
+import (
+	stdexpvar "expvar"
+
+	"github.com/akutz/gournal"
+)
+
+func init() {
+	publish("gournal.entries", func() interface{} {
+		m := map[string]int64{}
+		for lvl := gournal.PanicLevel; lvl <= gournal.DebugLevel; lvl++ {
+			m[lvl.String()] = gournal.EntryCount(lvl)
+		}
+		return m
+	})
+	publish("gournal.appendErrors", func() interface{} {
+		return gournal.AppendErrorCount()
+	})
+	publish("gournal.dropped", func() interface{} {
+		return gournal.DroppedCount()
+	})
+}
+
+// publish publishes the function with the provided name unless a variable
+// with the name is already published, in which case expvar.Publish would
+// panic.
+func publish(name string, f func() interface{}) {
+	if stdexpvar.Get(name) == nil {
+		stdexpvar.Publish(name, stdexpvar.Func(f))
+	}
+}
@@ -0,0 +1,36 @@
+package expvar
+
+import (
+	"context"
+	"encoding/json"
+	stdexpvar "expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestExpvar(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), gournal.Discard)
+	gournal.Warn(ctx, "Hello Bob")
+	gournal.RecordDropped(2)
+
+	var entries map[string]int64
+	err := json.Unmarshal(
+		[]byte(stdexpvar.Get("gournal.entries").String()), &entries)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, gournal.EntryCount(gournal.WarnLevel), entries["WARN"])
+	assert.EqualValues(t, 1, entries["WARN"])
+
+	assert.Equal(t, "2", stdexpvar.Get("gournal.dropped").String())
+	assert.Equal(t, "0", stdexpvar.Get("gournal.appendErrors").String())
+
+	// publishing a variable again does not panic
+	publish("gournal.dropped", func() interface{} { return 0 })
+	assert.Equal(t, "2", stdexpvar.Get("gournal.dropped").String())
+}
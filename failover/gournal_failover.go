@@ -0,0 +1,145 @@
+// Package failover provides a Gournal Appender that delivers entries to a
+// primary Appender and fails over to a secondary Appender when the primary
+// is unable to deliver them, for example a remote collector backed by a
+// local file.
+//
+// Delivery failures are detected with gournal.TryAppend, so only primary
+// Appenders that implement gournal.ErrorAppender, or that are slow enough to
+// exceed the configured timeout, cause a failover.
+package failover
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// DefaultTimeout is the amount of time the primary Appender has to
+	// deliver an entry before a failover occurs. A value of zero disables
+	// the timeout.
+	DefaultTimeout time.Duration
+
+	// DefaultProbeInterval is the amount of time after a failover before an
+	// entry is again sent to the primary Appender to probe whether it has
+	// recovered.
+	DefaultProbeInterval = 30 * time.Second
+
+	// ErrTimeout is the error reported when the primary Appender does not
+	// deliver an entry before the timeout elapses.
+	ErrTimeout = errors.New("failover: primary appender timed out")
+)
+
+// New returns an Appender that fails over from the primary to the secondary
+// Appender using DefaultTimeout and DefaultProbeInterval.
+func New(primary, secondary gournal.Appender) gournal.Appender {
+	return NewWithOptions(
+		primary, secondary, DefaultTimeout, DefaultProbeInterval)
+}
+
+// NewWithOptions returns an Appender that delivers entries to the primary
+// Appender. When the primary Appender fails to deliver an entry, or does
+// not deliver it within the timeout, the error is reported with
+// gournal.HandleError and the entry and all subsequent entries are
+// delivered to the secondary Appender.
+//
+// Once the probe interval elapses, the next entry is sent to the primary
+// Appender as a health probe. If it is delivered, the Appender fails back to
+// the primary Appender.
+//
+// An entry that times out may still be delivered by the primary Appender
+// after it is delivered to the secondary Appender. FATAL and PANIC entries
+// are never subject to the timeout.
+func NewWithOptions(
+	primary, secondary gournal.Appender,
+	timeout, probeInterval time.Duration) gournal.Appender {
+
+	return &appender{
+		primary:       primary,
+		secondary:     secondary,
+		timeout:       timeout,
+		probeInterval: probeInterval,
+	}
+}
+
+type appender struct {
+	primary       gournal.Appender
+	secondary     gournal.Appender
+	timeout       time.Duration
+	probeInterval time.Duration
+
+	sync.Mutex
+	failedAt time.Time
+	probing  bool
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if !a.usePrimary() {
+		a.secondary.Append(ctx, lvl, fields, msg)
+		return
+	}
+
+	err := a.tryPrimary(ctx, lvl, fields, msg)
+
+	a.Lock()
+	a.probing = false
+	if err == nil {
+		a.failedAt = time.Time{}
+	} else {
+		a.failedAt = time.Now()
+	}
+	a.Unlock()
+
+	if err != nil {
+		gournal.HandleError(err)
+		a.secondary.Append(ctx, lvl, fields, msg)
+	}
+}
+
+// usePrimary returns a flag indicating whether the entry should be sent to
+// the primary Appender. Only one entry at a time probes a failed primary.
+func (a *appender) usePrimary() bool {
+	a.Lock()
+	defer a.Unlock()
+	if a.failedAt.IsZero() {
+		return true
+	}
+	if a.probing || time.Since(a.failedAt) < a.probeInterval {
+		return false
+	}
+	a.probing = true
+	return true
+}
+
+func (a *appender) tryPrimary(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	if a.timeout <= 0 || lvl <= gournal.FatalLevel {
+		return gournal.TryAppend(a.primary, ctx, lvl, fields, msg)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- gournal.TryAppend(a.primary, ctx, lvl, fields, msg)
+	}()
+
+	t := time.NewTimer(a.timeout)
+	defer t.Stop()
+	select {
+	case err := <-errc:
+		return err
+	case <-t.C:
+		return ErrTimeout
+	}
+}
@@ -0,0 +1,92 @@
+package failover
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type failingWriter struct {
+	err error
+	buf bytes.Buffer
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.buf.Write(p)
+}
+
+func TestFailover(t *testing.T) {
+	var handled []error
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { handled = append(handled, err) }
+
+	primary := &failingWriter{}
+	secondary := &bytes.Buffer{}
+	a := NewWithOptions(
+		gournal.NewAppenderWithOptions(primary),
+		gournal.NewAppenderWithOptions(secondary),
+		0, time.Millisecond*50)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	gournal.Error(ctx, "one")
+	assert.Equal(t, "[ERROR] one\n", primary.buf.String())
+
+	primary.err = errors.New("network down")
+	gournal.Error(ctx, "two")
+	assert.Equal(t, []error{primary.err}, handled)
+	assert.Equal(t, "[ERROR] two\n", secondary.String())
+
+	// the primary is not used until the probe interval elapses
+	primary.err = nil
+	gournal.Error(ctx, "three")
+	assert.Equal(t, "[ERROR] two\n[ERROR] three\n", secondary.String())
+
+	time.Sleep(time.Millisecond * 60)
+	gournal.Error(ctx, "four")
+	gournal.Error(ctx, "five")
+	assert.Equal(
+		t, "[ERROR] one\n[ERROR] four\n[ERROR] five\n", primary.buf.String())
+	assert.Len(t, handled, 1)
+}
+
+type slowAppender struct {
+	release chan struct{}
+}
+
+func (a *slowAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+	<-a.release
+}
+
+func TestFailoverTimeout(t *testing.T) {
+	var handled error
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { handled = err }
+
+	primary := &slowAppender{make(chan struct{})}
+	defer close(primary.release)
+	secondary := &bytes.Buffer{}
+	a := NewWithOptions(
+		primary,
+		gournal.NewAppenderWithOptions(secondary),
+		time.Millisecond, time.Hour)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	gournal.Error(ctx, "one")
+	assert.Equal(t, ErrTimeout, handled)
+	assert.Equal(t, "[ERROR] one\n", secondary.String())
+}
@@ -0,0 +1,157 @@
+// Package file provides a Gournal Appender that writes entries to a file
+// that is rotated once it reaches a maximum size or once a day. Rotated
+// files may be compressed and are removed once they exceed a retention
+// count or age.
+package file
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// MaxSize is the size in bytes at which the file written by an Appender
+	// returned by New is rotated.
+	MaxSize int64 = 100 * 1024 * 1024
+
+	// Daily indicates whether the file written by an Appender returned by
+	// New is rotated once a day.
+	Daily = false
+
+	// MaxBackups is the number of rotated files retained by an Appender
+	// returned by New. Zero retains all rotated files.
+	MaxBackups = 0
+
+	// MaxAge is the amount of time for which rotated files are retained by
+	// an Appender returned by New. Zero retains rotated files indefinitely.
+	MaxAge time.Duration
+
+	// Compress indicates whether the files rotated by an Appender returned
+	// by New are compressed with gzip.
+	Compress = false
+)
+
+// ErrNotSupported is returned by Rotate and Reopen when the Appender's
+// io.WriteCloser does not support the operation.
+var ErrNotSupported = errors.New("file: operation not supported by writer")
+
+// Appender is a file Appender.
+type Appender struct {
+	syncState
+	next gournal.WriteCloserAppender
+	w    io.WriteCloser
+}
+
+// New returns an Appender that writes entries to the file at the provided
+// path in the same format as gournal.NewAppender using MaxSize, Daily,
+// MaxBackups, MaxAge, Compress, and Sync.
+func New(path string) (*Appender, error) {
+	return NewWithOptions(
+		path, nil, MaxSize, Daily, MaxBackups, MaxAge, Compress)
+}
+
+// NewWithOptions returns an Appender that writes entries to the file at the
+// provided path using the provided Formatter, or in the same format as
+// gournal.NewAppender if it is nil, and Sync. See NewWriterWithOptions for
+// the rotation and retention options.
+func NewWithOptions(
+	path string,
+	f gournal.Formatter,
+	maxSize int64,
+	daily bool,
+	maxBackups int,
+	maxAge time.Duration,
+	compress bool) (*Appender, error) {
+
+	w, err := NewWriterWithOptions(
+		path, maxSize, daily, maxBackups, maxAge, compress)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithWriter(w, f), nil
+}
+
+// NewWithWriter returns an Appender that writes entries to the provided
+// io.WriteCloser using the provided Formatter, or in the same format as
+// gournal.NewAppender if it is nil. This allows a writer that rotates files
+// on its own, such as a *lumberjack.Logger, to be used in place of a Writer:
+//
+//	a := file.NewWithWriter(&lumberjack.Logger{
+//		Filename: "/var/log/app.log",
+//		MaxSize:  100,
+//	}, nil)
+//	defer a.Close()
+//
+// The io.WriteCloser is flushed, if it has a Flush or Sync function, by
+// Flush and Close, and before the program exits or panics because of a
+// FATAL or PANIC entry. The file is also synced according to Sync.
+func NewWithWriter(w io.WriteCloser, f gournal.Formatter) *Appender {
+	return NewWithSync(w, f, Sync)
+}
+
+// Append writes the entry to the file. Errors are reported with
+// gournal.HandleError.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+// TryAppend is like Append but returns the error, if any, from writing or
+// syncing the entry.
+func (a *Appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	if err := a.next.TryAppend(ctx, lvl, fields, msg); err != nil {
+		return err
+	}
+	return a.written(lvl)
+}
+
+// Rotate rotates the file regardless of its size or age. ErrNotSupported
+// is returned if the Appender's io.WriteCloser does not have a Rotate
+// function.
+func (a *Appender) Rotate() error {
+	if r, ok := a.w.(interface {
+		Rotate() error
+	}); ok {
+		return r.Rotate()
+	}
+	return ErrNotSupported
+}
+
+// Reopen reopens the file at the Appender's path. It should be called once
+// the file has been renamed by an external tool such as logrotate, ex. upon
+// receiving SIGHUP. ErrNotSupported is returned if the Appender's
+// io.WriteCloser does not have a Reopen function.
+func (a *Appender) Reopen() error {
+	if r, ok := a.w.(interface {
+		Reopen() error
+	}); ok {
+		return r.Reopen()
+	}
+	return ErrNotSupported
+}
+
+// Flush flushes the file.
+func (a *Appender) Flush() error {
+	return a.next.Flush()
+}
+
+// Close flushes and closes the file.
+func (a *Appender) Close() error {
+	a.stopSync()
+	return a.next.Close()
+}
@@ -0,0 +1,64 @@
+package file
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/akutz/gournal"
+)
+
+// ReopenSignals are the signals upon which an Appender reopens its file
+// after ReopenOnSignal is called without any signals. They are SIGHUP and
+// SIGUSR1 on platforms that have them.
+var ReopenSignals = reopenSignals
+
+// ReopenOnSignal reopens the Appender's file each time the process receives
+// one of the provided signals, or one of ReopenSignals if none are
+// provided. This allows the file to be rotated by logrotate with a
+// postrotate script that signals the process, ex.:
+//
+//	postrotate
+//		kill -HUP $(cat /var/run/app.pid)
+//	endscript
+//
+// Entries written between the rename and the signal are written to the
+// renamed file, and entries written after the signal to the new one, so
+// none are lost or duplicated. With copytruncate the file is not renamed,
+// but reopening it resets the size the Appender uses to decide when to
+// rotate the file itself.
+//
+// Errors are reported with gournal.HandleError. The returned function stops
+// handling the signals.
+func (a *Appender) ReopenOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = ReopenSignals
+	}
+	if len(sigs) == 0 {
+		// signal.Notify relays every signal if none are provided
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := a.Reopen(); err != nil {
+					gournal.HandleError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package file
+
+import "os"
+
+var reopenSignals []os.Signal
@@ -0,0 +1,114 @@
+package file
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// SyncPolicy determines when an Appender syncs its file to stable storage.
+// The zero value never syncs the file except before the program exits or
+// panics because of a FATAL or PANIC entry, and when the Appender is
+// flushed or closed.
+type SyncPolicy struct {
+
+	// OnError syncs the file after each ERROR entry.
+	OnError bool
+
+	// Entries, if greater than zero, syncs the file after every Entries
+	// entries.
+	Entries int
+
+	// Interval, if greater than zero, syncs the file each time the interval
+	// elapses if entries have been written since it was last synced.
+	Interval time.Duration
+}
+
+// Sync is the SyncPolicy of Appenders returned by New, NewWithOptions, and
+// NewWithWriter.
+var Sync SyncPolicy
+
+// NewWithSync is like NewWithWriter but uses the provided SyncPolicy. Each
+// sync flushes the io.WriteCloser if it has a Flush or Sync function, as a
+// Writer does. Errors from syncing the file after an entry are returned by
+// TryAppend, and errors from syncing it after an interval are reported with
+// gournal.HandleError.
+//
+// The returned Appender must be closed if the policy has an interval.
+func NewWithSync(
+	w io.WriteCloser, f gournal.Formatter, p SyncPolicy) *Appender {
+
+	a := &Appender{
+		next:   gournal.NewAppenderWithCloser(w, f),
+		w:      w,
+		policy: p,
+		done:   make(chan struct{}),
+	}
+	if p.Interval > 0 {
+		a.wg.Add(1)
+		go a.syncEvery(p.Interval)
+	}
+	return a
+}
+
+// syncState is the state of an Appender's SyncPolicy.
+type syncState struct {
+	policy SyncPolicy
+
+	// mu protects unsynced, the number of entries written since the file
+	// was last synced
+	mu       sync.Mutex
+	unsynced int
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// written records that an entry was written and syncs the file if the
+// Appender's SyncPolicy requires it.
+func (a *Appender) written(lvl gournal.Level) error {
+	a.mu.Lock()
+	a.unsynced++
+	flush := (a.policy.OnError && lvl <= gournal.ErrorLevel) ||
+		(a.policy.Entries > 0 && a.unsynced >= a.policy.Entries)
+	if flush {
+		a.unsynced = 0
+	}
+	a.mu.Unlock()
+
+	if flush {
+		return a.next.Flush()
+	}
+	return nil
+}
+
+func (a *Appender) syncEvery(interval time.Duration) {
+	defer a.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			a.mu.Lock()
+			flush := a.unsynced > 0
+			a.unsynced = 0
+			a.mu.Unlock()
+			if flush {
+				if err := a.next.Flush(); err != nil {
+					gournal.HandleError(err)
+				}
+			}
+		case <-a.done:
+			return
+		}
+	}
+}
+
+// stopSync stops syncing the file each interval.
+func (a *Appender) stopSync() {
+	a.once.Do(func() { close(a.done) })
+	a.wg.Wait()
+}
@@ -0,0 +1,398 @@
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+var testTime = time.Date(2017, 10, 1, 12, 0, 0, 0, time.Local)
+
+func setNow(t time.Time) {
+	now = func() time.Time { return t }
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gournal-file")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return dir
+}
+
+func readDir(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	buf, err := ioutil.ReadFile(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return string(buf)
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := context.WithValue(
+		context.Background(), gournal.LevelKey(), gournal.InfoLevel)
+	return context.WithValue(ctx, gournal.AppenderKey(), a)
+}
+
+func TestFile(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	setNow(testTime)
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logs", "app.log")
+	a, err := New(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	assert.NoError(t, a.Close())
+	assert.Equal(t, "[INFO] Hello Bob\n", readFile(t, path))
+
+	err = gournal.TryAppend(a, ctx, gournal.InfoLevel, nil, "Hello Alice")
+	assert.Equal(t, os.ErrClosed, err)
+}
+
+func TestFileMaxSize(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	a, err := NewWithOptions(path, nil, 20, false, 2, 0, false)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	for i, name := range []string{"Bob", "Alice", "Mary", "Carl"} {
+		setNow(testTime.Add(time.Duration(i) * time.Second))
+		gournal.Info(ctx, "Hello %s", name)
+	}
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, []string{
+		"app-2017-10-01T12-00-02.000.log",
+		"app-2017-10-01T12-00-03.000.log",
+		"app.log",
+	}, readDir(t, dir))
+	assert.Equal(t, "[INFO] Hello Alice\n", readFile(
+		t, filepath.Join(dir, "app-2017-10-01T12-00-02.000.log")))
+	assert.Equal(t, "[INFO] Hello Carl\n", readFile(t, path))
+}
+
+func TestFileMaxSizeSameTime(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	setNow(testTime)
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	// every entry is larger than the maximum size, so the file is rotated
+	// several times in the same millisecond
+	path := filepath.Join(dir, "app.log")
+	a, err := NewWithOptions(path, nil, 5, false, 0, 0, false)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	for _, name := range []string{"Bob", "Alice", "Mary"} {
+		gournal.Info(ctx, "Hello %s", name)
+	}
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, []string{
+		"app-2017-10-01T12-00-00.000.log",
+		"app-2017-10-01T12-00-00.001.log",
+		"app.log",
+	}, readDir(t, dir))
+	assert.Equal(t, "[INFO] Hello Bob\n", readFile(
+		t, filepath.Join(dir, "app-2017-10-01T12-00-00.000.log")))
+	assert.Equal(t, "[INFO] Hello Alice\n", readFile(
+		t, filepath.Join(dir, "app-2017-10-01T12-00-00.001.log")))
+	assert.Equal(t, "[INFO] Hello Mary\n", readFile(t, path))
+}
+
+func TestFileRotateError(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	setNow(testTime)
+	var handled error
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { handled = err }
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	w, err := NewWriterWithOptions(path, 0, false, 0, 0, false)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// the file cannot be renamed once it is removed, so it is reopened
+	assert.NoError(t, os.Remove(path))
+	assert.Error(t, w.Rotate())
+	_, err = w.Write([]byte("Hello Bob\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Bob\n", readFile(t, path))
+
+	w.maxSize = 5
+	assert.NoError(t, os.Remove(path))
+	_, err = w.Write([]byte("Hello Alice\n"))
+	assert.NoError(t, err)
+	assert.Error(t, handled)
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "Hello Alice\n", readFile(t, path))
+	assert.Equal(t, []string{"app.log"}, readDir(t, dir))
+}
+
+func TestFileDaily(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	setNow(testTime)
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	a, err := NewWithOptions(
+		path, gournal.TextFormatter{}, 0, true, 0, 0, true)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	setNow(testTime.Add(time.Hour))
+	gournal.Info(ctx, "Hello Alice")
+	setNow(testTime.Add(24 * time.Hour))
+	gournal.Info(ctx, "Hello Mary")
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, []string{
+		"app-2017-10-02T12-00-00.000.log.gz",
+		"app.log",
+	}, readDir(t, dir))
+
+	f, err := os.Open(filepath.Join(dir, "app-2017-10-02T12-00-00.000.log.gz"))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	buf, err := ioutil.ReadAll(zr)
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf), "Hello Bob")
+	assert.Contains(t, string(buf), "Hello Alice")
+	assert.NotContains(t, readFile(t, path), "Hello Alice")
+	assert.Contains(t, readFile(t, path), "Hello Mary")
+}
+
+func TestFileMaxAge(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	w, err := NewWriterWithOptions(path, 0, false, 0, time.Hour, false)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	setNow(testTime)
+	assert.NoError(t, w.Rotate())
+	setNow(testTime.Add(30 * time.Minute))
+	assert.NoError(t, w.Rotate())
+	setNow(testTime.Add(90 * time.Minute))
+	assert.NoError(t, w.Rotate())
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-2017-10-01T12-30-00.000.log",
+		"app-2017-10-01T13-30-00.000.log",
+		"app.log",
+	}, readDir(t, dir))
+}
+
+func TestFileReopen(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	a, err := New(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	assert.NoError(t, os.Rename(path, path+".1"))
+	gournal.Info(ctx, "Hello Alice")
+	assert.NoError(t, a.Reopen())
+	gournal.Info(ctx, "Hello Mary")
+
+	assert.Equal(
+		t,
+		"[INFO] Hello Bob\n[INFO] Hello Alice\n",
+		readFile(t, path+".1"))
+	assert.Equal(t, "[INFO] Hello Mary\n", readFile(t, path))
+}
+
+// testWriter is an io.WriteCloser with a Rotate function, like a
+// *lumberjack.Logger.
+type testWriter struct {
+	bytes.Buffer
+	rotated int
+	closed  bool
+}
+
+func (w *testWriter) Rotate() error {
+	w.rotated++
+	return nil
+}
+
+func (w *testWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestFileWithWriter(t *testing.T) {
+	w := &testWriter{}
+	a := NewWithWriter(w, gournal.JSONFormatter{})
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	assert.NoError(t, a.Rotate())
+	assert.Equal(t, 1, w.rotated)
+	assert.Equal(t, ErrNotSupported, a.Reopen())
+	assert.NoError(t, a.Flush())
+	assert.NoError(t, a.Close())
+	assert.True(t, w.closed)
+	assert.Contains(t, w.String(), `"msg":"Hello Bob"`)
+}
+
+func TestFileReopenOnSignal(t *testing.T) {
+	if len(ReopenSignals) == 0 {
+		t.Skip("no reopen signals on this platform")
+	}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	a, err := New(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	stop := a.ReopenOnSignal()
+	defer stop()
+
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	assert.NoError(t, os.Rename(path, path+".1"))
+
+	p, err := os.FindProcess(os.Getpid())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NoError(t, p.Signal(ReopenSignals[0]))
+	for i := 0; i < 100; i++ {
+		if _, err = os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	gournal.Info(ctx, "Hello Alice")
+	assert.Equal(t, "[INFO] Hello Bob\n", readFile(t, path+".1"))
+	assert.Equal(t, "[INFO] Hello Alice\n", readFile(t, path))
+}
+
+// testSyncer is an io.WriteCloser that counts the calls to its Sync
+// function.
+type testSyncer struct {
+	sync.Mutex
+	bytes.Buffer
+	synced int
+}
+
+func (w *testSyncer) Sync() error {
+	w.Lock()
+	defer w.Unlock()
+	w.synced++
+	return nil
+}
+
+func (w *testSyncer) Close() error {
+	return nil
+}
+
+func (w *testSyncer) count() int {
+	w.Lock()
+	defer w.Unlock()
+	return w.synced
+}
+
+func TestFileSync(t *testing.T) {
+	w := &testSyncer{}
+	a := NewWithSync(w, nil, SyncPolicy{OnError: true, Entries: 3})
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	gournal.Info(ctx, "Hello Alice")
+	assert.Equal(t, 0, w.count())
+	gournal.Info(ctx, "Hello Mary")
+	assert.Equal(t, 1, w.count())
+	gournal.Error(ctx, "Goodbye Mary")
+	assert.Equal(t, 2, w.count())
+	gournal.Warn(ctx, "Hello Carl")
+	assert.Equal(t, 2, w.count())
+	assert.NoError(t, a.Close())
+	assert.Equal(t, 3, w.count())
+}
+
+func TestFileSyncInterval(t *testing.T) {
+	w := &testSyncer{}
+	a := NewWithSync(w, nil, SyncPolicy{Interval: 10 * time.Millisecond})
+	ctx := newContext(a)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, w.count())
+
+	gournal.Info(ctx, "Hello Bob")
+	for i := 0; i < 100 && w.count() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 1, w.count())
+	assert.NoError(t, a.Close())
+}
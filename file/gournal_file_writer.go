@@ -0,0 +1,349 @@
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// backupTimeFormat is the format of the time in the names of rotated files.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// now is replaced by tests.
+var now = time.Now
+
+// Writer is an io.WriteCloser that writes to a file and rotates it.
+//
+// A rotated file is renamed to the name of the file with the time of the
+// rotation inserted before its extension, ex. app-2017-10-01T12-00-00.000.log,
+// and a new file is created in its place. Rotated files are compressed and
+// removed by a background goroutine.
+type Writer struct {
+	path       string
+	maxSize    int64
+	daily      bool
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	day  string
+
+	// millMu serializes the compression and removal of rotated files
+	millMu sync.Mutex
+	wg     sync.WaitGroup
+}
+
+// NewWriter returns a Writer that writes to the file at the provided path
+// using MaxSize, Daily, MaxBackups, MaxAge, and Compress.
+func NewWriter(path string) (*Writer, error) {
+	return NewWriterWithOptions(
+		path, MaxSize, Daily, MaxBackups, MaxAge, Compress)
+}
+
+// NewWriterWithOptions returns a Writer that appends to the file at the
+// provided path, creating it and its directory if they do not exist.
+//
+// The file is rotated before a write would grow it beyond maxSize bytes if
+// maxSize is greater than zero, and before the first write on a new day if
+// daily is true. A single write larger than maxSize is written to a new file
+// as-is. If the file cannot be rotated, the error is reported with
+// gournal.HandleError and the write is made to the file in its place.
+//
+// Rotated files beyond the newest maxBackups files are removed if
+// maxBackups is greater than zero, as are rotated files older than maxAge if
+// maxAge is greater than zero. Rotated files are compressed with gzip if
+// compress is true.
+func NewWriterWithOptions(
+	path string,
+	maxSize int64,
+	daily bool,
+	maxBackups int,
+	maxAge time.Duration,
+	compress bool) (*Writer, error) {
+
+	w := &Writer{
+		path:       path,
+		maxSize:    maxSize,
+		daily:      daily,
+		maxBackups: maxBackups,
+		maxAge:     maxAge,
+		compress:   compress,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open opens the file at the Writer's path. The day of an existing file is
+// the day it was last modified.
+func (w *Writer) open() error {
+	f, err := os.OpenFile(
+		w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f = f
+	w.size = info.Size()
+	w.day = info.ModTime().Format("2006-01-02")
+	if w.size == 0 {
+		w.day = now().Format("2006-01-02")
+	}
+	return nil
+}
+
+// Write writes p to the file, rotating it first if necessary.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+
+	rotate := w.maxSize > 0 && w.size > 0 &&
+		w.size+int64(len(p)) > w.maxSize
+	if w.daily && now().Format("2006-01-02") != w.day {
+		rotate = rotate || w.size > 0
+	}
+	if rotate {
+		if err := w.rotate(); err != nil {
+			// the entry is written to the reopened file, if any, rather
+			// than lost because the file could not be rotated
+			if w.f == nil {
+				return 0, err
+			}
+			gournal.HandleError(err)
+		}
+	}
+
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file regardless of its size or age. If the file cannot
+// be rotated, it is reopened and the Writer continues to write to it.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+func (w *Writer) rotate() error {
+	err := w.f.Close()
+	w.f = nil
+	t := now()
+	if err == nil {
+		name := w.backupName(t)
+		if err = os.Rename(w.path, name); err == nil {
+			if err = w.open(); err == nil {
+				w.wg.Add(1)
+				go w.mill(t)
+				return nil
+			}
+			// the rotated file is put back so it is reopened below
+			os.Rename(name, w.path)
+		}
+	}
+
+	// the file is reopened so the Writer is not left without one, and the
+	// rotation is attempted again by a later write
+	if oerr := w.open(); oerr != nil {
+		gournal.HandleError(oerr)
+	}
+	return err
+}
+
+// Reopen closes the file and opens the file at the Writer's path in its
+// place, so entries are written to a new file once an external tool such as
+// logrotate has renamed the old one. The new file is opened before the old
+// one is closed, so the Writer is never without a file.
+func (w *Writer) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return os.ErrClosed
+	}
+	old := w.f
+	if err := w.open(); err != nil {
+		w.f = old
+		return err
+	}
+	return old.Close()
+}
+
+// Sync commits the contents of the file to stable storage.
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return os.ErrClosed
+	}
+	return w.f.Sync()
+}
+
+// Close closes the file and waits for rotated files to be compressed and
+// removed.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.f != nil {
+		err = w.f.Close()
+		w.f = nil
+	}
+	w.mu.Unlock()
+	w.wg.Wait()
+	return err
+}
+
+// split returns the name of the Writer's file without its extension, and
+// its extension.
+func (w *Writer) split() (string, string) {
+	ext := filepath.Ext(w.path)
+	return strings.TrimSuffix(w.path, ext), ext
+}
+
+// backupName returns the name to which the file is renamed when it is
+// rotated at the provided time. If a rotated file with that name exists,
+// for example because the file was rotated twice in the same millisecond,
+// the time is advanced a millisecond at a time until the name is unused.
+func (w *Writer) backupName(t time.Time) string {
+	prefix, ext := w.split()
+	for {
+		name := prefix + "-" + t.Format(backupTimeFormat) + ext
+		if !exists(name) && !exists(name+".gz") {
+			return name
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// exists returns true if there is a file at the provided path. Errors are
+// left to the operation that follows.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups returns the Writer's rotated files, newest first.
+func (w *Writer) backups() ([]backup, error) {
+	prefix, ext := w.split()
+	infos, err := ioutil.ReadDir(filepath.Dir(w.path))
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(prefix) + "-"
+	var backups []backup
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(name, base), ".gz")
+		if !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(
+			backupTimeFormat, strings.TrimSuffix(ts, ext), time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{
+			path: filepath.Join(filepath.Dir(w.path), name),
+			time: t,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].time.After(backups[j].time)
+	})
+	return backups, nil
+}
+
+// mill removes and compresses the rotated files as of the provided time.
+// Errors are reported with gournal.HandleError.
+func (w *Writer) mill(t time.Time) {
+	defer w.wg.Done()
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	backups, err := w.backups()
+	if err != nil {
+		gournal.HandleError(err)
+		return
+	}
+	cutoff := t.Add(-w.maxAge)
+	for i, b := range backups {
+		if (w.maxBackups > 0 && i >= w.maxBackups) ||
+			(w.maxAge > 0 && b.time.Before(cutoff)) {
+			if err := os.Remove(b.path); err != nil {
+				gournal.HandleError(err)
+			}
+			continue
+		}
+		if w.compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := compressFile(b.path); err != nil {
+				gournal.HandleError(err)
+			}
+		}
+	}
+}
+
+// compressFile replaces the file at the provided path with a gzip-compressed
+// copy. The copy is written to a temporary file that is renamed once it is
+// complete, so a partially compressed file is never left behind.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
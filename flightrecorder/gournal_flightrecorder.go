@@ -0,0 +1,143 @@
+// Package flightrecorder provides a Gournal Appender that keeps the most
+// recent verbose entries in a ring buffer and delivers them only when an
+// error occurs. This provides DEBUG context around failures without paying
+// to ship DEBUG entries all the time.
+//
+// Since entries are filtered by the level of their Context before they reach
+// an Appender, the Contexts used with a flight recorder should have a level
+// of DEBUG so every entry is recorded.
+package flightrecorder
+
+import (
+	"context"
+	"sync"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// Size is the number of entries buffered by an Appender returned by New.
+	Size = 256
+
+	// Level is the least severe level of the entries that are delivered
+	// immediately by an Appender returned by New.
+	Level = gournal.InfoLevel
+
+	// Trigger is the least severe level of the entries that cause an
+	// Appender returned by New to deliver its buffered entries.
+	Trigger = gournal.ErrorLevel
+)
+
+// New returns an Appender that records entries in front of next using
+// Size, Level, and Trigger.
+func New(next gournal.Appender) gournal.Appender {
+	return NewWithOptions(next, Size, Level, Trigger)
+}
+
+// NewWithOptions returns an Appender that delivers entries at or above the
+// provided level to next immediately and buffers the most recent size
+// entries that are more verbose. When an entry at or above the trigger
+// level arrives, the buffered entries are delivered to next, oldest first,
+// before the entry itself.
+//
+// Buffered entries are delivered with a Context created by gournal.WithTime
+// so Appenders that use gournal.TimeFrom record when they occurred.
+func NewWithOptions(
+	next gournal.Appender,
+	size int,
+	lvl, trigger gournal.Level) gournal.Appender {
+
+	if size < 1 {
+		size = 1
+	}
+	return &appender{
+		next:    next,
+		lvl:     lvl,
+		trigger: trigger,
+		ring:    make([]entry, size),
+	}
+}
+
+type entry struct {
+	ctx    context.Context
+	lvl    gournal.Level
+	fields map[string]interface{}
+	msg    string
+}
+
+type appender struct {
+	next    gournal.Appender
+	lvl     gournal.Level
+	trigger gournal.Level
+
+	sync.Mutex
+	ring  []entry
+	start int
+	count int
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if lvl <= a.trigger {
+		for _, e := range a.drain() {
+			a.next.Append(e.ctx, e.lvl, e.fields, e.msg)
+		}
+	}
+	if lvl <= a.lvl {
+		a.next.Append(ctx, lvl, fields, msg)
+		return
+	}
+	a.record(ctx, lvl, fields, msg)
+}
+
+// record adds the entry to the ring, overwriting the oldest entry if the
+// ring is full. The fields are copied since the entry outlives the call.
+func (a *appender) record(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	e := entry{
+		ctx: gournal.WithTime(ctx, gournal.TimeFrom(ctx)),
+		lvl: lvl,
+		msg: msg,
+	}
+	if len(fields) > 0 {
+		e.fields = make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			e.fields[k] = v
+		}
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	i := (a.start + a.count) % len(a.ring)
+	a.ring[i] = e
+	if a.count < len(a.ring) {
+		a.count++
+	} else {
+		a.start = (a.start + 1) % len(a.ring)
+	}
+}
+
+// drain removes and returns the buffered entries, oldest first.
+func (a *appender) drain() []entry {
+	a.Lock()
+	defer a.Unlock()
+	if a.count == 0 {
+		return nil
+	}
+	entries := make([]entry, a.count)
+	for i := range entries {
+		j := (a.start + i) % len(a.ring)
+		entries[i] = a.ring[j]
+		a.ring[j] = entry{}
+	}
+	a.start, a.count = 0, 0
+	return entries
+}
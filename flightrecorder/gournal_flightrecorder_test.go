@@ -0,0 +1,44 @@
+package flightrecorder
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestAppender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), NewWithOptions(
+		gournal.NewAppenderWithOptions(buf),
+		2,
+		gournal.InfoLevel,
+		gournal.ErrorLevel))
+
+	gournal.Debug(ctx, "one")
+	gournal.Info(ctx, "two")
+	assert.Equal(t, "[INFO] two\n", buf.String())
+
+	fields := map[string]interface{}{"size": 3}
+	gournal.WithFields(fields).Debug(ctx, "three")
+	fields["size"] = 4
+	gournal.Debug(ctx, "four")
+	gournal.Warn(ctx, "five")
+	assert.Equal(t, "[INFO] two\n[WARN] five\n", buf.String())
+	buf.Reset()
+
+	gournal.Error(ctx, "six")
+	assert.Equal(
+		t,
+		"[DEBUG] three map[size:3]\n[DEBUG] four\n[ERROR] six\n",
+		buf.String())
+	buf.Reset()
+
+	gournal.Error(ctx, "seven")
+	assert.Equal(t, "[ERROR] seven\n", buf.String())
+}
@@ -0,0 +1,248 @@
+// Package fluent provides a Gournal Appender that ships entries to Fluentd
+// or Fluent Bit with the Fluentd forward protocol, so programs can send
+// their logs to a cluster's Fluentd without a sidecar.
+//
+// Entries are buffered with a gournal.Batcher and sent in the protocol's
+// Forward mode, one message per tag. When acknowledgements are required,
+// every message carries a chunk ID and is sent again, on a new connection,
+// until Fluentd acknowledges it or MaxAttempts is reached, providing
+// at-least-once delivery.
+package fluent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"text/template"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// Tag is the text/template used by an Appender returned by New to
+	// create the tag of an entry from its gournal.Record, ex.
+	// "app.{{.Fields.service}}".
+	Tag = "gournal"
+
+	// BatchSize is the maximum number of entries sent at once by an
+	// Appender returned by New.
+	BatchSize = 1000
+
+	// Interval is the amount of time after which entries buffered by an
+	// Appender returned by New are sent.
+	Interval = time.Second
+
+	// MaxAttempts is the maximum number of times a message is sent before
+	// its entries are dropped.
+	MaxAttempts = 3
+
+	// Timeout is the amount of time allowed to connect to Fluentd, to send
+	// a message, and to receive its acknowledgement.
+	Timeout = 10 * time.Second
+
+	// LevelKey is the name of the record key that contains an entry's level.
+	LevelKey = "level"
+
+	// MessageKey is the name of the record key that contains an entry's
+	// message.
+	MessageKey = "message"
+
+	// ErrAck is the error reported when Fluentd does not acknowledge a
+	// message with its chunk ID.
+	ErrAck = errors.New("fluent: invalid ack")
+)
+
+// Appender is a Fluentd forward protocol Appender.
+type Appender struct {
+	batcher *gournal.Batcher
+	f       *forwarder
+}
+
+type forwarder struct {
+	network string
+	addr    string
+	tls     *tls.Config
+	tag     *template.Template
+	ack     bool
+	conn    net.Conn
+}
+
+// New returns an Appender that sends entries over TCP to the Fluentd
+// instance at the provided address using Tag, BatchSize, and Interval, and
+// that requires acknowledgements.
+func New(addr string) (*Appender, error) {
+	return NewWithOptions("tcp", addr, nil, Tag, BatchSize, Interval, true)
+}
+
+// NewWithOptions returns an Appender that sends entries to the Fluentd
+// instance at the provided network address. If the TLS config is not nil,
+// connections use TLS. The tag of each entry is created by executing the
+// provided text/template with the entry's gournal.Record; an error is
+// returned if it cannot be parsed.
+//
+// Entries are sent in batches of up to batchSize entries or, if the
+// interval is greater than zero, each time the interval elapses. Buffered
+// entries are sent before FATAL and PANIC entries. If ack is true, each
+// message must be acknowledged by Fluentd, which requires its
+// require_ack_response option. Messages that cannot be delivered are
+// reported with gournal.HandleError and their entries are counted with
+// gournal.RecordDropped.
+//
+// The connection is established when the first message is sent.
+func NewWithOptions(
+	network, addr string,
+	tlsConfig *tls.Config,
+	tag string,
+	batchSize int,
+	interval time.Duration,
+	ack bool) (*Appender, error) {
+
+	t, err := template.New("tag").Parse(tag)
+	if err != nil {
+		return nil, err
+	}
+	f := &forwarder{
+		network: network,
+		addr:    addr,
+		tls:     tlsConfig,
+		tag:     t,
+		ack:     ack,
+	}
+	return &Appender{gournal.NewBatcher(f, batchSize, interval), f}, nil
+}
+
+// Append buffers the entry.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.batcher.Append(ctx, lvl, fields, msg)
+}
+
+// Flush sends any buffered entries.
+func (a *Appender) Flush() {
+	a.batcher.Flush()
+}
+
+// Close sends any buffered entries and closes the connection to Fluentd.
+func (a *Appender) Close() error {
+	a.batcher.Close()
+	if a.f.conn == nil {
+		return nil
+	}
+	err := a.f.conn.Close()
+	a.f.conn = nil
+	return err
+}
+
+// AppendBatch sends the records as one Forward mode message per tag. It is
+// only called by the Batcher, which serializes calls.
+func (f *forwarder) AppendBatch(recs []gournal.Record) {
+	var (
+		tags    []string
+		entries = map[string][]interface{}{}
+	)
+	for i := range recs {
+		rec := &recs[i]
+		tag := &bytes.Buffer{}
+		if err := f.tag.Execute(tag, rec); err != nil {
+			gournal.HandleError(err)
+			gournal.RecordDropped(1)
+			continue
+		}
+
+		record := make(map[string]interface{}, len(rec.Fields)+2)
+		for k, v := range rec.Fields {
+			record[k] = v
+		}
+		record[LevelKey] = rec.Level.String()
+		record[MessageKey] = rec.Message
+
+		t := tag.String()
+		if _, ok := entries[t]; !ok {
+			tags = append(tags, t)
+		}
+		entries[t] = append(
+			entries[t], []interface{}{eventTime(rec.Time), record})
+	}
+
+	for _, tag := range tags {
+		if err := f.forward(tag, entries[tag]); err != nil {
+			gournal.HandleError(err)
+			gournal.RecordDropped(len(entries[tag]))
+		}
+	}
+}
+
+// forward sends a Forward mode message, retrying on a new connection until
+// it is delivered or MaxAttempts is reached.
+func (f *forwarder) forward(tag string, entries []interface{}) error {
+	option := map[string]interface{}{"size": len(entries)}
+	var chunk string
+	if f.ack {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id)
+		option["chunk"] = chunk
+	}
+
+	e := &encoder{}
+	e.encode([]interface{}{tag, entries, option})
+
+	var err error
+	for i := 0; i < MaxAttempts; i++ {
+		if err = f.send(e.buf, chunk); err == nil {
+			return nil
+		}
+		if f.conn != nil {
+			f.conn.Close()
+			f.conn = nil
+		}
+	}
+	return err
+}
+
+func (f *forwarder) send(buf []byte, chunk string) error {
+	if f.conn == nil {
+		d := &net.Dialer{Timeout: Timeout}
+		var err error
+		if f.tls != nil {
+			f.conn, err = tls.DialWithDialer(d, f.network, f.addr, f.tls)
+		} else {
+			f.conn, err = d.Dial(f.network, f.addr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := f.conn.SetDeadline(time.Now().Add(Timeout)); err != nil {
+		return err
+	}
+	if _, err := f.conn.Write(buf); err != nil {
+		return err
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	res, err := decode(bufio.NewReader(f.conn))
+	if err != nil {
+		return err
+	}
+	if m, ok := res.(map[string]interface{}); !ok || m["ack"] != chunk {
+		return fmt.Errorf("%v: %v", ErrAck, res)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package fluent
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+)
+
+// errMsgpack is returned when a value cannot be decoded.
+var errMsgpack = errors.New("fluent: invalid msgpack")
+
+// eventTime is a time encoded as the forward protocol's EventTime extension
+// type.
+type eventTime time.Time
+
+// encoder writes the subset of msgpack used by the forward protocol.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) encode(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if v {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case string:
+		e.encodeString(v)
+	case []byte:
+		e.encodeBytes(v)
+	case eventTime:
+		t := time.Time(v)
+		e.buf = append(e.buf, 0xd7, 0x00)
+		e.buf = appendUint32(e.buf, uint32(t.Unix()))
+		e.buf = appendUint32(e.buf, uint32(t.Nanosecond()))
+	case time.Time:
+		e.encodeString(v.Format(time.RFC3339Nano))
+	case error:
+		e.encodeString(v.Error())
+	case map[string]interface{}:
+		e.encodeMapHeader(len(v))
+		for k, v := range v {
+			e.encodeString(k)
+			e.encode(v)
+		}
+	case []interface{}:
+		e.encodeArrayHeader(len(v))
+		for _, v := range v {
+			e.encode(v)
+		}
+	default:
+		e.encodeReflect(v)
+	}
+}
+
+func (e *encoder) encodeReflect(v interface{}) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		e.encodeInt(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.buf = append(e.buf, 0xcb)
+		e.buf = appendUint64(e.buf, math.Float64bits(rv.Float()))
+	case reflect.String:
+		e.encodeString(rv.String())
+	default:
+		e.encodeString(fmt.Sprint(v))
+	}
+}
+
+func (e *encoder) encodeInt(i int64) {
+	if i >= 0 {
+		e.encodeUint(uint64(i))
+		return
+	}
+	if i >= -32 {
+		e.buf = append(e.buf, byte(i))
+		return
+	}
+	e.buf = append(e.buf, 0xd3)
+	e.buf = appendUint64(e.buf, uint64(i))
+}
+
+func (e *encoder) encodeUint(u uint64) {
+	switch {
+	case u < 0x80:
+		e.buf = append(e.buf, byte(u))
+	case u <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = appendUint32(e.buf, uint32(u))
+	default:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = appendUint64(e.buf, u)
+	}
+}
+
+func (e *encoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda, byte(n>>8), byte(n))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) encodeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xc5, byte(n>>8), byte(n))
+	default:
+		e.buf = append(e.buf, 0xc6)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) encodeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xdc, byte(n>>8), byte(n))
+	default:
+		e.buf = append(e.buf, 0xdd)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+}
+
+func (e *encoder) encodeMapHeader(n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xde, byte(n>>8), byte(n))
+	default:
+		e.buf = append(e.buf, 0xdf)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+}
+
+func appendUint32(b []byte, u uint32) []byte {
+	return append(b, byte(u>>24), byte(u>>16), byte(u>>8), byte(u))
+}
+
+func appendUint64(b []byte, u uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(u>>32)), uint32(u))
+}
+
+// decode reads a msgpack value. Maps are decoded as
+// map[string]interface{}, arrays as []interface{}, integers as int64 or
+// uint64, and EventTime extensions as time.Time.
+func decode(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c < 0x80:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return decodeMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return decodeArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return decodeString(r, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readLength(r, c-0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return readN(r, n)
+	case 0xcb:
+		u, err := readUint(r, 8)
+		return math.Float64frombits(u), err
+	case 0xce:
+		u, err := readUint(r, 4)
+		return int64(u), err
+	case 0xcf:
+		return readUint(r, 8)
+	case 0xd3:
+		u, err := readUint(r, 8)
+		return int64(u), err
+	case 0xd7:
+		buf, err := readN(r, 9)
+		if err != nil || buf[0] != 0x00 {
+			return nil, errMsgpack
+		}
+		return time.Unix(
+			int64(binary.BigEndian.Uint32(buf[1:5])),
+			int64(binary.BigEndian.Uint32(buf[5:]))), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := readLength(r, c-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return decodeString(r, n)
+	case 0xdc, 0xdd:
+		n, err := readLength(r, c-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return decodeArray(r, n)
+	case 0xde, 0xdf:
+		n, err := readLength(r, c-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMap(r, n)
+	}
+	return nil, errMsgpack
+}
+
+// readLength reads a length of 1, 2, or 4 bytes for a size class of 0, 1,
+// or 2.
+func readLength(r *bufio.Reader, class byte) (int, error) {
+	u, err := readUint(r, 1<<class)
+	return int(u), err
+}
+
+func readUint(r *bufio.Reader, size int) (uint64, error) {
+	buf, err := readN(r, size)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, b := range buf {
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+func readN(r *bufio.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+func decodeString(r *bufio.Reader, n int) (interface{}, error) {
+	buf, err := readN(r, n)
+	return string(buf), err
+}
+
+func decodeArray(r *bufio.Reader, n int) (interface{}, error) {
+	a := make([]interface{}, n)
+	for i := range a {
+		v, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func decodeMap(r *bufio.Reader, n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		ks, ok := k.(string)
+		if !ok {
+			return nil, errMsgpack
+		}
+		if m[ks], err = decode(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,143 @@
+package fluent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+var testTime = time.Date(2017, 10, 1, 12, 0, 0, 5, time.UTC)
+
+// serve accepts connections and sends every decoded message to msgs. If ack
+// is false, a message's chunk is answered with an invalid ack.
+func serve(l net.Listener, ack bool, msgs chan<- []interface{}) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				v, err := decode(r)
+				if err != nil {
+					return
+				}
+				msg := v.([]interface{})
+				msgs <- msg
+				option := msg[2].(map[string]interface{})
+				chunk, ok := option["chunk"]
+				if !ok {
+					continue
+				}
+				if !ack {
+					chunk = "invalid"
+				}
+				e := &encoder{}
+				e.encode(map[string]interface{}{"ack": chunk})
+				conn.Write(e.buf)
+			}
+		}()
+	}
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(), testTime)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	e := &encoder{}
+	e.encode([]interface{}{
+		nil, true, false, 1, -1, -100, uint64(1 << 40), 1.5,
+		"hello", string(make([]byte, 40)), []byte("bin"),
+		eventTime(testTime),
+		map[string]interface{}{"a": int8(2)},
+	})
+	v, err := decode(bufio.NewReader(bytes.NewReader(e.buf)))
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		nil, true, false, int64(1), int64(-1), int64(-100),
+		uint64(1 << 40), 1.5, "hello", string(make([]byte, 40)),
+		[]byte("bin"), testTime.Local(),
+		map[string]interface{}{"a": int64(2)},
+	}, v)
+}
+
+func TestFluentAppender(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer l.Close()
+	msgs := make(chan []interface{}, 10)
+	go serve(l, true, msgs)
+
+	a, err := NewWithOptions(
+		"tcp", l.Addr().String(), nil, "app.{{.Fields.svc}}", 10, 0, true)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+
+	gournal.WithField("svc", "web").Info(ctx, "Hello %s", "Bob")
+	gournal.WithField("svc", "db").Warn(ctx, "Hello Alice")
+	gournal.WithField("svc", "web").Info(ctx, "Hello Mary")
+	assert.NoError(t, a.Close())
+
+	msg := <-msgs
+	assert.Equal(t, "app.web", msg[0])
+	entries := msg[1].([]interface{})
+	if assert.Len(t, entries, 2) {
+		entry := entries[0].([]interface{})
+		assert.True(t, testTime.Equal(entry[0].(time.Time)))
+		assert.Equal(t, map[string]interface{}{
+			"svc":     "web",
+			"level":   "INFO",
+			"message": "Hello Bob",
+		}, entry[1])
+	}
+	assert.Equal(t, int64(2), msg[2].(map[string]interface{})["size"])
+
+	msg = <-msgs
+	assert.Equal(t, "app.db", msg[0])
+	assert.Len(t, msg[1], 1)
+}
+
+func TestFluentAppenderAckRetry(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer l.Close()
+	msgs := make(chan []interface{}, 10)
+	go serve(l, false, msgs)
+
+	var errs []error
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { errs = append(errs, err) }
+
+	a, err := NewWithOptions(
+		"tcp", l.Addr().String(), nil, Tag, 10, 0, true)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	gournal.Info(newContext(a), "Hello Bob")
+	assert.NoError(t, a.Close())
+
+	assert.Len(t, msgs, MaxAttempts)
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), ErrAck.Error())
+	}
+}
@@ -1,48 +1,190 @@
-// +build none
-
 // Package gae provides a Google App Engine logger that implements the Gournal
 // Appender interface.
+//
+// Second generation App Engine runtimes do not support the
+// google.golang.org/appengine/log package. Instead, entries are written to
+// standard output as single-line JSON objects, which Cloud Logging parses
+// into structured log entries. The entry's level is emitted as "severity",
+// and, when the Context carries the request's X-Cloud-Trace-Context header,
+// the trace and span IDs are emitted so the entry is grouped with the
+// request that produced it.
 package gae
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-
-	gae "google.golang.org/appengine/log"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
 
 	"github.com/akutz/gournal"
 )
 
-type appender int
+const (
+	// TraceHeader is the name of the HTTP header with which App Engine
+	// propagates a request's trace context.
+	TraceHeader = "X-Cloud-Trace-Context"
+
+	traceKey        = "logging.googleapis.com/trace"
+	spanIDKey       = "logging.googleapis.com/spanId"
+	traceSampledKey = "logging.googleapis.com/trace_sampled"
+)
+
+// ProjectID is the Google Cloud project ID used to qualify trace IDs. The
+// default value is read from the GOOGLE_CLOUD_PROJECT environment variable,
+// which App Engine sets.
+var ProjectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+
+type traceKeyType int
+
+var traceCtxKey traceKeyType
+
+// WithTraceContext returns a new Context with the provided value of the
+// X-Cloud-Trace-Context header.
+func WithTraceContext(ctx context.Context, header string) context.Context {
+	return context.WithValue(ctx, traceCtxKey, header)
+}
+
+// Handler returns an http.Handler that stores the value of each request's
+// X-Cloud-Trace-Context header in the request's Context before invoking
+// next.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get(TraceHeader); v != "" {
+			r = r.WithContext(WithTraceContext(r.Context(), v))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-var theAppender = appender(0)
+type appender struct {
+	sync.Mutex
+	w         io.Writer
+	projectID string
+}
 
 // New returns a Google App Engine logger that implements the Gournal Appender
-// interface.
+// interface. Entries are written to os.Stdout and trace IDs are qualified
+// with ProjectID.
 func New() gournal.Appender {
-	return theAppender
+	return NewWithOptions(os.Stdout, ProjectID)
+}
+
+// NewWithOptions returns a Google App Engine logger that implements the
+// Gournal Appender interface. Entries are written to the provided writer
+// and trace IDs are qualified with the provided project ID.
+func NewWithOptions(w io.Writer, projectID string) gournal.Appender {
+	return &appender{w: w, projectID: projectID}
+}
+
+// Append writes the entry as a JSON object. FATAL entries exit the program
+// and PANIC entries panic after they are written.
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.write(ctx, lvl, fields, msg)
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
 }
 
-func (a appender) Append(
+func (a *appender) write(
 	ctx context.Context,
 	lvl gournal.Level,
 	fields map[string]interface{},
 	msg string) {
 
-	if len(fields) > 0 {
-		msg = fmt.Sprintf("%s %v", msg, fields)
+	m := make(map[string]interface{}, len(fields)+6)
+	for k, v := range fields {
+		m[k] = v
+	}
+	m["severity"] = severity(lvl)
+	m["message"] = msg
+	m["time"] = gournal.TimeFrom(ctx)
+	a.addTrace(ctx, m)
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(m); err != nil {
+		// emit the string form of fields that cannot be marshaled
+		for k, v := range fields {
+			if _, ok := m[k].(string); !ok {
+				m[k] = fmt.Sprint(v)
+			}
+		}
+		buf.Reset()
+		if err := enc.Encode(m); err != nil {
+			gournal.HandleError(err)
+			return
+		}
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	if _, err := a.w.Write(buf.Bytes()); err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+// addTrace adds the trace fields parsed from the Context's
+// X-Cloud-Trace-Context header, which has the form
+// TRACE_ID/SPAN_ID;o=TRACE_TRUE.
+func (a *appender) addTrace(ctx context.Context, m map[string]interface{}) {
+	header, _ := ctx.Value(traceCtxKey).(string)
+	if header == "" {
+		return
+	}
+
+	var opts string
+	if i := strings.IndexByte(header, ';'); i >= 0 {
+		header, opts = header[:i], header[i+1:]
+	}
+	traceID, spanID := header, ""
+	if i := strings.IndexByte(header, '/'); i >= 0 {
+		traceID, spanID = header[:i], header[i+1:]
+	}
+	if traceID == "" {
+		return
+	}
+
+	if a.projectID != "" {
+		m[traceKey] = "projects/" + a.projectID + "/traces/" + traceID
+	} else {
+		m[traceKey] = traceID
+	}
+	if spanID != "" {
+		m[spanIDKey] = spanID
 	}
+	m[traceSampledKey] = opts == "o=1"
+}
 
+func severity(lvl gournal.Level) string {
+	if s, ok := gournal.MapSeverityString("gae", lvl); ok {
+		return s
+	}
 	switch lvl {
 	case gournal.DebugLevel:
-		gae.Debugf(ctx, msg)
+		return "DEBUG"
 	case gournal.InfoLevel:
-		gae.Infof(ctx, msg)
+		return "INFO"
 	case gournal.WarnLevel:
-		gae.Warningf(ctx, msg)
+		return "WARNING"
 	case gournal.ErrorLevel:
-		gae.Errorf(ctx, msg)
+		return "ERROR"
 	case gournal.FatalLevel, gournal.PanicLevel:
-		gae.Criticalf(ctx, msg)
+		return "CRITICAL"
 	}
+	return "DEFAULT"
 }
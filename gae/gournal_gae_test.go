@@ -1,60 +1,106 @@
-// +build none
-
 package gae
 
 import (
+	"bytes"
 	"context"
-	"fmt"
-	"os"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
-	gaetest "google.golang.org/appengine/aetest"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/akutz/gournal"
 )
 
-var gaeCtx gournal.Context
-
-func TestMain(m *testing.M) {
-
-	var (
-		err  error
-		done func()
-	)
-
-	if gaeCtx, done, err = gaetest.NewContext(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+var testTime = time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
 
-	ec := m.Run()
+func TestGAEAppenderNoFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gournal.Info(ctx(buf), "Hello %s", "Bob")
+	assert.Equal(
+		t,
+		`{"message":"Hello Bob","severity":"INFO",`+
+			`"time":"2017-10-01T12:00:00Z"}`+"\n",
+		buf.String())
+}
 
-	done()
-	os.Exit(ec)
+func TestGAEAppenderWithFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gournal.WithFields(map[string]interface{}{
+		"size":     1,
+		"location": "<Austin>",
+	}).Warn(ctx(buf), "Hello %s", "Mary")
+	assert.Equal(
+		t,
+		`{"location":"<Austin>","message":"Hello Mary",`+
+			`"severity":"WARNING","size":1,`+
+			`"time":"2017-10-01T12:00:00Z"}`+"\n",
+		buf.String())
 }
 
-func TestGAEAppenderNoFields(t *testing.T) {
-	gournal.Info(ctx(), "Hello %s", "Bob")
+func TestGAEAppenderUnmarshalableField(t *testing.T) {
+	buf := &bytes.Buffer{}
+	gournal.WithField("ch", make(chan int)).Error(ctx(buf), "Hello")
+	m := decode(t, buf)
+	assert.Equal(t, "ERROR", m["severity"])
+	assert.IsType(t, "", m["ch"])
 }
 
-func TestGAEAppenderWithField(t *testing.T) {
-	gournal.WithField("size", 2).Info(ctx(), "Hello %s", "Alice")
+func TestGAEAppenderTrace(t *testing.T) {
+	buf := &bytes.Buffer{}
+	c := WithTraceContext(ctx(buf), "105445aa7843bc8bf206b120001000/1;o=1")
+	gournal.Info(c, "Hello")
+	m := decode(t, buf)
+	assert.Equal(
+		t,
+		"projects/my-project/traces/105445aa7843bc8bf206b120001000",
+		m[traceKey])
+	assert.Equal(t, "1", m[spanIDKey])
+	assert.Equal(t, true, m[traceSampledKey])
 }
 
-func TestGAEAppenderWithFields(t *testing.T) {
-	gournal.WithFields(map[string]interface{}{
-		"size":     1,
-		"location": "Austin",
-	}).Warn(ctx(), "Hello %s", "Mary")
+func TestGAEAppenderHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		c := r.Context()
+		c = context.WithValue(c, gournal.LevelKey(), gournal.InfoLevel)
+		c = context.WithValue(
+			c, gournal.AppenderKey(), NewWithOptions(buf, ""))
+		gournal.Info(c, "Hello")
+	}
+	h := Handler(http.HandlerFunc(fn))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(TraceHeader, "abc;o=0")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	m := decode(t, buf)
+	assert.Equal(t, "abc", m[traceKey])
+	assert.NotContains(t, m, spanIDKey)
+	assert.Equal(t, false, m[traceSampledKey])
 }
 
 func TestGAEAppenderPanic(t *testing.T) {
-	gournal.Panic(ctx(), "Hello %s", "Bob")
+	buf := &bytes.Buffer{}
+	defer func() {
+		assert.NotNil(t, recover())
+		assert.Equal(t, "CRITICAL", decode(t, buf)["severity"])
+	}()
+	gournal.Panic(ctx(buf), "Hello %s", "Bob")
 }
 
-func ctx() context.Context {
-	ctx := context.Background()
+func ctx(buf *bytes.Buffer) context.Context {
+	ctx := gournal.WithTime(context.Background(), testTime)
 	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
-	ctx = context.WithValue(ctx, gournal.AppenderKey(), New())
+	ctx = context.WithValue(
+		ctx, gournal.AppenderKey(), NewWithOptions(buf, "my-project"))
 	return ctx
 }
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	return m
+}
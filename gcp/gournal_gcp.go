@@ -0,0 +1,194 @@
+//go:build go1.9
+// +build go1.9
+
+// Package gcp provides a Google Cloud Logging appender that implements the
+// Gournal Appender interface by writing entries directly to the Cloud
+// Logging API, for services such as those on GKE or Cloud Run that do not
+// rely on an agent to collect their output.
+//
+// This package requires Go 1.9 or later.
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"cloud.google.com/go/logging"
+	"google.golang.org/api/option"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/propagation"
+)
+
+// LabelKeys are the names of the fields that are emitted as entry labels
+// rather than as part of the entry's JSON payload. Label values are
+// formatted with fmt.Sprint.
+var LabelKeys []string
+
+// Appender is a Google Cloud Logging appender. Entries are buffered and
+// written in the background by the Cloud Logging client, so Close must be
+// called before a program exits to deliver buffered entries.
+type Appender struct {
+	client    *logging.Client
+	logger    *logging.Logger
+	projectID string
+	labelKeys map[string]struct{}
+}
+
+// New returns an Appender that writes entries to the log with the provided
+// ID in the provided project. The monitored resource is detected by the
+// Cloud Logging client and LabelKeys determines which fields are labels.
+func New(
+	ctx context.Context, projectID, logID string) (*Appender, error) {
+
+	return NewWithOptions(ctx, projectID, logID, nil, LabelKeys)
+}
+
+// NewWithOptions returns an Appender that writes entries to the log with
+// the provided ID in the provided project. If the provided monitored
+// resource is nil, it is detected by the Cloud Logging client. The fields
+// named by labelKeys are emitted as entry labels. The client options, ex.
+// option.WithCredentialsFile, configure the Cloud Logging client.
+func NewWithOptions(
+	ctx context.Context,
+	projectID, logID string,
+	resource *mrpb.MonitoredResource,
+	labelKeys []string,
+	opts ...option.ClientOption) (*Appender, error) {
+
+	client, err := logging.NewClient(ctx, projectID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	client.OnError = gournal.HandleError
+
+	var loggerOpts []logging.LoggerOption
+	if resource != nil {
+		loggerOpts = append(loggerOpts, logging.CommonResource(resource))
+	}
+
+	a := &Appender{
+		client:    client,
+		logger:    client.Logger(logID, loggerOpts...),
+		projectID: projectID,
+		labelKeys: make(map[string]struct{}, len(labelKeys)),
+	}
+	for _, k := range labelKeys {
+		a.labelKeys[k] = struct{}{}
+	}
+	return a, nil
+}
+
+// Append buffers the entry for delivery. The trace and span of the entry
+// are taken from the propagation.TraceContext stored in the Context, if
+// any. FATAL and PANIC entries are delivered synchronously before the
+// program exits or panics.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	e := logging.Entry{
+		Timestamp: gournal.TimeFrom(ctx),
+		Severity:  severity(lvl),
+		Payload:   a.payload(fields, msg),
+		Labels:    a.labels(fields),
+	}
+	if tc, ok := propagation.FromContext(ctx); ok {
+		e.Trace = "projects/" + a.projectID + "/traces/" + tc.TraceID
+		e.SpanID = tc.SpanID
+	}
+
+	if lvl > gournal.FatalLevel {
+		a.logger.Log(e)
+		return
+	}
+
+	// deliver the buffered entries first so the entries remain in order
+	if err := a.Flush(); err != nil {
+		gournal.HandleError(err)
+	}
+	if err := a.logger.LogSync(ctx, e); err != nil {
+		gournal.HandleError(err)
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+// Flush delivers any buffered entries.
+func (a *Appender) Flush() error {
+	return a.logger.Flush()
+}
+
+// Close delivers any buffered entries and closes the Cloud Logging client.
+func (a *Appender) Close() error {
+	return a.client.Close()
+}
+
+// payload returns the entry's JSON payload, which is made of the fields
+// that are not labels and the message. The string form of the fields is
+// used if the fields cannot be marshaled, as the Cloud Logging client would
+// otherwise drop the entry.
+func (a *Appender) payload(
+	fields map[string]interface{}, msg string) map[string]interface{} {
+
+	payload := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		if _, ok := a.labelKeys[k]; !ok {
+			payload[k] = v
+		}
+	}
+	if _, err := json.Marshal(payload); err != nil {
+		for k, v := range payload {
+			payload[k] = fmt.Sprint(v)
+		}
+	}
+	payload["message"] = msg
+	return payload
+}
+
+// labels returns the entry's labels, or nil if none of the fields are
+// labels.
+func (a *Appender) labels(fields map[string]interface{}) map[string]string {
+	var labels map[string]string
+	for k, v := range fields {
+		if _, ok := a.labelKeys[k]; !ok {
+			continue
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[k] = fmt.Sprint(v)
+	}
+	return labels
+}
+
+func severity(lvl gournal.Level) logging.Severity {
+	if n, ok := gournal.MapSeverityInt("gcp", lvl); ok {
+		return logging.Severity(n)
+	}
+	switch lvl {
+	case gournal.DebugLevel:
+		return logging.Debug
+	case gournal.InfoLevel:
+		return logging.Info
+	case gournal.WarnLevel:
+		return logging.Warning
+	case gournal.ErrorLevel:
+		return logging.Error
+	case gournal.FatalLevel:
+		return logging.Critical
+	case gournal.PanicLevel:
+		return logging.Alert
+	}
+	return logging.Default
+}
@@ -0,0 +1,7 @@
+//go:build !go1.9
+// +build !go1.9
+
+// Package gcp provides a Google Cloud Logging appender that implements the
+// Gournal Appender interface. It requires Go 1.9 or later, so with older
+// versions of Go the package is empty.
+package gcp
@@ -0,0 +1,153 @@
+//go:build go1.9
+// +build go1.9
+
+package gcp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+	mrpb "google.golang.org/genproto/googleapis/api/monitoredres"
+	logtypepb "google.golang.org/genproto/googleapis/logging/type"
+	logpb "google.golang.org/genproto/googleapis/logging/v2"
+	"google.golang.org/grpc"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/propagation"
+)
+
+// testServer is a Cloud Logging API server that records the entries it
+// receives.
+type testServer struct {
+	logpb.LoggingServiceV2Server
+
+	sync.Mutex
+	entries []*logpb.LogEntry
+}
+
+func (s *testServer) WriteLogEntries(
+	ctx context.Context,
+	req *logpb.WriteLogEntriesRequest) (*logpb.WriteLogEntriesResponse, error) {
+
+	s.Lock()
+	defer s.Unlock()
+	for _, e := range req.Entries {
+		if e.LogName == "" {
+			e.LogName = req.LogName
+		}
+		if e.Resource == nil {
+			e.Resource = req.Resource
+		}
+		s.entries = append(s.entries, e)
+	}
+	return &logpb.WriteLogEntriesResponse{}, nil
+}
+
+func (s *testServer) DeleteLog(
+	ctx context.Context, req *logpb.DeleteLogRequest) (*empty.Empty, error) {
+
+	return &empty.Empty{}, nil
+}
+
+func newTestAppender(t *testing.T, labelKeys ...string) (
+	*Appender, *testServer, func()) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	srv := &testServer{}
+	gs := grpc.NewServer()
+	logpb.RegisterLoggingServiceV2Server(gs, srv)
+	go gs.Serve(l)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	a, err := NewWithOptions(
+		context.Background(), "planets", "app",
+		&mrpb.MonitoredResource{Type: "global"},
+		labelKeys,
+		option.WithGRPCConn(conn))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return a, srv, gs.Stop
+}
+
+func TestAppender(t *testing.T) {
+	a, srv, stop := newTestAppender(t, "component")
+	defer stop()
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	ctx = gournal.WithTime(ctx, time.Unix(1500000000, 0))
+
+	tc := propagation.TraceContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+	}
+	tctx, pop := propagation.WithTraceContext(ctx, tc)
+	defer pop()
+
+	gournal.WithFields(map[string]interface{}{
+		"component": "volumes",
+		"size":      2,
+	}).Warn(tctx, "Hello Bob")
+	gournal.WithField("ch", make(chan int)).Error(ctx, "Hello Alice")
+	assert.NoError(t, a.Close())
+
+	srv.Lock()
+	defer srv.Unlock()
+	if !assert.Len(t, srv.entries, 2) {
+		t.FailNow()
+	}
+
+	e := srv.entries[0]
+	assert.Equal(t, "projects/planets/logs/app", e.LogName)
+	assert.Equal(t, "global", e.Resource.Type)
+	assert.Equal(t, logtypepb.LogSeverity_WARNING, e.Severity)
+	assert.Equal(t, int64(1500000000), e.Timestamp.Seconds)
+	assert.Equal(t, map[string]string{"component": "volumes"}, e.Labels)
+	assert.Equal(t,
+		"projects/planets/traces/4bf92f3577b34da6a3ce929d0e0e4736", e.Trace)
+	assert.Equal(t, "00f067aa0ba902b7", e.SpanId)
+	payload := e.GetJsonPayload().Fields
+	assert.Equal(t, "Hello Bob", payload["message"].GetStringValue())
+	assert.Equal(t, float64(2), payload["size"].GetNumberValue())
+	assert.NotContains(t, payload, "component")
+
+	// fields that cannot be marshaled are sent as strings rather than
+	// dropping the entry
+	e = srv.entries[1]
+	assert.Equal(t, logtypepb.LogSeverity_ERROR, e.Severity)
+	assert.Empty(t, e.Trace)
+	payload = e.GetJsonPayload().Fields
+	assert.Equal(t, "Hello Alice", payload["message"].GetStringValue())
+	assert.NotEmpty(t, payload["ch"].GetStringValue())
+}
+
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, logging.Debug, severity(gournal.DebugLevel))
+	assert.Equal(t, logging.Info, severity(gournal.InfoLevel))
+	assert.Equal(t, logging.Warning, severity(gournal.WarnLevel))
+	assert.Equal(t, logging.Error, severity(gournal.ErrorLevel))
+	assert.Equal(t, logging.Critical, severity(gournal.FatalLevel))
+	assert.Equal(t, logging.Alert, severity(gournal.PanicLevel))
+
+	defer func() { gournal.SeverityMapper = nil }()
+	gournal.SeverityMapper = gournal.SeverityTable{
+		"gcp": {gournal.InfoLevel: int(logging.Notice)},
+	}.Severity
+	assert.Equal(t, logging.Notice, severity(gournal.InfoLevel))
+	assert.Equal(t, logging.Warning, severity(gournal.WarnLevel))
+}
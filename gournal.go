@@ -29,10 +29,14 @@ var (
 	// ErrorKey defines the key when adding errors using WithError.
 	ErrorKey = "error"
 
-	// DefaultLevel is used when a Level is not present in a Context.
+	// DefaultLevel is used when a Level is not present in a Context and a
+	// level has not been set with SetDefaultLevel. It is not guarded against
+	// concurrent access, so use SetDefaultLevel to change the level while
+	// entries are logged.
 	DefaultLevel = ErrorLevel
 
 	// DefaultAppender is used when an Appender is not present in a Context.
+	// If DefaultAppender is nil, entries without an Appender are discarded.
 	DefaultAppender = NewAppender()
 
 	// DefaultContext is used when a log method is invoked with a nil Context.
@@ -45,12 +49,24 @@ const (
 	levelKeyC contextKey = iota
 	fieldsKeyC
 	appenderKeyC
+	stackKeyC
+	retriesKeyC
+	requestIDKeyC
+	nameKeyC
+	timeKeyC
+	callerKeyC
 )
 
 var (
-	levelKey    interface{} = levelKeyC
-	fieldsKey   interface{} = fieldsKeyC
-	appenderKey interface{} = appenderKeyC
+	levelKey     interface{} = levelKeyC
+	fieldsKey    interface{} = fieldsKeyC
+	appenderKey  interface{} = appenderKeyC
+	stackKey     interface{} = stackKeyC
+	retriesKey   interface{} = retriesKeyC
+	requestIDKey interface{} = requestIDKeyC
+	nameKey      interface{} = nameKeyC
+	timeKey      interface{} = timeKeyC
+	callerKey    interface{} = callerKeyC
 )
 
 // LevelKey returns the Context key used for storing and retrieving the log
@@ -146,6 +162,18 @@ func (level Level) String() string {
 	return lvlValsToStrs[level]
 }
 
+// MarshalText marshals a Level as its string representation.
+func (level Level) MarshalText() ([]byte, error) {
+	return []byte(level.String()), nil
+}
+
+// UnmarshalText unmarshals a Level from its string representation. Strings
+// that are not valid levels are unmarshaled as UnknownLevel.
+func (level *Level) UnmarshalText(text []byte) error {
+	*level = ParseLevel(string(text))
+	return nil
+}
+
 // ParseLevel parses a string and returns its constant.
 func ParseLevel(lvl string) Level {
 	switch {
@@ -242,9 +270,14 @@ type Entry interface {
 	WithFields(fields map[string]interface{}) Entry
 
 	// WithError adds the provided error to the Entry using the ErrorKey value
-	// as the key.
+	// as the key. The error's message is obtained only if the entry is
+	// appended.
 	WithError(err error) Entry
 
+	// WithStruct adds the exported fields of a struct to the Entry. See the
+	// WithStruct function for how the fields are named.
+	WithStruct(v interface{}) Entry
+
 	// Debug emits a log entry at the DEBUG level.
 	Debug(ctx context.Context, msg string, args ...interface{})
 
@@ -267,6 +300,15 @@ type Entry interface {
 	Panic(ctx context.Context, msg string, args ...interface{})
 }
 
+// LevelEntry is implemented by Entries that emit log entries at a level
+// provided at runtime. The Entries returned by WithField, WithFields, and
+// WithError implement it.
+type LevelEntry interface {
+
+	// Log emits a log entry at the provided level.
+	Log(ctx context.Context, lvl Level, msg string, args ...interface{})
+}
+
 // Appender is the interface that must be implemented by the logging frameworks
 // which are members of the Gournal facade.
 type Appender interface {
@@ -283,60 +325,79 @@ type Appender interface {
 // WithField adds a single field to the Entry. The provided key will override
 // an existing, equivalent key in the Entry.
 func WithField(key string, value interface{}) Entry {
-	return &entry{map[string]interface{}{key: value}}
+	e := &entry{}
+	e.fields.addField(key, value)
+	return e
 }
 
 // WithFields adds a map to the Entry. Keys in the provided map will override
 // existing, equivalent keys in the Entry.
 func WithFields(fields map[string]interface{}) Entry {
-	return &entry{fields}
+	return &entry{fieldsFromMap(fields)}
 }
 
 // WithError adds the provided error to the Entry using the ErrorKey value
-// as the key.
+// as the key. The error's message is obtained only if the entry is appended.
 func WithError(err error) Entry {
-	return &entry{map[string]interface{}{ErrorKey: err.Error()}}
+	e := &entry{}
+	e.fields.addField(ErrorKey, lazyError{err})
+	return e
 }
 
 // Debug emits a log entry at the DEBUG level.
 func Debug(ctx context.Context, msg string, args ...interface{}) {
-	sendToAppender(ctx, DebugLevel, nil, msg, args...)
+	sendToAppender(ctx, DebugLevel, fieldSet{}, msg, args...)
 }
 
 // Info emits a log entry at the INFO level.
 func Info(ctx context.Context, msg string, args ...interface{}) {
-	sendToAppender(ctx, InfoLevel, nil, msg, args...)
+	sendToAppender(ctx, InfoLevel, fieldSet{}, msg, args...)
 }
 
 // Print emits a log entry at the INFO level.
 func Print(ctx context.Context, msg string, args ...interface{}) {
-	sendToAppender(ctx, InfoLevel, nil, msg, args...)
+	sendToAppender(ctx, InfoLevel, fieldSet{}, msg, args...)
 }
 
 // Warn emits a log entry at the WARN level.
 func Warn(ctx context.Context, msg string, args ...interface{}) {
-	sendToAppender(ctx, WarnLevel, nil, msg, args...)
+	sendToAppender(ctx, WarnLevel, fieldSet{}, msg, args...)
 }
 
 // Error emits a log entry at the ERROR level.
 func Error(ctx context.Context, msg string, args ...interface{}) {
-	sendToAppender(ctx, ErrorLevel, nil, msg, args...)
+	sendToAppender(ctx, ErrorLevel, fieldSet{}, msg, args...)
 }
 
 // Fatal emits a log entry at the FATAL level.
 func Fatal(ctx context.Context, msg string, args ...interface{}) {
-	sendToAppender(ctx, FatalLevel, nil, msg, args...)
+	sendToAppender(ctx, FatalLevel, fieldSet{}, msg, args...)
 }
 
 // Panic emits a log entry at the PANIC level.
 func Panic(ctx context.Context, msg string, args ...interface{}) {
-	sendToAppender(ctx, PanicLevel, nil, msg, args...)
+	sendToAppender(ctx, PanicLevel, fieldSet{}, msg, args...)
+}
+
+// Log emits a log entry at the provided level.
+func Log(ctx context.Context, lvl Level, msg string, args ...interface{}) {
+	sendToAppender(ctx, lvl, fieldSet{}, msg, args...)
+}
+
+// Enabled returns a flag indicating whether an entry at the provided level
+// would be emitted with the provided context. This is useful to avoid
+// expensive work that is only required to emit an entry.
+func Enabled(ctx context.Context, lvl Level) bool {
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+	return getLevel(ctx) >= lvl
 }
 
 func sendToAppender(
 	ctx context.Context,
 	lvl Level,
-	fields map[string]interface{},
+	fields fieldSet,
 	msg string,
 	args ...interface{}) {
 
@@ -350,115 +411,162 @@ func sendToAppender(
 		return
 	}
 
-	// do not proceed without an appender
-	a := getAppender(ctx)
+	var stack fieldSet
+	inspectStackFields(ctx, &stack)
+	appendEntry(
+		ctx, getAppender(ctx), lvl,
+		stack, fields, ctx.Value(fieldsKey),
+		msg, args...)
+}
+
+// appendEntry formats the message and sends the entry to the appender. The
+// entry's fields are collected from all of their sources in order of
+// precedence, lowest first: the context's field stack, the fields provided
+// to the log function, and the value stored in the context with FieldsKey.
+func appendEntry(
+	ctx context.Context,
+	a Appender,
+	lvl Level,
+	all fieldSet,
+	fields fieldSet,
+	ctxFieldsVal interface{},
+	msg string,
+	args ...interface{}) {
 
 	// format the message with args if any
-	if len(msg) == 0 && len(args) > 0 {
-		msg = fmt.Sprint(args...)
-	} else if len(msg) > 0 && len(args) > 0 {
-		msg = fmt.Sprintf(msg, args...)
+	if len(args) > 0 {
+		msg = formatMessage(msg, args)
 	}
 
-	// grab any of the context fields to append alongside each new log entry
-	inspectCustomCtxFields(ctx, lvl, &fields, msg)
+	if CaptureCaller && ctx.Value(callerKey) == nil {
+		if c := captureCaller(); c != nil {
+			ctx = context.WithValue(ctx, callerKey, c)
+		}
+	}
+
+	fields, ctxFields := inspectCustomCtxFields(
+		ctx, ctxFieldsVal, lvl, fields, msg)
+	all.addSet(fields)
+	all.addMap(ctxFields)
+	all.resolve()
 
 	if debug {
-		if len(fields) == 0 {
+		if all.empty() {
 			fmt.Fprintf(os.Stderr,
 				"GOURNAL: append: a=%T, lvl=%s, msg=%s\n",
 				a, lvl, msg)
 		} else {
 			fmt.Fprintf(os.Stderr,
 				"GOURNAL: append: a=%T, lvl=%s, msg=%s, fields=%v\n",
-				a, lvl, msg, fields)
+				a, lvl, msg, all.toMap())
+		}
+	}
+
+	recordEntry(lvl)
+
+	if subs := getSubscribers(); len(subs) > 0 {
+		rec := Record{
+			Time:    TimeFrom(ctx),
+			Level:   lvl,
+			Message: msg,
+			Fields:  all.toMap(),
+			Name:    NameFrom(ctx),
+			Caller:  CallerFrom(ctx),
 		}
+		for _, s := range subs {
+			s.fn(rec)
+		}
+	}
+
+	// only Appenders that do not accept a slice of fields receive a map
+	if fa, ok := a.(FieldAppender); ok {
+		fa.AppendFields(ctx, lvl, all.toList(), msg)
+		return
 	}
+	a.Append(ctx, lvl, all.toMap(), msg)
+}
 
-	a.Append(ctx, lvl, fields, msg)
+func formatMessage(msg string, args []interface{}) string {
+	if len(args) == 1 && (len(msg) == 0 || msg == "%s") {
+		if s, ok := args[0].(string); ok {
+			return s
+		}
+	}
+	if len(msg) == 0 {
+		return fmt.Sprint(args...)
+	}
+	return fmt.Sprintf(msg, args...)
 }
 
 func getLevel(ctx context.Context) Level {
 	if ctx == nil {
-		return DefaultLevel
+		return CurrentDefaultLevel()
 	}
 
 	if v, ok := ctx.Value(levelKey).(Level); ok {
 		return v
 	}
-	return DefaultLevel
+	if v, ok := getNamedLevel(ctx); ok {
+		return v
+	}
+	return CurrentDefaultLevel()
 }
 
 func getAppender(ctx context.Context) Appender {
-	if ctx == nil {
-		return DefaultAppender
-	}
-
-	if ctx == DefaultContext {
-		return DefaultAppender
+	if ctx != nil && ctx != DefaultContext {
+		if v, ok := ctx.Value(appenderKey).(Appender); ok && v != nil {
+			return v
+		}
 	}
-
-	if v, ok := ctx.Value(appenderKey).(Appender); ok {
-		return v
+	if a := DefaultAppender; a != nil {
+		return a
 	}
-
-	return DefaultAppender
+	return Discard
 }
 
+// inspectCustomCtxFields returns the entry's fields and the fields described
+// by v, the value stored in the context with the FieldsKey. A context fields
+// function that accepts the entry's fields may modify them, so the entry's
+// fields are returned as well.
 func inspectCustomCtxFields(
 	ctx context.Context,
+	v interface{},
 	lvl Level,
-	fields *map[string]interface{},
-	msg string) {
+	fields fieldSet,
+	msg string) (fieldSet, map[string]interface{}) {
 
-	switch tv := ctx.Value(fieldsKey).(type) {
+	switch tv := v.(type) {
 	case map[string]interface{}:
-		swapFields(fields, &tv)
+		return fields, tv
 	case func() map[string]interface{}:
-		ctxFields := tv()
-		swapFields(fields, &ctxFields)
+		return fields, tv()
 	case func(
 		ctx context.Context,
 		lvl Level,
 		fields map[string]interface{},
 		msg string) map[string]interface{}:
 
-		ctxFields := tv(ctx, lvl, *fields, msg)
-		swapFields(fields, &ctxFields)
-	}
-}
-
-func swapFields(appendFields, ctxFields *map[string]interface{}) {
-	if len(*ctxFields) == 0 {
-		return
-	}
-
-	if len(*appendFields) == 0 {
-		*appendFields = *ctxFields
-		return
-	}
-
-	for k, v := range *ctxFields {
-		(*appendFields)[k] = v
+		m := fields.toMap()
+		ctxFields := tv(ctx, lvl, m, msg)
+		return fieldsFromMap(m), ctxFields
 	}
+	return fields, nil
 }
 
 type entry struct {
-	fields map[string]interface{}
+	fields fieldSet
 }
 
 func (e *entry) WithField(key string, value interface{}) Entry {
-	e.fields[key] = value
+	e.fields.addField(key, value)
 	return e
 }
 func (e *entry) WithFields(fields map[string]interface{}) Entry {
-	for k, v := range fields {
-		e.fields[k] = v
-	}
+	e.fields.addMap(fields)
 	return e
 }
 func (e *entry) WithError(err error) Entry {
-	e.fields[ErrorKey] = err.Error()
+	e.fields.addField(ErrorKey, lazyError{err})
 	return e
 }
 
@@ -489,3 +597,9 @@ func (e *entry) Fatal(ctx context.Context, msg string, args ...interface{}) {
 func (e *entry) Panic(ctx context.Context, msg string, args ...interface{}) {
 	sendToAppender(ctx, PanicLevel, e.fields, msg, args...)
 }
+
+func (e *entry) Log(
+	ctx context.Context, lvl Level, msg string, args ...interface{}) {
+
+	sendToAppender(ctx, lvl, e.fields, msg, args...)
+}
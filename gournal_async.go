@@ -0,0 +1,203 @@
+package gournal
+
+import (
+	"context"
+	"sync"
+)
+
+// AsyncAppender is an Appender that enqueues entries to a bounded queue
+// that is consumed by worker goroutines, which deliver the entries to
+// another Appender. This keeps the latency of log calls flat regardless of
+// the latency of the underlying sink.
+//
+// What happens when the queue is full is determined by the Appender's
+// OverflowPolicy. Dropped entries are counted with RecordDropped. FATAL and
+// PANIC entries
+// are delivered synchronously after the queue is drained so they are never
+// lost. Entries appended after Close are also delivered synchronously.
+//
+// With more than one worker, entries may be delivered out of order.
+type AsyncAppender struct {
+	next     Appender
+	queue    chan asyncEntry
+	overflow OverflowPolicy
+
+	// closed is protected by the RWMutex, which also prevents sending to a
+	// closed queue
+	sync.RWMutex
+	closed bool
+
+	workers sync.WaitGroup
+
+	// pending is the number of enqueued entries that have not yet been
+	// delivered
+	pendingMu   sync.Mutex
+	pendingCond *sync.Cond
+	pending     int
+}
+
+type asyncEntry struct {
+	ctx    context.Context
+	lvl    Level
+	fields map[string]interface{}
+	msg    string
+}
+
+// OverflowPolicy determines how an AsyncAppender handles an entry when its
+// queue is full.
+type OverflowPolicy uint8
+
+const (
+	// OverflowDropNewest drops the entry being appended.
+	OverflowDropNewest OverflowPolicy = iota
+
+	// OverflowDropOldest drops the oldest entry in the queue to make room
+	// for the entry being appended.
+	OverflowDropOldest
+
+	// OverflowBlock blocks the caller until there is room in the queue.
+	OverflowBlock
+)
+
+// NewAsyncAppender returns an AsyncAppender with a queue that holds up to
+// size entries and the provided number of workers that deliver entries to
+// next. Entries are dropped when the queue is full.
+func NewAsyncAppender(next Appender, size, workers int) *AsyncAppender {
+	return NewAsyncAppenderWithOptions(
+		next, size, workers, OverflowDropNewest)
+}
+
+// NewAsyncAppenderWithOptions returns an AsyncAppender with a queue that
+// holds up to size entries, the provided number of workers that deliver
+// entries to next, and the policy used when the queue is full.
+func NewAsyncAppenderWithOptions(
+	next Appender,
+	size, workers int,
+	overflow OverflowPolicy) *AsyncAppender {
+
+	if size < 1 {
+		size = 1
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	a := &AsyncAppender{
+		next:     next,
+		queue:    make(chan asyncEntry, size),
+		overflow: overflow,
+	}
+	a.pendingCond = sync.NewCond(&a.pendingMu)
+	a.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go a.work()
+	}
+	return a
+}
+
+func (a *AsyncAppender) work() {
+	defer a.workers.Done()
+	for e := range a.queue {
+		a.next.Append(e.ctx, e.lvl, e.fields, e.msg)
+		a.done()
+	}
+}
+
+// done records that an enqueued entry was delivered or dropped.
+func (a *AsyncAppender) done() {
+	a.pendingMu.Lock()
+	a.pending--
+	if a.pending == 0 {
+		a.pendingCond.Broadcast()
+	}
+	a.pendingMu.Unlock()
+}
+
+// Append enqueues the entry. The fields are copied since the entry outlives
+// the call, and the entry is delivered with a Context created by WithTime
+// so its time is when it was appended rather than when it was delivered.
+func (a *AsyncAppender) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if lvl <= FatalLevel {
+		a.Flush()
+		a.next.Append(ctx, lvl, fields, msg)
+		return
+	}
+
+	a.RLock()
+	defer a.RUnlock()
+
+	if a.closed {
+		a.next.Append(ctx, lvl, fields, msg)
+		return
+	}
+
+	e := asyncEntry{ctx: WithTime(ctx, TimeFrom(ctx)), lvl: lvl, msg: msg}
+	if len(fields) > 0 {
+		e.fields = make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			e.fields[k] = v
+		}
+	}
+
+	a.pendingMu.Lock()
+	a.pending++
+	a.pendingMu.Unlock()
+
+	switch a.overflow {
+	case OverflowBlock:
+		a.queue <- e
+	case OverflowDropOldest:
+		for {
+			select {
+			case a.queue <- e:
+				return
+			default:
+			}
+			select {
+			case <-a.queue:
+				a.done()
+				RecordDropped(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case a.queue <- e:
+		default:
+			a.done()
+			RecordDropped(1)
+		}
+	}
+}
+
+// Len returns the number of enqueued entries that have not been delivered.
+func (a *AsyncAppender) Len() int {
+	a.pendingMu.Lock()
+	defer a.pendingMu.Unlock()
+	return a.pending
+}
+
+// Flush blocks until every enqueued entry is delivered.
+func (a *AsyncAppender) Flush() {
+	a.pendingMu.Lock()
+	for a.pending > 0 {
+		a.pendingCond.Wait()
+	}
+	a.pendingMu.Unlock()
+}
+
+// Close delivers every enqueued entry and stops the workers.
+func (a *AsyncAppender) Close() error {
+	a.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.Unlock()
+	a.workers.Wait()
+	return nil
+}
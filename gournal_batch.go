@@ -0,0 +1,151 @@
+package gournal
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// BatchAppender may be implemented by sinks that are able to amortize the
+// cost of delivering entries, such as network or bulk backends, by
+// receiving many Records at once.
+type BatchAppender interface {
+
+	// AppendBatch delivers the provided Records. The slice must not be
+	// retained after the call returns.
+	AppendBatch(records []Record)
+}
+
+// Batcher is an Appender that buffers entries as Records and delivers them
+// to a BatchAppender when the buffer is full or, if an interval is
+// configured, when the interval elapses. FATAL and PANIC entries are
+// delivered immediately along with any buffered Records.
+//
+// Buffered Records are not delivered when a program exits without calling
+// Close.
+type Batcher struct {
+	b    BatchAppender
+	size int
+
+	sync.Mutex
+	buf []Record
+
+	// flushing serializes deliveries so batches arrive in order
+	flushing sync.Mutex
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+}
+
+// NewBatcher returns a Batcher that delivers Records to the provided
+// BatchAppender in batches of up to size Records. If the interval is
+// greater than zero, buffered Records are also delivered each time the
+// interval elapses.
+func NewBatcher(
+	b BatchAppender, size int, interval time.Duration) *Batcher {
+
+	if size < 1 {
+		size = 1
+	}
+	bt := &Batcher{
+		b:    b,
+		size: size,
+		buf:  make([]Record, 0, size),
+		done: make(chan struct{}),
+	}
+	if interval > 0 {
+		bt.wg.Add(1)
+		go bt.tick(interval)
+	}
+	return bt
+}
+
+func (bt *Batcher) tick(interval time.Duration) {
+	defer bt.wg.Done()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			bt.Flush()
+		case <-bt.done:
+			return
+		}
+	}
+}
+
+// Append buffers the entry as a Record. The fields are copied since the
+// Record outlives the call.
+func (bt *Batcher) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+
+	rec := Record{
+		Time:    TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Name:    NameFrom(ctx),
+		Caller:  CallerFrom(ctx),
+	}
+	if len(fields) > 0 {
+		rec.Fields = make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			rec.Fields[k] = v
+		}
+	}
+
+	bt.Lock()
+	bt.buf = append(bt.buf, rec)
+	full := len(bt.buf) >= bt.size
+	bt.Unlock()
+
+	if full || lvl <= FatalLevel {
+		bt.Flush()
+	}
+
+	switch lvl {
+	case FatalLevel:
+		os.Exit(1)
+	case PanicLevel:
+		panic(msg)
+	}
+}
+
+// Len returns the number of buffered Records.
+func (bt *Batcher) Len() int {
+	bt.Lock()
+	defer bt.Unlock()
+	return len(bt.buf)
+}
+
+// Flush delivers any buffered Records.
+func (bt *Batcher) Flush() {
+	bt.flushing.Lock()
+	defer bt.flushing.Unlock()
+
+	bt.Lock()
+	recs := bt.buf
+	if len(recs) == 0 {
+		bt.Unlock()
+		return
+	}
+	bt.buf = make([]Record, 0, bt.size)
+	bt.Unlock()
+
+	bt.b.AppendBatch(recs)
+}
+
+// Close stops the Batcher's interval, if any, and delivers any buffered
+// Records.
+func (bt *Batcher) Close() error {
+	bt.once.Do(func() {
+		close(bt.done)
+		bt.wg.Wait()
+	})
+	bt.Flush()
+	return nil
+}
@@ -0,0 +1,100 @@
+package gournal
+
+import "context"
+
+// FromContext returns a Logger bound to the provided Context. Unlike New,
+// the returned Logger resolves the Context's appender, level, and fields
+// once, when it is created, rather than for every entry. This avoids walking
+// the Context chain in hot loops that emit many entries against the same
+// Context.
+//
+// The returned Logger reflects the Context at the time FromContext is
+// called; fields pushed to or popped from the Context's field stack
+// afterwards are not observed. If the Context does not have a level, the
+// level of its named logger is still consulted for every entry so that
+// changes made with SetNamedLevel take effect.
+func FromContext(ctx context.Context) Logger {
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+
+	l := &boundLogger{
+		ctx:       ctx,
+		appender:  getAppender(ctx),
+		ctxFields: ctx.Value(fieldsKey),
+	}
+
+	if v, ok := ctx.Value(levelKey).(Level); ok {
+		l.lvl, l.hasLvl = v, true
+	} else {
+		l.name, l.hasName = ctx.Value(nameKey).(string)
+	}
+
+	// the stack's slice is shared by every entry, so mark it as such to
+	// ensure it is copied rather than modified
+	inspectStackFields(ctx, &l.stack)
+	l.stack.shared = len(l.stack.list) > 0
+
+	return l
+}
+
+type boundLogger struct {
+	ctx       context.Context
+	appender  Appender
+	lvl       Level
+	hasLvl    bool
+	name      string
+	hasName   bool
+	stack     fieldSet
+	ctxFields interface{}
+}
+
+func (l *boundLogger) level() Level {
+	if l.hasLvl {
+		return l.lvl
+	}
+	if l.hasName {
+		if v, ok := NamedLevel(l.name); ok {
+			return v
+		}
+	}
+	return CurrentDefaultLevel()
+}
+
+func (l *boundLogger) log(lvl Level, msg string, args ...interface{}) {
+	if l.level() < lvl {
+		return
+	}
+	appendEntry(
+		l.ctx, l.appender, lvl,
+		l.stack, fieldSet{}, l.ctxFields,
+		msg, args...)
+}
+
+func (l *boundLogger) Debug(msg string, args ...interface{}) {
+	l.log(DebugLevel, msg, args...)
+}
+
+func (l *boundLogger) Info(msg string, args ...interface{}) {
+	l.log(InfoLevel, msg, args...)
+}
+
+func (l *boundLogger) Print(msg string, args ...interface{}) {
+	l.log(InfoLevel, msg, args...)
+}
+
+func (l *boundLogger) Warn(msg string, args ...interface{}) {
+	l.log(WarnLevel, msg, args...)
+}
+
+func (l *boundLogger) Error(msg string, args ...interface{}) {
+	l.log(ErrorLevel, msg, args...)
+}
+
+func (l *boundLogger) Fatal(msg string, args ...interface{}) {
+	l.log(FatalLevel, msg, args...)
+}
+
+func (l *boundLogger) Panic(msg string, args ...interface{}) {
+	l.log(PanicLevel, msg, args...)
+}
@@ -0,0 +1,149 @@
+package gournal
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var (
+	// CaptureCaller specifies whether the source location of each log
+	// function call is captured and stored in the entry's Context, from
+	// which Appenders obtain it with CallerFrom. Capturing the caller walks
+	// the stack, so it is disabled by default.
+	CaptureCaller = false
+
+	// FullCallerPath specifies whether FormatCaller writes the full path of
+	// the caller's file instead of only its directory and name, ex.
+	// "gournal/gournal.go:42".
+	FullCallerPath = false
+
+	// CallerWidth is the maximum length of the callers written by
+	// FormatCaller. Longer callers are truncated from the left. A width of
+	// zero disables truncation.
+	CallerWidth = 0
+
+	// NameWidth is the maximum length of the logger names written by
+	// FormatName. Longer names are truncated from the left. A width of zero
+	// disables truncation.
+	NameWidth = 0
+)
+
+// Caller is the source location of a log function call. It is marshaled as
+// text in the form "file:line".
+type Caller struct {
+
+	// File is the full path of the caller's file.
+	File string
+
+	// Line is the line number of the call.
+	Line int
+
+	// Function is the package path-qualified name of the calling function.
+	// It is not preserved when the Caller is marshaled.
+	Function string
+}
+
+// String returns the caller as "file:line" with the file's full path.
+func (c Caller) String() string {
+	return c.File + ":" + strconv.Itoa(c.Line)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c Caller) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. Text without a line
+// number is stored in the File field.
+func (c *Caller) UnmarshalText(text []byte) error {
+	s := string(text)
+	*c = Caller{File: s}
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		if line, err := strconv.Atoi(s[i+1:]); err == nil {
+			c.File, c.Line = s[:i], line
+		}
+	}
+	return nil
+}
+
+// CallerKey returns the Context key for storing and retrieving the caller
+// of an entry as a *Caller. A caller stored in the Context with this key,
+// for example by a bridge from a logging library that already knows the
+// caller, is used instead of the one captured when CaptureCaller is
+// enabled.
+func CallerKey() interface{} {
+	return callerKey
+}
+
+// CallerFrom returns the caller stored in the Context when the entry was
+// emitted with CaptureCaller enabled, otherwise nil.
+func CallerFrom(ctx context.Context) *Caller {
+	if ctx == nil {
+		return nil
+	}
+	c, _ := ctx.Value(callerKey).(*Caller)
+	return c
+}
+
+// FormatCaller returns the caller as "file:line", with the file's full path
+// if FullCallerPath is true, otherwise its directory and name, truncated to
+// CallerWidth. An empty string is returned if the caller is nil.
+func FormatCaller(c *Caller) string {
+	if c == nil {
+		return ""
+	}
+	file := c.File
+	if !FullCallerPath {
+		if i := strings.LastIndexByte(file, '/'); i >= 0 {
+			if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+				file = file[j+1:]
+			}
+		}
+	}
+	return truncateLeft(file+":"+strconv.Itoa(c.Line), CallerWidth)
+}
+
+// FormatName returns the logger name truncated to NameWidth.
+func FormatName(name string) string {
+	return truncateLeft(name, NameWidth)
+}
+
+// truncateLeft returns s if it is no longer than width runes, otherwise an
+// ellipsis followed by the end of s such that the result is width runes.
+func truncateLeft(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return "…" + string(r[len(r)-width+1:])
+}
+
+// captureCaller returns the source location of the first function on the
+// stack outside of Gournal, or of the first function in a test file.
+func captureCaller() *Caller {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		fr, more := frames.Next()
+		if !strings.HasPrefix(fr.Function, "github.com/akutz/gournal") ||
+			strings.HasSuffix(fr.File, "_test.go") {
+			if fr.Function == "" {
+				return nil
+			}
+			return &Caller{
+				File:     fr.File,
+				Line:     fr.Line,
+				Function: fr.Function,
+			}
+		}
+		if !more {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package gournal
+
+import (
+	"io"
+	"os"
+)
+
+// NewConsoleAppender returns an Appender that writes INFO and DEBUG entries
+// to os.Stdout and WARN and more severe entries to os.Stderr, the
+// convention expected by container runtimes and systemd, using the provided
+// Formatter or, if it is nil, the same format as NewAppender.
+func NewConsoleAppender(f Formatter) Appender {
+	return NewConsoleAppenderWithOptions(os.Stdout, os.Stderr, f)
+}
+
+// NewConsoleAppenderWithOptions is like NewConsoleAppender but writes to the
+// provided io.Writers in place of os.Stdout and os.Stderr.
+func NewConsoleAppenderWithOptions(
+	stdout, stderr io.Writer, f Formatter) Appender {
+
+	return NewAppenderWithWriters(map[Level]io.Writer{
+		WarnLevel:  stderr,
+		ErrorLevel: stderr,
+		FatalLevel: stderr,
+		PanicLevel: stderr,
+	}, stdout, f)
+}
@@ -0,0 +1,86 @@
+package gournal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DelimitedFormatter formats entries as rows of delimiter-separated values
+// with the columns chosen by the user, for example to post-process logs in
+// spreadsheets or with awk. The column names "time", "level", and "msg"
+// refer to the entry's time, level, and message; any other name refers to
+// the field with that key, which is formatted with fmt.Sprint. Missing
+// fields and zero times are written as empty values.
+//
+// Values that contain the delimiter, quotation marks, or line breaks are
+// quoted as described by RFC 4180.
+type DelimitedFormatter struct {
+	comma   rune
+	columns []string
+}
+
+// NewCSVFormatter returns a DelimitedFormatter that writes comma-separated
+// values with the provided columns.
+func NewCSVFormatter(columns ...string) *DelimitedFormatter {
+	return NewDelimitedFormatter(',', columns...)
+}
+
+// NewTSVFormatter returns a DelimitedFormatter that writes tab-separated
+// values with the provided columns.
+func NewTSVFormatter(columns ...string) *DelimitedFormatter {
+	return NewDelimitedFormatter('\t', columns...)
+}
+
+// NewDelimitedFormatter returns a DelimitedFormatter that writes values
+// separated by the provided delimiter with the provided columns. The
+// delimiter must not be a quotation mark, carriage return, or line feed.
+func NewDelimitedFormatter(
+	comma rune, columns ...string) *DelimitedFormatter {
+
+	return &DelimitedFormatter{comma: comma, columns: columns}
+}
+
+// WriteHeader writes a row with the names of the columns to w.
+func (f *DelimitedFormatter) WriteHeader(w io.Writer) error {
+	return f.write(w, f.columns)
+}
+
+// Format writes the Record to the buffer.
+func (f *DelimitedFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	row := make([]string, len(f.columns))
+	for i, c := range f.columns {
+		switch c {
+		case "time":
+			if !rec.Time.IsZero() {
+				row[i] = rec.Time.Format(time.RFC3339Nano)
+			}
+		case "level":
+			row[i] = rec.Level.String()
+		case "msg":
+			row[i] = rec.Message
+		default:
+			if v, ok := rec.Fields[c]; ok {
+				row[i] = fmt.Sprint(v)
+			}
+		}
+	}
+	n := buf.Len()
+	if err := f.write(buf, row); err != nil {
+		buf.Truncate(n)
+		return err
+	}
+	return nil
+}
+
+func (f *DelimitedFormatter) write(w io.Writer, row []string) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = f.comma
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
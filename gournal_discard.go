@@ -0,0 +1,20 @@
+package gournal
+
+import "context"
+
+// Discard is an Appender that discards every entry. It is used in place of
+// DefaultAppender when DefaultAppender is nil so that a log call never
+// crashes a program simply because an Appender was not configured.
+//
+// Unlike other Appenders, Discard does not exit the program or panic when
+// it receives FATAL or PANIC entries.
+var Discard Appender = discard{}
+
+type discard struct{}
+
+func (discard) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+}
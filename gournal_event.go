@@ -0,0 +1,73 @@
+package gournal
+
+import (
+	"context"
+	"reflect"
+)
+
+var (
+	// EventKey is the name of the field that contains the name of an event
+	// emitted with Event.
+	EventKey = "event"
+
+	// EventLevel is the level at which Event emits events.
+	EventLevel = InfoLevel
+)
+
+// LogFielder is implemented by events that provide their own fields.
+type LogFielder interface {
+
+	// LogFields returns the event's fields.
+	LogFields() map[string]interface{}
+}
+
+// EventNamer is implemented by events that provide their own name.
+type EventNamer interface {
+
+	// EventName returns the event's name.
+	EventName() string
+}
+
+// Event emits a schema'd event, such as a UserLoggedIn or VolumeAttached
+// struct, at EventLevel. The entry's message and the field named by
+// EventKey are the event's name, which is the result of its EventName
+// function if it is an EventNamer, otherwise the name of its type.
+//
+// The entry's fields are the result of the event's LogFields function if
+// it is a LogFielder, otherwise they are obtained from the event's struct
+// fields the same way as WithStruct.
+func Event(ctx context.Context, ev interface{}) {
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+	if getLevel(ctx) < EventLevel {
+		return
+	}
+
+	name := eventName(ev)
+	var fields fieldSet
+	if lf, ok := ev.(LogFielder); ok {
+		fields.addMap(lf.LogFields())
+	} else {
+		addStructFields(&fields, reflect.ValueOf(ev), "", 0)
+	}
+	fields.addField(EventKey, name)
+	sendToAppender(ctx, EventLevel, fields, name)
+}
+
+func eventName(ev interface{}) string {
+	if en, ok := ev.(EventNamer); ok {
+		return en.EventName()
+	}
+	t := reflect.TypeOf(ev)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
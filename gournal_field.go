@@ -0,0 +1,280 @@
+package gournal
+
+import (
+	"bytes"
+	"context"
+	"sort"
+)
+
+// FieldPriority is a list of field keys that are written before all other
+// fields, in the order listed, ex. []string{RequestIDKey}. The remaining
+// fields are written sorted by key. FieldPriority is observed by the
+// Appender returned by NewAppender and by the bundled Formatters, so their
+// output does not depend on the order in which maps are iterated.
+var FieldPriority []string
+
+// Field is a single key/value pair of field data.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// FieldAppender may be implemented by Appenders that accept an entry's
+// field data as an ordered slice rather than as a map. Appenders that
+// implement this interface avoid the allocation of a map for every entry
+// and receive fields in a deterministic order: fields pushed with
+// PushFields, then fields provided to the entry, then the Context's fields.
+// Keys are unique, and a later source overrides an earlier one.
+//
+// Appenders that do not implement this interface continue to receive
+// fields as a map via Append.
+type FieldAppender interface {
+	Appender
+
+	// AppendFields is like Append except the field data is an ordered
+	// slice. The slice must not be retained after the call returns.
+	AppendFields(
+		ctx context.Context,
+		lvl Level,
+		fields []Field,
+		msg string)
+}
+
+// fieldSet collects an entry's field data from its sources. A single map
+// source is not materialized into a slice until a second source is added,
+// allowing a lone map to be copied directly into the map handed to an
+// Appender.
+type fieldSet struct {
+	list []Field
+
+	// src is a map that has not yet been materialized into list
+	src map[string]interface{}
+
+	// shared indicates that list is backed by an array that belongs to
+	// another fieldSet and must be copied before it is modified
+	shared bool
+}
+
+func (f *fieldSet) empty() bool {
+	return len(f.list) == 0 && len(f.src) == 0
+}
+
+func (f *fieldSet) addMap(m map[string]interface{}) {
+	if len(m) == 0 {
+		return
+	}
+	if f.empty() {
+		f.src = m
+		return
+	}
+	f.materialize()
+	f.list = appendMap(f.list, m)
+}
+
+func (f *fieldSet) addField(key string, value interface{}) {
+	f.materialize()
+	f.list = append(f.list, Field{key, value})
+}
+
+func (f *fieldSet) addSet(o fieldSet) {
+	if o.empty() {
+		return
+	}
+	if f.empty() {
+		// the other set's slice is shared rather than copied and is
+		// copied on the next write
+		*f = o
+		f.shared = f.shared || len(f.list) > 0
+		return
+	}
+	f.materialize()
+	f.list = append(f.list, o.list...)
+	f.list = appendMap(f.list, o.src)
+}
+
+// materialize moves the map source, if any, into the slice and ensures the
+// slice is not shared.
+func (f *fieldSet) materialize() {
+	if f.shared {
+		f.list = append(make([]Field, 0, len(f.list)+4), f.list...)
+		f.shared = false
+	}
+	if f.src != nil {
+		f.list = appendMap(f.list, f.src)
+		f.src = nil
+	}
+}
+
+// resolve replaces the lazy values, if any, with their results. A map
+// source is materialized rather than modified if it has lazy values.
+func (f *fieldSet) resolve() {
+	found := false
+	for _, fld := range f.list {
+		if _, found = fld.Value.(lazyValue); found {
+			break
+		}
+	}
+	if !found {
+		for _, v := range f.src {
+			if _, found = v.(lazyValue); found {
+				break
+			}
+		}
+	}
+	if !found {
+		return
+	}
+	f.materialize()
+	for i := range f.list {
+		if lv, ok := f.list[i].Value.(lazyValue); ok {
+			f.list[i].Value = lv.resolve()
+		}
+	}
+}
+
+// toMap returns a copy of the fields as a map, so it may be modified
+// without affecting the maps from which the fields were added.
+func (f *fieldSet) toMap() map[string]interface{} {
+	if f.empty() {
+		return nil
+	}
+	m := make(map[string]interface{}, len(f.list)+len(f.src))
+	for _, fld := range f.list {
+		m[fld.Key] = fld.Value
+	}
+	for k, v := range f.src {
+		m[k] = v
+	}
+	return m
+}
+
+// toList returns the fields as a slice with unique keys. When a key occurs
+// more than once, the last value wins and the position of the first
+// occurrence is kept.
+func (f *fieldSet) toList() []Field {
+	if f.src != nil || f.shared && hasDuplicates(f.list) {
+		f.materialize()
+	}
+	if !f.shared {
+		f.list = dedupe(f.list)
+	}
+	return f.list
+}
+
+func hasDuplicates(list []Field) bool {
+	for i := 1; i < len(list); i++ {
+		for j := 0; j < i; j++ {
+			if list[i].Key == list[j].Key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func dedupe(list []Field) []Field {
+	n := 0
+	for i := range list {
+		dup := false
+		for j := 0; j < n; j++ {
+			if list[j].Key == list[i].Key {
+				list[j].Value = list[i].Value
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			list[n] = list[i]
+			n++
+		}
+	}
+	return list[:n]
+}
+
+// appendMap appends the map's fields to the slice in key order so the
+// result is deterministic.
+func appendMap(list []Field, m map[string]interface{}) []Field {
+	if len(m) == 0 {
+		return list
+	}
+	start := len(list)
+	for k, v := range m {
+		list = append(list, Field{k, v})
+	}
+	sortFields(list[start:])
+	return list
+}
+
+// sortFields sorts the fields in the order returned by SortKeys using an
+// insertion sort, which does not allocate and is fast for the small number
+// of fields in an entry.
+func sortFields(list []Field) {
+	for i := 1; i < len(list); i++ {
+		for j := i; j > 0 && keyLess(list[j].Key, list[j-1].Key); j-- {
+			list[j], list[j-1] = list[j-1], list[j]
+		}
+	}
+}
+
+// SortKeys returns the keys of the fields in the order in which they are
+// written: the keys in FieldPriority, followed by the remaining keys sorted
+// in increasing order.
+func SortKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keyLess(keys[i], keys[j]) })
+	return keys
+}
+
+func keyLess(a, b string) bool {
+	if pa, pb := keyPriority(a), keyPriority(b); pa != pb {
+		return pa < pb
+	}
+	return a < b
+}
+
+func keyPriority(k string) int {
+	for i, p := range FieldPriority {
+		if p == k {
+			return i
+		}
+	}
+	return len(FieldPriority)
+}
+
+// OrderedFields is field data that is marshaled to a JSON object with its
+// keys in the order returned by SortKeys.
+type OrderedFields map[string]interface{}
+
+// MarshalJSON marshals the fields to a JSON object.
+func (f OrderedFields) MarshalJSON() ([]byte, error) {
+	if f == nil {
+		return []byte("null"), nil
+	}
+	buf := &bytes.Buffer{}
+	enc := newJSONEncoder(buf)
+	buf.WriteByte('{')
+	for i, k := range SortKeys(f) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := enc.Encode(k); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(f[k]); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// fieldsFromMap returns a fieldSet for the provided map.
+func fieldsFromMap(m map[string]interface{}) fieldSet {
+	return fieldSet{src: m}
+}
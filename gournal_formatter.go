@@ -0,0 +1,191 @@
+package gournal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Formatter formats entries for Appenders that write them as text or
+// bytes, such as the one returned by NewAppenderWithFormatter.
+type Formatter interface {
+
+	// Format writes the Record to the buffer, including a trailing newline.
+	Format(buf *bytes.Buffer, rec *Record) error
+}
+
+// TextFormatter formats entries the same way as the Appender returned by
+// NewAppenderWithOptions:
+//
+//	[LEVEL] message map[key:value ...]
+//
+// The logger name and caller, if any, precede the message:
+//
+//	[LEVEL] name dir/file.go:42: message map[key:value ...]
+type TextFormatter struct{}
+
+// Format writes the Record to the buffer.
+func (TextFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	buf.WriteByte('[')
+	buf.WriteString(rec.Level.String())
+	buf.WriteString("] ")
+	writeNameCaller(buf, rec.Name, rec.Caller)
+	buf.WriteString(rec.Message)
+	if len(rec.Fields) > 0 {
+		buf.WriteByte(' ')
+		writeFieldMap(buf, rec.Fields)
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// JSONFormatter formats entries as newline-delimited JSON Records with the
+// fields in the order returned by SortKeys. The caller is written with
+// FormatCaller and the logger name with FormatName. Field values that
+// cannot be marshaled to JSON are written as strings.
+type JSONFormatter struct{}
+
+// jsonRecord is a Record with ordered fields and formatted columns.
+type jsonRecord struct {
+	Time    time.Time     `json:"time"`
+	Level   Level         `json:"level"`
+	Message string        `json:"msg"`
+	Fields  OrderedFields `json:"fields,omitempty"`
+	Name    string        `json:"logger,omitempty"`
+	Caller  string        `json:"caller,omitempty"`
+}
+
+// Format writes the Record to the buffer.
+func (JSONFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	jrec := &jsonRecord{
+		Time:    rec.Time,
+		Level:   rec.Level,
+		Message: rec.Message,
+		Fields:  rec.Fields,
+		Name:    FormatName(rec.Name),
+		Caller:  FormatCaller(rec.Caller),
+	}
+	n := buf.Len()
+	err := newJSONEncoder(buf).Encode(jrec)
+	if err == nil {
+		return nil
+	}
+	buf.Truncate(n)
+
+	jrec.Fields = make(OrderedFields, len(rec.Fields))
+	for k, v := range rec.Fields {
+		if _, err := json.Marshal(v); err != nil {
+			v = fmt.Sprint(v)
+		}
+		jrec.Fields[k] = v
+	}
+	return newJSONEncoder(buf).Encode(jrec)
+}
+
+// LogfmtFormatter formats entries as logfmt lines, with the fields in the
+// order returned by SortKeys:
+//
+//	time=2017-10-01T12:00:00Z level=info msg="Hello Bob" size=1
+//
+// The logger name and caller, if any, are written after the level with the
+// keys "logger" and "caller", formatted with FormatName and FormatCaller.
+// Values that are empty or contain spaces, equals signs, quotation marks,
+// or non-printable characters are quoted and escaped. Characters that are
+// not valid in keys are replaced with underscores. The time is omitted if
+// it is zero.
+type LogfmtFormatter struct{}
+
+// Format writes the Record to the buffer.
+func (LogfmtFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	if !rec.Time.IsZero() {
+		buf.WriteString("time=")
+		buf.WriteString(rec.Time.Format(time.RFC3339Nano))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString("level=")
+	buf.WriteString(strings.ToLower(rec.Level.String()))
+	if rec.Name != "" {
+		buf.WriteString(" logger=")
+		writeLogfmtValue(buf, FormatName(rec.Name))
+	}
+	if rec.Caller != nil {
+		buf.WriteString(" caller=")
+		writeLogfmtValue(buf, FormatCaller(rec.Caller))
+	}
+	buf.WriteString(" msg=")
+	writeLogfmtValue(buf, rec.Message)
+
+	for _, k := range SortKeys(rec.Fields) {
+		buf.WriteByte(' ')
+		writeLogfmtKey(buf, k)
+		buf.WriteByte('=')
+		writeLogfmtValue(buf, fmt.Sprint(rec.Fields[k]))
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+func writeLogfmtKey(buf *bytes.Buffer, k string) {
+	if k == "" {
+		buf.WriteByte('_')
+		return
+	}
+	for _, r := range k {
+		if r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r) {
+			r = '_'
+		}
+		buf.WriteRune(r)
+	}
+}
+
+func writeLogfmtValue(buf *bytes.Buffer, v string) {
+	if v == "" || strings.IndexFunc(v, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || !unicode.IsPrint(r)
+	}) >= 0 {
+		buf.WriteString(strconv.Quote(v))
+		return
+	}
+	buf.WriteString(v)
+}
+
+// writeNameCaller writes the logger name and caller, formatted with
+// FormatName and FormatCaller, followed by a colon and a space if either
+// is present.
+func writeNameCaller(buf *bytes.Buffer, name string, c *Caller) {
+	if name == "" && c == nil {
+		return
+	}
+	buf.WriteString(FormatName(name))
+	if c != nil {
+		if name != "" {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(FormatCaller(c))
+	}
+	buf.WriteString(": ")
+}
+
+// writeFieldMap writes the fields the same way as fmt.Print, but in the
+// order returned by SortKeys.
+func writeFieldMap(buf *bytes.Buffer, fields map[string]interface{}) {
+	buf.WriteString("map[")
+	for i, k := range SortKeys(fields) {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(k)
+		buf.WriteByte(':')
+		fmt.Fprint(buf, fields[k])
+	}
+	buf.WriteByte(']')
+}
+
+func newJSONEncoder(buf *bytes.Buffer) *json.Encoder {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return enc
+}
@@ -6,21 +6,120 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
+	"time"
 )
 
+// TimeLayout is the layout with which an Appender returned by
+// NewAppenderWithTimestamps formats the time of entries.
+var TimeLayout = time.RFC3339Nano
+
 // NewAppender returns an Appender that writes to os.Stdout.
 func NewAppender() Appender {
-	return &appender{os.Stdout}
+	return &appender{w: os.Stdout}
 }
 
 // NewAppenderWithOptions returns an Appender that writes to the provided
 // io.Writer object.
 func NewAppenderWithOptions(w io.Writer) Appender {
-	return &appender{w}
+	return &appender{w: w}
+}
+
+// NewAppenderWithTimestamps returns an Appender that writes entries to the
+// provided io.Writer object in the same format as NewAppender, prefixed
+// with the time of the entry formatted with TimeLayout.
+func NewAppenderWithTimestamps(w io.Writer) Appender {
+	return NewAppenderWithTimeLayout(w, TimeLayout)
+}
+
+// NewAppenderWithTimeLayout returns an Appender that writes entries to the
+// provided io.Writer object in the same format as NewAppender, prefixed
+// with the time of the entry formatted with the provided layout, ex.:
+//
+//	2017-10-01T12:00:00Z [INFO] Hello Bob map[size:1]
+//
+// The time is obtained with TimeFrom, so it may be fixed by replacing Clock.
+func NewAppenderWithTimeLayout(w io.Writer, layout string) Appender {
+	return &appender{w: w, layout: layout}
+}
+
+// NewAppenderWithFormatter returns an Appender that writes entries to the
+// provided io.Writer object using the provided Formatter.
+func NewAppenderWithFormatter(w io.Writer, f Formatter) Appender {
+	return &appender{w: w, f: f}
+}
+
+// NewAppenderWithWriters returns an Appender that writes entries of each
+// level in the provided map to that level's io.Writer, and entries of all
+// other levels to the default io.Writer, using the provided Formatter or,
+// if it is nil, the same format as NewAppender. For example, to write DEBUG
+// entries to a file and WARN and more severe entries to os.Stderr:
+//
+//	NewAppenderWithWriters(map[Level]io.Writer{
+//		DebugLevel: f,
+//		WarnLevel:  os.Stderr,
+//		ErrorLevel: os.Stderr,
+//		FatalLevel: os.Stderr,
+//		PanicLevel: os.Stderr,
+//	}, os.Stdout, nil)
+//
+// A nil io.Writer discards the entries of its level.
+func NewAppenderWithWriters(
+	writers map[Level]io.Writer, def io.Writer, f Formatter) Appender {
+
+	a := &appender{w: def, f: f, writers: make(map[Level]io.Writer)}
+	for lvl, w := range writers {
+		a.writers[lvl] = w
+	}
+	return a
+}
+
+// WriteCloserAppender is an Appender that writes to an io.WriteCloser.
+type WriteCloserAppender interface {
+	ErrorAppender
+
+	// Flush flushes the io.WriteCloser if it has a Flush or Sync function.
+	Flush() error
+
+	// Close flushes and closes the io.WriteCloser.
+	Close() error
+}
+
+// NewAppenderWithCloser returns an Appender that writes entries to the
+// provided io.WriteCloser, such as a *lumberjack.Logger, using the provided
+// Formatter or, if it is nil, the same format as NewAppender. The
+// io.WriteCloser is flushed before the program exits or panics because of a
+// FATAL or PANIC entry.
+func NewAppenderWithCloser(w io.WriteCloser, f Formatter) WriteCloserAppender {
+	a := &closerAppender{appender: appender{w: w, f: f}, c: w}
+	a.flush = a.flushWriter
+	return a
+}
+
+// maxPooledBufSize is the capacity above which buffers are not returned to
+// the pool so a single, large entry does not pin memory indefinitely.
+const maxPooledBufSize = 64 * 1024
+
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
 }
 
 type appender struct {
+	sync.Mutex
 	w io.Writer
+	f Formatter
+
+	// layout, if not empty, is the layout of the time that prefixes
+	// entries written without a Formatter
+	layout string
+
+	// writers are the io.Writers of levels not written to w
+	writers map[Level]io.Writer
+
+	// flush, if not nil, is called before the program exits or panics
+	flush func() error
 }
 
 func (a *appender) Append(
@@ -29,39 +128,193 @@ func (a *appender) Append(
 	fields map[string]interface{},
 	msg string) {
 
-	var (
-		w        = a.w
-		panicBuf *bytes.Buffer
-	)
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		HandleError(err)
+	}
+}
+
+// TryAppend is like Append but returns the error, if any, from writing the
+// entry.
+func (a *appender) TryAppend(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) error {
 
-	if lvl == PanicLevel {
-		// the base size of the buffer is:
-		//
-		//   8 - len("[PANIC] ")
-		//   x - len(msg)
-		//   1 - len("\n")
-		//
-		// then add 64 bytes for every possible field. this may be more than
-		// is required, or it may be less. but by pre-allocating more than is
-		// required the buffer will not need to double in size if additional
-		// capacity is needed to write the fields object
-		//panicBufLen := 8 + len(msg) + (len(fields) * 64)
+	if a.f != nil {
+		return a.format(&Record{
+			Time:    TimeFrom(ctx),
+			Level:   lvl,
+			Message: msg,
+			Fields:  fields,
+			Name:    NameFrom(ctx),
+			Caller:  CallerFrom(ctx),
+		})
+	}
 
-		panicBuf = &bytes.Buffer{} //bytes.NewBuffer(make([]byte, panicBufLen))
-		w = io.MultiWriter(a.w, panicBuf)
+	buf := a.begin(ctx, lvl, msg)
+	if len(fields) > 0 {
+		buf.WriteByte(' ')
+		writeFieldMap(buf, fields)
 	}
+	buf.WriteByte('\n')
+	return a.end(lvl, buf)
+}
 
-	if len(fields) == 0 {
-		fmt.Fprintf(w, "[%s] %s\n", lvl, msg)
-	} else {
-		fmt.Fprintf(w, "[%s] %s %v\n", lvl, msg, fields)
+// format writes the Record using the Appender's Formatter.
+func (a *appender) format(rec *Record) error {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if err := a.f.Format(buf, rec); err != nil {
+		if buf.Cap() <= maxPooledBufSize {
+			bufPool.Put(buf)
+		}
+		return err
 	}
+	return a.end(rec.Level, buf)
+}
 
-	if lvl == FatalLevel {
+// maxSortedFields is the number of fields AppendFields is able to sort
+// without allocating.
+const maxSortedFields = 16
+
+// AppendFields formats the fields the same way as Append, in the order
+// returned by SortKeys, but without requiring a map.
+func (a *appender) AppendFields(
+	ctx context.Context,
+	lvl Level,
+	fields []Field,
+	msg string) {
+
+	if a.f != nil {
+		var m map[string]interface{}
+		if len(fields) > 0 {
+			m = make(map[string]interface{}, len(fields))
+			for _, f := range fields {
+				m[f.Key] = f.Value
+			}
+		}
+		a.Append(ctx, lvl, m, msg)
+		return
+	}
+
+	buf := a.begin(ctx, lvl, msg)
+	if len(fields) > 0 {
+		var arr [maxSortedFields]Field
+		sorted := append(arr[:0], fields...)
+		sortFields(sorted)
+
+		buf.WriteString(" map[")
+		for i, f := range sorted {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(f.Key)
+			buf.WriteByte(':')
+			fmt.Fprint(buf, f.Value)
+		}
+		buf.WriteByte(']')
+	}
+	buf.WriteByte('\n')
+	if err := a.end(lvl, buf); err != nil {
+		HandleError(err)
+	}
+}
+
+// begin returns a pooled buffer that contains the entry's time, if the
+// Appender has a layout, level, logger name and caller, if any, and
+// message. The entire entry is formatted
+// into the buffer so it is emitted with a single write that cannot
+// interleave with other entries.
+func (a *appender) begin(
+	ctx context.Context, lvl Level, msg string) *bytes.Buffer {
+
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	if a.layout != "" {
+		buf.WriteString(TimeFrom(ctx).Format(a.layout))
+		buf.WriteByte(' ')
+	}
+	buf.WriteByte('[')
+	buf.WriteString(lvl.String())
+	buf.WriteString("] ")
+	writeNameCaller(buf, NameFrom(ctx), CallerFrom(ctx))
+	buf.WriteString(msg)
+	return buf
+}
+
+// end writes the buffer and returns it to the pool along with the error, if
+// any, from writing it.
+func (a *appender) end(lvl Level, buf *bytes.Buffer) error {
+	defer func() {
+		if buf.Cap() <= maxPooledBufSize {
+			bufPool.Put(buf)
+		}
+	}()
+
+	w := a.w
+	if lw, ok := a.writers[lvl]; ok {
+		w = lw
+	}
+
+	a.Lock()
+	var err error
+	if w != nil {
+		_, err = w.Write(buf.Bytes())
+	}
+	if lvl <= FatalLevel && a.flush != nil {
+		if ferr := a.flush(); err == nil {
+			err = ferr
+		}
+	}
+	a.Unlock()
+
+	// the error cannot be returned once the program exits or panics
+	if lvl <= FatalLevel && err != nil {
+		HandleError(err)
+	}
+	switch lvl {
+	case FatalLevel:
 		os.Exit(1)
+	case PanicLevel:
+		panic(buf.String())
 	}
+	return err
+}
+
+type closerAppender struct {
+	appender
+	c io.Closer
+}
+
+func (a *closerAppender) Flush() error {
+	a.Lock()
+	defer a.Unlock()
+	return a.flushWriter()
+}
+
+func (a *closerAppender) Close() error {
+	a.Lock()
+	defer a.Unlock()
+	err := a.flushWriter()
+	if cerr := a.c.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
 
-	if lvl == PanicLevel {
-		panic(panicBuf.String())
+// flushWriter flushes the writer if it has a Flush or Sync function. The
+// Appender must be locked.
+func (a *closerAppender) flushWriter() error {
+	switch w := a.w.(type) {
+	case interface {
+		Flush() error
+	}:
+		return w.Flush()
+	case interface {
+		Sync() error
+	}:
+		return w.Sync()
 	}
+	return nil
 }
@@ -0,0 +1,46 @@
+package gournal
+
+import "fmt"
+
+// lazyValue is implemented by field values whose results are computed only
+// when an entry is appended, after its level has been checked.
+type lazyValue interface {
+	resolve() interface{}
+}
+
+// LazyStringer returns a field value that is replaced with the result of
+// the Stringer's String function when the entry is appended. String is not
+// called for entries that are discarded because of their level, so values
+// with expensive String functions may be logged at levels that are usually
+// disabled:
+//
+//	gournal.WithField("plan", gournal.LazyStringer(plan)).Debug(ctx, "")
+func LazyStringer(s fmt.Stringer) fmt.Stringer {
+	return lazyStringer{s}
+}
+
+type lazyStringer struct {
+	s fmt.Stringer
+}
+
+func (l lazyStringer) resolve() interface{} {
+	return l.s.String()
+}
+
+func (l lazyStringer) String() string {
+	return l.s.String()
+}
+
+// lazyError is an error whose message is obtained when the entry is
+// appended.
+type lazyError struct {
+	err error
+}
+
+func (l lazyError) resolve() interface{} {
+	return l.err.Error()
+}
+
+func (l lazyError) String() string {
+	return l.err.Error()
+}
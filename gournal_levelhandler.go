@@ -0,0 +1,92 @@
+package gournal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// levelPayload is the JSON document exchanged with the LevelHandler.
+type levelPayload struct {
+	Level   *Level           `json:"level,omitempty"`
+	Loggers map[string]Level `json:"loggers,omitempty"`
+}
+
+// levelUpdate is the JSON document accepted by a PUT request to the
+// LevelHandler. The levels are strings so invalid levels can be rejected
+// rather than unmarshaled as UnknownLevel.
+type levelUpdate struct {
+	Level   *string           `json:"level,omitempty"`
+	Loggers map[string]string `json:"loggers,omitempty"`
+}
+
+// LevelHandler returns an http.Handler that inspects and changes the default
+// level and the levels of named loggers at runtime. A GET request returns
+// the current levels:
+//
+//	{"level":"ERROR","loggers":{"db":"DEBUG"}}
+//
+// A PUT request accepts a document with the default level and the loggers
+// whose levels are changed, either of which may be omitted. The default
+// level is changed with SetDefaultLevel, and a named logger whose level is
+// "UNKNOWN" is removed. The levels are only changed if they are all valid,
+// and the response to a PUT request is the updated levels.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(serveLevels)
+}
+
+func serveLevels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var p levelUpdate
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dlvl := UnknownLevel
+		if p.Level != nil {
+			if dlvl = ParseLevel(*p.Level); dlvl == UnknownLevel {
+				http.Error(
+					w,
+					fmt.Sprintf("invalid default level %q", *p.Level),
+					http.StatusBadRequest)
+				return
+			}
+		}
+		levels := make(map[string]Level, len(p.Loggers))
+		for name, s := range p.Loggers {
+			lvl := ParseLevel(s)
+			if lvl == UnknownLevel &&
+				!strings.EqualFold(s, unknownLevelStr) {
+				http.Error(
+					w,
+					fmt.Sprintf("invalid level %q for logger %q", s, name),
+					http.StatusBadRequest)
+				return
+			}
+			levels[name] = lvl
+		}
+		if dlvl != UnknownLevel {
+			SetDefaultLevel(dlvl)
+		}
+		for name, lvl := range levels {
+			SetNamedLevel(name, lvl)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(
+			w,
+			fmt.Sprintf("method %s not allowed", r.Method),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	lvl := CurrentDefaultLevel()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&levelPayload{
+		Level:   &lvl,
+		Loggers: NamedLevels(),
+	})
+}
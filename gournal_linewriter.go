@@ -0,0 +1,68 @@
+package gournal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// Writer returns an io.WriteCloser that emits one log entry at the provided
+// level for every line written to it, using the given Context's appender
+// and fields. This makes it possible to capture the output of subprocesses
+// or other components that only know how to write to an io.Writer.
+//
+// Partial lines are buffered until a newline is written or the Writer is
+// closed. It is safe to write to the returned object from multiple
+// goroutines.
+func Writer(ctx context.Context, lvl Level) io.WriteCloser {
+	return &lineWriter{ctx: ctx, lvl: lvl}
+}
+
+type lineWriter struct {
+	sync.Mutex
+	ctx context.Context
+	lvl Level
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.buf = append(w.buf, p...)
+			break
+		}
+		if len(w.buf) > 0 {
+			w.buf = append(w.buf, p[:i]...)
+			w.emit(w.buf)
+			w.buf = w.buf[:0]
+		} else {
+			w.emit(p[:i])
+		}
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// Close emits any buffered partial line.
+func (w *lineWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(w.buf)
+		w.buf = nil
+	}
+	return nil
+}
+
+func (w *lineWriter) emit(line []byte) {
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	sendToAppender(w.ctx, w.lvl, fieldSet{}, string(line))
+}
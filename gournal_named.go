@@ -0,0 +1,92 @@
+package gournal
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// namedLevels are the levels of named loggers, keyed by name.
+var namedLevels = struct {
+	sync.RWMutex
+	m map[string]Level
+}{m: map[string]Level{}}
+
+// defaultLevel is the level set with SetDefaultLevel. It is accessed
+// atomically so it may be changed while entries are logged, and is zero,
+// which is UnknownLevel, until a level is set.
+var defaultLevel int32
+
+// SetDefaultLevel sets the level used when a Level is not present in a
+// Context and the Context does not name a logger with a level. Unlike
+// assigning DefaultLevel, it is safe to call while entries are logged.
+// Setting a level of UnknownLevel reverts to DefaultLevel.
+func SetDefaultLevel(lvl Level) {
+	atomic.StoreInt32(&defaultLevel, int32(lvl))
+}
+
+// CurrentDefaultLevel returns the level set with SetDefaultLevel, or
+// DefaultLevel if a level has not been set.
+func CurrentDefaultLevel() Level {
+	if lvl := Level(atomic.LoadInt32(&defaultLevel)); lvl != UnknownLevel {
+		return lvl
+	}
+	return DefaultLevel
+}
+
+// NameKey returns the Context key for storing and retrieving the name of the
+// logger. If a Context does not have a level, the level of the named logger
+// is used before falling back to CurrentDefaultLevel.
+func NameKey() interface{} {
+	return nameKey
+}
+
+// SetNamedLevel sets the level of the named logger. Setting a level of
+// UnknownLevel removes the named logger's level.
+func SetNamedLevel(name string, lvl Level) {
+	namedLevels.Lock()
+	defer namedLevels.Unlock()
+	if lvl == UnknownLevel {
+		delete(namedLevels.m, name)
+		return
+	}
+	namedLevels.m[name] = lvl
+}
+
+// NamedLevel returns the level of the named logger and a flag indicating
+// whether the named logger has a level.
+func NamedLevel(name string) (Level, bool) {
+	namedLevels.RLock()
+	defer namedLevels.RUnlock()
+	lvl, ok := namedLevels.m[name]
+	return lvl, ok
+}
+
+// NamedLevels returns a copy of the levels of all named loggers.
+func NamedLevels() map[string]Level {
+	namedLevels.RLock()
+	defer namedLevels.RUnlock()
+	m := make(map[string]Level, len(namedLevels.m))
+	for k, v := range namedLevels.m {
+		m[k] = v
+	}
+	return m
+}
+
+// NameFrom returns the name of the logger stored in the Context with
+// NameKey, if any.
+func NameFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	name, _ := ctx.Value(nameKey).(string)
+	return name
+}
+
+func getNamedLevel(ctx context.Context) (Level, bool) {
+	name, ok := ctx.Value(nameKey).(string)
+	if !ok {
+		return UnknownLevel, false
+	}
+	return NamedLevel(name)
+}
@@ -0,0 +1,51 @@
+//go:build go1.9
+// +build go1.9
+
+package gournal
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+)
+
+// SetProfileLabels sets runtime/pprof labels on the current goroutine from
+// the named fields of the provided Context, so CPU profiles may be sliced by
+// the same dimensions as the logs. The fields are resolved from the
+// Context's field stack and from a map stored with the FieldsKey. Fields
+// that are not present are ignored.
+//
+// The returned Context carries the labels so goroutines started with it
+// inherit them, and the returned function restores the goroutine's labels
+// to those of the provided Context:
+//
+//	ctx, restore := gournal.SetProfileLabels(ctx, "handler", "tenant")
+//	defer restore()
+func SetProfileLabels(
+	ctx context.Context, keys ...string) (context.Context, func()) {
+
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+
+	var set fieldSet
+	inspectStackFields(ctx, &set)
+	if m, ok := ctx.Value(fieldsKey).(map[string]interface{}); ok {
+		set.addMap(m)
+	}
+	fields := set.toMap()
+
+	var labels []string
+	for _, k := range keys {
+		if v, ok := fields[k]; ok {
+			labels = append(labels, k, fmt.Sprint(v))
+		}
+	}
+	if len(labels) == 0 {
+		return ctx, func() {}
+	}
+
+	lctx := pprof.WithLabels(ctx, pprof.Labels(labels...))
+	pprof.SetGoroutineLabels(lctx)
+	return lctx, func() { pprof.SetGoroutineLabels(ctx) }
+}
@@ -0,0 +1,19 @@
+//go:build !go1.9
+// +build !go1.9
+
+package gournal
+
+import "context"
+
+// SetProfileLabels sets runtime/pprof labels on the current goroutine from
+// the named fields of the provided Context. Profiler labels require Go 1.9,
+// so with older versions of Go the provided Context and a function that
+// does nothing are returned.
+func SetProfileLabels(
+	ctx context.Context, keys ...string) (context.Context, func()) {
+
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+	return ctx, func() {}
+}
@@ -0,0 +1,31 @@
+//go:build go1.9
+// +build go1.9
+
+package gournal
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetProfileLabels(t *testing.T) {
+	ctx := context.WithValue(
+		context.Background(),
+		FieldsKey(),
+		map[string]interface{}{"tenant": "acme"})
+	ctx, pop := PushFields(ctx, map[string]interface{}{"handler": 2})
+	defer pop()
+
+	lctx, restore := SetProfileLabels(ctx, "handler", "tenant", "missing")
+	defer restore()
+
+	v, _ := pprof.Label(lctx, "handler")
+	assert.Equal(t, "2", v)
+	v, _ = pprof.Label(lctx, "tenant")
+	assert.Equal(t, "acme", v)
+	_, ok := pprof.Label(lctx, "missing")
+	assert.False(t, ok)
+}
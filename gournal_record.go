@@ -0,0 +1,68 @@
+package gournal
+
+import (
+	"context"
+	"time"
+)
+
+// Clock returns the current time. It may be replaced, for example to fix the
+// time of entries during tests.
+var Clock = time.Now
+
+// TimeLocation is the location in which TimeFrom expresses times, ex.
+// time.UTC, time.Local, or a location returned by time.LoadLocation for a
+// named zone such as "America/Chicago". Using the same location across a
+// fleet of hosts makes their entries easier to correlate. If TimeLocation
+// is nil, times are returned in the location in which they were obtained.
+var TimeLocation *time.Location
+
+// Record is a log entry that has been emitted.
+type Record struct {
+
+	// Time is when the entry was emitted.
+	Time time.Time `json:"time"`
+
+	// Level is the entry's level.
+	Level Level `json:"level"`
+
+	// Message is the entry's formatted message.
+	Message string `json:"msg"`
+
+	// Fields is the entry's field data.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+
+	// Name is the name of the logger that emitted the entry, if any.
+	Name string `json:"logger,omitempty"`
+
+	// Caller is the source location of the log function call that emitted
+	// the entry if CaptureCaller was enabled.
+	Caller *Caller `json:"caller,omitempty"`
+}
+
+// WithTime returns a Context that causes TimeFrom to return the provided
+// time. This is used to preserve the original time of entries that are
+// re-emitted, for example when they are replayed from storage.
+func WithTime(ctx context.Context, t time.Time) context.Context {
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+	return context.WithValue(ctx, timeKey, t)
+}
+
+// TimeFrom returns the time of an entry emitted with the provided Context.
+// This is the time stored in the Context with WithTime, if any, otherwise
+// the result of Clock, in TimeLocation. Appenders that record when an entry
+// occurred should use this function to obtain the time.
+func TimeFrom(ctx context.Context) time.Time {
+	t, ok := time.Time{}, false
+	if ctx != nil {
+		t, ok = ctx.Value(timeKey).(time.Time)
+	}
+	if !ok {
+		t = Clock()
+	}
+	if TimeLocation != nil {
+		t = t.In(TimeLocation)
+	}
+	return t
+}
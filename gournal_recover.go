@@ -0,0 +1,52 @@
+package gournal
+
+import (
+	"context"
+	"fmt"
+	rtdebug "runtime/debug"
+)
+
+// RepanicOnRecover indicates whether Recover and RecoverWith re-panic with
+// the recovered value after it has been logged.
+var RepanicOnRecover = false
+
+// Recover recovers from a panic and logs the panic's value and stack trace at
+// the ERROR level. Recover must be deferred directly:
+//
+//	go func() {
+//		defer gournal.Recover(ctx)
+//		...
+//	}()
+func Recover(ctx context.Context) {
+	if r := recover(); r != nil {
+		logRecovered(ctx, nil, r)
+	}
+}
+
+// RecoverWith is like Recover but includes the provided fields in the entry.
+// RecoverWith must be deferred directly.
+func RecoverWith(ctx context.Context, fields map[string]interface{}) {
+	if r := recover(); r != nil {
+		logRecovered(ctx, fields, r)
+	}
+}
+
+func logRecovered(
+	ctx context.Context,
+	fields map[string]interface{},
+	r interface{}) {
+
+	entryFields := map[string]interface{}{
+		"panic": fmt.Sprint(r),
+		"stack": string(rtdebug.Stack()),
+	}
+	for k, v := range fields {
+		entryFields[k] = v
+	}
+	sendToAppender(
+		ctx, ErrorLevel, fieldsFromMap(entryFields), "recovered from panic")
+
+	if RepanicOnRecover {
+		panic(r)
+	}
+}
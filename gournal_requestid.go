@@ -0,0 +1,38 @@
+package gournal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RequestIDKey defines the key when adding request IDs using WithRequestID.
+var RequestIDKey = "requestID"
+
+// WithRequestID returns a Context that stores the provided request ID and
+// includes it as a field in every entry emitted with the Context. If the
+// provided ID is empty, for example when an incoming request has no request
+// ID header, a new, random ID is generated.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+	if id == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	ctx = context.WithValue(ctx, requestIDKey, id)
+	ctx, _ = PushFields(ctx, map[string]interface{}{RequestIDKey: id})
+	return ctx
+}
+
+// RequestIDFrom returns the request ID stored in the provided Context or an
+// empty string if the Context has no request ID.
+func RequestIDFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,65 @@
+package gournal
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// SequenceKey is the name of the field that contains the sequence
+	// number added by an Appender returned by NewSequenceAppender.
+	SequenceKey = "seq"
+
+	// UptimeKey is the name of the field that contains the uptime added by
+	// an Appender returned by NewSequenceAppender.
+	UptimeKey = "uptime"
+)
+
+// processStart is when the process started, or near enough to it, and
+// includes a monotonic clock reading.
+var processStart = time.Now()
+
+// NewSequenceAppender returns an Appender that adds a sequence number and
+// the uptime of the process to every entry before delegating to next. The
+// sequence number starts at one and increases by one with every entry the
+// Appender receives. The uptime is a time.Duration measured with the
+// monotonic clock, so unlike timestamps it is not affected by changes to
+// the wall clock or time zone. Together they order the entries of a
+// process even when their timestamps are equal or out of order.
+//
+// The fields are named by seqKey and uptimeKey. A field is not added if
+// its key is empty.
+func NewSequenceAppender(next Appender, seqKey, uptimeKey string) Appender {
+	return &sequenceAppender{
+		next:      next,
+		seqKey:    seqKey,
+		uptimeKey: uptimeKey,
+	}
+}
+
+type sequenceAppender struct {
+	seq       uint64
+	next      Appender
+	seqKey    string
+	uptimeKey string
+}
+
+func (a *sequenceAppender) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+
+	enriched := make(map[string]interface{}, len(fields)+2)
+	for k, v := range fields {
+		enriched[k] = v
+	}
+	if a.seqKey != "" {
+		enriched[a.seqKey] = atomic.AddUint64(&a.seq, 1)
+	}
+	if a.uptimeKey != "" {
+		enriched[a.uptimeKey] = time.Since(processStart)
+	}
+	a.next.Append(ctx, lvl, enriched, msg)
+}
@@ -0,0 +1,67 @@
+package gournal
+
+// SeverityFunc returns the severity of a level in the backend with the
+// provided name and true, or false if the backend's default severity for
+// the level should be used.
+type SeverityFunc func(backend string, lvl Level) (interface{}, bool)
+
+// SeverityMapper, if not nil, converts Levels, including custom ones, to the
+// severities of backends, overriding the defaults of Gournal's packages.
+// Packages identify the backend by their name and expect the following
+// types of severities, ignoring severities of other types:
+//
+//	cef, leef     int, from 0 to 10
+//	gae           string, ex. "WARNING"
+//	gcp           int, the value of a logging.Severity
+//	otel          int, an OpenTelemetry severity number
+//	sentry        string, ex. "warning"
+//	slog          int, the value of a slog.Level
+//	stackdriver   string, ex. "WARNING"
+//	syslog        int, a syslog severity from 0 to 7
+//
+// For example, to write INFO entries to syslog as notices:
+//
+//	gournal.SeverityMapper = gournal.SeverityTable{
+//		"syslog": {gournal.InfoLevel: 5},
+//	}.Severity
+var SeverityMapper SeverityFunc
+
+// SeverityTable maps the names of backends to the severities of levels.
+type SeverityTable map[string]map[Level]interface{}
+
+// Severity returns the severity of the level in the backend, if the table
+// has one. It may be used as the SeverityMapper.
+func (t SeverityTable) Severity(
+	backend string, lvl Level) (interface{}, bool) {
+
+	v, ok := t[backend][lvl]
+	return v, ok
+}
+
+// MapSeverityInt returns the severity of the level in the backend returned
+// by SeverityMapper, if it is an int.
+func MapSeverityInt(backend string, lvl Level) (int, bool) {
+	if SeverityMapper == nil {
+		return 0, false
+	}
+	v, ok := SeverityMapper(backend, lvl)
+	if !ok {
+		return 0, false
+	}
+	n, ok := v.(int)
+	return n, ok
+}
+
+// MapSeverityString returns the severity of the level in the backend
+// returned by SeverityMapper, if it is a string.
+func MapSeverityString(backend string, lvl Level) (string, bool) {
+	if SeverityMapper == nil {
+		return "", false
+	}
+	v, ok := SeverityMapper(backend, lvl)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
@@ -0,0 +1,68 @@
+package gournal
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// fieldFrame is a single layer of diagnostic fields pushed onto a Context's
+// field stack. Frames are immutable once pushed except for their popped
+// flag, so a Context derived from PushFields may be shared by multiple
+// goroutines.
+type fieldFrame struct {
+	parent *fieldFrame
+	fields map[string]interface{}
+	popped int32
+}
+
+// PushFields layers the provided fields on top of any fields previously
+// pushed onto the Context, similar to SLF4J's mapped diagnostic context. The
+// full stack of fields is merged into every entry emitted with the returned
+// Context. Fields pushed later override those pushed earlier, and fields
+// provided directly to a log function or via the FieldsKey override both.
+//
+// The returned function pops the fields from the stack and is intended to
+// be deferred:
+//
+//	ctx, pop := gournal.PushFields(ctx, map[string]interface{}{
+//	    "volume": volumeID,
+//	})
+//	defer pop()
+func PushFields(
+	ctx context.Context,
+	fields map[string]interface{}) (context.Context, func()) {
+
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+
+	parent, _ := ctx.Value(stackKey).(*fieldFrame)
+	f := &fieldFrame{parent: parent, fields: fields}
+	return context.WithValue(ctx, stackKey, f), f.pop
+}
+
+func (f *fieldFrame) pop() {
+	atomic.StoreInt32(&f.popped, 1)
+}
+
+func (f *fieldFrame) active() bool {
+	return atomic.LoadInt32(&f.popped) == 0 && len(f.fields) > 0
+}
+
+// inspectStackFields adds the fields of the context's active stack frames
+// to the provided set from the bottom of the stack to the top.
+func inspectStackFields(ctx context.Context, fields *fieldSet) {
+	if top, ok := ctx.Value(stackKey).(*fieldFrame); ok {
+		mergeFrames(top, fields)
+	}
+}
+
+func mergeFrames(f *fieldFrame, fields *fieldSet) {
+	if f == nil {
+		return
+	}
+	mergeFrames(f.parent, fields)
+	if f.active() {
+		fields.addMap(f.fields)
+	}
+}
@@ -0,0 +1,62 @@
+package gournal
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// ErrorHandler is invoked by HandleError when an entry cannot be delivered.
+// The default ErrorHandler writes the error to os.Stderr.
+var ErrorHandler = func(err error) {
+	fmt.Fprintf(os.Stderr, "GOURNAL: error: %v\n", err)
+}
+
+// The following counters record logging activity. They are read with
+// EntryCount, AppendErrorCount, and DroppedCount, and may be published via
+// expvar by importing the github.com/akutz/gournal/expvar package.
+var (
+	statsEntries      [levelCount]int64
+	statsAppendErrors int64
+	statsDropped      int64
+)
+
+// HandleError records that an Appender failed to deliver an entry and
+// invokes the ErrorHandler with the provided error. Appenders that are unable
+// to return errors to the caller should report them with this function.
+func HandleError(err error) {
+	atomic.AddInt64(&statsAppendErrors, 1)
+	if h := ErrorHandler; h != nil {
+		h(err)
+	}
+}
+
+// RecordDropped records that the provided number of entries were dropped,
+// for example by an Appender whose queue is full.
+func RecordDropped(n int) {
+	atomic.AddInt64(&statsDropped, int64(n))
+}
+
+// EntryCount returns the number of entries emitted at the provided level.
+func EntryCount(lvl Level) int64 {
+	if lvl >= levelCount {
+		return 0
+	}
+	return atomic.LoadInt64(&statsEntries[lvl])
+}
+
+// AppendErrorCount returns the number of errors reported with HandleError.
+func AppendErrorCount() int64 {
+	return atomic.LoadInt64(&statsAppendErrors)
+}
+
+// DroppedCount returns the number of entries recorded with RecordDropped.
+func DroppedCount() int64 {
+	return atomic.LoadInt64(&statsDropped)
+}
+
+func recordEntry(lvl Level) {
+	if lvl > UnknownLevel && lvl < levelCount {
+		atomic.AddInt64(&statsEntries[lvl], 1)
+	}
+}
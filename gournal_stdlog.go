@@ -0,0 +1,40 @@
+package gournal
+
+import (
+	"context"
+	"log"
+	"regexp"
+)
+
+// stdLogPrefixRX matches the date and time prefixes the standard library's
+// log package emits when the log.Ldate, log.Ltime, or log.Lmicroseconds flags
+// are set.
+var stdLogPrefixRX = regexp.MustCompile(
+	`^(\d{4}/\d{2}/\d{2} )?(\d{2}:\d{2}:\d{2}(\.\d{6})? )?`)
+
+// StdLogger returns a *log.Logger that forwards everything written to it
+// through Gournal at the provided level using the given Context's appender
+// and fields. This is useful for third-party libraries that only accept a
+// *log.Logger, such as http.Server's ErrorLog.
+//
+// Any date or time prefixes added by the returned logger's flags are
+// stripped from the message since the Appender is responsible for recording
+// when an entry occurred.
+func StdLogger(ctx context.Context, lvl Level) *log.Logger {
+	return log.New(&stdLogWriter{ctx: ctx, lvl: lvl}, "", 0)
+}
+
+type stdLogWriter struct {
+	ctx context.Context
+	lvl Level
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	msg := p
+	if n := len(msg); n > 0 && msg[n-1] == '\n' {
+		msg = msg[:n-1]
+	}
+	msg = stdLogPrefixRX.ReplaceAll(msg, nil)
+	sendToAppender(w.ctx, w.lvl, fieldSet{}, string(msg))
+	return len(p), nil
+}
@@ -0,0 +1,145 @@
+package gournal
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RedactedValue is the value of struct fields tagged with "redact" that are
+// added to an Entry with WithStruct.
+var RedactedValue = "[REDACTED]"
+
+// maxStructDepth is the depth of nested structs beyond which WithStruct
+// adds structs as values instead of adding their fields.
+const maxStructDepth = 8
+
+// WithStruct adds the exported fields of a struct, or of the struct to which
+// a pointer refers, to the Entry, so values such as request and response
+// DTOs are logged consistently without building maps at every call site:
+//
+//	type Request struct {
+//		User     User   `log:"user"`
+//		Password string `log:"password,redact"`
+//		Note     string `log:"note,omitempty"`
+//		Internal string `log:"-"`
+//	}
+//
+// Fields are named by their "log" tags, or their "json" tags if they do not
+// have a "log" tag, otherwise by their names. Fields tagged with "-" are
+// omitted, fields tagged with "omitempty" are omitted if they have their
+// zero value or are empty, and the values of fields tagged with "redact"
+// are replaced with RedactedValue.
+//
+// The fields of nested structs are added with their names prefixed by the
+// name of the field that contains them and a period, ex. "user.id", and
+// the fields of embedded structs without a tag are added as if they were
+// fields of the outer struct. Structs that implement fmt.Stringer, error,
+// json.Marshaler, or encoding.TextMarshaler, such as time.Time, are added
+// as values.
+func WithStruct(v interface{}) Entry {
+	e := &entry{}
+	addStructFields(&e.fields, reflect.ValueOf(v), "", 0)
+	return e
+}
+
+func (e *entry) WithStruct(v interface{}) Entry {
+	addStructFields(&e.fields, reflect.ValueOf(v), "", 0)
+	return e
+}
+
+// addStructFields adds the fields of the struct, or the struct pointed to
+// by v, to the field set with their names prefixed by the prefix.
+func addStructFields(
+	fields *fieldSet, v reflect.Value, prefix string, depth int) {
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("log")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		key, opts := tag, ""
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			key, opts = tag[:j], ","+tag[j+1:]+","
+		}
+
+		fv := v.Field(i)
+		if sf.Anonymous && key == "" {
+			addStructFields(fields, fv, prefix, depth)
+			continue
+		}
+		if sf.PkgPath != "" || !fv.CanInterface() {
+			continue
+		}
+		if key == "" {
+			key = sf.Name
+		}
+		key = prefix + key
+
+		if strings.Contains(opts, ",omitempty,") && isZero(fv) {
+			continue
+		}
+		if strings.Contains(opts, ",redact,") {
+			fields.addField(key, RedactedValue)
+			continue
+		}
+		if depth < maxStructDepth && isNestedStruct(fv) {
+			addStructFields(fields, fv, key+".", depth+1)
+			continue
+		}
+		fields.addField(key, fv.Interface())
+	}
+}
+
+// isNestedStruct returns true if the value is a struct, or a non-nil
+// pointer to a struct, whose fields are added instead of the value itself.
+func isNestedStruct(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	switch v.Interface().(type) {
+	case fmt.Stringer, error, json.Marshaler, encoding.TextMarshaler:
+		return false
+	}
+	if v.CanAddr() {
+		switch v.Addr().Interface().(type) {
+		case fmt.Stringer, error, json.Marshaler, encoding.TextMarshaler:
+			return false
+		}
+	}
+	return true
+}
+
+// isZero returns true if the value is the zero value of its type, or an
+// empty array, map, slice, or string.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return reflect.DeepEqual(
+		v.Interface(), reflect.Zero(v.Type()).Interface())
+}
@@ -0,0 +1,59 @@
+package gournal
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// subscribers is a []*subscriber that is replaced, never modified, when a
+// subscriber is added or removed so it may be read without locking
+var (
+	subscribers   atomic.Value
+	subscribersMu sync.Mutex
+)
+
+type subscriber struct {
+	fn func(Record)
+}
+
+// Subscribe registers a function that is invoked with every entry emitted
+// by Gournal, regardless of the entry's Appender, before the entry is sent
+// to its Appender. This enables live log viewers, triggers, and test
+// instrumentation. The returned function removes the subscription.
+//
+// The function is invoked synchronously by the goroutine that emits the
+// entry, so it should return quickly. The Record's Fields must not be
+// modified, nor retained after the function returns.
+func Subscribe(fn func(Record)) func() {
+	s := &subscriber{fn}
+
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	old, _ := subscribers.Load().([]*subscriber)
+	subs := make([]*subscriber, len(old), len(old)+1)
+	copy(subs, old)
+	subscribers.Store(append(subs, s))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { unsubscribe(s) })
+	}
+}
+
+func unsubscribe(s *subscriber) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	old, _ := subscribers.Load().([]*subscriber)
+	subs := make([]*subscriber, 0, len(old))
+	for _, v := range old {
+		if v != s {
+			subs = append(subs, v)
+		}
+	}
+	subscribers.Store(subs)
+}
+
+func getSubscribers() []*subscriber {
+	subs, _ := subscribers.Load().([]*subscriber)
+	return subs
+}
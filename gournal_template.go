@@ -0,0 +1,75 @@
+package gournal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs are the functions available to the templates of
+// TemplateFormatters in addition to the text/template builtins:
+//
+//	pad n v    formats v with fmt.Sprint and pads it with spaces to a
+//	           width of n, on the right if n is positive and on the left
+//	           if it is negative, ex. {{.Level | pad 5}}
+//	upper v    formats v with fmt.Sprint in upper case
+//	lower v    formats v with fmt.Sprint in lower case
+//	json v     marshals v to JSON, or formats it with fmt.Sprint if it
+//	           cannot be marshaled
+var TemplateFuncs = template.FuncMap{
+	"pad":   templatePad,
+	"upper": func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+	"lower": func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+	"json":  templateJSON,
+}
+
+// TemplateFormatter formats entries by executing a text/template with the
+// entry's Record, so the template may refer to .Time, .Level, .Message, and
+// .Fields. A newline is appended if the template's output does not end
+// with one.
+type TemplateFormatter struct {
+	t *template.Template
+}
+
+// NewTemplateFormatter returns a TemplateFormatter that executes the
+// provided text/template, which may call TemplateFuncs, ex.:
+//
+//	{{.Time.Format "15:04:05"}} {{.Level | pad 5}} {{.Message}}
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	t, err := template.New("gournal").Funcs(TemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFormatter{t: t}, nil
+}
+
+// Format writes the Record to the buffer.
+func (f *TemplateFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	n := buf.Len()
+	if err := f.t.Execute(buf, rec); err != nil {
+		buf.Truncate(n)
+		return err
+	}
+	if buf.Len() == n || buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+func templatePad(n int, v interface{}) string {
+	s := fmt.Sprint(v)
+	if n < 0 {
+		return fmt.Sprintf("%*s", -n, s)
+	}
+	return fmt.Sprintf("%-*s", n, s)
+}
+
+func templateJSON(v interface{}) string {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(buf)
+}
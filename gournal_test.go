@@ -1,15 +1,27 @@
 package gournal
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -460,3 +472,1428 @@ func newTestContext() (*bytes.Buffer, context.Context) {
 	a := NewAppenderWithOptions(w)
 	return w, context.WithValue(context.Background(), AppenderKey(), a)
 }
+
+func TestLevelEntry(t *testing.T) {
+	buf, ctx := newTestContext()
+
+	Log(ctx, WarnLevel, "Hello %s", "Bob")
+	assert.Equal(t, "[WARN] Hello Bob\n", buf.String())
+	buf.Reset()
+
+	WithField("size", 1).(LevelEntry).Log(ctx, ErrorLevel, "Hello Mary")
+	assert.Equal(t, "[ERROR] Hello Mary map[size:1]\n", buf.String())
+}
+
+func TestPushFields(t *testing.T) {
+	buf, ctx := newTestContext()
+
+	ctx1, pop1 := PushFields(ctx, map[string]interface{}{
+		"planet": "Venus",
+		"galaxy": "Milky Way",
+	})
+	ctx2, pop2 := PushFields(ctx1, map[string]interface{}{"planet": "Mars"})
+
+	Info(ctx2, "Discovered planet")
+	assert.Equal(
+		t,
+		"[INFO] Discovered planet map[galaxy:Milky Way planet:Mars]\n",
+		buf.String())
+	buf.Reset()
+
+	WithField("planet", "Earth").Info(ctx2, "Discovered planet")
+	assert.Equal(
+		t,
+		"[INFO] Discovered planet map[galaxy:Milky Way planet:Earth]\n",
+		buf.String())
+	buf.Reset()
+
+	pop2()
+	Info(ctx2, "Discovered planet")
+	assert.Equal(
+		t,
+		"[INFO] Discovered planet map[galaxy:Milky Way planet:Venus]\n",
+		buf.String())
+	buf.Reset()
+
+	pop1()
+	Info(ctx2, "Discovered planet")
+	assert.Equal(t, "[INFO] Discovered planet\n", buf.String())
+}
+
+func TestStdLogger(t *testing.T) {
+	buf, ctx := newTestContext()
+
+	l := StdLogger(ctx, WarnLevel)
+	l.Printf("Hello %s", "Bob")
+	assert.Equal(t, "[WARN] Hello Bob\n", buf.String())
+	buf.Reset()
+
+	l.SetFlags(log.LstdFlags | log.Lmicroseconds)
+	l.Print("100% done")
+	assert.Equal(t, "[WARN] 100% done\n", buf.String())
+	buf.Reset()
+
+	ctx = context.WithValue(ctx, LevelKey(), ErrorLevel)
+	StdLogger(ctx, InfoLevel).Print("Hello Alice")
+	assert.Zero(t, buf.Len())
+}
+
+func TestWriter(t *testing.T) {
+	buf, ctx := newTestContext()
+
+	w := Writer(ctx, InfoLevel)
+	fmt.Fprint(w, "Hello Bob\nHello ")
+	assert.Equal(t, "[INFO] Hello Bob\n", buf.String())
+	buf.Reset()
+
+	fmt.Fprint(w, "Alice\r\nHello Mary")
+	assert.Equal(t, "[INFO] Hello Alice\n", buf.String())
+	buf.Reset()
+
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "[INFO] Hello Mary\n", buf.String())
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "Hello Bob")
+		}))
+	defer srv.Close()
+
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), DebugLevel)
+	ctx = TrackRetries(ctx)
+	client := &http.Client{Transport: Transport(nil)}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		res, err := client.Do(req.WithContext(ctx))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "Hello Bob", string(body))
+		assert.Contains(t, buf.String(), "[INFO] outbound request")
+		assert.Contains(t, buf.String(), "body:Hello Bob")
+		assert.Contains(t, buf.String(), fmt.Sprintf("retries:%d", i))
+		assert.Contains(t, buf.String(), "status:200")
+		buf.Reset()
+	}
+}
+
+func TestTransportStreaming(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "Hello ")
+			w.(http.Flusher).Flush()
+			<-release
+			io.WriteString(w, "Bob")
+		}))
+	defer srv.Close()
+	defer close(release)
+
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), DebugLevel)
+	client := &http.Client{Transport: Transport(nil)}
+
+	// the response is returned before the body is complete, and the entry
+	// is appended once the body is closed
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	res, err := client.Do(req.WithContext(ctx))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	p := make([]byte, 6)
+	_, err = io.ReadFull(res.Body, p)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello ", string(p))
+	assert.Equal(t, "", buf.String())
+
+	res.Body.Close()
+	assert.Contains(t, buf.String(), "[INFO] outbound request")
+	assert.Contains(t, buf.String(), "body:Hello ")
+}
+
+func TestTransportSwitchingProtocols(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, brw, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\n"+
+				"Connection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+			line, _ := brw.ReadString('\n')
+			io.WriteString(conn, line)
+		}))
+	defer srv.Close()
+
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), DebugLevel)
+	client := &http.Client{Transport: Transport(nil)}
+
+	// the body of the upgraded connection remains writable
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+	res, err := client.Do(req.WithContext(ctx))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer res.Body.Close()
+	assert.Contains(t, buf.String(), "[INFO] outbound request")
+	assert.Contains(t, buf.String(), "status:101")
+
+	rw, ok := res.Body.(io.ReadWriteCloser)
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	io.WriteString(rw, "Hello Bob\n")
+	line, err := bufio.NewReader(rw).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello Bob\n", line)
+}
+
+func TestTransportRedactsURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+	client := &http.Client{Transport: Transport(nil)}
+	u, _ := url.Parse(srv.URL)
+	u.User = url.UserPassword("bob", "secret")
+	u.Path = "/planets"
+	u.RawQuery = "key=secret"
+
+	req, _ := http.NewRequest("GET", u.String(), nil)
+	res, err := client.Do(req.WithContext(ctx))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	res.Body.Close()
+	assert.Contains(t, buf.String(), "url:"+srv.URL+"/planets]")
+	assert.NotContains(t, buf.String(), "secret")
+	assert.NotContains(t, buf.String(), "bob")
+	buf.Reset()
+
+	TransportLogQuery = true
+	defer func() { TransportLogQuery = false }()
+	res, err = client.Do(req.WithContext(ctx))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	res.Body.Close()
+	assert.Contains(t, buf.String(), "url:"+srv.URL+"/planets?key=secret]")
+	assert.NotContains(t, buf.String(), "bob")
+}
+
+func TestRecover(t *testing.T) {
+	buf, ctx := newTestContext()
+
+	func() {
+		defer Recover(ctx)
+		panic("Hello Bob")
+	}()
+	assert.Contains(t, buf.String(), "[ERROR] recovered from panic")
+	assert.Contains(t, buf.String(), "panic:Hello Bob")
+	assert.Contains(t, buf.String(), "stack:goroutine")
+	buf.Reset()
+
+	func() {
+		defer RecoverWith(ctx, map[string]interface{}{"size": 2})
+		panic("Hello Alice")
+	}()
+	assert.Contains(t, buf.String(), "panic:Hello Alice")
+	assert.Contains(t, buf.String(), "size:2")
+	buf.Reset()
+
+	RepanicOnRecover = true
+	defer func() { RepanicOnRecover = false }()
+	func() {
+		defer func() {
+			assert.Equal(t, "Hello Mary", recover())
+		}()
+		defer Recover(ctx)
+		panic("Hello Mary")
+	}()
+	assert.Contains(t, buf.String(), "panic:Hello Mary")
+}
+
+func TestWithRequestID(t *testing.T) {
+	buf, ctx := newTestContext()
+	assert.Equal(t, "", RequestIDFrom(ctx))
+
+	ctx1 := WithRequestID(ctx, "1234")
+	assert.Equal(t, "1234", RequestIDFrom(ctx1))
+	Info(ctx1, "Hello Bob")
+	assert.Equal(t, "[INFO] Hello Bob map[requestID:1234]\n", buf.String())
+
+	ctx2 := WithRequestID(ctx, "")
+	assert.Len(t, RequestIDFrom(ctx2), 32)
+}
+
+func TestStats(t *testing.T) {
+	_, ctx := newTestContext()
+	warns := EntryCount(WarnLevel)
+	Warn(ctx, "Hello Bob")
+	assert.Equal(t, warns+1, EntryCount(WarnLevel))
+	assert.Zero(t, EntryCount(levelCount))
+
+	var handled error
+	defer func(h func(error)) { ErrorHandler = h }(ErrorHandler)
+	ErrorHandler = func(err error) { handled = err }
+	errs := AppendErrorCount()
+	HandleError(errors.New("failed"))
+	assert.EqualError(t, handled, "failed")
+	assert.Equal(
+		t, errs+1, AppendErrorCount())
+
+	dropped := DroppedCount()
+	RecordDropped(2)
+	assert.Equal(
+		t, dropped+2, DroppedCount())
+}
+
+func TestNamedLevel(t *testing.T) {
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, NameKey(), "db")
+	defer SetNamedLevel("db", UnknownLevel)
+
+	SetNamedLevel("db", WarnLevel)
+	Info(ctx, "Hello Bob")
+	assert.Zero(t, buf.Len())
+	Warn(ctx, "Hello Bob")
+	assert.Equal(t, "[WARN] db: Hello Bob\n", buf.String())
+	buf.Reset()
+
+	Info(context.WithValue(ctx, LevelKey(), InfoLevel), "Hello Alice")
+	assert.Equal(t, "[INFO] db: Hello Alice\n", buf.String())
+}
+
+func TestLevelHandler(t *testing.T) {
+	defer SetDefaultLevel(UnknownLevel)
+	defer SetNamedLevel("db", UnknownLevel)
+	defer SetNamedLevel("http", UnknownLevel)
+
+	h := LevelHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"DEBUG"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("PUT", "/", strings.NewReader(
+		`{"loggers":{"db":"info","http":"warn"}}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t,
+		`{"level":"DEBUG","loggers":{"db":"INFO","http":"WARN"}}`,
+		rec.Body.String())
+	lvl, ok := NamedLevel("db")
+	assert.True(t, ok)
+	assert.Equal(t, InfoLevel, lvl)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("PUT", "/", strings.NewReader(
+		`{"loggers":{"http":"unknown"}}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t,
+		`{"level":"DEBUG","loggers":{"db":"INFO"}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(
+		"PUT", "/", strings.NewReader(`{"level":"warn"}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t,
+		`{"level":"WARN","loggers":{"db":"INFO"}}`, rec.Body.String())
+	assert.Equal(t, WarnLevel, CurrentDefaultLevel())
+	assert.Equal(t, DebugLevel, DefaultLevel)
+
+	buf := &bytes.Buffer{}
+	ctx := context.WithValue(
+		context.Background(), AppenderKey(), NewAppenderWithOptions(buf))
+	Info(ctx, "Hello Bob")
+	Warn(ctx, "Hello Alice")
+	assert.Equal(t, "[WARN] Hello Alice\n", buf.String())
+
+	// an invalid level does not remove the logger's level, and no levels
+	// are changed
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("PUT", "/", strings.NewReader(
+		`{"loggers":{"db":"bogus","http":"warn"}}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	lvl, ok = NamedLevel("db")
+	assert.True(t, ok)
+	assert.Equal(t, InfoLevel, lvl)
+	_, ok = NamedLevel("http")
+	assert.False(t, ok)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("PUT", "/", strings.NewReader(
+		`{"level":"bogus","loggers":{"http":"warn"}}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, WarnLevel, CurrentDefaultLevel())
+	_, ok = NamedLevel("http")
+	assert.False(t, ok)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestNoFieldsZeroAllocs(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, AppenderKey(), Discard)
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		Info(ctx, "Run Barry, run.")
+	}))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		Debug(ctx, "Run %s, run.", "Barry")
+	}))
+
+	// a format string with args performs a single format
+	assert.Equal(t, 1.0, testing.AllocsPerRun(100, func() {
+		Info(ctx, "Run %s, run.", "Barry")
+	}))
+}
+
+type mutatingAppender struct {
+	records []Record
+}
+
+func (a *mutatingAppender) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+	a.records = append(a.records, Record{Fields: fields})
+	fields["size"] = 2
+	delete(fields, "color")
+}
+
+func TestFieldsCopied(t *testing.T) {
+	a := &mutatingAppender{}
+	ctx := context.WithValue(context.Background(), AppenderKey(), a)
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+
+	var subscribed []map[string]interface{}
+	unsub := Subscribe(func(rec Record) {
+		subscribed = append(subscribed, rec.Fields)
+		rec.Fields["subscribed"] = true
+	})
+	defer unsub()
+
+	// the context's fields and a single frame of stack fields are copied
+	// before they are handed to subscribers and Appenders
+	ctxFields := map[string]interface{}{"size": 1, "color": "red"}
+	fctx := context.WithValue(ctx, FieldsKey(), ctxFields)
+	Info(fctx, "Run Barry, run.")
+	Info(fctx, "Run Barry, run.")
+
+	stackFields := map[string]interface{}{"size": 1, "color": "red"}
+	sctx, pop := PushFields(ctx, stackFields)
+	defer pop()
+	Info(sctx, "Run Barry, run.")
+
+	want := map[string]interface{}{"size": 1, "color": "red"}
+	assert.Equal(t, want, ctxFields)
+	assert.Equal(t, want, stackFields)
+	if !assert.Len(t, subscribed, 3) || !assert.Len(t, a.records, 3) {
+		t.FailNow()
+	}
+	for i := range a.records {
+		assert.Equal(t, map[string]interface{}{"size": 2},
+			a.records[i].Fields)
+		assert.Equal(t, map[string]interface{}{
+			"size": 1, "color": "red", "subscribed": true}, subscribed[i])
+	}
+}
+
+func TestFormatMessage(t *testing.T) {
+	assert.Equal(t, "Hello Bob", formatMessage("", []interface{}{"Hello Bob"}))
+	assert.Equal(
+		t, "Hello Bob", formatMessage("%s", []interface{}{"Hello Bob"}))
+	assert.Equal(t, "Hello 2", formatMessage("", []interface{}{"Hello ", 2}))
+	assert.Equal(t, "2", formatMessage("%s", []interface{}{myString("2")}))
+	assert.Equal(t, "Hello Bob", formatMessage("Hello %s", []interface{}{"Bob"}))
+
+	s := "Run Barry, run."
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		formatMessage("%s", []interface{}{s})
+	}))
+}
+
+type myString string
+
+func TestLevelCheckPrecedesArgInspection(t *testing.T) {
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+
+	invoked := false
+	ctx = context.WithValue(ctx, FieldsKey(), func() map[string]interface{} {
+		invoked = true
+		return nil
+	})
+
+	Debug(ctx, "%v", testFormatter(func() { invoked = true }))
+	assert.False(t, invoked)
+	assert.Zero(t, buf.Len())
+}
+
+type testFormatter func()
+
+func (f testFormatter) Format(s fmt.State, c rune) {
+	f()
+}
+
+func TestAppenderConcurrentWrites(t *testing.T) {
+	w := &countingWriter{}
+	ctx := context.WithValue(
+		context.Background(), AppenderKey(), NewAppenderWithOptions(w))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				WithField("size", j).Info(ctx, "Run Barry, run.")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every entry must be emitted with a single, complete write
+	assert.Equal(t, 1000, w.writes)
+	assert.Equal(t, 1000, strings.Count(w.buf.String(), "\n"))
+}
+
+type countingWriter struct {
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.buf.Write(p)
+}
+
+type fieldsAppender struct {
+	fields []Field
+}
+
+func (a *fieldsAppender) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+	panic("Append called on a FieldAppender")
+}
+
+func (a *fieldsAppender) AppendFields(
+	ctx context.Context,
+	lvl Level,
+	fields []Field,
+	msg string) {
+	a.fields = append([]Field(nil), fields...)
+}
+
+func TestFieldAppender(t *testing.T) {
+	a := &fieldsAppender{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, AppenderKey(), a)
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+	ctx, pop := PushFields(ctx, map[string]interface{}{"b": 1, "a": 1})
+	defer pop()
+	ctx = context.WithValue(
+		ctx, FieldsKey(), map[string]interface{}{"c": 3, "a": 3})
+
+	WithField("d", 2).WithField("b", 2).Info(ctx, "Hello")
+	assert.Equal(t, []Field{
+		{"a", 3}, {"b", 2}, {"d", 2}, {"c", 3},
+	}, a.fields)
+
+	Info(ctx, "Hello")
+	assert.Equal(t, []Field{
+		{"a", 3}, {"b", 1}, {"c", 3},
+	}, a.fields)
+}
+
+func TestFieldAppenderOutputMatchesAppend(t *testing.T) {
+	fields := map[string]interface{}{"size": 2, "color": "red", "ok": nil}
+
+	buf1 := &bytes.Buffer{}
+	NewAppenderWithOptions(buf1).Append(
+		context.Background(), InfoLevel, fields, "Hello")
+
+	buf2 := &bytes.Buffer{}
+	NewAppenderWithOptions(buf2).(FieldAppender).AppendFields(
+		context.Background(),
+		InfoLevel,
+		[]Field{{"size", 2}, {"ok", nil}, {"color", "red"}},
+		"Hello")
+
+	assert.Equal(t, buf1.String(), buf2.String())
+}
+
+func TestFromContext(t *testing.T) {
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+	ctx = context.WithValue(
+		ctx, FieldsKey(), map[string]interface{}{"size": 1})
+	ctx, pop1 := PushFields(ctx, map[string]interface{}{"color": "red"})
+	defer pop1()
+	ctx, pop2 := PushFields(ctx, map[string]interface{}{"shape": "round"})
+	defer pop2()
+
+	l := FromContext(ctx)
+	l.Debug("Hello %s", "Bob")
+	assert.Zero(t, buf.Len())
+	l.Info("Hello %s", "Bob")
+	assert.Equal(t,
+		"[INFO] Hello Bob map[color:red shape:round size:1]\n",
+		buf.String())
+
+	// the bound logger's fields are not modified by emitting entries
+	buf.Reset()
+	l.Warn("Goodbye")
+	assert.Equal(t,
+		"[WARN] Goodbye map[color:red shape:round size:1]\n",
+		buf.String())
+}
+
+func TestFromContextNamedLevel(t *testing.T) {
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, NameKey(), "TestFromContextNamedLevel")
+	defer SetNamedLevel("TestFromContextNamedLevel", UnknownLevel)
+
+	l := FromContext(ctx)
+	SetNamedLevel("TestFromContextNamedLevel", DebugLevel)
+	l.Debug("Hello")
+	assert.Equal(
+		t, "[DEBUG] TestFromContextNamedLevel: Hello\n", buf.String())
+}
+
+func TestFromContextZeroAllocs(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, AppenderKey(), Discard)
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+
+	l := FromContext(ctx)
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		l.Info("Run Barry, run.")
+	}))
+}
+
+type batchRecorder struct {
+	sync.Mutex
+	batches [][]Record
+}
+
+func (r *batchRecorder) AppendBatch(records []Record) {
+	r.Lock()
+	defer r.Unlock()
+	r.batches = append(r.batches, append([]Record(nil), records...))
+}
+
+func (r *batchRecorder) len() int {
+	r.Lock()
+	defer r.Unlock()
+	return len(r.batches)
+}
+
+func TestBatcher(t *testing.T) {
+	r := &batchRecorder{}
+	b := NewBatcher(r, 2, 0)
+	ctx := context.WithValue(context.Background(), AppenderKey(), b)
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+
+	fields := map[string]interface{}{"size": 1}
+	WithFields(fields).Info(ctx, "one")
+	fields["size"] = 2
+	assert.Equal(t, 0, r.len())
+	Info(ctx, "two")
+	Info(ctx, "three")
+	assert.Equal(t, 1, r.len())
+
+	assert.NoError(t, b.Close())
+	if !assert.Equal(t, 2, r.len()) {
+		t.FailNow()
+	}
+	assert.Len(t, r.batches[0], 2)
+	assert.Equal(t, "one", r.batches[0][0].Message)
+	assert.Equal(t, 1, r.batches[0][0].Fields["size"])
+	assert.Equal(t, InfoLevel, r.batches[0][1].Level)
+	assert.Equal(t, "three", r.batches[1][0].Message)
+}
+
+func TestBatcherNameAndCaller(t *testing.T) {
+	defer func() { CaptureCaller = false }()
+	CaptureCaller = true
+
+	r := &batchRecorder{}
+	b := NewBatcher(r, 1, 0)
+	ctx := context.WithValue(context.Background(), AppenderKey(), b)
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+	ctx = context.WithValue(ctx, NameKey(), "db")
+
+	Info(ctx, "Hello Bob")
+	assert.NoError(t, b.Close())
+	if !assert.Equal(t, 1, r.len()) {
+		t.FailNow()
+	}
+	rec := r.batches[0][0]
+	assert.Equal(t, "db", rec.Name)
+	if assert.NotNil(t, rec.Caller) {
+		assert.Equal(t, "gournal_test.go", filepath.Base(rec.Caller.File))
+	}
+}
+
+func TestBatcherInterval(t *testing.T) {
+	r := &batchRecorder{}
+	b := NewBatcher(r, 100, time.Millisecond)
+	defer b.Close()
+	ctx := context.WithValue(context.Background(), AppenderKey(), b)
+
+	Warn(ctx, "one")
+	for i := 0; i < 1000 && r.len() == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 1, r.len())
+}
+
+type blockingAppender struct {
+	release chan struct{}
+	sync.Mutex
+	msgs []string
+}
+
+func (a *blockingAppender) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+	<-a.release
+	a.Lock()
+	a.msgs = append(a.msgs, msg)
+	a.Unlock()
+}
+
+func TestAsyncAppender(t *testing.T) {
+	next := &blockingAppender{release: make(chan struct{})}
+	a := NewAsyncAppender(next, 1, 1)
+	ctx := context.WithValue(context.Background(), AppenderKey(), a)
+
+	dropped := DroppedCount()
+
+	// the worker blocks on the first entry, the second fills the queue, and
+	// the third is dropped
+	Warn(ctx, "one")
+	for i := 0; i < 1000 && len(a.queue) > 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	Warn(ctx, "two")
+	Warn(ctx, "three")
+	assert.Equal(
+		t, dropped+1, DroppedCount())
+
+	close(next.release)
+	a.Flush()
+	assert.Equal(t, []string{"one", "two"}, next.msgs)
+
+	assert.NoError(t, a.Close())
+	Warn(ctx, "four")
+	assert.Equal(t, []string{"one", "two", "four"}, next.msgs)
+}
+
+type timeRecorder struct {
+	release chan struct{}
+	sync.Mutex
+	times []time.Time
+}
+
+func (r *timeRecorder) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+	<-r.release
+	r.Lock()
+	r.times = append(r.times, TimeFrom(ctx))
+	r.Unlock()
+}
+
+func TestAsyncAppenderTime(t *testing.T) {
+	defer func(c func() time.Time) { Clock = c }(Clock)
+	var clockMu sync.Mutex
+	now := time.Unix(0, 0).UTC()
+	Clock = func() time.Time {
+		clockMu.Lock()
+		defer clockMu.Unlock()
+		return now
+	}
+
+	// the worker is blocked until after the clock moves
+	r := &timeRecorder{release: make(chan struct{})}
+	a := NewAsyncAppender(r, 10, 1)
+	defer a.Close()
+	ctx := context.WithValue(context.Background(), AppenderKey(), a)
+
+	Warn(ctx, "one")
+	clockMu.Lock()
+	now = now.Add(time.Hour)
+	clockMu.Unlock()
+	close(r.release)
+	a.Flush()
+
+	assert.Equal(t, []time.Time{time.Unix(0, 0).UTC()}, r.times)
+}
+
+func TestAsyncAppenderPanicIsSynchronous(t *testing.T) {
+	next := &blockingAppender{release: make(chan struct{})}
+	close(next.release)
+	a := NewAsyncAppender(next, 10, 2)
+	defer a.Close()
+	ctx := context.WithValue(context.Background(), AppenderKey(), a)
+
+	Warn(ctx, "one")
+	Panic(ctx, "two")
+	next.Lock()
+	defer next.Unlock()
+	assert.Equal(t, []string{"one", "two"}, next.msgs)
+}
+
+func TestNilDefaultAppender(t *testing.T) {
+	defer func(a Appender) { DefaultAppender = a }(DefaultAppender)
+	DefaultAppender = nil
+
+	ctx := context.WithValue(context.Background(), LevelKey(), DebugLevel)
+	Info(ctx, "Hello")
+	Panic(ctx, "Hello")
+	WithField("size", 1).Fatal(ctx, "Hello")
+	FromContext(ctx).Panic("Hello")
+	assert.Equal(t, Discard, getAppender(ctx))
+	assert.Equal(t, Discard, getAppender(nil))
+
+	ctx = context.WithValue(ctx, AppenderKey(), nil)
+	assert.Equal(t, Discard, getAppender(ctx))
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestTryAppend(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, TryAppend(
+		NewAppenderWithOptions(buf), nil, InfoLevel, nil, "Hello"))
+	assert.Equal(t, "[INFO] Hello\n", buf.String())
+
+	assert.EqualError(t, TryAppend(
+		NewAppenderWithOptions(errWriter{}), nil, InfoLevel, nil, "Hello"),
+		"write failed")
+	assert.NoError(t, TryAppend(Discard, nil, InfoLevel, nil, "Hello"))
+}
+
+func TestSubscribe(t *testing.T) {
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+
+	var recs1, recs2 []Record
+	unsub1 := Subscribe(func(rec Record) { recs1 = append(recs1, rec) })
+	unsub2 := Subscribe(func(rec Record) { recs2 = append(recs2, rec) })
+
+	WithField("size", 1).Info(ctx, "Hello %s", "Bob")
+	Debug(ctx, "Hello Mary")
+	unsub1()
+	unsub1()
+	Warn(ctx, "Hello Alice")
+	unsub2()
+	Warn(ctx, "Hello Bill")
+
+	if assert.Len(t, recs1, 1) {
+		assert.Equal(t, InfoLevel, recs1[0].Level)
+		assert.Equal(t, "Hello Bob", recs1[0].Message)
+		assert.Equal(t, map[string]interface{}{"size": 1}, recs1[0].Fields)
+	}
+	if assert.Len(t, recs2, 2) {
+		assert.Equal(t, "Hello Alice", recs2[1].Message)
+	}
+	assert.Equal(t, 3, strings.Count(buf.String(), "\n"))
+}
+
+func TestAppenderWithFormatter(t *testing.T) {
+	fields := map[string]interface{}{"size": 2, "color": "red"}
+
+	buf1 := &bytes.Buffer{}
+	NewAppenderWithOptions(buf1).Append(nil, InfoLevel, fields, "Hello")
+	buf2 := &bytes.Buffer{}
+	NewAppenderWithFormatter(buf2, TextFormatter{}).Append(
+		nil, InfoLevel, fields, "Hello")
+	assert.Equal(t, buf1.String(), buf2.String())
+
+	defer func(c func() time.Time) { Clock = c }(Clock)
+	Clock = func() time.Time { return time.Unix(0, 0).UTC() }
+
+	buf := &bytes.Buffer{}
+	ctx := context.WithValue(context.Background(), AppenderKey(),
+		NewAppenderWithFormatter(buf, JSONFormatter{}))
+	WithField("ch", make(chan int)).WithField("size", 1).Error(ctx, "Hello")
+	assert.Regexp(t,
+		`^{"time":"1970-01-01T00:00:00Z","level":"ERROR","msg":"Hello",`+
+			`"fields":{"ch":"0x[0-9a-f]+","size":1}}\n$`,
+		buf.String())
+}
+
+func TestAppenderWithTimestamps(t *testing.T) {
+	when := time.Date(2017, 10, 1, 12, 0, 0, 500, time.UTC)
+	defer func() { Clock = time.Now }()
+	Clock = func() time.Time { return when }
+
+	buf := &bytes.Buffer{}
+	ctx := context.WithValue(context.Background(), LevelKey(), InfoLevel)
+	ctx = context.WithValue(
+		ctx, AppenderKey(), NewAppenderWithTimestamps(buf))
+	WithField("size", 1).Info(ctx, "Hello Bob")
+	ctx = WithTime(ctx, when.Add(time.Hour))
+	Info(ctx, "Hello Alice")
+
+	a := NewAppenderWithTimeLayout(buf, "15:04:05")
+	a.(FieldAppender).AppendFields(
+		nil, WarnLevel, []Field{{"size", 2}}, "Hello Mary")
+
+	assert.Equal(t,
+		"2017-10-01T12:00:00.0000005Z [INFO] Hello Bob map[size:1]\n"+
+			"2017-10-01T13:00:00.0000005Z [INFO] Hello Alice\n"+
+			"12:00:00 [WARN] Hello Mary map[size:2]\n",
+		buf.String())
+}
+
+func TestTimeLocation(t *testing.T) {
+	when := time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
+	defer func() {
+		Clock = time.Now
+		TimeLocation = nil
+	}()
+	Clock = func() time.Time { return when }
+	chicago := time.FixedZone("CDT", -5*60*60)
+
+	assert.Equal(t, time.UTC, TimeFrom(nil).Location())
+	TimeLocation = chicago
+	assert.Equal(t, "2017-10-01T07:00:00-05:00",
+		TimeFrom(nil).Format(time.RFC3339))
+	assert.Equal(t, "2017-10-01T08:00:00-05:00",
+		TimeFrom(WithTime(nil, when.Add(time.Hour))).Format(time.RFC3339))
+
+	buf := &bytes.Buffer{}
+	TimeLocation = time.UTC
+	Clock = func() time.Time { return when.In(chicago) }
+	ctx := context.WithValue(context.Background(), AppenderKey(),
+		NewAppenderWithTimeLayout(buf, time.RFC3339))
+	Error(ctx, "Hello Bob")
+	assert.Equal(t, "2017-10-01T12:00:00Z [ERROR] Hello Bob\n", buf.String())
+}
+
+type recordingAppender struct {
+	records []Record
+}
+
+func (a *recordingAppender) Append(
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) {
+	a.records = append(a.records, Record{
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+	})
+}
+
+func TestSequenceAppender(t *testing.T) {
+	next := &recordingAppender{}
+	ctx := context.WithValue(context.Background(), LevelKey(), InfoLevel)
+	ctx = context.WithValue(ctx, AppenderKey(),
+		NewSequenceAppender(next, SequenceKey, UptimeKey))
+	WithField("size", 1).Info(ctx, "Hello Bob")
+	Info(ctx, "Hello Alice")
+
+	if !assert.Len(t, next.records, 2) {
+		t.FailNow()
+	}
+	f1, f2 := next.records[0].Fields, next.records[1].Fields
+	assert.Equal(t, 1, f1["size"])
+	assert.Equal(t, uint64(1), f1["seq"])
+	assert.Equal(t, uint64(2), f2["seq"])
+	u1, ok1 := f1["uptime"].(time.Duration)
+	u2, ok2 := f2["uptime"].(time.Duration)
+	assert.True(t, ok1 && ok2)
+	assert.True(t, u1 > 0 && u2 >= u1)
+
+	next = &recordingAppender{}
+	ctx = context.WithValue(ctx, AppenderKey(),
+		NewSequenceAppender(next, "", "up"))
+	Info(ctx, "Hello Mary")
+	assert.Len(t, next.records[0].Fields, 1)
+	assert.Contains(t, next.records[0].Fields, "up")
+}
+
+func TestAppenderWithWriters(t *testing.T) {
+	debug, warn, def := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	a := NewAppenderWithWriters(map[Level]io.Writer{
+		DebugLevel: debug,
+		WarnLevel:  warn,
+		InfoLevel:  nil,
+	}, def, nil)
+	a.Append(nil, DebugLevel, nil, "Hello Bob")
+	a.Append(nil, InfoLevel, nil, "Hello Alice")
+	a.Append(nil, WarnLevel, nil, "Hello Mary")
+	a.Append(nil, ErrorLevel, nil, "Hello Carl")
+	assert.Equal(t, "[DEBUG] Hello Bob\n", debug.String())
+	assert.Equal(t, "[WARN] Hello Mary\n", warn.String())
+	assert.Equal(t, "[ERROR] Hello Carl\n", def.String())
+}
+
+func TestConsoleAppender(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	a := NewConsoleAppenderWithOptions(stdout, stderr, TextFormatter{})
+	for _, lvl := range []Level{DebugLevel, InfoLevel, WarnLevel, ErrorLevel} {
+		a.Append(nil, lvl, nil, "Hello")
+	}
+	assert.Equal(t, "[DEBUG] Hello\n[INFO] Hello\n", stdout.String())
+	assert.Equal(t, "[WARN] Hello\n[ERROR] Hello\n", stderr.String())
+	assert.Panics(t, func() { a.Append(nil, PanicLevel, nil, "Goodbye") })
+	assert.Contains(t, stderr.String(), "[PANIC] Goodbye")
+}
+
+// testWriteCloser records the calls to its Sync and Close functions.
+type testWriteCloser struct {
+	bytes.Buffer
+	synced int
+	closed bool
+}
+
+func (w *testWriteCloser) Sync() error {
+	w.synced++
+	return nil
+}
+
+func (w *testWriteCloser) Close() error {
+	w.closed = true
+	return errors.New("already closed")
+}
+
+func TestAppenderWithCloser(t *testing.T) {
+	w := &testWriteCloser{}
+	a := NewAppenderWithCloser(w, nil)
+	a.Append(nil, InfoLevel, nil, "Hello")
+	assert.NoError(t, a.Flush())
+	assert.Equal(t, 1, w.synced)
+	assert.EqualError(t, a.Close(), "already closed")
+	assert.Equal(t, 2, w.synced)
+	assert.True(t, w.closed)
+	assert.Equal(t, "[INFO] Hello\n", w.String())
+
+	assert.Panics(t, func() {
+		a.Append(nil, PanicLevel, nil, "Goodbye")
+	})
+	assert.Equal(t, 3, w.synced)
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, LogfmtFormatter{}.Format(buf, &Record{
+		Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:   WarnLevel,
+		Message: "Hello Bob",
+		Fields: map[string]interface{}{
+			"size":     1,
+			"color":    "red",
+			"empty":    "",
+			"quote":    `say "hi"`,
+			"eq":       "a=b",
+			"line":     "a\nb",
+			"bad key=": "ok",
+		},
+	}))
+	assert.Equal(t,
+		`time=2017-10-01T12:00:00Z level=warn msg="Hello Bob" `+
+			`bad_key_=ok color=red empty="" eq="a=b" line="a\nb" `+
+			`quote="say \"hi\"" size=1`+"\n",
+		buf.String())
+
+	buf.Reset()
+	assert.NoError(t, LogfmtFormatter{}.Format(buf, &Record{
+		Level:   InfoLevel,
+		Message: "Hello",
+	}))
+	assert.Equal(t, "level=info msg=Hello\n", buf.String())
+}
+
+func TestTemplateFormatter(t *testing.T) {
+	f, err := NewTemplateFormatter(
+		`{{.Time.Format "15:04:05"}} {{.Level | pad 5}}|` +
+			`{{.Level | lower | pad -6}} {{.Message | upper}} ` +
+			`{{json .Fields}}`)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, f.Format(buf, &Record{
+		Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:   InfoLevel,
+		Message: "Hello Bob",
+		Fields:  map[string]interface{}{"size": 1},
+	}))
+	assert.Equal(t,
+		"12:00:00 INFO |  info HELLO BOB {\"size\":1}\n", buf.String())
+
+	f, err = NewTemplateFormatter("{{.Message}}{{.Message.Nope}}")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	buf.Reset()
+	buf.WriteString("prefix")
+	assert.Error(t, f.Format(buf, &Record{Message: "Hello"}))
+	assert.Equal(t, "prefix", buf.String())
+
+	_, err = NewTemplateFormatter("{{.Message")
+	assert.Error(t, err)
+}
+
+func TestFieldPriority(t *testing.T) {
+	defer func() { FieldPriority = nil }()
+	FieldPriority = []string{"requestID", "user"}
+
+	fields := map[string]interface{}{
+		"b": 2, "user": "bob", "a": "<1>", "requestID": "r1",
+	}
+	assert.Equal(t,
+		[]string{"requestID", "user", "a", "b"}, SortKeys(fields))
+
+	rec := &Record{Level: InfoLevel, Message: "Hello", Fields: fields}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, TextFormatter{}.Format(buf, rec))
+	assert.NoError(t, LogfmtFormatter{}.Format(buf, rec))
+	assert.NoError(t, JSONFormatter{}.Format(buf, rec))
+	assert.Equal(t,
+		"[INFO] Hello map[requestID:r1 user:bob a:<1> b:2]\n"+
+			"level=info msg=Hello requestID=r1 user=bob a=<1> b=2\n"+
+			`{"time":"0001-01-01T00:00:00Z","level":"INFO","msg":"Hello",`+
+			`"fields":{"requestID":"r1","user":"bob","a":"<1>","b":2}}`+"\n",
+		buf.String())
+
+	buf.Reset()
+	a := NewAppenderWithOptions(buf)
+	a.Append(nil, InfoLevel, fields, "Hello")
+	a.(FieldAppender).AppendFields(nil, InfoLevel, []Field{
+		{"b", 2}, {"user", "bob"}, {"requestID", "r1"},
+	}, "Hello")
+	assert.Equal(t,
+		"[INFO] Hello map[requestID:r1 user:bob a:<1> b:2]\n"+
+			"[INFO] Hello map[requestID:r1 user:bob b:2]\n",
+		buf.String())
+
+	b, err := json.Marshal(OrderedFields{"b": 1, "user": "<bob>"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"user":"\u003cbob\u003e","b":1}`, string(b))
+	b, err = json.Marshal(OrderedFields(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+	_, err = json.Marshal(OrderedFields{"ch": make(chan int)})
+	assert.Error(t, err)
+}
+
+func TestDelimitedFormatter(t *testing.T) {
+	rec := &Record{
+		Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:   WarnLevel,
+		Message: `Hello, "Bob"`,
+		Fields:  map[string]interface{}{"size": 1, "city": "Austin\tTX"},
+	}
+
+	buf := &bytes.Buffer{}
+	f := NewCSVFormatter("time", "level", "msg", "size", "city", "none")
+	assert.NoError(t, f.WriteHeader(buf))
+	assert.NoError(t, f.Format(buf, rec))
+	assert.NoError(t, f.Format(buf, &Record{Level: InfoLevel, Message: "Hi"}))
+	assert.Equal(t,
+		"time,level,msg,size,city,none\n"+
+			`2017-10-01T12:00:00Z,WARN,"Hello, ""Bob""",1,Austin`+
+			"\tTX,\n,INFO,Hi,,,\n",
+		buf.String())
+
+	buf.Reset()
+	assert.NoError(t, NewTSVFormatter("level", "city").Format(buf, rec))
+	assert.Equal(t, "WARN\t\"Austin\tTX\"\n", buf.String())
+
+	buf.Reset()
+	buf.WriteString("prefix")
+	assert.Error(t, NewDelimitedFormatter('"', "msg").Format(buf, rec))
+	assert.Equal(t, "prefix", buf.String())
+}
+
+func TestEnabled(t *testing.T) {
+	ctx := context.WithValue(context.Background(), LevelKey(), WarnLevel)
+	assert.True(t, Enabled(ctx, ErrorLevel))
+	assert.True(t, Enabled(ctx, WarnLevel))
+	assert.False(t, Enabled(ctx, InfoLevel))
+	assert.Equal(t, DefaultLevel >= ErrorLevel, Enabled(nil, ErrorLevel))
+}
+
+func TestCaptureCaller(t *testing.T) {
+	defer func() { CaptureCaller = false }()
+
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, NameKey(), "db")
+	Info(ctx, "Hello Bob")
+	assert.Equal(t, "[INFO] db: Hello Bob\n", buf.String())
+	buf.Reset()
+
+	CaptureCaller = true
+	Info(ctx, "Hello Bob")
+	_, file, line, _ := runtime.Caller(0)
+	file = filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file)
+	assert.Equal(t,
+		fmt.Sprintf("[INFO] db %s:%d: Hello Bob\n", file, line-1),
+		buf.String())
+	buf.Reset()
+
+	var rec Record
+	ctx = context.WithValue(ctx, AppenderKey(), NewAppenderWithFormatter(
+		buf, JSONFormatter{}))
+	Info(ctx, "Hello Bob")
+	_, _, line, _ = runtime.Caller(0)
+	if !assert.NoError(t, json.Unmarshal(buf.Bytes(), &rec)) {
+		t.FailNow()
+	}
+	assert.Equal(t, "db", rec.Name)
+	if assert.NotNil(t, rec.Caller) {
+		assert.Equal(t, file, rec.Caller.File)
+		assert.Equal(t, line-1, rec.Caller.Line)
+	}
+}
+
+func TestFormatCaller(t *testing.T) {
+	defer func() {
+		FullCallerPath, CallerWidth, NameWidth = false, 0, 0
+	}()
+
+	c := &Caller{File: "/src/github.com/akutz/gournal/gournal.go", Line: 42}
+	assert.Equal(t, "", FormatCaller(nil))
+	assert.Equal(t, "gournal/gournal.go:42", FormatCaller(c))
+	CallerWidth = 12
+	assert.Equal(t, "…urnal.go:42", FormatCaller(c))
+	FullCallerPath, CallerWidth = true, 0
+	assert.Equal(t, c.String(), FormatCaller(c))
+
+	assert.Equal(t, "app.db.pool", FormatName("app.db.pool"))
+	NameWidth = 7
+	assert.Equal(t, "…b.pool", FormatName("app.db.pool"))
+
+	var rec Record
+	assert.NoError(t, json.Unmarshal(
+		[]byte(`{"msg":"Hello","logger":"db","caller":"C:/a.go:7"}`), &rec))
+	assert.Equal(t, &Caller{File: "C:/a.go", Line: 7}, rec.Caller)
+	assert.Equal(t, "db", rec.Name)
+}
+
+func TestFormattersNameCaller(t *testing.T) {
+	rec := &Record{
+		Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:   InfoLevel,
+		Message: "Hello Bob",
+		Fields:  map[string]interface{}{"size": 1},
+		Name:    "db",
+		Caller:  &Caller{File: "/src/app/main.go", Line: 12},
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, TextFormatter{}.Format(buf, rec))
+	assert.Equal(t,
+		"[INFO] db app/main.go:12: Hello Bob map[size:1]\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, LogfmtFormatter{}.Format(buf, rec))
+	assert.Equal(t,
+		"time=2017-10-01T12:00:00Z level=info logger=db "+
+			"caller=app/main.go:12 msg=\"Hello Bob\" size=1\n",
+		buf.String())
+
+	buf.Reset()
+	assert.NoError(t, JSONFormatter{}.Format(buf, rec))
+	assert.Equal(t,
+		`{"time":"2017-10-01T12:00:00Z","level":"INFO","msg":"Hello Bob",`+
+			`"fields":{"size":1},"logger":"db","caller":"app/main.go:12"}`+
+			"\n",
+		buf.String())
+}
+
+func TestSeverityMapper(t *testing.T) {
+	defer func() { SeverityMapper = nil }()
+
+	_, ok := MapSeverityInt("syslog", InfoLevel)
+	assert.False(t, ok)
+
+	SeverityMapper = SeverityTable{
+		"syslog": {InfoLevel: 5, Level(7): 7},
+		"sentry": {WarnLevel: "warning", ErrorLevel: 3},
+	}.Severity
+
+	n, ok := MapSeverityInt("syslog", InfoLevel)
+	assert.True(t, ok)
+	assert.Equal(t, 5, n)
+	n, ok = MapSeverityInt("syslog", Level(7))
+	assert.True(t, ok)
+	assert.Equal(t, 7, n)
+	_, ok = MapSeverityInt("syslog", WarnLevel)
+	assert.False(t, ok)
+
+	s, ok := MapSeverityString("sentry", WarnLevel)
+	assert.True(t, ok)
+	assert.Equal(t, "warning", s)
+	_, ok = MapSeverityString("sentry", ErrorLevel)
+	assert.False(t, ok)
+}
+
+type testAudit struct {
+	Actor string `json:"actor"`
+}
+
+type testVolumeAttached struct {
+	testAudit
+	VolumeID string `log:"volume_id" json:"volumeId"`
+	Size     int    `json:"size,omitempty"`
+	Secret   string `json:"-"`
+	Host     string
+	private  string
+}
+
+type testUserLoggedIn struct {
+	User string
+}
+
+func (e testUserLoggedIn) EventName() string {
+	return "user.login"
+}
+
+func (e testUserLoggedIn) LogFields() map[string]interface{} {
+	return map[string]interface{}{"username": e.User}
+}
+
+func TestEvent(t *testing.T) {
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+
+	Event(ctx, &testVolumeAttached{
+		testAudit: testAudit{Actor: "bob"},
+		VolumeID:  "vol-1",
+		Secret:    "hunter2",
+		Host:      "node1",
+		private:   "x",
+	})
+	assert.Equal(t,
+		"[INFO] testVolumeAttached map[Host:node1 actor:bob "+
+			"event:testVolumeAttached volume_id:vol-1]\n",
+		buf.String())
+	buf.Reset()
+
+	Event(ctx, testUserLoggedIn{User: "alice"})
+	assert.Equal(t,
+		"[INFO] user.login map[event:user.login username:alice]\n",
+		buf.String())
+}
+
+type testUser struct {
+	ID    int    `log:"id"`
+	Email string `log:"email,redact"`
+}
+
+type testRequest struct {
+	User      testUser  `log:"user"`
+	Owner     *testUser `log:"owner,omitempty"`
+	Password  string    `log:"password,redact"`
+	Note      string    `log:"note,omitempty"`
+	Internal  string    `log:"-"`
+	Timestamp time.Time `json:"ts"`
+}
+
+func TestWithStruct(t *testing.T) {
+	buf, ctx := newTestContext()
+	ts := time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
+	req := &testRequest{
+		User:      testUser{ID: 1, Email: "bob@example.com"},
+		Password:  "hunter2",
+		Internal:  "x",
+		Timestamp: ts,
+	}
+
+	WithStruct(req).WithField("size", 1).Error(ctx, "Hello Bob")
+	assert.Equal(t,
+		"[ERROR] Hello Bob map[password:[REDACTED] size:1 "+
+			"ts:2017-10-01 12:00:00 +0000 UTC user.email:[REDACTED] "+
+			"user.id:1]\n",
+		buf.String())
+	buf.Reset()
+
+	req.Owner = &testUser{ID: 2}
+	req.Note = "urgent"
+	WithField("size", 1).WithStruct(*req).Error(ctx, "Hello Bob")
+	assert.Equal(t,
+		"[ERROR] Hello Bob map[note:urgent owner.email:[REDACTED] "+
+			"owner.id:2 password:[REDACTED] size:1 "+
+			"ts:2017-10-01 12:00:00 +0000 UTC user.email:[REDACTED] "+
+			"user.id:1]\n",
+		buf.String())
+}
+
+type testCountingStringer struct {
+	calls int
+}
+
+func (s *testCountingStringer) String() string {
+	s.calls++
+	return "expensive"
+}
+
+func (s *testCountingStringer) Error() string {
+	return s.String()
+}
+
+func TestLazyFormatting(t *testing.T) {
+	buf, ctx := newTestContext()
+	ctx = context.WithValue(ctx, LevelKey(), InfoLevel)
+
+	s, err := &testCountingStringer{}, &testCountingStringer{}
+	WithField("plan", LazyStringer(s)).WithError(err).Debug(ctx, "%v", s)
+	Debug(ctx, "%v", s)
+	assert.Zero(t, s.calls)
+	assert.Zero(t, err.calls)
+	assert.Zero(t, buf.Len())
+
+	WithField("plan", LazyStringer(s)).WithError(err).Info(ctx, "Hello")
+	assert.Equal(t, 1, s.calls)
+	assert.Equal(t, 1, err.calls)
+	assert.Equal(t,
+		"[INFO] Hello map[error:expensive plan:expensive]\n", buf.String())
+	buf.Reset()
+
+	rec := &recordingAppender{}
+	ctx = context.WithValue(ctx, AppenderKey(), rec)
+	fields := map[string]interface{}{"plan": LazyStringer(s)}
+	WithFields(fields).Info(ctx, "Hello")
+	if assert.Len(t, rec.records, 1) {
+		assert.Equal(t, "expensive", rec.records[0].Fields["plan"])
+	}
+	assert.IsType(t, lazyStringer{}, fields["plan"])
+}
@@ -0,0 +1,161 @@
+package gournal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// TransportBodySnippetSize is the maximum number of bytes of a response
+	// body that Transport captures when the request's Context is at the
+	// DEBUG level.
+	TransportBodySnippetSize = 512
+
+	// TransportLogQuery specifies whether Transport includes the query of
+	// request URLs in entries. Queries often contain credentials such as
+	// API keys, so they are omitted by default.
+	TransportLogQuery = false
+)
+
+// Transport returns an http.RoundTripper that logs every outbound request
+// using the appender, level, and fields of the request's Context. Successful
+// requests are logged at the INFO level with their method, URL, status, and
+// duration. Failed requests are logged at the ERROR level with the error.
+//
+// The user information of request URLs is omitted from entries, as is their
+// query unless TransportLogQuery is true.
+//
+// If the request's Context is at the DEBUG level, a snippet of the response
+// body is included in the entry. The snippet is captured as the body is
+// read, so the entry is not appended until the snippet is captured, the
+// body is read to its end, or the body is closed. The body of a 101
+// Switching Protocols response is not captured. If next is nil,
+// http.DefaultTransport is used.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{next}
+}
+
+// TrackRetries returns a Context that enables Transport to count the number
+// of times a request using the Context, or a Context derived from it, is sent.
+// Retry loops that reuse the same Context will have the number of previous
+// attempts included in each entry as the "retries" field.
+func TrackRetries(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = DefaultContext
+	}
+	return context.WithValue(ctx, retriesKey, new(int32))
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	u := *req.URL
+	u.User = nil
+	if !TransportLogQuery {
+		u.RawQuery = ""
+		u.ForceQuery = false
+	}
+
+	fields := map[string]interface{}{
+		"method": req.Method,
+		"url":    LazyStringer(&u),
+	}
+	if v, ok := ctx.Value(retriesKey).(*int32); ok {
+		fields["retries"] = atomic.AddInt32(v, 1) - 1
+	}
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	fields["duration"] = time.Since(start)
+
+	if err != nil {
+		fields[ErrorKey] = lazyError{err}
+		sendToAppender(
+			ctx,
+			ErrorLevel,
+			fieldsFromMap(fields),
+			"outbound request failed")
+		return res, err
+	}
+
+	fields["status"] = res.StatusCode
+
+	// the body of a 101 Switching Protocols response is an
+	// io.ReadWriteCloser for the upgraded connection, so it is not wrapped
+	if getLevel(ctx) >= DebugLevel && res.Body != nil &&
+		res.StatusCode != http.StatusSwitchingProtocols {
+		res.Body = &snippetBody{
+			ReadCloser: res.Body,
+			ctx:        ctx,
+			fields:     fields,
+			size:       TransportBodySnippetSize,
+		}
+		return res, nil
+	}
+
+	sendToAppender(
+		ctx, InfoLevel, fieldsFromMap(fields), "outbound request")
+	return res, nil
+}
+
+// snippetBody captures the beginning of a response body as it is read and
+// appends the request's entry with the snippet once the snippet is
+// captured, the body is read to its end, or the body is closed.
+type snippetBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	fields map[string]interface{}
+	size   int
+
+	sync.Mutex
+	snip   []byte
+	logged bool
+}
+
+func (b *snippetBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.Lock()
+	defer b.Unlock()
+	if b.logged {
+		return n, err
+	}
+	if r := b.size - len(b.snip); r > 0 {
+		if r > n {
+			r = n
+		}
+		b.snip = append(b.snip, p[:r]...)
+	}
+	if len(b.snip) >= b.size || err != nil {
+		b.log()
+	}
+	return n, err
+}
+
+func (b *snippetBody) Close() error {
+	b.Lock()
+	if !b.logged {
+		b.log()
+	}
+	b.Unlock()
+	return b.ReadCloser.Close()
+}
+
+// log appends the entry. The lock must be held.
+func (b *snippetBody) log() {
+	b.logged = true
+	b.fields["body"] = string(b.snip)
+	sendToAppender(
+		b.ctx, InfoLevel, fieldsFromMap(b.fields), "outbound request")
+}
@@ -0,0 +1,36 @@
+package gournal
+
+import "context"
+
+// ErrorAppender may be implemented by Appenders that are able to report
+// when an entry could not be delivered. Wrappers that react to delivery
+// failures, such as those that fail over or retry, use TryAppend to deliver
+// entries.
+type ErrorAppender interface {
+	Appender
+
+	// TryAppend is like Append except it returns an error if the entry
+	// could not be delivered instead of reporting it with HandleError.
+	TryAppend(
+		ctx context.Context,
+		lvl Level,
+		fields map[string]interface{},
+		msg string) error
+}
+
+// TryAppend delivers the entry with the Appender's TryAppend function if it
+// implements ErrorAppender, otherwise with its Append function, in which
+// case a nil error is always returned.
+func TryAppend(
+	a Appender,
+	ctx context.Context,
+	lvl Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	if ea, ok := a.(ErrorAppender); ok {
+		return ea.TryAppend(ctx, lvl, fields, msg)
+	}
+	a.Append(ctx, lvl, fields, msg)
+	return nil
+}
@@ -0,0 +1,567 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: gournal.proto
+
+/*
+Package gournalpb is a generated protocol buffer package.
+
+It is generated from these files:
+
+	gournal.proto
+
+It has these top-level messages:
+
+	Record
+	Caller
+	Value
+	ListValue
+	MapValue
+*/
+package gournalpb
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import google_protobuf "github.com/golang/protobuf/ptypes/timestamp"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// Level is the severity of an entry.
+type Level int32
+
+const (
+	Level_LEVEL_UNKNOWN Level = 0
+	Level_LEVEL_PANIC   Level = 1
+	Level_LEVEL_FATAL   Level = 2
+	Level_LEVEL_ERROR   Level = 3
+	Level_LEVEL_WARN    Level = 4
+	Level_LEVEL_INFO    Level = 5
+	Level_LEVEL_DEBUG   Level = 6
+)
+
+var Level_name = map[int32]string{
+	0: "LEVEL_UNKNOWN",
+	1: "LEVEL_PANIC",
+	2: "LEVEL_FATAL",
+	3: "LEVEL_ERROR",
+	4: "LEVEL_WARN",
+	5: "LEVEL_INFO",
+	6: "LEVEL_DEBUG",
+}
+var Level_value = map[string]int32{
+	"LEVEL_UNKNOWN": 0,
+	"LEVEL_PANIC":   1,
+	"LEVEL_FATAL":   2,
+	"LEVEL_ERROR":   3,
+	"LEVEL_WARN":    4,
+	"LEVEL_INFO":    5,
+	"LEVEL_DEBUG":   6,
+}
+
+func (x Level) String() string {
+	return proto.EnumName(Level_name, int32(x))
+}
+func (Level) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+// Record is a log entry.
+type Record struct {
+	// Time is when the entry was emitted.
+	Time *google_protobuf.Timestamp `protobuf:"bytes,1,opt,name=time" json:"time,omitempty"`
+	// Level is the entry's level.
+	Level Level `protobuf:"varint,2,opt,name=level,enum=gournal.Level" json:"level,omitempty"`
+	// Message is the entry's formatted message.
+	Message string `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+	// Fields is the entry's field data, excluding the error.
+	Fields map[string]*Value `protobuf:"bytes,4,rep,name=fields" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Caller is the location of the function call that emitted the entry.
+	Caller *Caller `protobuf:"bytes,5,opt,name=caller" json:"caller,omitempty"`
+	// Error is the description of the error added to the entry, if any.
+	Error string `protobuf:"bytes,6,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *Record) Reset()                    { *m = Record{} }
+func (m *Record) String() string            { return proto.CompactTextString(m) }
+func (*Record) ProtoMessage()               {}
+func (*Record) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *Record) GetTime() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *Record) GetLevel() Level {
+	if m != nil {
+		return m.Level
+	}
+	return Level_LEVEL_UNKNOWN
+}
+
+func (m *Record) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *Record) GetFields() map[string]*Value {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *Record) GetCaller() *Caller {
+	if m != nil {
+		return m.Caller
+	}
+	return nil
+}
+
+func (m *Record) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// Caller is a location in source code.
+type Caller struct {
+	File     string `protobuf:"bytes,1,opt,name=file" json:"file,omitempty"`
+	Line     int32  `protobuf:"varint,2,opt,name=line" json:"line,omitempty"`
+	Function string `protobuf:"bytes,3,opt,name=function" json:"function,omitempty"`
+}
+
+func (m *Caller) Reset()                    { *m = Caller{} }
+func (m *Caller) String() string            { return proto.CompactTextString(m) }
+func (*Caller) ProtoMessage()               {}
+func (*Caller) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Caller) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *Caller) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *Caller) GetFunction() string {
+	if m != nil {
+		return m.Function
+	}
+	return ""
+}
+
+// Value is a field value. A Value without a kind is null.
+type Value struct {
+	// Types that are valid to be assigned to Kind:
+	//	*Value_BoolValue
+	//	*Value_IntValue
+	//	*Value_UintValue
+	//	*Value_DoubleValue
+	//	*Value_StringValue
+	//	*Value_BytesValue
+	//	*Value_TimeValue
+	//	*Value_ListValue
+	//	*Value_MapValue
+	Kind isValue_Kind `protobuf_oneof:"kind"`
+}
+
+func (m *Value) Reset()                    { *m = Value{} }
+func (m *Value) String() string            { return proto.CompactTextString(m) }
+func (*Value) ProtoMessage()               {}
+func (*Value) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+type isValue_Kind interface{ isValue_Kind() }
+
+type Value_BoolValue struct {
+	BoolValue bool `protobuf:"varint,1,opt,name=bool_value,json=boolValue,oneof"`
+}
+type Value_IntValue struct {
+	IntValue int64 `protobuf:"varint,2,opt,name=int_value,json=intValue,oneof"`
+}
+type Value_UintValue struct {
+	UintValue uint64 `protobuf:"varint,3,opt,name=uint_value,json=uintValue,oneof"`
+}
+type Value_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue,oneof"`
+}
+type Value_StringValue struct {
+	StringValue string `protobuf:"bytes,5,opt,name=string_value,json=stringValue,oneof"`
+}
+type Value_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,6,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+type Value_TimeValue struct {
+	TimeValue *google_protobuf.Timestamp `protobuf:"bytes,7,opt,name=time_value,json=timeValue,oneof"`
+}
+type Value_ListValue struct {
+	ListValue *ListValue `protobuf:"bytes,8,opt,name=list_value,json=listValue,oneof"`
+}
+type Value_MapValue struct {
+	MapValue *MapValue `protobuf:"bytes,9,opt,name=map_value,json=mapValue,oneof"`
+}
+
+func (*Value_BoolValue) isValue_Kind()   {}
+func (*Value_IntValue) isValue_Kind()    {}
+func (*Value_UintValue) isValue_Kind()   {}
+func (*Value_DoubleValue) isValue_Kind() {}
+func (*Value_StringValue) isValue_Kind() {}
+func (*Value_BytesValue) isValue_Kind()  {}
+func (*Value_TimeValue) isValue_Kind()   {}
+func (*Value_ListValue) isValue_Kind()   {}
+func (*Value_MapValue) isValue_Kind()    {}
+
+func (m *Value) GetKind() isValue_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return nil
+}
+
+func (m *Value) GetBoolValue() bool {
+	if x, ok := m.GetKind().(*Value_BoolValue); ok {
+		return x.BoolValue
+	}
+	return false
+}
+
+func (m *Value) GetIntValue() int64 {
+	if x, ok := m.GetKind().(*Value_IntValue); ok {
+		return x.IntValue
+	}
+	return 0
+}
+
+func (m *Value) GetUintValue() uint64 {
+	if x, ok := m.GetKind().(*Value_UintValue); ok {
+		return x.UintValue
+	}
+	return 0
+}
+
+func (m *Value) GetDoubleValue() float64 {
+	if x, ok := m.GetKind().(*Value_DoubleValue); ok {
+		return x.DoubleValue
+	}
+	return 0
+}
+
+func (m *Value) GetStringValue() string {
+	if x, ok := m.GetKind().(*Value_StringValue); ok {
+		return x.StringValue
+	}
+	return ""
+}
+
+func (m *Value) GetBytesValue() []byte {
+	if x, ok := m.GetKind().(*Value_BytesValue); ok {
+		return x.BytesValue
+	}
+	return nil
+}
+
+func (m *Value) GetTimeValue() *google_protobuf.Timestamp {
+	if x, ok := m.GetKind().(*Value_TimeValue); ok {
+		return x.TimeValue
+	}
+	return nil
+}
+
+func (m *Value) GetListValue() *ListValue {
+	if x, ok := m.GetKind().(*Value_ListValue); ok {
+		return x.ListValue
+	}
+	return nil
+}
+
+func (m *Value) GetMapValue() *MapValue {
+	if x, ok := m.GetKind().(*Value_MapValue); ok {
+		return x.MapValue
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*Value) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _Value_OneofMarshaler, _Value_OneofUnmarshaler, _Value_OneofSizer, []interface{}{
+		(*Value_BoolValue)(nil),
+		(*Value_IntValue)(nil),
+		(*Value_UintValue)(nil),
+		(*Value_DoubleValue)(nil),
+		(*Value_StringValue)(nil),
+		(*Value_BytesValue)(nil),
+		(*Value_TimeValue)(nil),
+		(*Value_ListValue)(nil),
+		(*Value_MapValue)(nil),
+	}
+}
+
+func _Value_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*Value)
+	// kind
+	switch x := m.Kind.(type) {
+	case *Value_BoolValue:
+		t := uint64(0)
+		if x.BoolValue {
+			t = 1
+		}
+		b.EncodeVarint(1<<3 | proto.WireVarint)
+		b.EncodeVarint(t)
+	case *Value_IntValue:
+		b.EncodeVarint(2<<3 | proto.WireVarint)
+		b.EncodeVarint(uint64(x.IntValue))
+	case *Value_UintValue:
+		b.EncodeVarint(3<<3 | proto.WireVarint)
+		b.EncodeVarint(uint64(x.UintValue))
+	case *Value_DoubleValue:
+		b.EncodeVarint(4<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.DoubleValue))
+	case *Value_StringValue:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		b.EncodeStringBytes(x.StringValue)
+	case *Value_BytesValue:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		b.EncodeRawBytes(x.BytesValue)
+	case *Value_TimeValue:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.TimeValue); err != nil {
+			return err
+		}
+	case *Value_ListValue:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.ListValue); err != nil {
+			return err
+		}
+	case *Value_MapValue:
+		b.EncodeVarint(9<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.MapValue); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("Value.Kind has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _Value_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*Value)
+	switch tag {
+	case 1: // kind.bool_value
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Kind = &Value_BoolValue{x != 0}
+		return true, err
+	case 2: // kind.int_value
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Kind = &Value_IntValue{int64(x)}
+		return true, err
+	case 3: // kind.uint_value
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Kind = &Value_UintValue{x}
+		return true, err
+	case 4: // kind.double_value
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.Kind = &Value_DoubleValue{math.Float64frombits(x)}
+		return true, err
+	case 5: // kind.string_value
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeStringBytes()
+		m.Kind = &Value_StringValue{x}
+		return true, err
+	case 6: // kind.bytes_value
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeRawBytes(true)
+		m.Kind = &Value_BytesValue{x}
+		return true, err
+	case 7: // kind.time_value
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(google_protobuf.Timestamp)
+		err := b.DecodeMessage(msg)
+		m.Kind = &Value_TimeValue{msg}
+		return true, err
+	case 8: // kind.list_value
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ListValue)
+		err := b.DecodeMessage(msg)
+		m.Kind = &Value_ListValue{msg}
+		return true, err
+	case 9: // kind.map_value
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(MapValue)
+		err := b.DecodeMessage(msg)
+		m.Kind = &Value_MapValue{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _Value_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*Value)
+	// kind
+	switch x := m.Kind.(type) {
+	case *Value_BoolValue:
+		n += proto.SizeVarint(1<<3 | proto.WireVarint)
+		n += 1
+	case *Value_IntValue:
+		n += proto.SizeVarint(2<<3 | proto.WireVarint)
+		n += proto.SizeVarint(uint64(x.IntValue))
+	case *Value_UintValue:
+		n += proto.SizeVarint(3<<3 | proto.WireVarint)
+		n += proto.SizeVarint(uint64(x.UintValue))
+	case *Value_DoubleValue:
+		n += proto.SizeVarint(4<<3 | proto.WireFixed64)
+		n += 8
+	case *Value_StringValue:
+		n += proto.SizeVarint(5<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(len(x.StringValue)))
+		n += len(x.StringValue)
+	case *Value_BytesValue:
+		n += proto.SizeVarint(6<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(len(x.BytesValue)))
+		n += len(x.BytesValue)
+	case *Value_TimeValue:
+		s := proto.Size(x.TimeValue)
+		n += proto.SizeVarint(7<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Value_ListValue:
+		s := proto.Size(x.ListValue)
+		n += proto.SizeVarint(8<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Value_MapValue:
+		s := proto.Size(x.MapValue)
+		n += proto.SizeVarint(9<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+// ListValue is a list of field values.
+type ListValue struct {
+	Values []*Value `protobuf:"bytes,1,rep,name=values" json:"values,omitempty"`
+}
+
+func (m *ListValue) Reset()                    { *m = ListValue{} }
+func (m *ListValue) String() string            { return proto.CompactTextString(m) }
+func (*ListValue) ProtoMessage()               {}
+func (*ListValue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *ListValue) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+// MapValue is a map of field values.
+type MapValue struct {
+	Fields map[string]*Value `protobuf:"bytes,1,rep,name=fields" json:"fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *MapValue) Reset()                    { *m = MapValue{} }
+func (m *MapValue) String() string            { return proto.CompactTextString(m) }
+func (*MapValue) ProtoMessage()               {}
+func (*MapValue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *MapValue) GetFields() map[string]*Value {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Record)(nil), "gournal.Record")
+	proto.RegisterType((*Caller)(nil), "gournal.Caller")
+	proto.RegisterType((*Value)(nil), "gournal.Value")
+	proto.RegisterType((*ListValue)(nil), "gournal.ListValue")
+	proto.RegisterType((*MapValue)(nil), "gournal.MapValue")
+	proto.RegisterEnum("gournal.Level", Level_name, Level_value)
+}
+
+func init() { proto.RegisterFile("gournal.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 588 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x53, 0x4b, 0x6b, 0xdb, 0x5c,
+	0x10, 0xb5, 0xac, 0x47, 0xa4, 0x51, 0x1e, 0xca, 0xe5, 0x5b, 0x08, 0x7f, 0x84, 0xa8, 0x6e, 0x69,
+	0x4d, 0x17, 0x4a, 0x89, 0x29, 0x94, 0x76, 0x65, 0xa7, 0x76, 0x6d, 0xea, 0xca, 0xe5, 0x92, 0x07,
+	0x74, 0x63, 0x24, 0xfb, 0xda, 0x88, 0x5c, 0x4b, 0x46, 0x8f, 0x80, 0x37, 0x85, 0xee, 0xfb, 0x7f,
+	0xfa, 0xf7, 0xca, 0x7d, 0x48, 0x0a, 0x34, 0xd0, 0x4d, 0x77, 0x33, 0x67, 0xce, 0x39, 0x33, 0x73,
+	0x35, 0x82, 0xa3, 0x4d, 0x5a, 0x66, 0x49, 0x48, 0xfd, 0x5d, 0x96, 0x16, 0x29, 0x3a, 0x90, 0x69,
+	0xe7, 0x7c, 0x93, 0xa6, 0x1b, 0x4a, 0x2e, 0x38, 0x1c, 0x95, 0xeb, 0x8b, 0x22, 0xde, 0x92, 0xbc,
+	0x08, 0xb7, 0x3b, 0xc1, 0xec, 0xfe, 0x6a, 0x83, 0x81, 0xc9, 0x32, 0xcd, 0x56, 0xc8, 0x07, 0x8d,
+	0x55, 0x5d, 0xc5, 0x53, 0x7a, 0xf6, 0x65, 0xc7, 0x17, 0x52, 0xbf, 0x92, 0xfa, 0xd7, 0x95, 0x14,
+	0x73, 0x1e, 0x7a, 0x01, 0x3a, 0x25, 0x0f, 0x84, 0xba, 0x6d, 0x4f, 0xe9, 0x1d, 0x5f, 0x1e, 0xfb,
+	0xd5, 0x0c, 0x33, 0x86, 0x62, 0x51, 0x44, 0x2e, 0x1c, 0x6c, 0x49, 0x9e, 0x87, 0x1b, 0xe2, 0xaa,
+	0x9e, 0xd2, 0xb3, 0x70, 0x95, 0xa2, 0x3e, 0x18, 0xeb, 0x98, 0xd0, 0x55, 0xee, 0x6a, 0x9e, 0xda,
+	0xb3, 0x2f, 0xff, 0xaf, 0x0d, 0xc4, 0x40, 0xfe, 0x98, 0x57, 0x47, 0x49, 0x91, 0xed, 0xb1, 0xa4,
+	0xa2, 0x57, 0x60, 0x2c, 0x43, 0x4a, 0x49, 0xe6, 0xea, 0x7c, 0xcc, 0x93, 0x5a, 0x74, 0xc5, 0x61,
+	0x2c, 0xcb, 0xe8, 0x3f, 0xd0, 0x49, 0x96, 0xa5, 0x99, 0x6b, 0xf0, 0xae, 0x22, 0xe9, 0x4c, 0xc1,
+	0x7e, 0xe4, 0x8a, 0x1c, 0x50, 0xef, 0xc9, 0x9e, 0x6f, 0x6c, 0x61, 0x16, 0xb2, 0xa5, 0x1e, 0x42,
+	0x5a, 0x12, 0xbe, 0x94, 0xfd, 0x68, 0xa9, 0x5b, 0x86, 0x62, 0x51, 0x7c, 0xdf, 0x7e, 0xa7, 0x74,
+	0x67, 0x60, 0x88, 0x96, 0x08, 0x81, 0xb6, 0x8e, 0x29, 0x91, 0x36, 0x3c, 0x66, 0x18, 0x8d, 0x13,
+	0x61, 0xa3, 0x63, 0x1e, 0xa3, 0x0e, 0x98, 0xeb, 0x32, 0x59, 0x16, 0x71, 0x9a, 0xc8, 0xb7, 0xa8,
+	0xf3, 0xee, 0x0f, 0x15, 0x74, 0xde, 0x02, 0x9d, 0x03, 0x44, 0x69, 0x4a, 0x17, 0x62, 0x0c, 0xe6,
+	0x69, 0x4e, 0x5a, 0xd8, 0x62, 0x98, 0x20, 0x9c, 0x81, 0x15, 0x27, 0xc5, 0xa2, 0x19, 0x53, 0x9d,
+	0xb4, 0xb0, 0x19, 0x27, 0x45, 0xad, 0x2f, 0x9b, 0x3a, 0xeb, 0xa3, 0x31, 0x7d, 0x59, 0x13, 0x9e,
+	0xc3, 0xe1, 0x2a, 0x2d, 0x23, 0x4a, 0x24, 0x45, 0xf3, 0x94, 0x9e, 0x32, 0x69, 0x61, 0x5b, 0xa0,
+	0x35, 0x29, 0x2f, 0xb2, 0x38, 0xd9, 0x48, 0x12, 0x7b, 0x6d, 0x8b, 0x91, 0x04, 0x2a, 0x48, 0xcf,
+	0xc0, 0x8e, 0xf6, 0x05, 0xc9, 0x25, 0x87, 0xbd, 0xf4, 0xe1, 0xa4, 0x85, 0x81, 0x83, 0x82, 0xf2,
+	0x01, 0x80, 0x1d, 0x8b, 0x64, 0x1c, 0xfc, 0xed, 0xb4, 0xd8, 0xa4, 0x8c, 0x2f, 0xc4, 0x7d, 0x00,
+	0x1a, 0xe7, 0xd5, 0x2a, 0x26, 0x17, 0xa3, 0xe6, 0xcc, 0xe2, 0x5c, 0x6c, 0xc4, 0x44, 0xb4, 0x4a,
+	0xd0, 0x1b, 0xb0, 0xb6, 0xe1, 0x4e, 0x6a, 0x2c, 0xae, 0x39, 0xad, 0x35, 0x5f, 0xc2, 0x5d, 0x25,
+	0x31, 0xb7, 0x32, 0x1e, 0x1a, 0xa0, 0xdd, 0xc7, 0xc9, 0xaa, 0xdb, 0x07, 0xab, 0xf6, 0x44, 0x2f,
+	0xc1, 0xe0, 0x16, 0xb9, 0xab, 0x78, 0xea, 0x13, 0x97, 0x20, 0xab, 0xdd, 0x9f, 0x0a, 0x98, 0x95,
+	0x2b, 0x7a, 0x5b, 0x9f, 0xb4, 0x10, 0x9d, 0xfd, 0xd1, 0xf8, 0xa9, 0xa3, 0xfe, 0x87, 0x57, 0xf9,
+	0xfa, 0x3b, 0xe8, 0xfc, 0xf7, 0x43, 0xa7, 0x70, 0x34, 0x1b, 0xdd, 0x8e, 0x66, 0x8b, 0x9b, 0xe0,
+	0x73, 0x30, 0xbf, 0x0b, 0x9c, 0x16, 0x3a, 0x01, 0x5b, 0x40, 0x5f, 0x07, 0xc1, 0xf4, 0xca, 0x51,
+	0x1a, 0x60, 0x3c, 0xb8, 0x1e, 0xcc, 0x9c, 0x76, 0x03, 0x8c, 0x30, 0x9e, 0x63, 0x47, 0x45, 0xc7,
+	0x00, 0x02, 0xb8, 0x1b, 0xe0, 0xc0, 0xd1, 0x9a, 0x7c, 0x1a, 0x8c, 0xe7, 0x8e, 0xde, 0x08, 0x3e,
+	0x8e, 0x86, 0x37, 0x9f, 0x1c, 0x63, 0x68, 0x7f, 0xb3, 0xe4, 0x6c, 0xbb, 0x28, 0x32, 0xf8, 0x07,
+	0xee, 0xff, 0x1e, 0x00, 0x0f, 0x73, 0x03, 0x37, 0x9e, 0x04, 0x00, 0x00,
+}
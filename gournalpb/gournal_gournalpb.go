@@ -0,0 +1,350 @@
+// Package gournalpb defines a Protocol Buffers schema for Gournal Records,
+// gournal.proto, so consumers of log streams written in other languages
+// have a stable contract. The package also provides a Formatter that
+// encodes entries as length-delimited Record messages and a Decoder that
+// reads them back.
+//
+// A Record's level, time, message, and fields are mapped directly to the
+// message. The field named by gournal.ErrorKey is written as the message's
+// error, and the Formatter may also write the location of the function
+// call that emitted the entry.
+package gournalpb
+
+//go:generate protoc --go_out=. gournal.proto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// CallerKey is the name of the field to which a Record's caller is
+	// decoded by ToRecord.
+	CallerKey = "caller"
+
+	// MaxSize is the maximum size of a message read by a Decoder.
+	MaxSize = 4 << 20
+
+	// ErrInvalid is returned by a Decoder when the stream does not contain
+	// a valid length-delimited Record.
+	ErrInvalid = errors.New("gournalpb: invalid record")
+)
+
+// FromRecord returns the message for the Record. If the field named by
+// gournal.ErrorKey is an error or string, it is written as the message's
+// error.
+func FromRecord(rec *gournal.Record) *Record {
+	m := &Record{
+		Time:    toTimestamp(rec.Time),
+		Level:   Level(rec.Level),
+		Message: rec.Message,
+	}
+	for k, v := range rec.Fields {
+		if k == gournal.ErrorKey {
+			switch tv := v.(type) {
+			case error:
+				m.Error = tv.Error()
+				continue
+			case string:
+				m.Error = tv
+				continue
+			}
+		}
+		if m.Fields == nil {
+			m.Fields = make(map[string]*Value, len(rec.Fields))
+		}
+		m.Fields[k] = toValue(v)
+	}
+	return m
+}
+
+// ToRecord returns the Record for the message. The message's error is
+// decoded to the field named by gournal.ErrorKey, and its caller to the
+// field named by CallerKey as a map with the keys "file", "line", and
+// "function".
+func ToRecord(m *Record) *gournal.Record {
+	rec := &gournal.Record{
+		Time:    fromTimestamp(m.Time),
+		Level:   gournal.Level(m.Level),
+		Message: m.Message,
+	}
+	n := len(m.Fields)
+	if m.Error != "" {
+		n++
+	}
+	if m.Caller != nil {
+		n++
+	}
+	if n == 0 {
+		return rec
+	}
+	rec.Fields = make(map[string]interface{}, n)
+	for k, v := range m.Fields {
+		rec.Fields[k] = fromValue(v)
+	}
+	if m.Error != "" {
+		rec.Fields[gournal.ErrorKey] = m.Error
+	}
+	if c := m.Caller; c != nil {
+		rec.Fields[CallerKey] = map[string]interface{}{
+			"file":     c.File,
+			"line":     int64(c.Line),
+			"function": c.Function,
+		}
+	}
+	return rec
+}
+
+// Formatter is a Protocol Buffers Formatter.
+type Formatter struct {
+	caller bool
+}
+
+// NewFormatter returns a Formatter that writes the caller.
+func NewFormatter() *Formatter {
+	return NewFormatterWithOptions(true)
+}
+
+// NewFormatterWithOptions returns a Formatter that writes the location of
+// the function call that emitted each entry if caller is true.
+//
+// The caller is only available when the Formatter is used by an Appender
+// that formats entries on the goroutine that emitted them, so it is not
+// written for entries delivered by asynchronous Appenders.
+func NewFormatterWithOptions(caller bool) *Formatter {
+	return &Formatter{caller: caller}
+}
+
+// Format writes the Record to the buffer as a message preceded by its
+// varint-encoded length.
+func (f *Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	m := FromRecord(rec)
+	if f.caller {
+		if fr, ok := caller(); ok {
+			m.Caller = &Caller{
+				File:     fr.File,
+				Line:     int32(fr.Line),
+				Function: fr.Function,
+			}
+		}
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	buf.Write(proto.EncodeVarint(uint64(len(b))))
+	buf.Write(b)
+	return nil
+}
+
+// Decoder decodes Records written by a Formatter from a stream.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder returns a Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode returns the next Record from the stream or io.EOF when there are
+// no more Records. The Record is converted with ToRecord.
+func (d *Decoder) Decode() (*gournal.Record, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	n, err := binary.ReadUvarint(d.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(MaxSize) {
+		return nil, ErrInvalid
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	m := &Record{}
+	if err := proto.Unmarshal(b, m); err != nil {
+		return nil, ErrInvalid
+	}
+	return ToRecord(m), nil
+}
+
+func toTimestamp(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return &timestamp.Timestamp{
+		Seconds: t.Unix(),
+		Nanos:   int32(t.Nanosecond()),
+	}
+}
+
+func fromTimestamp(ts *timestamp.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC()
+}
+
+func toValue(v interface{}) *Value {
+	switch tv := v.(type) {
+	case nil:
+		return &Value{}
+	case bool:
+		return &Value{Kind: &Value_BoolValue{tv}}
+	case int:
+		return &Value{Kind: &Value_IntValue{int64(tv)}}
+	case int8:
+		return &Value{Kind: &Value_IntValue{int64(tv)}}
+	case int16:
+		return &Value{Kind: &Value_IntValue{int64(tv)}}
+	case int32:
+		return &Value{Kind: &Value_IntValue{int64(tv)}}
+	case int64:
+		return &Value{Kind: &Value_IntValue{tv}}
+	case uint:
+		return &Value{Kind: &Value_UintValue{uint64(tv)}}
+	case uint8:
+		return &Value{Kind: &Value_UintValue{uint64(tv)}}
+	case uint16:
+		return &Value{Kind: &Value_UintValue{uint64(tv)}}
+	case uint32:
+		return &Value{Kind: &Value_UintValue{uint64(tv)}}
+	case uint64:
+		return &Value{Kind: &Value_UintValue{tv}}
+	case float32:
+		return &Value{Kind: &Value_DoubleValue{float64(tv)}}
+	case float64:
+		return &Value{Kind: &Value_DoubleValue{tv}}
+	case string:
+		return &Value{Kind: &Value_StringValue{tv}}
+	case []byte:
+		return &Value{Kind: &Value_BytesValue{tv}}
+	case time.Time:
+		return &Value{Kind: &Value_TimeValue{toTimestamp(tv)}}
+	case error:
+		return &Value{Kind: &Value_StringValue{tv.Error()}}
+	case []interface{}:
+		l := &ListValue{Values: make([]*Value, len(tv))}
+		for i, e := range tv {
+			l.Values[i] = toValue(e)
+		}
+		return &Value{Kind: &Value_ListValue{l}}
+	case map[string]interface{}:
+		m := &MapValue{Fields: make(map[string]*Value, len(tv))}
+		for k, e := range tv {
+			m.Fields[k] = toValue(e)
+		}
+		return &Value{Kind: &Value_MapValue{m}}
+	}
+	return toValue(jsonValue(v))
+}
+
+func fromValue(v *Value) interface{} {
+	switch k := v.GetKind().(type) {
+	case *Value_BoolValue:
+		return k.BoolValue
+	case *Value_IntValue:
+		return k.IntValue
+	case *Value_UintValue:
+		return k.UintValue
+	case *Value_DoubleValue:
+		return k.DoubleValue
+	case *Value_StringValue:
+		return k.StringValue
+	case *Value_BytesValue:
+		return k.BytesValue
+	case *Value_TimeValue:
+		return fromTimestamp(k.TimeValue)
+	case *Value_ListValue:
+		l := make([]interface{}, len(k.ListValue.GetValues()))
+		for i, e := range k.ListValue.GetValues() {
+			l[i] = fromValue(e)
+		}
+		return l
+	case *Value_MapValue:
+		m := make(map[string]interface{}, len(k.MapValue.GetFields()))
+		for key, e := range k.MapValue.GetFields() {
+			m[key] = fromValue(e)
+		}
+		return m
+	}
+	return nil
+}
+
+// jsonValue returns the value of the JSON representation of v, or the
+// string representation of v if it cannot be marshaled to JSON.
+func jsonValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var jv interface{}
+	if err := d.Decode(&jv); err != nil {
+		return fmt.Sprint(v)
+	}
+	return jsonNumbers(jv)
+}
+
+// jsonNumbers replaces the json.Number values in v with integers or
+// floats.
+func jsonNumbers(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case json.Number:
+		if i, err := tv.Int64(); err == nil {
+			return i
+		}
+		f, _ := tv.Float64()
+		return f
+	case []interface{}:
+		for i, e := range tv {
+			tv[i] = jsonNumbers(e)
+		}
+	case map[string]interface{}:
+		for k, e := range tv {
+			tv[k] = jsonNumbers(e)
+		}
+	}
+	return v
+}
+
+// caller returns the frame of the first function on the stack outside of
+// Gournal, or of the first function in a test file.
+func caller() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		fr, more := frames.Next()
+		if !strings.HasPrefix(fr.Function, "github.com/akutz/gournal") ||
+			strings.HasSuffix(fr.File, "_test.go") {
+			return fr, fr.Function != ""
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
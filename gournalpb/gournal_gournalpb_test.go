@@ -0,0 +1,166 @@
+package gournalpb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/channel"
+	"github.com/akutz/gournal/replay"
+)
+
+type point struct {
+	X, Y int
+}
+
+func TestRoundTrip(t *testing.T) {
+	when := time.Date(2017, 10, 1, 12, 0, 0, 123, time.UTC)
+	buf := &bytes.Buffer{}
+	f := NewFormatterWithOptions(false)
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    when,
+		Level:   gournal.WarnLevel,
+		Message: "Hello Bob",
+		Fields: map[string]interface{}{
+			"error":   errors.New("EOF"),
+			"nil":     nil,
+			"bool":    true,
+			"int8":    int8(-100),
+			"uint16":  uint16(60000),
+			"float32": float32(1.5),
+			"string":  "s",
+			"bytes":   []byte{1, 2},
+			"time":    time.Date(1900, 1, 1, 0, 0, 0, 1, time.UTC),
+			"array":   []interface{}{"a", 1},
+			"map":     map[string]interface{}{"k": "v"},
+			"struct":  point{1, 2},
+			"chan":    make(chan int),
+		},
+	}))
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Level:   gournal.ErrorLevel,
+		Message: "Hello Alice",
+	}))
+
+	d := NewDecoder(buf)
+	rec, err := d.Decode()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, when, rec.Time)
+	assert.Equal(t, gournal.WarnLevel, rec.Level)
+	assert.Equal(t, "Hello Bob", rec.Message)
+	assert.Regexp(t, "^0x[0-9a-f]+$", rec.Fields["chan"])
+	delete(rec.Fields, "chan")
+	assert.Equal(t, map[string]interface{}{
+		"error":   "EOF",
+		"nil":     nil,
+		"bool":    true,
+		"int8":    int64(-100),
+		"uint16":  uint64(60000),
+		"float32": 1.5,
+		"string":  "s",
+		"bytes":   []byte{1, 2},
+		"time":    time.Date(1900, 1, 1, 0, 0, 0, 1, time.UTC),
+		"array":   []interface{}{"a", int64(1)},
+		"map":     map[string]interface{}{"k": "v"},
+		"struct":  map[string]interface{}{"X": int64(1), "Y": int64(2)},
+	}, rec.Fields)
+
+	rec, err = d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, &gournal.Record{
+		Level:   gournal.ErrorLevel,
+		Message: "Hello Alice",
+	}, rec)
+
+	_, err = d.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFromRecord(t *testing.T) {
+	m := FromRecord(&gournal.Record{
+		Time:    time.Unix(1, 2),
+		Level:   gournal.DebugLevel,
+		Message: "Hi",
+		Fields:  map[string]interface{}{"error": 1},
+	})
+	b, err := proto.Marshal(m)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	m = &Record{}
+	assert.NoError(t, proto.Unmarshal(b, m))
+	assert.Equal(t, Level_LEVEL_DEBUG, m.Level)
+	assert.Equal(t, int64(1), m.GetTime().GetSeconds())
+	assert.Equal(t, int32(2), m.GetTime().GetNanos())
+	assert.Equal(t, "Hi", m.Message)
+	assert.Empty(t, m.Error)
+	assert.Equal(t, int64(1), m.Fields["error"].GetIntValue())
+}
+
+func TestCaller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(),
+		gournal.NewAppenderWithFormatter(buf, NewFormatter()))
+	gournal.WithError(errors.New("EOF")).Info(ctx, "Hello Bob")
+
+	rec, err := NewDecoder(buf).Decode()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "EOF", rec.Fields["error"])
+	c, ok := rec.Fields["caller"].(map[string]interface{})
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.Regexp(t, `gournal_gournalpb_test\.go$`, c["file"])
+	assert.NotZero(t, c["line"])
+	assert.Equal(t,
+		"github.com/akutz/gournal/gournalpb.TestCaller", c["function"])
+}
+
+func TestDecodeError(t *testing.T) {
+	_, err := NewDecoder(bytes.NewReader([]byte{2, 0xff, 0xff})).Decode()
+	assert.Equal(t, ErrInvalid, err)
+
+	_, err = NewDecoder(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0x7f})).Decode()
+	assert.Equal(t, ErrInvalid, err)
+
+	_, err = NewDecoder(bytes.NewReader([]byte{2, 0x1a})).Decode()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, err = NewDecoder(bytes.NewReader([]byte{0x80})).Decode()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReplay(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, NewFormatterWithOptions(false).Format(
+		buf, &gournal.Record{
+			Time:    time.Unix(0, 1).UTC(),
+			Level:   gournal.InfoLevel,
+			Message: "Hello Bob",
+			Fields:  map[string]interface{}{"size": 1},
+		}))
+
+	ch := make(chan gournal.Record, 1)
+	n, err := replay.Replay(nil, NewDecoder(buf), channel.New(ch))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, gournal.Record{
+		Time:    time.Unix(0, 1).UTC(),
+		Level:   gournal.InfoLevel,
+		Message: "Hello Bob",
+		Fields:  map[string]interface{}{"size": int64(1)},
+	}, <-ch)
+}
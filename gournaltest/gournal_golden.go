@@ -0,0 +1,92 @@
+package gournaltest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// UpdateGoldenEnv is the name of the environment variable that, when set to
+// a true value such as "1", causes AssertGolden to write golden files
+// rather than compare against them.
+var UpdateGoldenEnv = "GOURNAL_UPDATE_GOLDEN"
+
+// update is the -update flag of test binaries that import this package.
+var update = flag.Bool(
+	"update", false, "write golden files rather than compare against them")
+
+// FixedTime is the time of every entry rendered by Render.
+var FixedTime = time.Date(2017, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+// Render returns the output of the entries emitted by fn with the provided
+// Context when they are formatted by the provided Formatter. The Context
+// uses the DEBUG level, and gournal.Clock returns FixedTime while fn runs,
+// so Render must not be used concurrently with other code that emits
+// entries. The function must not emit FATAL or PANIC entries.
+func Render(f gournal.Formatter, fn func(ctx context.Context)) []byte {
+	defer func(c func() time.Time) { gournal.Clock = c }(gournal.Clock)
+	gournal.Clock = func() time.Time { return FixedTime }
+
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(),
+		gournal.NewAppenderWithFormatter(buf, f))
+	fn(ctx)
+	return buf.Bytes()
+}
+
+// AssertGolden asserts that the output of Render matches the contents of
+// the golden file at the provided path. If the UpdateGoldenEnv environment
+// variable is true, or the -update flag is set, ex. "go test -update", the
+// golden file is written with the output instead, so changes to a
+// Formatter's output may be reviewed as diffs of the golden files. A flag
+// is returned indicating whether the assertion succeeded.
+func AssertGolden(
+	t TestingT,
+	path string,
+	f gournal.Formatter,
+	fn func(ctx context.Context)) bool {
+
+	act := Render(f, fn)
+
+	if updateGolden() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Errorf("gournaltest: %v", err)
+			return false
+		}
+		if err := ioutil.WriteFile(path, act, 0644); err != nil {
+			t.Errorf("gournaltest: %v", err)
+			return false
+		}
+		return true
+	}
+
+	exp, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("gournaltest: %v; set %s=1 to create it",
+			err, UpdateGoldenEnv)
+		return false
+	}
+	if !bytes.Equal(exp, act) {
+		t.Errorf("gournaltest: output does not match %s\n"+
+			"expected:\n%s\nactual:\n%s", path, exp, act)
+		return false
+	}
+	return true
+}
+
+// updateGolden returns true if AssertGolden should write golden files.
+func updateGolden() bool {
+	if v, err := strconv.ParseBool(os.Getenv(UpdateGoldenEnv)); err == nil {
+		return v
+	}
+	return *update
+}
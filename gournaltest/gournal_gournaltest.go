@@ -0,0 +1,169 @@
+// Package gournaltest provides an Appender that records entries in memory
+// and helpers for asserting that an application logs what it should.
+package gournaltest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/akutz/gournal"
+)
+
+// TestingT is the subset of *testing.T used by the assertion helpers.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Appender records every entry it receives. Unlike most Appenders, it does
+// not exit the program or panic when it receives FATAL or PANIC entries, so
+// code paths that emit them may be tested.
+type Appender struct {
+	sync.Mutex
+	entries []gournal.Record
+}
+
+// New returns a new Appender.
+func New() *Appender {
+	return &Appender{}
+}
+
+// NewContext returns a new Appender and a Context derived from the provided
+// Context that uses the Appender and the DEBUG level so every entry is
+// recorded.
+func NewContext(ctx context.Context) (context.Context, *Appender) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	a := New()
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+	return ctx, a
+}
+
+// Append records the entry. The fields are copied.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	rec := gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	}
+	if len(fields) > 0 {
+		rec.Fields = make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			rec.Fields[k] = v
+		}
+	}
+
+	a.Lock()
+	a.entries = append(a.entries, rec)
+	a.Unlock()
+}
+
+// Entries returns a copy of the recorded entries, oldest first.
+func (a *Appender) Entries() []gournal.Record {
+	a.Lock()
+	defer a.Unlock()
+	return append([]gournal.Record(nil), a.entries...)
+}
+
+// LastEntry returns the most recently recorded entry or nil if no entries
+// have been recorded.
+func (a *Appender) LastEntry() *gournal.Record {
+	a.Lock()
+	defer a.Unlock()
+	if len(a.entries) == 0 {
+		return nil
+	}
+	rec := a.entries[len(a.entries)-1]
+	return &rec
+}
+
+// Reset removes the recorded entries.
+func (a *Appender) Reset() {
+	a.Lock()
+	defer a.Unlock()
+	a.entries = nil
+}
+
+// AssertLogged asserts that an entry was recorded at the provided level
+// whose message contains msg and whose fields include the provided fields.
+// A flag is returned indicating whether the assertion succeeded.
+func (a *Appender) AssertLogged(
+	t TestingT,
+	lvl gournal.Level,
+	msg string,
+	fields map[string]interface{}) bool {
+
+	entries := a.Entries()
+	for _, rec := range entries {
+		if matches(rec, lvl, msg, fields) {
+			return true
+		}
+	}
+	t.Errorf("gournaltest: no entry matched level=%s msg=%q fields=%v%s",
+		lvl, msg, fields, describe(entries))
+	return false
+}
+
+// AssertNotLogged asserts that no entry was recorded at the provided level
+// whose message contains msg and whose fields include the provided fields.
+// A flag is returned indicating whether the assertion succeeded.
+func (a *Appender) AssertNotLogged(
+	t TestingT,
+	lvl gournal.Level,
+	msg string,
+	fields map[string]interface{}) bool {
+
+	for _, rec := range a.Entries() {
+		if matches(rec, lvl, msg, fields) {
+			t.Errorf("gournaltest: unexpected entry: %s", format(rec))
+			return false
+		}
+	}
+	return true
+}
+
+func matches(
+	rec gournal.Record,
+	lvl gournal.Level,
+	msg string,
+	fields map[string]interface{}) bool {
+
+	if rec.Level != lvl || !strings.Contains(rec.Message, msg) {
+		return false
+	}
+	for k, v := range fields {
+		if av, ok := rec.Fields[k]; !ok || !reflect.DeepEqual(av, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func describe(entries []gournal.Record) string {
+	if len(entries) == 0 {
+		return "; no entries were recorded"
+	}
+	s := "; recorded entries:"
+	for _, rec := range entries {
+		s += "\n\t" + format(rec)
+	}
+	return s
+}
+
+func format(rec gournal.Record) string {
+	if len(rec.Fields) == 0 {
+		return fmt.Sprintf("[%s] %s", rec.Level, rec.Message)
+	}
+	return fmt.Sprintf("[%s] %s %v", rec.Level, rec.Message, rec.Fields)
+}
@@ -0,0 +1,116 @@
+package gournaltest
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAppender(t *testing.T) {
+	ctx, a := NewContext(nil)
+	assert.Nil(t, a.LastEntry())
+
+	gournal.WithField("size", 1).Info(ctx, "Hello Bob")
+	gournal.Debug(ctx, "Hello Mary")
+	gournal.Fatal(ctx, "Goodbye")
+
+	assert.Len(t, a.Entries(), 3)
+	assert.Equal(t, "Goodbye", a.LastEntry().Message)
+	assert.Equal(t, gournal.FatalLevel, a.LastEntry().Level)
+
+	a.AssertLogged(t, gournal.InfoLevel, "Bob", map[string]interface{}{
+		"size": 1,
+	})
+	a.AssertLogged(t, gournal.DebugLevel, "Mary", nil)
+	a.AssertNotLogged(t, gournal.ErrorLevel, "", nil)
+
+	ft := &fakeT{}
+	assert.False(t, a.AssertLogged(
+		ft, gournal.InfoLevel, "Bob", map[string]interface{}{"size": 2}))
+	assert.False(t, a.AssertNotLogged(ft, gournal.DebugLevel, "Mary", nil))
+	if assert.Len(t, ft.errors, 2) {
+		assert.Contains(t, ft.errors[0], "[INFO] Hello Bob map[size:1]")
+		assert.Contains(t, ft.errors[1], "[DEBUG] Hello Mary")
+	}
+
+	a.Reset()
+	assert.Empty(t, a.Entries())
+}
+
+func emitGoldenEntries(ctx context.Context) {
+	gournal.Debug(ctx, "Hello Bob")
+	gournal.WithFields(map[string]interface{}{
+		"size":  1,
+		"color": "red",
+		"html":  "<b>",
+	}).Info(ctx, "Hello %s", "Mary")
+	gournal.WithError(errors.New("failed")).Error(ctx, "Goodbye")
+}
+
+func TestGoldenText(t *testing.T) {
+	AssertGolden(t,
+		filepath.Join("testdata", "text.golden"),
+		gournal.TextFormatter{},
+		emitGoldenEntries)
+}
+
+func TestGoldenJSON(t *testing.T) {
+	AssertGolden(t,
+		filepath.Join("testdata", "json.golden"),
+		gournal.JSONFormatter{},
+		emitGoldenEntries)
+}
+
+func TestGoldenMismatch(t *testing.T) {
+	if updateGolden() {
+		t.Skip()
+	}
+	ft := &fakeT{}
+	assert.False(t, AssertGolden(ft,
+		filepath.Join("testdata", "text.golden"),
+		gournal.TextFormatter{},
+		func(ctx context.Context) { gournal.Info(ctx, "Hello Bob") }))
+	assert.Len(t, ft.errors, 1)
+}
+
+func TestGoldenUpdateEnv(t *testing.T) {
+	defer os.Setenv(UpdateGoldenEnv, os.Getenv(UpdateGoldenEnv))
+
+	os.Setenv(UpdateGoldenEnv, "1")
+	assert.True(t, updateGolden())
+	os.Setenv(UpdateGoldenEnv, "false")
+	assert.False(t, updateGolden())
+}
+
+func TestGoldenUpdateFlag(t *testing.T) {
+	defer os.Setenv(UpdateGoldenEnv, os.Getenv(UpdateGoldenEnv))
+	os.Unsetenv(UpdateGoldenEnv)
+
+	// the flag is defined by this package, so "go test -update" works
+	// without each test package defining it
+	if !assert.NotNil(t, flag.Lookup("update")) {
+		t.FailNow()
+	}
+	defer flag.Set("update", flag.Lookup("update").Value.String())
+
+	assert.NoError(t, flag.Set("update", "true"))
+	assert.True(t, updateGolden())
+	assert.NoError(t, flag.Set("update", "false"))
+	assert.False(t, updateGolden())
+}
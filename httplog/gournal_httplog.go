@@ -0,0 +1,139 @@
+// Package httplog provides net/http middleware that emits structured access
+// log entries using Gournal.
+package httplog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// RequestIDHeader is the name of the header inspected for an incoming
+	// request's ID.
+	RequestIDHeader = "X-Request-Id"
+
+	// Level is the level at which completed requests are logged.
+	Level = gournal.InfoLevel
+
+	// ProfileLabels is a list of field names used to set runtime/pprof labels
+	// for the duration of each request. Fields are resolved from the request's
+	// Context, including the fields added by the Handler, such as "path".
+	// Profiler labels require Go 1.9 and are not set with older versions.
+	ProfileLabels []string
+)
+
+// Handler returns an http.Handler that wraps the next handler. Every
+// request's Context falls back to the provided Context, so the appender,
+// level, fields, pushed fields, and logger name of the provided Context are
+// used along with the request's method, path, remote IP, and request ID.
+// The request's cancellation, deadline, and values are preserved, and its
+// values, such as those set by earlier middleware, take priority over the
+// provided Context's.
+// The request ID is adopted from the RequestIDHeader or generated if the
+// header is absent, and it is returned to the client in the same header.
+// Once the next handler returns, an entry is emitted with the response's
+// status, the number of bytes written, and the request's latency.
+func Handler(ctx context.Context, next http.Handler) http.Handler {
+	return &handler{ctx: ctx, next: next}
+}
+
+type handler struct {
+	ctx  context.Context
+	next http.Handler
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	var ctx context.Context = &requestContext{r.Context(), h.ctx}
+
+	fields := map[string]interface{}{
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		fields["remoteIP"] = ip
+	} else {
+		fields["remoteIP"] = r.RemoteAddr
+	}
+
+	ctx = gournal.WithRequestID(ctx, r.Header.Get(RequestIDHeader))
+	w.Header().Set(RequestIDHeader, gournal.RequestIDFrom(ctx))
+
+	ctx, pop := gournal.PushFields(ctx, fields)
+	defer pop()
+
+	if len(ProfileLabels) > 0 {
+		var restore func()
+		ctx, restore = gournal.SetProfileLabels(ctx, ProfileLabels...)
+		defer restore()
+	}
+
+	rw := &responseWriter{ResponseWriter: w}
+	h.next.ServeHTTP(rw, r.WithContext(ctx))
+
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+
+	gournal.WithFields(map[string]interface{}{
+		"status":  rw.status,
+		"bytes":   rw.bytes,
+		"latency": time.Since(start),
+	}).(gournal.LevelEntry).Log(ctx, Level, "request completed")
+}
+
+// requestContext is a request's Context whose values are looked up in the
+// handler's Context when the request's Context does not have them.
+type requestContext struct {
+	context.Context
+	base context.Context
+}
+
+func (c *requestContext) Value(key interface{}) interface{} {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.base.Value(key)
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("httplog: ResponseWriter is not a Hijacker")
+}
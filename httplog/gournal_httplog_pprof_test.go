@@ -0,0 +1,35 @@
+//go:build go1.9
+// +build go1.9
+
+package httplog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestHandlerProfileLabels(t *testing.T) {
+	ProfileLabels = []string{"method", "path"}
+	defer func() { ProfileLabels = nil }()
+
+	var method, path string
+	h := Handler(context.Background(), http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			method, _ = pprof.Label(r.Context(), "method")
+			path, _ = pprof.Label(r.Context(), "path")
+		}))
+
+	ctx := context.WithValue(
+		context.Background(), gournal.AppenderKey(), &testAppender{})
+	req := httptest.NewRequest("PUT", "/volumes", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+	assert.Equal(t, "PUT", method)
+	assert.Equal(t, "/volumes", path)
+}
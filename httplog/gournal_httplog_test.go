@@ -0,0 +1,149 @@
+package httplog
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type testAppender struct {
+	entries []testEntry
+}
+
+type testEntry struct {
+	lvl    gournal.Level
+	fields map[string]interface{}
+	msg    string
+}
+
+func (a *testAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.entries = append(a.entries, testEntry{lvl, fields, msg})
+}
+
+func TestHandler(t *testing.T) {
+	a := &testAppender{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+
+	h := Handler(ctx, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gournal.Info(r.Context(), "Hello %s", "Bob")
+			w.WriteHeader(http.StatusTeapot)
+			io.WriteString(w, "short and stout")
+		}))
+
+	req := httptest.NewRequest("GET", "/teapot", nil)
+	req.Header.Set(RequestIDHeader, "1234")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, "1234", rec.Header().Get(RequestIDHeader))
+
+	if !assert.Len(t, a.entries, 2) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, "Hello Bob", a.entries[0].msg)
+	assert.Equal(t, "GET", a.entries[0].fields["method"])
+	assert.Equal(t, "/teapot", a.entries[0].fields["path"])
+	assert.Equal(t, "192.0.2.1", a.entries[0].fields["remoteIP"])
+	assert.Equal(t, "1234", a.entries[0].fields["requestID"])
+
+	assert.Equal(t, gournal.InfoLevel, a.entries[1].lvl)
+	assert.Equal(t, "request completed", a.entries[1].msg)
+	assert.Equal(t, http.StatusTeapot, a.entries[1].fields["status"])
+	assert.Equal(t, int64(15), a.entries[1].fields["bytes"])
+	assert.IsType(t, time.Duration(0), a.entries[1].fields["latency"])
+	assert.Equal(t, "1234", a.entries[1].fields["requestID"])
+}
+
+func TestHandlerBaseContext(t *testing.T) {
+	a := &testAppender{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	ctx = context.WithValue(ctx, gournal.NameKey(), "api")
+	ctx, pop := gournal.PushFields(
+		ctx, map[string]interface{}{"component": "volumes"})
+	defer pop()
+
+	var (
+		name   string
+		reqErr error
+	)
+	h := Handler(ctx, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			name = gournal.NameFrom(r.Context())
+			reqErr = r.Context().Err()
+		}))
+
+	// the request's cancellation is preserved
+	rctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("GET", "/", nil).WithContext(rctx)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "api", name)
+	assert.Equal(t, context.Canceled, reqErr)
+	if assert.Len(t, a.entries, 1) {
+		assert.Equal(t, "volumes", a.entries[0].fields["component"])
+		assert.Equal(t, "GET", a.entries[0].fields["method"])
+	}
+}
+
+func TestHandlerRequestContextPriority(t *testing.T) {
+	a := &testAppender{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	ctx = context.WithValue(ctx, gournal.NameKey(), "api")
+
+	var name string
+	h := Handler(ctx, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			name = gournal.NameFrom(r.Context())
+		}))
+
+	// the values set on the request's Context by earlier middleware are
+	// not shadowed by the handler's Context
+	rctx := context.WithValue(
+		context.Background(), gournal.NameKey(), "volumes")
+	req := httptest.NewRequest("GET", "/", nil).WithContext(rctx)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "volumes", name)
+	assert.Len(t, a.entries, 1)
+}
+
+func TestHandlerGeneratesRequestID(t *testing.T) {
+	a := &testAppender{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+
+	var id string
+	h := Handler(ctx, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			id = gournal.RequestIDFrom(r.Context())
+		}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.NotEmpty(t, id)
+	assert.Equal(t, id, rec.Header().Get(RequestIDHeader))
+	if assert.Len(t, a.entries, 1) {
+		assert.Equal(t, id, a.entries[0].fields["requestID"])
+	}
+}
@@ -0,0 +1,296 @@
+// Package httppost provides a Gournal Appender that POSTs entries as JSON
+// gournal.Record objects to an HTTP endpoint, for collectors that do not
+// have a dedicated Appender.
+//
+// With a batch size of one, each entry is sent as a JSON object. Otherwise
+// entries are buffered with a gournal.Batcher and each batch is sent as a
+// JSON array.
+package httppost
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// BatchSize is the maximum number of entries sent in one request by an
+	// Appender returned by New.
+	BatchSize = 100
+
+	// Interval is the amount of time after which entries buffered by an
+	// Appender returned by New are sent.
+	Interval = time.Second
+
+	// Concurrency is the maximum number of concurrent requests made by an
+	// Appender returned by New.
+	Concurrency = 4
+
+	// MaxAttempts is the maximum number of attempts made to send a request.
+	MaxAttempts = 5
+
+	// MinBackoff is the delay before the first retry of a request.
+	MinBackoff = 100 * time.Millisecond
+
+	// MaxBackoff is the maximum delay between retries of a request.
+	MaxBackoff = 10 * time.Second
+)
+
+// sleep is replaced by tests
+var sleep = time.Sleep
+
+var (
+	rnd   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	rndMu sync.Mutex
+)
+
+// HeaderData is the data with which header templates are executed. The
+// templates may also call the "env" function, which returns the value of
+// an environment variable, ex. "Bearer {{env \"TOKEN\"}}".
+type HeaderData struct {
+
+	// Records are the entries sent in the request.
+	Records []gournal.Record
+}
+
+// Appender is an HTTP POST Appender.
+type Appender struct {
+	batcher *gournal.Batcher
+	p       *poster
+}
+
+type poster struct {
+	client  *http.Client
+	url     string
+	headers map[string]*template.Template
+	gzip    bool
+	single  bool
+
+	sem chan struct{}
+	wg  sync.WaitGroup
+}
+
+// New returns an Appender that sends entries to the provided URL with
+// http.DefaultClient, using BatchSize, Interval, and Concurrency, and
+// without compression.
+func New(url string) *Appender {
+	a, _ := NewWithOptions(
+		http.DefaultClient, url, nil, false, BatchSize, Interval, Concurrency)
+	return a
+}
+
+// NewWithOptions returns an Appender that sends entries to the provided URL
+// with the provided client. Each header value is a text/template executed
+// with a HeaderData for every request; an error is returned if one cannot
+// be parsed. If gzipped is true, requests are gzip-compressed.
+//
+// Entries are sent in batches of up to batchSize entries or, if the
+// interval is greater than zero, each time the interval elapses. Up to
+// concurrency requests are made at once. Requests that fail with a network
+// error or a 429 or 5xx status are retried up to MaxAttempts times with
+// exponential backoff between MinBackoff and MaxBackoff. Requests that
+// cannot be sent are reported with gournal.HandleError and their entries
+// are counted with gournal.RecordDropped.
+//
+// Buffered and in-flight requests are completed before FATAL and PANIC
+// entries exit or panic.
+func NewWithOptions(
+	client *http.Client,
+	url string,
+	headers map[string]string,
+	gzipped bool,
+	batchSize int,
+	interval time.Duration,
+	concurrency int) (*Appender, error) {
+
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	p := &poster{
+		client:  client,
+		url:     url,
+		headers: make(map[string]*template.Template, len(headers)),
+		gzip:    gzipped,
+		single:  batchSize == 1,
+		sem:     make(chan struct{}, concurrency),
+	}
+	funcs := template.FuncMap{"env": os.Getenv}
+	for k, v := range headers {
+		t, err := template.New(k).Funcs(funcs).Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		p.headers[k] = t
+	}
+	return &Appender{gournal.NewBatcher(p, batchSize, interval), p}, nil
+}
+
+// Append buffers the entry.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.batcher.Append(ctx, lvl, fields, msg)
+}
+
+// Flush sends any buffered entries and waits for in-flight requests.
+func (a *Appender) Flush() {
+	a.batcher.Flush()
+	a.p.wg.Wait()
+}
+
+// Close sends any buffered entries and waits for in-flight requests.
+func (a *Appender) Close() error {
+	a.batcher.Close()
+	a.p.wg.Wait()
+	return nil
+}
+
+// AppendBatch encodes the records and sends them in the background once
+// fewer than the maximum number of requests are in flight.
+func (p *poster) AppendBatch(recs []gournal.Record) {
+	req, err := p.newRequest(recs)
+	if err != nil {
+		gournal.HandleError(err)
+		gournal.RecordDropped(len(recs))
+		return
+	}
+
+	p.sem <- struct{}{}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.sem }()
+		if err := p.send(req); err != nil {
+			gournal.HandleError(err)
+			gournal.RecordDropped(len(recs))
+		}
+	}()
+
+	// the Batcher exits or panics after a batch with a FATAL or PANIC
+	// entry is delivered, so every request must be complete
+	for i := range recs {
+		if recs[i].Level <= gournal.FatalLevel {
+			p.wg.Wait()
+			return
+		}
+	}
+}
+
+// request is an encoded request that may be sent more than once.
+type request struct {
+	body   []byte
+	header http.Header
+}
+
+func (p *poster) newRequest(recs []gournal.Record) (*request, error) {
+	var v interface{} = recs
+	if p.single {
+		v = &recs[0]
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		// use the string form of fields that cannot be marshaled
+		for i := range recs {
+			fields := make(map[string]interface{}, len(recs[i].Fields))
+			for k, v := range recs[i].Fields {
+				fields[k] = fmt.Sprint(v)
+			}
+			recs[i].Fields = fields
+		}
+		if body, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &request{header: http.Header{}}
+	req.header.Set("Content-Type", "application/json")
+	for k, t := range p.headers {
+		buf := &bytes.Buffer{}
+		if err := t.Execute(buf, HeaderData{recs}); err != nil {
+			return nil, err
+		}
+		req.header.Set(k, buf.String())
+	}
+
+	if !p.gzip {
+		req.body = body
+		return req, nil
+	}
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	gz.Write(body)
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	req.body = buf.Bytes()
+	req.header.Set("Content-Encoding", "gzip")
+	return req, nil
+}
+
+// send posts the request, retrying failures that may be temporary.
+func (p *poster) send(req *request) error {
+	backoff := MinBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := p.post(req)
+		if err == nil || !retry || attempt >= MaxAttempts {
+			return err
+		}
+		sleep(jitter(backoff))
+		if backoff *= 2; backoff > MaxBackoff {
+			backoff = MaxBackoff
+		}
+	}
+}
+
+// post posts the request and returns any error and a flag indicating
+// whether the request may be retried.
+func (p *poster) post(req *request) (bool, error) {
+	r, err := http.NewRequest("POST", p.url, bytes.NewReader(req.body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range req.header {
+		r.Header[k] = v
+	}
+
+	res, err := p.client.Do(r)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	if res.StatusCode/100 == 2 {
+		return false, nil
+	}
+	err = fmt.Errorf(
+		"httppost: %s: %s", res.Status, bytes.TrimSpace(msg))
+	return res.StatusCode == http.StatusTooManyRequests ||
+		res.StatusCode >= 500, err
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	rndMu.Lock()
+	defer rndMu.Unlock()
+	return time.Duration(rnd.Int63n(int64(d) + 1))
+}
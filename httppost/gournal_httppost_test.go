@@ -0,0 +1,161 @@
+package httppost
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(),
+		time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC))
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestHTTPPostAppenderSingle(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			buf, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(buf))
+		}))
+	defer srv.Close()
+
+	a, err := NewWithOptions(http.DefaultClient, srv.URL, nil, false, 1, 0, 1)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	gournal.WithField("size", 1).Info(newContext(a), "Hello %s", "Bob")
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, []string{
+		`{"time":"2017-10-01T12:00:00Z","level":"INFO",` +
+			`"msg":"Hello Bob","fields":{"size":1}}`,
+	}, bodies)
+}
+
+func TestHTTPPostAppenderBatch(t *testing.T) {
+	os.Setenv("GOURNAL_HTTPPOST_TOKEN", "secret")
+	defer os.Unsetenv("GOURNAL_HTTPPOST_TOKEN")
+
+	var (
+		header http.Header
+		recs   []gournal.Record
+	)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header
+			gz, err := gzip.NewReader(r.Body)
+			if assert.NoError(t, err) {
+				assert.NoError(t, json.NewDecoder(gz).Decode(&recs))
+			}
+		}))
+	defer srv.Close()
+
+	a, err := NewWithOptions(http.DefaultClient, srv.URL, map[string]string{
+		"Authorization": `Bearer {{env "GOURNAL_HTTPPOST_TOKEN"}}`,
+		"X-Count":       `{{len .Records}}`,
+	}, true, 10, 0, 1)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	gournal.Warn(ctx, "Hello Alice")
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"))
+	assert.Equal(t, "2", header.Get("X-Count"))
+	assert.Equal(t, "gzip", header.Get("Content-Encoding"))
+	if assert.Len(t, recs, 2) {
+		assert.Equal(t, "Hello Alice", recs[1].Message)
+	}
+}
+
+func TestHTTPPostAppenderBadHeader(t *testing.T) {
+	_, err := NewWithOptions(http.DefaultClient, "http://localhost",
+		map[string]string{"X-Bad": "{{"}, false, 1, 0, 1)
+	assert.Error(t, err)
+}
+
+func TestHTTPPostAppenderRetry(t *testing.T) {
+	defer func(f func(time.Duration)) { sleep = f }(sleep)
+	sleep = func(time.Duration) {}
+
+	var errs []error
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { errs = append(errs, err) }
+
+	var attempts int
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(status)
+			}
+		}))
+	defer srv.Close()
+
+	a := New(srv.URL)
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	a.Flush()
+	assert.Equal(t, 3, attempts)
+	assert.Empty(t, errs)
+
+	// client errors are not retried
+	attempts, status = 0, http.StatusBadRequest
+	gournal.Info(ctx, "Hello Alice")
+	assert.NoError(t, a.Close())
+	assert.Equal(t, 1, attempts)
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "httppost: 400 Bad Request: ")
+	}
+}
+
+func TestHTTPPostAppenderConcurrency(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		max      int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			if inFlight++; inFlight > max {
+				max = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}))
+	defer srv.Close()
+
+	a, err := NewWithOptions(http.DefaultClient, srv.URL, nil, false, 1, 0, 2)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	for i := 0; i < 6; i++ {
+		gournal.Info(ctx, "Hello Bob")
+	}
+	assert.NoError(t, a.Close())
+	assert.Equal(t, 2, max)
+}
@@ -0,0 +1,221 @@
+// Package kafka provides a Gournal Appender that produces entries as
+// messages to a Kafka topic.
+//
+// This package does not import a Kafka client. Instead, callers provide a
+// Producer, for example one backed by a sarama.SyncProducer:
+//
+//	type producer struct{ sarama.SyncProducer }
+//
+//	func (p producer) Produce(
+//		ctx context.Context, msgs []kafka.Message) error {
+//
+//		pms := make([]*sarama.ProducerMessage, len(msgs))
+//		for i, m := range msgs {
+//			pms[i] = &sarama.ProducerMessage{
+//				Topic: m.Topic,
+//				Key:   sarama.ByteEncoder(m.Key),
+//				Value: sarama.ByteEncoder(m.Value),
+//			}
+//		}
+//		return p.SendMessages(pms)
+//	}
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// KeyField is the name of the field whose value is used as the message
+	// key by an Appender returned by New. Entries without the field, or all
+	// entries if KeyField is empty, are produced without a key and so are
+	// distributed across the topic's partitions by the Producer.
+	KeyField string
+
+	// BatchSize is the number of entries buffered by an Appender returned by
+	// New before they are produced. A value of zero produces every entry
+	// synchronously.
+	BatchSize int
+
+	// Interval is the amount of time after which entries buffered by an
+	// Appender returned by New are produced.
+	Interval = time.Second
+)
+
+// Message is a Kafka message.
+type Message struct {
+	Topic string
+	Key   []byte
+	Value []byte
+}
+
+// Producer produces messages to Kafka. A Producer that also implements
+// io.Closer is closed when the Appender is closed.
+type Producer interface {
+	Produce(ctx context.Context, msgs []Message) error
+}
+
+// Encoder encodes a Record as the value of a message.
+type Encoder func(rec *gournal.Record) ([]byte, error)
+
+// JSONEncoder encodes a Record as a JSON object. It is the default Encoder.
+func JSONEncoder(rec *gournal.Record) ([]byte, error) {
+	return json.Marshal(rec)
+}
+
+// Appender is a Kafka Appender.
+type Appender struct {
+	p        Producer
+	topic    string
+	keyField string
+	enc      Encoder
+	batcher  *gournal.Batcher
+}
+
+// New returns an Appender that produces entries to the provided topic
+// using KeyField, JSONEncoder, BatchSize, and Interval.
+func New(p Producer, topic string) *Appender {
+	return NewWithOptions(p, topic, KeyField, JSONEncoder, BatchSize, Interval)
+}
+
+// NewWithOptions returns an Appender that produces entries to the provided
+// topic, encoded with enc and keyed by the value of the keyField field,
+// which is formatted with fmt.Sprint.
+//
+// If batchSize is zero, entries are produced synchronously and errors are
+// reported with gournal.HandleError, or returned by TryAppend. Otherwise,
+// entries are buffered and produced in batches of up to batchSize entries
+// or, if the interval is greater than zero, each time the interval
+// elapses. Buffered entries are produced before FATAL and PANIC entries.
+func NewWithOptions(
+	p Producer,
+	topic, keyField string,
+	enc Encoder,
+	batchSize int,
+	interval time.Duration) *Appender {
+
+	if enc == nil {
+		enc = JSONEncoder
+	}
+	a := &Appender{p: p, topic: topic, keyField: keyField, enc: enc}
+	if batchSize > 0 {
+		a.batcher = gournal.NewBatcher(
+			batchProducer{a}, batchSize, interval)
+	}
+	return a
+}
+
+// Append produces the entry or, if the Appender is batching, buffers it.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if a.batcher != nil {
+		a.batcher.Append(ctx, lvl, fields, msg)
+		return
+	}
+
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+// TryAppend produces the entry and returns any error that occurs. If the
+// Appender is batching, the entry is buffered and nil is returned.
+func (a *Appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	if a.batcher != nil {
+		a.batcher.Append(ctx, lvl, fields, msg)
+		return nil
+	}
+
+	m, err := a.message(&gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	})
+	if err != nil {
+		return err
+	}
+	return a.p.Produce(ctx, []Message{m})
+}
+
+func (a *Appender) message(rec *gournal.Record) (Message, error) {
+	m := Message{Topic: a.topic}
+	if a.keyField != "" {
+		if v, ok := rec.Fields[a.keyField]; ok {
+			m.Key = []byte(fmt.Sprint(v))
+		}
+	}
+	var err error
+	m.Value, err = a.enc(rec)
+	return m, err
+}
+
+// Flush produces any buffered entries.
+func (a *Appender) Flush() {
+	if a.batcher != nil {
+		a.batcher.Flush()
+	}
+}
+
+// Close produces any buffered entries and, if the Producer implements
+// io.Closer, closes the Producer.
+func (a *Appender) Close() error {
+	if a.batcher != nil {
+		a.batcher.Close()
+	}
+	if c, ok := a.p.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// batchProducer implements gournal.BatchAppender for an Appender's Batcher
+// without exporting AppendBatch from the Appender.
+type batchProducer struct {
+	a *Appender
+}
+
+func (b batchProducer) AppendBatch(recs []gournal.Record) {
+	msgs := make([]Message, 0, len(recs))
+	for i := range recs {
+		m, err := b.a.message(&recs[i])
+		if err != nil {
+			gournal.HandleError(err)
+			gournal.RecordDropped(1)
+			continue
+		}
+		msgs = append(msgs, m)
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	if err := b.a.p.Produce(context.Background(), msgs); err != nil {
+		gournal.HandleError(err)
+		gournal.RecordDropped(len(msgs))
+	}
+}
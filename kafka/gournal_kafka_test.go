@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type testProducer struct {
+	sync.Mutex
+	batches [][]Message
+	err     error
+	closed  bool
+}
+
+func (p *testProducer) Produce(ctx context.Context, msgs []Message) error {
+	p.Lock()
+	defer p.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.batches = append(p.batches, msgs)
+	return nil
+}
+
+func (p *testProducer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(),
+		time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC))
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestKafkaAppenderSync(t *testing.T) {
+	p := &testProducer{}
+	a := NewWithOptions(p, "logs", "tenant", nil, 0, 0)
+	ctx := newContext(a)
+
+	gournal.WithField("tenant", "acme").Info(ctx, "Hello %s", "Bob")
+	gournal.Info(ctx, "Hello Alice")
+
+	if assert.Len(t, p.batches, 2) {
+		m := p.batches[0][0]
+		assert.Equal(t, "logs", m.Topic)
+		assert.Equal(t, "acme", string(m.Key))
+		assert.Equal(
+			t,
+			`{"time":"2017-10-01T12:00:00Z","level":"INFO",`+
+				`"msg":"Hello Bob","fields":{"tenant":"acme"}}`,
+			string(m.Value))
+		assert.Nil(t, p.batches[1][0].Key)
+	}
+
+	assert.NoError(t, a.Close())
+	assert.True(t, p.closed)
+}
+
+func TestKafkaAppenderTryAppend(t *testing.T) {
+	p := &testProducer{err: errors.New("no brokers")}
+	a := New(p, "logs")
+	err := gournal.TryAppend(
+		a, newContext(a), gournal.InfoLevel, nil, "Hello")
+	assert.EqualError(t, err, "no brokers")
+}
+
+func TestKafkaAppenderEncoder(t *testing.T) {
+	p := &testProducer{}
+	enc := func(rec *gournal.Record) ([]byte, error) {
+		return []byte(rec.Message), nil
+	}
+	a := NewWithOptions(p, "logs", "", enc, 0, 0)
+	gournal.Info(newContext(a), "Hello Bob")
+	if assert.Len(t, p.batches, 1) {
+		assert.Equal(t, "Hello Bob", string(p.batches[0][0].Value))
+	}
+}
+
+func TestKafkaAppenderAsync(t *testing.T) {
+	p := &testProducer{}
+	a := NewWithOptions(p, "logs", "host", nil, 2, 0)
+	ctx := newContext(a)
+
+	gournal.WithField("host", "a").Info(ctx, "Hello Bob")
+	assert.Len(t, p.batches, 0)
+	gournal.WithField("host", "b").Info(ctx, "Hello Alice")
+	gournal.Info(ctx, "Hello Mary")
+	if assert.Len(t, p.batches, 1) && assert.Len(t, p.batches[0], 2) {
+		assert.Equal(t, "a", string(p.batches[0][0].Key))
+		assert.Equal(t, "b", string(p.batches[0][1].Key))
+	}
+
+	assert.NoError(t, a.Close())
+	if assert.Len(t, p.batches, 2) {
+		assert.Len(t, p.batches[1], 1)
+	}
+	assert.True(t, p.closed)
+}
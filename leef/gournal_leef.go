@@ -0,0 +1,211 @@
+// Package leef provides a Gournal Formatter that formats entries in the IBM
+// Log Event Extended Format (LEEF) 2.0, which is required by QRadar:
+//
+//	LEEF:2.0|Acme|Storage|1.0|volume.attach|x09|devTime=...	sev=3	usrName=bob
+//
+// Like CEF, LEEF messages are usually delivered over syslog, for example
+// with the socket package.
+package leef
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/gournal"
+)
+
+// Header contains the header fields of LEEF messages that are the same for
+// every entry.
+type Header struct {
+
+	// Vendor is the vendor of the product, ex. "Acme".
+	Vendor string
+
+	// Product is the name of the product, ex. "Storage".
+	Product string
+
+	// Version is the version of the product, ex. "1.0".
+	Version string
+}
+
+// DevTimeFormat is the format of the devTime attribute, which corresponds
+// to the devTimeFormat "MMM dd yyyy HH:mm:ss.SSS z".
+const DevTimeFormat = "Jan 02 2006 15:04:05.000 MST"
+
+var (
+	// EventIDKey is the name of the field whose value is the Event ID of
+	// the LEEF messages written by a Formatter returned by NewFormatter.
+	// The entry's level is used if the field is missing.
+	EventIDKey = "event"
+
+	// Attributes maps the names of fields to the LEEF attributes, ex.
+	// "user" to "usrName", with which they are written by a Formatter
+	// returned by NewFormatter. Other fields are written with their own
+	// names, without the characters that are not valid in attribute names.
+	Attributes map[string]string
+
+	// Delimiter is the character that separates the attributes of the LEEF
+	// messages written by a Formatter returned by NewFormatter.
+	Delimiter = '\t'
+)
+
+// Formatter is a LEEF Formatter.
+type Formatter struct {
+	hdr     Header
+	eventID string
+	attrs   map[string]string
+	delim   rune
+}
+
+// New returns an Appender that writes LEEF messages to w with a Formatter
+// returned by NewFormatter.
+func New(w io.Writer, hdr Header) gournal.Appender {
+	return gournal.NewAppenderWithFormatter(w, NewFormatter(hdr))
+}
+
+// NewFormatter returns a Formatter that writes LEEF messages with the
+// provided Header using EventIDKey, Attributes, and Delimiter.
+func NewFormatter(hdr Header) *Formatter {
+	return NewFormatterWithOptions(hdr, EventIDKey, Attributes, Delimiter)
+}
+
+// NewFormatterWithOptions returns a Formatter that writes LEEF messages with
+// the provided Header and attributes separated by delim. The Event ID of
+// each message is the value of the field named by eventIDKey, or the
+// entry's level if it is missing.
+//
+// The entry's time is written as the devTime attribute, its level as the
+// sev attribute, its message as the msg attribute, and its fields, other
+// than the one named by eventIDKey, as attributes with the names they are
+// mapped to by attrs or with their own names, in the order returned by
+// gournal.SortKeys. Occurrences
+// of the delimiter and line breaks in values are replaced with spaces,
+// since LEEF does not allow them to be escaped.
+func NewFormatterWithOptions(
+	hdr Header,
+	eventIDKey string,
+	attrs map[string]string,
+	delim rune) *Formatter {
+
+	f := &Formatter{
+		hdr:     hdr,
+		eventID: eventIDKey,
+		attrs:   make(map[string]string, len(attrs)),
+		delim:   delim,
+	}
+	for k, v := range attrs {
+		f.attrs[k] = v
+	}
+	return f
+}
+
+// Format writes the Record to the buffer.
+func (f *Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	id := strings.ToLower(rec.Level.String())
+	if v, ok := rec.Fields[f.eventID]; ok {
+		id = fmt.Sprint(v)
+	}
+
+	buf.WriteString("LEEF:2.0|")
+	for _, s := range []string{
+		f.hdr.Vendor, f.hdr.Product, f.hdr.Version, id,
+	} {
+		writeHeader(buf, s)
+		buf.WriteByte('|')
+	}
+	if f.delim < 0x21 || f.delim > 0x7e {
+		fmt.Fprintf(buf, "x%02X", f.delim)
+	} else {
+		buf.WriteRune(f.delim)
+	}
+	buf.WriteByte('|')
+
+	type attr struct{ k, v string }
+	attrs := make([]attr, 0, len(rec.Fields)+4)
+	if !rec.Time.IsZero() {
+		attrs = append(attrs,
+			attr{"devTime", rec.Time.Format(DevTimeFormat)},
+			attr{"devTimeFormat", "MMM dd yyyy HH:mm:ss.SSS z"})
+	}
+	attrs = append(attrs,
+		attr{"sev", strconv.Itoa(Severity(rec.Level))},
+		attr{"msg", rec.Message})
+	for _, k := range gournal.SortKeys(rec.Fields) {
+		if k == f.eventID {
+			continue
+		}
+		v := rec.Fields[k]
+		if mk, ok := f.attrs[k]; ok {
+			k = mk
+		}
+		if k = attributeName(k); k == "" {
+			continue
+		}
+		attrs = append(attrs, attr{k, fmt.Sprint(v)})
+	}
+	for i, a := range attrs {
+		if i > 0 {
+			buf.WriteRune(f.delim)
+		}
+		buf.WriteString(a.k)
+		buf.WriteByte('=')
+		for _, c := range a.v {
+			if c == f.delim || c == '\r' || c == '\n' {
+				c = ' '
+			}
+			buf.WriteRune(c)
+		}
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// Severity returns the LEEF severity, from 1 to 10, of the level, or the
+// one returned by gournal.SeverityMapper for the "leef" backend.
+func Severity(lvl gournal.Level) int {
+	if n, ok := gournal.MapSeverityInt("leef", lvl); ok {
+		return n
+	}
+	switch lvl {
+	case gournal.PanicLevel:
+		return 10
+	case gournal.FatalLevel:
+		return 9
+	case gournal.ErrorLevel:
+		return 7
+	case gournal.WarnLevel:
+		return 5
+	case gournal.InfoLevel:
+		return 3
+	}
+	return 1
+}
+
+// writeHeader writes a header field, escaping backslashes and pipes and
+// replacing line breaks, which are not allowed, with spaces.
+func writeHeader(buf *bytes.Buffer, s string) {
+	for _, c := range s {
+		switch c {
+		case '\\', '|':
+			buf.WriteByte('\\')
+		case '\r', '\n':
+			c = ' '
+		}
+		buf.WriteRune(c)
+	}
+}
+
+// attributeName returns the name without the characters that are not valid
+// in attribute names, which are letters, digits, and underscores.
+func attributeName(k string) string {
+	return strings.Map(func(c rune) rune {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			c >= '0' && c <= '9' {
+			return c
+		}
+		return -1
+	}, k)
+}
@@ -0,0 +1,49 @@
+package leef
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := NewFormatterWithOptions(Header{
+		Vendor:  "Acme|Corp",
+		Product: "Storage",
+		Version: "1.0",
+	}, "event", map[string]string{"user": "usrName"}, '^')
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:   gournal.WarnLevel,
+		Message: "Hello^Bob",
+		Fields: map[string]interface{}{
+			"event":    "volume.attach",
+			"user":     "bob",
+			"query":    "a=b\nc",
+			"http.url": "/v1",
+		},
+	}))
+	assert.Equal(t,
+		`LEEF:2.0|Acme\|Corp|Storage|1.0|volume.attach|^|`+
+			`devTime=Oct 01 2017 12:00:00.000 UTC^`+
+			`devTimeFormat=MMM dd yyyy HH:mm:ss.SSS z^`+
+			`sev=5^msg=Hello Bob^httpurl=/v1^query=a=b c^usrName=bob`+"\n",
+		buf.String())
+}
+
+func TestNew(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := New(buf, Header{Vendor: "Acme", Product: "Storage", Version: "1"})
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	gournal.Error(ctx, "Hello Bob")
+	assert.Regexp(t,
+		"^LEEF:2.0\\|Acme\\|Storage\\|1\\|error\\|x09\\|devTime=.*\t"+
+			"devTimeFormat=.*\tsev=7\tmsg=Hello Bob\n$",
+		buf.String())
+}
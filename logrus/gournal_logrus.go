@@ -1,9 +1,15 @@
 // Package logrus provides a Logrus logger that implements the Gournal Appender
 // interface.
+//
+// This package uses the vendored github.com/sirupsen/logrus v1.0.3, which
+// predates Logger.ReportCaller and Logger.ExitFunc. FATAL entries exit the
+// program with logrus.Exit, which runs any handlers registered with
+// logrus.RegisterExitHandler.
 package logrus
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/sirupsen/logrus"
@@ -11,13 +17,25 @@ import (
 	"github.com/akutz/gournal"
 )
 
+// Levels is the default mapping of Gournal levels to logrus levels used by
+// the Appenders created with New, NewWithOptions, and NewWithLogger.
+var Levels = map[gournal.Level]logrus.Level{
+	gournal.PanicLevel: logrus.PanicLevel,
+	gournal.FatalLevel: logrus.FatalLevel,
+	gournal.ErrorLevel: logrus.ErrorLevel,
+	gournal.WarnLevel:  logrus.WarnLevel,
+	gournal.InfoLevel:  logrus.InfoLevel,
+	gournal.DebugLevel: logrus.DebugLevel,
+}
+
 type appender struct {
 	logger *logrus.Logger
+	levels map[gournal.Level]logrus.Level
 }
 
 // New returns a logrus logger that implements the Gournal Appender interface.
 func New() gournal.Appender {
-	return &appender{logrus.New()}
+	return &appender{logrus.New(), Levels}
 }
 
 // NewWithOptions returns a logrus logger that implements the Gournal Appender
@@ -27,7 +45,45 @@ func NewWithOptions(
 	lvl logrus.Level,
 	formatter logrus.Formatter) gournal.Appender {
 
-	return &appender{&logrus.Logger{Out: out, Level: lvl, Formatter: formatter}}
+	logger := logrus.New()
+	logger.Out = out
+	logger.Level = lvl
+	logger.Formatter = formatter
+	return &appender{logger, Levels}
+}
+
+// NewWithLogger returns a Gournal Appender that uses the provided logrus
+// logger, including its output, level, formatter, and hooks. This allows an
+// application's configured logger to be reused.
+func NewWithLogger(logger *logrus.Logger) gournal.Appender {
+	return &appender{logger, Levels}
+}
+
+// NewWithLevels returns a Gournal Appender that uses the provided logrus
+// logger and maps Gournal levels to logrus levels with the provided map
+// instead of Levels. For example, mapping gournal.PanicLevel to
+// logrus.ErrorLevel prevents logrus from panicking when the caller is
+// already handling the panic.
+//
+// An error is returned if a Gournal level from PANIC through DEBUG is not
+// mapped or is mapped to an invalid logrus level. The map is copied.
+func NewWithLevels(
+	logger *logrus.Logger,
+	levels map[gournal.Level]logrus.Level) (gournal.Appender, error) {
+
+	m := make(map[gournal.Level]logrus.Level, len(levels))
+	for lvl := gournal.PanicLevel; lvl <= gournal.DebugLevel; lvl++ {
+		v, ok := levels[lvl]
+		if !ok {
+			return nil, fmt.Errorf("logrus: unmapped level %s", lvl)
+		}
+		if v > logrus.DebugLevel {
+			return nil, fmt.Errorf(
+				"logrus: invalid level %d for %s", v, lvl)
+		}
+		m[lvl] = v
+	}
+	return &appender{logger, m}, nil
 }
 
 func (a *appender) Append(
@@ -36,36 +92,30 @@ func (a *appender) Append(
 	fields map[string]interface{},
 	msg string) {
 
-	if len(fields) == 0 {
-		switch lvl {
-		case gournal.DebugLevel:
-			a.logger.Debugf(msg)
-		case gournal.InfoLevel:
-			a.logger.Infof(msg)
-		case gournal.WarnLevel:
-			a.logger.Warnf(msg)
-		case gournal.ErrorLevel:
-			a.logger.Errorf(msg)
-		case gournal.FatalLevel:
-			a.logger.Fatalf(msg)
-		case gournal.PanicLevel:
-			a.logger.Panicf(msg)
-		}
+	l, ok := a.levels[lvl]
+	if !ok {
 		return
 	}
 
-	switch lvl {
-	case gournal.DebugLevel:
-		a.logger.WithFields(fields).Debugf(msg)
-	case gournal.InfoLevel:
-		a.logger.WithFields(fields).Infof(msg)
-	case gournal.WarnLevel:
-		a.logger.WithFields(fields).Warnf(msg)
-	case gournal.ErrorLevel:
-		a.logger.WithFields(fields).Errorf(msg)
-	case gournal.FatalLevel:
-		a.logger.WithFields(fields).Fatalf(msg)
-	case gournal.PanicLevel:
-		a.logger.WithFields(fields).Panicf(msg)
+	entry := logrus.NewEntry(a.logger)
+	if len(fields) > 0 {
+		entry = entry.WithFields(fields)
+	}
+
+	// the message is already formatted, so the non-formatting functions are
+	// used to avoid interpreting it as a format string
+	switch l {
+	case logrus.DebugLevel:
+		entry.Debug(msg)
+	case logrus.InfoLevel:
+		entry.Info(msg)
+	case logrus.WarnLevel:
+		entry.Warn(msg)
+	case logrus.ErrorLevel:
+		entry.Error(msg)
+	case logrus.FatalLevel:
+		entry.Fatal(msg)
+	case logrus.PanicLevel:
+		entry.Panic(msg)
 	}
 }
@@ -1,6 +1,7 @@
 package logrus
 
 import (
+	"bytes"
 	"context"
 	"testing"
 
@@ -45,3 +46,71 @@ func ctx() context.Context {
 	ctx = context.WithValue(ctx, gournal.AppenderKey(), New())
 	return ctx
 }
+
+type testHook struct {
+	entries []*logrus.Entry
+}
+
+func (h *testHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *testHook) Fire(entry *logrus.Entry) error {
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+func TestLogrusAppenderWithLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	hook := &testHook{}
+	logger := logrus.New()
+	logger.Out = buf
+	logger.Formatter = &logrus.JSONFormatter{DisableTimestamp: true}
+	logger.Hooks.Add(hook)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), NewWithLogger(logger))
+
+	gournal.WithField("size", 1).Warn(ctx, "Hello %s", "100%")
+	assert.Equal(
+		t,
+		`{"level":"warning","msg":"Hello 100%","size":1}`+"\n",
+		buf.String())
+	if assert.Len(t, hook.entries, 1) {
+		assert.Equal(t, "Hello 100%", hook.entries[0].Message)
+	}
+}
+
+func TestLogrusAppenderWithLevels(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.Out = buf
+	logger.Formatter = &logrus.JSONFormatter{DisableTimestamp: true}
+
+	levels := map[gournal.Level]logrus.Level{}
+	for k, v := range Levels {
+		levels[k] = v
+	}
+	levels[gournal.PanicLevel] = logrus.ErrorLevel
+
+	a, err := NewWithLevels(logger, levels)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+
+	assert.NotPanics(t, func() { gournal.Panic(ctx, "Hello Bob") })
+	assert.Equal(t, `{"level":"error","msg":"Hello Bob"}`+"\n", buf.String())
+
+	delete(levels, gournal.DebugLevel)
+	_, err = NewWithLevels(logger, levels)
+	assert.EqualError(t, err, "logrus: unmapped level DEBUG")
+
+	levels[gournal.DebugLevel] = logrus.Level(42)
+	_, err = NewWithLevels(logger, levels)
+	assert.EqualError(t, err, "logrus: invalid level 42 for DEBUG")
+}
@@ -0,0 +1,244 @@
+// Package logstash provides a Gournal Appender that writes entries as
+// Logstash-compatible JSON lines over TCP or TLS, suitable for Logstash's
+// tcp input with the json_lines codec.
+//
+// Each line is a JSON object with the "@timestamp", "@version", "level",
+// and "message" keys, as well as the entry's fields. Fields with the same
+// names as those keys are overwritten.
+package logstash
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// BufferSize is the number of entries buffered in memory by an Appender
+	// returned by New while Logstash is unavailable.
+	BufferSize = 1024
+
+	// RetryInterval is the amount of time to wait before reconnecting after
+	// a connection fails.
+	RetryInterval = time.Second
+
+	// Timeout is the amount of time allowed to connect to Logstash and to
+	// write an entry.
+	Timeout = 10 * time.Second
+)
+
+// Appender is a Logstash Appender.
+type Appender struct {
+	async *gournal.AsyncAppender
+	w     *writer
+}
+
+// New returns an Appender that writes entries over TCP to the Logstash
+// instance at the provided address using BufferSize.
+func New(addr string) *Appender {
+	return NewWithOptions("tcp", addr, nil, BufferSize)
+}
+
+// NewWithOptions returns an Appender that writes entries to the Logstash
+// instance at the provided network address. If the TLS config is not nil,
+// connections use TLS.
+//
+// Entries are written in the background. While Logstash is unavailable,
+// the connection is retried every RetryInterval and up to bufferSize
+// entries are buffered in memory; entries appended while the buffer is full
+// are dropped and counted with gournal.RecordDropped. FATAL and PANIC
+// entries are written after the buffered entries, which are dropped rather
+// than retried if Logstash is unavailable so the exit or panic is not
+// delayed.
+//
+// The connection is established when the first entry is written.
+func NewWithOptions(
+	network, addr string,
+	tlsConfig *tls.Config,
+	bufferSize int) *Appender {
+
+	w := &writer{
+		network: network,
+		addr:    addr,
+		tls:     tlsConfig,
+		done:    make(chan struct{}),
+	}
+	return &Appender{
+		async: gournal.NewAsyncAppender(w, bufferSize, 1),
+		w:     w,
+	}
+}
+
+// Append enqueues the entry.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	// stop retrying so the buffered entries are written or dropped
+	// promptly before the program exits or panics
+	if lvl <= gournal.FatalLevel {
+		a.w.stop()
+	}
+	a.async.Append(ctx, lvl, fields, msg)
+}
+
+// Flush blocks until every buffered entry is written or dropped.
+func (a *Appender) Flush() {
+	a.async.Flush()
+}
+
+// Close writes the buffered entries and closes the connection. If the
+// connection is unavailable, the remaining buffered entries are dropped
+// rather than retried.
+func (a *Appender) Close() error {
+	a.w.stop()
+	a.async.Close()
+
+	a.w.Lock()
+	defer a.w.Unlock()
+	if a.w.conn == nil {
+		return nil
+	}
+	err := a.w.conn.Close()
+	a.w.conn = nil
+	return err
+}
+
+// writer writes JSON lines to a connection, reconnecting when it fails.
+type writer struct {
+	network string
+	addr    string
+	tls     *tls.Config
+
+	sync.Mutex
+	conn net.Conn
+
+	// down is set once a write fails after the writer is stopped, after
+	// which entries are dropped without attempting to write them
+	down bool
+
+	done chan struct{}
+	once sync.Once
+}
+
+// stop ends retries. Entries are still written while the connection is
+// available.
+func (w *writer) stop() {
+	w.once.Do(func() { close(w.done) })
+}
+
+func (w *writer) isDown() bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.down
+}
+
+func (w *writer) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	line, err := encode(gournal.TimeFrom(ctx), lvl, fields, msg)
+	if err != nil {
+		gournal.HandleError(err)
+		return
+	}
+
+	for !w.isDown() {
+		err := w.write(line)
+		if err == nil {
+			break
+		}
+		gournal.HandleError(err)
+
+		t := time.NewTimer(RetryInterval)
+		select {
+		case <-t.C:
+			continue
+		case <-w.done:
+			t.Stop()
+		}
+		w.Lock()
+		w.down = true
+		w.Unlock()
+	}
+	if lvl > gournal.FatalLevel && w.isDown() {
+		gournal.RecordDropped(1)
+		return
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+func (w *writer) write(line []byte) error {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.conn == nil {
+		d := &net.Dialer{Timeout: Timeout}
+		var err error
+		if w.tls != nil {
+			w.conn, err = tls.DialWithDialer(d, w.network, w.addr, w.tls)
+		} else {
+			w.conn, err = d.Dial(w.network, w.addr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err := w.conn.SetWriteDeadline(time.Now().Add(Timeout))
+	if err == nil {
+		_, err = w.conn.Write(line)
+	}
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
+
+func encode(
+	t time.Time,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) ([]byte, error) {
+
+	m := make(map[string]interface{}, len(fields)+4)
+	for k, v := range fields {
+		m[k] = v
+	}
+	m["@timestamp"] = t.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+	m["@version"] = "1"
+	m["level"] = lvl.String()
+	m["message"] = msg
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		// use the string form of fields that cannot be marshaled
+		for k, v := range m {
+			if _, err := json.Marshal(v); err != nil {
+				m[k] = fmt.Sprint(v)
+			}
+		}
+		if buf, err = json.Marshal(m); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, '\n'), nil
+}
@@ -0,0 +1,125 @@
+package logstash
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+var testTime = time.Date(2017, 10, 1, 12, 0, 0, 123456789, time.UTC)
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(), testTime)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func accept(l net.Listener, lines chan<- string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			s := bufio.NewScanner(conn)
+			for s.Scan() {
+				lines <- s.Text()
+			}
+		}()
+	}
+}
+
+func TestEncode(t *testing.T) {
+	buf, err := encode(testTime, gournal.WarnLevel, map[string]interface{}{
+		"size":    1,
+		"message": "overwritten",
+		"ch":      make(chan int),
+	}, "Hello Bob")
+	assert.NoError(t, err)
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf, &m))
+	assert.Equal(t, "2017-10-01T12:00:00.123Z", m["@timestamp"])
+	assert.Equal(t, "1", m["@version"])
+	assert.Equal(t, "WARN", m["level"])
+	assert.Equal(t, "Hello Bob", m["message"])
+	assert.Equal(t, float64(1), m["size"])
+	assert.IsType(t, "", m["ch"])
+	assert.Equal(t, byte('\n'), buf[len(buf)-1])
+}
+
+func TestLogstashAppender(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer l.Close()
+	lines := make(chan string, 10)
+	go accept(l, lines)
+
+	a := New(l.Addr().String())
+	ctx := newContext(a)
+	gournal.WithField("size", 1).Info(ctx, "Hello %s", "Bob")
+	gournal.Info(ctx, "Hello Alice")
+	assert.NoError(t, a.Close())
+
+	for _, msg := range []string{"Hello Bob", "Hello Alice"} {
+		select {
+		case line := <-lines:
+			assert.Contains(t, line, `"message":"`+msg+`"`)
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not written", msg)
+		}
+	}
+}
+
+func TestLogstashAppenderOutage(t *testing.T) {
+	defer func(d time.Duration) { RetryInterval = d }(RetryInterval)
+	RetryInterval = 10 * time.Millisecond
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(error) {}
+
+	// reserve an address and close it so the connection is refused
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	a := NewWithOptions("tcp", addr, nil, 10)
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	gournal.Info(ctx, "Hello Alice")
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, 2, a.async.Len())
+
+	// the buffered entries are written once Logstash is available
+	if l, err = net.Listen("tcp", addr); err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+	lines := make(chan string, 10)
+	go accept(l, lines)
+
+	a.Flush()
+	assert.NoError(t, a.Close())
+	for _, msg := range []string{"Hello Bob", "Hello Alice"} {
+		select {
+		case line := <-lines:
+			assert.Contains(t, line, `"message":"`+msg+`"`)
+		case <-time.After(time.Second):
+			t.Fatalf("%s was not written", msg)
+		}
+	}
+}
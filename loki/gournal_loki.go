@@ -0,0 +1,218 @@
+// Package loki provides a Gournal Appender that pushes batches of entries to
+// Grafana Loki with its HTTP push API.
+//
+// Every entry is labeled with its level. Fields named by the configured
+// label keys are promoted to labels, and the remaining fields are encoded
+// with the message as a JSON object that is the entry's log line. Promote
+// only fields with few distinct values, such as an application or region,
+// since every distinct set of labels is a separate Loki stream.
+//
+// Batches are pushed as gzip-compressed JSON. Loki's snappy-compressed
+// protobuf encoding is not supported since it requires dependencies that
+// are not vendored.
+package loki
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// LabelKeys are the names of the fields promoted to labels by an
+	// Appender returned by New.
+	LabelKeys []string
+
+	// BatchSize is the maximum number of entries pushed at once by an
+	// Appender returned by New.
+	BatchSize = 1000
+
+	// Interval is the amount of time after which entries buffered by an
+	// Appender returned by New are pushed.
+	Interval = time.Second
+)
+
+// LevelLabel is the name of the label that contains an entry's level.
+const LevelLabel = "level"
+
+// New returns a Batcher that pushes entries to the provided URL, ex.
+// http://localhost:3100/loki/api/v1/push, using http.DefaultClient,
+// LabelKeys, BatchSize, and Interval.
+func New(url string) *gournal.Batcher {
+	return NewWithOptions(
+		http.DefaultClient, url, "", LabelKeys, BatchSize, Interval)
+}
+
+// NewWithOptions returns a Batcher that pushes entries to the provided
+// URL with the provided client. If the tenant is not empty, it is sent as
+// the X-Scope-OrgID header of every push. The fields named by labelKeys
+// are promoted to labels.
+//
+// Entries are pushed in batches of up to batchSize entries or, if the
+// interval is greater than zero, each time the interval elapses. Buffered
+// entries are pushed before FATAL and PANIC entries, and when the Batcher
+// is flushed or closed. Batches that cannot be pushed are reported with
+// gournal.HandleError and their entries are counted with
+// gournal.RecordDropped.
+func NewWithOptions(
+	client *http.Client,
+	url, tenant string,
+	labelKeys []string,
+	batchSize int,
+	interval time.Duration) *gournal.Batcher {
+
+	p := &pusher{
+		client:    client,
+		url:       url,
+		tenant:    tenant,
+		labelKeys: make(map[string]string, len(labelKeys)),
+	}
+	for _, k := range labelKeys {
+		p.labelKeys[k] = labelName(k)
+	}
+	return gournal.NewBatcher(p, batchSize, interval)
+}
+
+type pusher struct {
+	client *http.Client
+	url    string
+	tenant string
+
+	// labelKeys maps the names of promoted fields to their label names
+	labelKeys map[string]string
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (p *pusher) AppendBatch(recs []gournal.Record) {
+	if err := p.push(recs); err != nil {
+		gournal.HandleError(err)
+		gournal.RecordDropped(len(recs))
+	}
+}
+
+func (p *pusher) push(recs []gournal.Record) error {
+	streams := map[string]*stream{}
+	var order []string
+
+	for i := range recs {
+		rec := &recs[i]
+		labels := map[string]string{LevelLabel: strings.ToLower(
+			rec.Level.String())}
+		line := map[string]interface{}{"msg": rec.Message}
+		for k, v := range rec.Fields {
+			if name, ok := p.labelKeys[k]; ok {
+				labels[name] = fmt.Sprint(v)
+			} else {
+				line[k] = v
+			}
+		}
+
+		buf, err := json.Marshal(line)
+		if err != nil {
+			// use the string form of fields that cannot be marshaled
+			for k, v := range line {
+				line[k] = fmt.Sprint(v)
+			}
+			if buf, err = json.Marshal(line); err != nil {
+				return err
+			}
+		}
+
+		key := streamKey(labels)
+		s, ok := streams[key]
+		if !ok {
+			s = &stream{Stream: labels}
+			streams[key] = s
+			order = append(order, key)
+		}
+		s.Values = append(s.Values, [2]string{
+			strconv.FormatInt(rec.Time.UnixNano(), 10), string(buf)})
+	}
+
+	req := struct {
+		Streams []*stream `json:"streams"`
+	}{make([]*stream, len(order))}
+	for i, key := range order {
+		req.Streams[i] = streams[key]
+	}
+
+	body := &bytes.Buffer{}
+	gz := gzip.NewWriter(body)
+	if err := json.NewEncoder(gz).Encode(&req); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return p.post(body)
+}
+
+func (p *pusher) post(body io.Reader) error {
+	req, err := http.NewRequest("POST", p.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	if p.tenant != "" {
+		req.Header.Set("X-Scope-OrgID", p.tenant)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf(
+			"loki: push failed: %s: %s",
+			res.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// streamKey returns a string that uniquely identifies a set of labels.
+func streamKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf := &bytes.Buffer{}
+	for _, k := range keys {
+		fmt.Fprintf(buf, "%s=%q,", k, labels[k])
+	}
+	return buf.String()
+}
+
+// labelName replaces the characters that are not valid in a label name
+// with underscores.
+func labelName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		switch {
+		case c == '_',
+			c >= 'a' && c <= 'z',
+			c >= 'A' && c <= 'Z',
+			c >= '0' && c <= '9' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
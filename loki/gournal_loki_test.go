@@ -0,0 +1,103 @@
+package loki
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type pushRequest struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(), time.Unix(1, 5))
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestLokiAppender(t *testing.T) {
+	var (
+		tenant string
+		req    pushRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			tenant = r.Header.Get("X-Scope-OrgID")
+			gz, err := gzip.NewReader(r.Body)
+			if assert.NoError(t, err) {
+				assert.NoError(t, json.NewDecoder(gz).Decode(&req))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	defer srv.Close()
+
+	a := NewWithOptions(http.DefaultClient,
+		srv.URL, "acme", []string{"app", "k8s.ns"}, 10, 0)
+	ctx := newContext(a)
+
+	gournal.WithFields(map[string]interface{}{
+		"app":    "web",
+		"k8s.ns": "prod",
+		"size":   1,
+	}).Info(ctx, "Hello %s", "Bob")
+	gournal.WithField("app", "web").Warn(ctx, "Hello Alice")
+	gournal.WithField("app", "web").Warn(ctx, "Hello Mary")
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, "acme", tenant)
+	if assert.Len(t, req.Streams, 2) {
+		s := req.Streams[0]
+		assert.Equal(t, map[string]string{
+			"level":  "info",
+			"app":    "web",
+			"k8s_ns": "prod",
+		}, s.Stream)
+		assert.Equal(t, [][2]string{
+			{"1000000005", `{"msg":"Hello Bob","size":1}`},
+		}, s.Values)
+
+		s = req.Streams[1]
+		assert.Equal(t, map[string]string{
+			"level": "warn",
+			"app":   "web",
+		}, s.Stream)
+		assert.Len(t, s.Values, 2)
+	}
+}
+
+func TestLokiAppenderPushError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "too many streams", http.StatusTooManyRequests)
+		}))
+	defer srv.Close()
+
+	var errs []error
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { errs = append(errs, err) }
+
+	dropped := gournal.DroppedCount()
+	a := NewWithOptions(http.DefaultClient, srv.URL, "", nil, 10, 0)
+	gournal.Info(newContext(a), "Hello Bob")
+	a.Flush()
+
+	assert.Equal(
+		t, dropped+1, gournal.DroppedCount())
+	assert.Equal(t, []error{errors.New(
+		"loki: push failed: 429 Too Many Requests: too many streams")}, errs)
+}
@@ -0,0 +1,303 @@
+// Package metrics provides Gournal Appender middleware that records metrics
+// about the entries emitted through the wrapped Appenders.
+//
+// A Collector implements prometheus.Collector, so it may be registered with
+// a Prometheus registry, and is also an http.Handler that exposes the
+// metrics using the Prometheus text exposition format:
+//
+//	gournal_entries_total{appender,level}
+//	gournal_append_duration_seconds{appender}
+//	gournal_dropped_total{appender}
+//	gournal_append_success_total{appender}
+//	gournal_append_errors_total{appender}
+//	gournal_queue_depth{appender}
+//
+// Delivery failures are detected with gournal.TryAppend. The queue depth is
+// reported for wrapped Appenders that implement QueueDepther, such as
+// *gournal.AsyncAppender and *gournal.Batcher.
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/akutz/gournal"
+)
+
+// Buckets are the upper bounds, in seconds, of the append latency histogram
+// buckets used by new Collectors.
+var Buckets = []float64{
+	0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1,
+}
+
+var (
+	entriesDesc = prometheus.NewDesc(
+		"gournal_entries_total",
+		"Number of entries appended by appender and level.",
+		[]string{"appender", "level"}, nil)
+	durationDesc = prometheus.NewDesc(
+		"gournal_append_duration_seconds",
+		"Latency of appending entries by appender.",
+		[]string{"appender"}, nil)
+	droppedDesc = prometheus.NewDesc(
+		"gournal_dropped_total",
+		"Number of entries dropped by appender.",
+		[]string{"appender"}, nil)
+	successesDesc = prometheus.NewDesc(
+		"gournal_append_success_total",
+		"Number of entries delivered by appender.",
+		[]string{"appender"}, nil)
+	errorsDesc = prometheus.NewDesc(
+		"gournal_append_errors_total",
+		"Number of entries that failed to be delivered by appender.",
+		[]string{"appender"}, nil)
+	depthDesc = prometheus.NewDesc(
+		"gournal_queue_depth",
+		"Number of entries queued by appender.",
+		[]string{"appender"}, nil)
+)
+
+// DefaultCollector is the Collector used by New and Handler.
+var DefaultCollector = NewCollector()
+
+// New returns an Appender that records metrics about the entries it receives
+// with the DefaultCollector before delegating to the next Appender.
+func New(name string, next gournal.Appender) gournal.Appender {
+	return DefaultCollector.Wrap(name, next)
+}
+
+// Handler returns an http.Handler that exposes the DefaultCollector's
+// metrics.
+func Handler() http.Handler {
+	return DefaultCollector
+}
+
+// Collector records metrics for one or more named Appenders.
+type Collector struct {
+	sync.Mutex
+	buckets   []float64
+	appenders map[string]*appenderMetrics
+}
+
+type appenderMetrics struct {
+	entries   [gournal.DebugLevel + 1]uint64
+	buckets   []uint64
+	sum       float64
+	count     uint64
+	dropped   uint64
+	successes uint64
+	errors    uint64
+	depth     QueueDepther
+}
+
+// QueueDepther may be implemented by Appenders that queue entries.
+type QueueDepther interface {
+
+	// Len returns the number of queued entries.
+	Len() int
+}
+
+// NewCollector returns a new Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		buckets:   append([]float64(nil), Buckets...),
+		appenders: map[string]*appenderMetrics{},
+	}
+}
+
+// Wrap returns an Appender that records metrics about the entries it
+// receives using the provided name before delegating to the next Appender.
+func (c *Collector) Wrap(name string, next gournal.Appender) gournal.Appender {
+	c.Lock()
+	m := c.get(name)
+	if qd, ok := next.(QueueDepther); ok {
+		m.depth = qd
+	}
+	c.Unlock()
+	return &appender{c: c, name: name, next: next}
+}
+
+// RecordDropped records that the named Appender dropped the provided number
+// of entries.
+func (c *Collector) RecordDropped(name string, n int) {
+	c.Lock()
+	c.get(name).dropped += uint64(n)
+	c.Unlock()
+}
+
+// RecordError records that the named Appender failed to deliver an entry.
+// Failures of wrapped Appenders that implement gournal.ErrorAppender are
+// recorded automatically.
+func (c *Collector) RecordError(name string) {
+	c.Lock()
+	c.get(name).errors++
+	c.Unlock()
+}
+
+func (c *Collector) get(name string) *appenderMetrics {
+	m, ok := c.appenders[name]
+	if !ok {
+		m = &appenderMetrics{buckets: make([]uint64, len(c.buckets))}
+		c.appenders[name] = m
+	}
+	return m
+}
+
+func (c *Collector) observe(
+	name string, lvl gournal.Level, d time.Duration, ok bool) {
+
+	secs := d.Seconds()
+	c.Lock()
+	defer c.Unlock()
+	m := c.get(name)
+	if int(lvl) < len(m.entries) {
+		m.entries[lvl]++
+	}
+	if ok {
+		m.successes++
+	} else {
+		m.errors++
+	}
+	for i, b := range c.buckets {
+		if secs <= b {
+			m.buckets[i]++
+		}
+	}
+	m.sum += secs
+	m.count++
+}
+
+// Describe sends the descriptors of the Collector's metrics to the
+// provided channel. It implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- entriesDesc
+	ch <- durationDesc
+	ch <- droppedDesc
+	ch <- successesDesc
+	ch <- errorsDesc
+	ch <- depthDesc
+}
+
+// Collect sends the Collector's metrics to the provided channel. It
+// implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, name := range c.names() {
+		m := c.appenders[name]
+		for lvl := gournal.PanicLevel; lvl <= gournal.DebugLevel; lvl++ {
+			ch <- prometheus.MustNewConstMetric(
+				entriesDesc, prometheus.CounterValue,
+				float64(m.entries[lvl]),
+				name, strings.ToLower(lvl.String()))
+		}
+		buckets := make(map[float64]uint64, len(c.buckets))
+		for i, b := range c.buckets {
+			buckets[b] = m.buckets[i]
+		}
+		ch <- prometheus.MustNewConstHistogram(
+			durationDesc, m.count, m.sum, buckets, name)
+		ch <- prometheus.MustNewConstMetric(
+			droppedDesc, prometheus.CounterValue, float64(m.dropped), name)
+		ch <- prometheus.MustNewConstMetric(
+			successesDesc, prometheus.CounterValue,
+			float64(m.successes), name)
+		ch <- prometheus.MustNewConstMetric(
+			errorsDesc, prometheus.CounterValue, float64(m.errors), name)
+		if m.depth != nil {
+			ch <- prometheus.MustNewConstMetric(
+				depthDesc, prometheus.GaugeValue,
+				float64(m.depth.Len()), name)
+		}
+	}
+}
+
+// ServeHTTP writes the Collector's metrics using the Prometheus text
+// exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveText(w, c)
+}
+
+// WriteText writes the Collector's metrics to the provided writer using the
+// Prometheus text exposition format.
+func (c *Collector) WriteText(w io.Writer) error {
+	return writeText(w, c)
+}
+
+// serveText writes the metrics of the provided prometheus.Collector to the
+// response using the Prometheus text exposition format.
+func serveText(w http.ResponseWriter, c prometheus.Collector) {
+	w.Header().Set("Content-Type", string(expfmt.FmtText))
+	if err := writeText(w, c); err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+// writeText gathers the metrics of the provided prometheus.Collector and
+// writes them to w using the Prometheus text exposition format.
+func writeText(w io.Writer, c prometheus.Collector) error {
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(c); err != nil {
+		return err
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		return err
+	}
+	for _, mf := range mfs {
+		if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// names returns the sorted names of the Collector's Appenders.
+func (c *Collector) names() []string {
+	names := make([]string, 0, len(c.appenders))
+	for name := range c.appenders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type appender struct {
+	c    *Collector
+	name string
+	next gournal.Appender
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+func (a *appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) (err error) {
+
+	// the observation is deferred so PANIC entries are recorded as well
+	start := time.Now()
+	defer func() {
+		a.c.observe(a.name, lvl, time.Since(start), err == nil)
+	}()
+	return gournal.TryAppend(a.next, ctx, lvl, fields, msg)
+}
@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/akutz/gournal"
+)
+
+// LevelCounter is an Appender that counts entries by level and by the
+// values of selected fields without writing them anywhere. It is meant to
+// be used alongside an Appender that does, so alerts may be raised on
+// spikes of errors, and therefore does not exit or panic for FATAL and
+// PANIC entries either.
+//
+// A LevelCounter implements prometheus.Collector and exposes the counts
+// using the Prometheus text exposition format:
+//
+//	<namespace>_entries_total{level,<labels>}
+//	<namespace>_last_entry_timestamp_seconds{level}
+//
+// Each distinct combination of label values is a separate series, so the
+// fields should have few distinct values.
+type LevelCounter struct {
+	namespace string
+	labels    []string
+	keys      []string
+
+	entriesDesc *prometheus.Desc
+	lastDesc    *prometheus.Desc
+
+	sync.Mutex
+	series map[string]*levelSeries
+	last   [gournal.DebugLevel + 1]float64
+}
+
+type levelSeries struct {
+	lvl    gournal.Level
+	values []string
+	count  uint64
+}
+
+// NewLevelCounter returns a LevelCounter whose metric names begin with the
+// provided namespace, ex. "gournal_log", and whose entries are labelled by
+// the values of the fields with the provided names. Characters that are not
+// valid in Prometheus label names are replaced with underscores. Entries
+// without a field are labelled with an empty value for it.
+func NewLevelCounter(namespace string, keys ...string) *LevelCounter {
+	c := &LevelCounter{
+		namespace: namespace,
+		keys:      keys,
+		labels:    make([]string, len(keys)),
+		series:    map[string]*levelSeries{},
+	}
+	for i, k := range keys {
+		c.labels[i] = labelName(k)
+	}
+	c.entriesDesc = prometheus.NewDesc(
+		namespace+"_entries_total",
+		"Number of entries by level.",
+		append([]string{"level"}, c.labels...), nil)
+	c.lastDesc = prometheus.NewDesc(
+		namespace+"_last_entry_timestamp_seconds",
+		"Time of the most recent entry by level.",
+		[]string{"level"}, nil)
+	return c
+}
+
+// Append counts the entry.
+func (c *LevelCounter) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	values := make([]string, len(c.keys))
+	for i, k := range c.keys {
+		if v, ok := fields[k]; ok {
+			values[i] = fmt.Sprint(v)
+		}
+	}
+	id := lvl.String() + "\xff" + strings.Join(values, "\xff")
+	ts := float64(gournal.TimeFrom(ctx).UnixNano()) / 1e9
+
+	c.Lock()
+	defer c.Unlock()
+	s, ok := c.series[id]
+	if !ok {
+		s = &levelSeries{lvl: lvl, values: values}
+		c.series[id] = s
+	}
+	s.count++
+	if int(lvl) < len(c.last) && ts > c.last[lvl] {
+		c.last[lvl] = ts
+	}
+}
+
+// Describe sends the descriptors of the LevelCounter's metrics to the
+// provided channel. It implements prometheus.Collector.
+func (c *LevelCounter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entriesDesc
+	ch <- c.lastDesc
+}
+
+// Collect sends the LevelCounter's metrics to the provided channel. It
+// implements prometheus.Collector.
+func (c *LevelCounter) Collect(ch chan<- prometheus.Metric) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, id := range c.ids() {
+		s := c.series[id]
+		ch <- newConstMetric(
+			c.entriesDesc, prometheus.CounterValue, float64(s.count),
+			append([]string{strings.ToLower(s.lvl.String())}, s.values...))
+	}
+	for lvl := gournal.PanicLevel; lvl <= gournal.DebugLevel; lvl++ {
+		if c.last[lvl] > 0 {
+			ch <- newConstMetric(
+				c.lastDesc, prometheus.GaugeValue, c.last[lvl],
+				[]string{strings.ToLower(lvl.String())})
+		}
+	}
+}
+
+// newConstMetric returns a constant metric, or an invalid metric that
+// reports the error to the registry if the namespace or the names of the
+// fields did not produce a valid descriptor.
+func newConstMetric(
+	desc *prometheus.Desc,
+	typ prometheus.ValueType,
+	val float64,
+	labelValues []string) prometheus.Metric {
+
+	m, err := prometheus.NewConstMetric(desc, typ, val, labelValues...)
+	if err != nil {
+		return prometheus.NewInvalidMetric(desc, err)
+	}
+	return m
+}
+
+// ServeHTTP writes the LevelCounter's metrics using the Prometheus text
+// exposition format.
+func (c *LevelCounter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveText(w, c)
+}
+
+// WriteText writes the LevelCounter's metrics to the provided writer using
+// the Prometheus text exposition format.
+func (c *LevelCounter) WriteText(w io.Writer) error {
+	return writeText(w, c)
+}
+
+// ids returns the sorted identifiers of the LevelCounter's series.
+func (c *LevelCounter) ids() []string {
+	ids := make([]string, 0, len(c.series))
+	for id := range c.series {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// labelName replaces the characters that are not valid in Prometheus label
+// names with underscores.
+func labelName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		b[i] = '_'
+	}
+	return string(b)
+}
@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), c.Wrap(
+		"stdout", gournal.NewAppenderWithOptions(ioutil.Discard)))
+
+	gournal.Info(ctx, "Hello Bob")
+	gournal.Info(ctx, "Hello Alice")
+	gournal.Warn(ctx, "Hello Mary")
+	c.RecordDropped("stdout", 3)
+	c.RecordError("stdout")
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+
+	assert.Contains(
+		t, out, `gournal_entries_total{appender="stdout",level="info"} 2`)
+	assert.Contains(
+		t, out, `gournal_entries_total{appender="stdout",level="warn"} 1`)
+	assert.Contains(
+		t, out, `gournal_entries_total{appender="stdout",level="debug"} 0`)
+	assert.Contains(
+		t, out,
+		`gournal_append_duration_seconds_bucket{appender="stdout",le="+Inf"} 3`)
+	assert.Contains(
+		t, out, `gournal_append_duration_seconds_count{appender="stdout"} 3`)
+	assert.Contains(t, out, `gournal_dropped_total{appender="stdout"} 3`)
+	assert.Contains(t, out, `gournal_append_errors_total{appender="stdout"} 1`)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestCollectorErrorsAndQueueDepth(t *testing.T) {
+	var handled error
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { handled = err }
+
+	c := NewCollector()
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(),
+		c.Wrap("broken", gournal.NewAppenderWithOptions(failingWriter{})))
+	gournal.Error(ctx, "Hello Bob")
+	assert.EqualError(t, handled, "write failed")
+
+	b := gournal.NewBatcher(&nopBatchAppender{}, 10, 0)
+	ctx = context.WithValue(
+		context.Background(), gournal.AppenderKey(), c.Wrap("batch", b))
+	gournal.Error(ctx, "Hello Bob")
+	gournal.Error(ctx, "Hello Alice")
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+
+	assert.Contains(t, out, `gournal_append_errors_total{appender="broken"} 1`)
+	assert.Contains(t, out, `gournal_append_success_total{appender="broken"} 0`)
+	assert.Contains(t, out, `gournal_append_success_total{appender="batch"} 2`)
+	assert.Contains(t, out, `gournal_queue_depth{appender="batch"} 2`)
+	assert.NotContains(t, out, `gournal_queue_depth{appender="broken"}`)
+}
+
+func TestRegistry(t *testing.T) {
+	c := NewCollector()
+	lc := NewLevelCounter("app_log", "status")
+	ctx := context.WithValue(
+		context.Background(), gournal.LevelKey(), gournal.InfoLevel)
+	ctx = gournal.WithTime(ctx, time.Unix(1500000000, 0))
+	for _, a := range []gournal.Appender{
+		c.Wrap("batch", gournal.NewBatcher(&nopBatchAppender{}, 10, 0)),
+		lc,
+	} {
+		ctx := context.WithValue(ctx, gournal.AppenderKey(), a)
+		gournal.WithField("status", 500).Error(ctx, "Hello Bob")
+		gournal.Info(ctx, "Hello Alice")
+	}
+	c.RecordDropped("batch", 2)
+
+	reg := prometheus.NewPedanticRegistry()
+	if !assert.NoError(t, reg.Register(c)) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, reg.Register(lc)) {
+		t.FailNow()
+	}
+
+	// the metrics gathered by the registry are the same as the ones written
+	// using the text exposition format
+	buf := &bytes.Buffer{}
+	assert.NoError(t, c.WriteText(buf))
+	assert.NoError(t, lc.WriteText(buf))
+	assert.NoError(t, testutil.GatherAndCompare(reg, buf))
+}
+
+func TestWriteTextError(t *testing.T) {
+	c := NewCollector()
+	c.RecordDropped("stdout", 1)
+	assert.EqualError(t, c.WriteText(failingWriter{}), "write failed")
+	lc := NewLevelCounter("app_log")
+	lc.Append(context.Background(), gournal.ErrorLevel, nil, "Hello Bob")
+	assert.EqualError(t, lc.WriteText(failingWriter{}), "write failed")
+}
+
+type nopBatchAppender struct{}
+
+func (nopBatchAppender) AppendBatch(records []gournal.Record) {}
+
+func TestLevelCounter(t *testing.T) {
+	c := NewLevelCounter("app_log", "component", "http.status")
+	ctx := context.WithValue(
+		context.Background(), gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), c)
+	ctx = gournal.WithTime(ctx, time.Unix(1500000000, 0))
+
+	gournal.WithField("component", "db").Error(ctx, "Hello Bob")
+	gournal.WithField("component", "db").Error(ctx, "Hello Alice")
+	gournal.WithFields(map[string]interface{}{
+		"component":   "web",
+		"http.status": 500,
+	}).Error(ctx, "Hello Mary")
+	gournal.Info(ctx, "Hello Carl")
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t,
+		"# HELP app_log_entries_total Number of entries by level.\n"+
+			"# TYPE app_log_entries_total counter\n"+
+			`app_log_entries_total{component="",http_status="",`+
+			`level="info"} 1`+"\n"+
+			`app_log_entries_total{component="db",http_status="",`+
+			`level="error"} 2`+"\n"+
+			`app_log_entries_total{component="web",http_status="500",`+
+			`level="error"} 1`+"\n"+
+			"# HELP app_log_last_entry_timestamp_seconds "+
+			"Time of the most recent entry by level.\n"+
+			"# TYPE app_log_last_entry_timestamp_seconds gauge\n"+
+			`app_log_last_entry_timestamp_seconds{level="error"} `+
+			"1.5e+09\n"+
+			`app_log_last_entry_timestamp_seconds{level="info"} `+
+			"1.5e+09\n",
+		rec.Body.String())
+}
+
+func TestLevelCounterEscaping(t *testing.T) {
+	c := NewLevelCounter("app_log", "component")
+	ctx := context.WithValue(
+		context.Background(), gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), c)
+
+	// label values are escaped as the exposition format requires, which
+	// differs from Go's quoting of tabs
+	gournal.WithField("component", "caf\u00e9\t\"db\"\\\n").Info(
+		ctx, "Hello Bob")
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, c.WriteText(buf))
+	assert.Contains(t, buf.String(),
+		"app_log_entries_total{component=\"caf\u00e9\t\\\"db\\\"\\\\\\n\","+
+			"level=\"info\"} 1\n")
+}
@@ -0,0 +1,136 @@
+// Package mqtt provides a Gournal Appender that publishes entries as JSON
+// gournal.Record objects to an MQTT topic, for devices that already speak
+// MQTT to their control plane.
+//
+// This package does not import an MQTT client. Instead, callers provide a
+// Publisher, for example one backed by an Eclipse Paho client:
+//
+//	type publisher struct{ mqtt.Client }
+//
+//	func (p publisher) Publish(
+//		topic string, qos byte, retained bool, payload []byte) error {
+//
+//		t := p.Client.Publish(topic, qos, retained, payload)
+//		t.Wait()
+//		return t.Error()
+//	}
+//
+// The Appender implements gournal.ErrorAppender, so entries may be stored
+// while the device is offline and forwarded once it reconnects by wrapping
+// the Appender with the wal package:
+//
+//	a, err := wal.New("/var/lib/app/logs", mqttAppender)
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"text/template"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// QoS is the quality of service level used by an Appender returned by
+	// New.
+	QoS byte = 1
+
+	// Retained is the retained flag used by an Appender returned by New.
+	Retained bool
+)
+
+// Publisher publishes a message to an MQTT topic. A Publisher that also
+// implements io.Closer is closed when the Appender is closed.
+type Publisher interface {
+	Publish(topic string, qos byte, retained bool, payload []byte) error
+}
+
+// Appender is an MQTT Appender.
+type Appender struct {
+	p        Publisher
+	topic    *template.Template
+	qos      byte
+	retained bool
+}
+
+// New returns an Appender that publishes entries with p using QoS and
+// Retained. The topic is a text/template executed with the entry's
+// gournal.Record, for example "devices/{{.Fields.device}}/logs/{{.Level}}".
+// An error is returned if the template cannot be parsed.
+func New(p Publisher, topic string) (*Appender, error) {
+	return NewWithOptions(p, topic, QoS, Retained)
+}
+
+// NewWithOptions returns an Appender that publishes entries with p using
+// the provided topic template, quality of service level, and retained
+// flag. An error is returned if the template cannot be parsed.
+func NewWithOptions(
+	p Publisher,
+	topic string,
+	qos byte,
+	retained bool) (*Appender, error) {
+
+	t, err := template.New("topic").Parse(topic)
+	if err != nil {
+		return nil, err
+	}
+	return &Appender{p: p, topic: t, qos: qos, retained: retained}, nil
+}
+
+// Append publishes the entry. Errors are reported with
+// gournal.HandleError.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+// TryAppend publishes the entry and returns any error that occurs.
+func (a *Appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	rec := &gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	}
+
+	topic := &bytes.Buffer{}
+	if err := a.topic.Execute(topic, rec); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return a.p.Publish(topic.String(), a.qos, a.retained, payload)
+}
+
+// Close closes the Publisher if it implements io.Closer.
+func (a *Appender) Close() error {
+	if c, ok := a.p.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/wal"
+)
+
+type message struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  string
+}
+
+type testPublisher struct {
+	sync.Mutex
+	msgs []message
+	err  error
+}
+
+func (p *testPublisher) Publish(
+	topic string, qos byte, retained bool, payload []byte) error {
+
+	p.Lock()
+	defer p.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.msgs = append(p.msgs, message{topic, qos, retained, string(payload)})
+	return nil
+}
+
+func (p *testPublisher) setErr(err error) {
+	p.Lock()
+	p.err = err
+	p.Unlock()
+}
+
+func (p *testPublisher) len() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.msgs)
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(),
+		time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC))
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestMQTTAppender(t *testing.T) {
+	p := &testPublisher{}
+	a, err := New(p, "devices/{{.Fields.device}}/logs/{{.Level}}")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	gournal.WithField("device", "d1").Warn(newContext(a), "Hello %s", "Bob")
+
+	if assert.Len(t, p.msgs, 1) {
+		assert.Equal(t, message{
+			topic: "devices/d1/logs/WARN",
+			qos:   1,
+			payload: `{"time":"2017-10-01T12:00:00Z","level":"WARN",` +
+				`"msg":"Hello Bob","fields":{"device":"d1"}}`,
+		}, p.msgs[0])
+	}
+}
+
+func TestMQTTAppenderBadTemplate(t *testing.T) {
+	_, err := New(&testPublisher{}, "{{.Fields")
+	assert.Error(t, err)
+}
+
+func TestMQTTAppenderStoreAndForward(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gournal-mqtt")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(d time.Duration) { wal.RetryInterval = d }(wal.RetryInterval)
+	wal.RetryInterval = time.Millisecond
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(error) {}
+
+	p := &testPublisher{}
+	p.setErr(errors.New("offline"))
+	m, _ := NewWithOptions(p, "logs", 0, true)
+	a, err := wal.New(dir, m)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+
+	gournal.Info(newContext(a), "Hello Bob")
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, p.len())
+
+	p.setErr(nil)
+	for i := 0; i < 100 && p.len() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if assert.Equal(t, 1, p.len()) {
+		assert.True(t, p.msgs[0].retained)
+	}
+}
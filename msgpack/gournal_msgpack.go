@@ -0,0 +1,283 @@
+// Package msgpack provides a Gournal Formatter that encodes entries as
+// MessagePack maps, and a Decoder that reads them back. MessagePack records
+// are smaller and cheaper to produce than JSON records, which makes them
+// well-suited for high-volume agents that ship entries over the network or
+// store them in files.
+//
+// A record is a map with the same keys as a JSON Record: "time", "level",
+// "msg", and "fields". The time is encoded with the MessagePack timestamp
+// extension type and the level as its name. Field values that are not
+// booleans, numbers, strings, byte slices, times, slices, or maps are
+// converted to the value of their JSON representation, and values that
+// cannot be marshaled to JSON are written as strings.
+//
+// Records are self-delimiting, so a stream of them may be written without
+// framing, for example with the socket package's Raw framing, and decoded
+// with a Decoder, which also implements the replay package's Decoder
+// interface.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// Formatter is a MessagePack Formatter.
+type Formatter struct{}
+
+// Format writes the Record to the buffer.
+func (Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	n := 2
+	if !rec.Time.IsZero() {
+		n++
+	}
+	if len(rec.Fields) > 0 {
+		n++
+	}
+	writeMapHeader(buf, n)
+	if !rec.Time.IsZero() {
+		writeString(buf, "time")
+		writeTime(buf, rec.Time)
+	}
+	writeString(buf, "level")
+	writeString(buf, rec.Level.String())
+	writeString(buf, "msg")
+	writeString(buf, rec.Message)
+	if len(rec.Fields) > 0 {
+		writeString(buf, "fields")
+		writeMapHeader(buf, len(rec.Fields))
+		for _, k := range gournal.SortKeys(rec.Fields) {
+			writeString(buf, k)
+			writeValue(buf, rec.Fields[k])
+		}
+	}
+	return nil
+}
+
+func writeValue(buf *bytes.Buffer, v interface{}) {
+	switch tv := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if tv {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int:
+		writeInt(buf, int64(tv))
+	case int8:
+		writeInt(buf, int64(tv))
+	case int16:
+		writeInt(buf, int64(tv))
+	case int32:
+		writeInt(buf, int64(tv))
+	case int64:
+		writeInt(buf, tv)
+	case uint:
+		writeUint(buf, uint64(tv))
+	case uint8:
+		writeUint(buf, uint64(tv))
+	case uint16:
+		writeUint(buf, uint64(tv))
+	case uint32:
+		writeUint(buf, uint64(tv))
+	case uint64:
+		writeUint(buf, tv)
+	case float32:
+		buf.WriteByte(0xca)
+		writeBE(buf, uint64(math.Float32bits(tv)), 4)
+	case float64:
+		buf.WriteByte(0xcb)
+		writeBE(buf, math.Float64bits(tv), 8)
+	case string:
+		writeString(buf, tv)
+	case []byte:
+		writeBytes(buf, tv)
+	case time.Time:
+		writeTime(buf, tv)
+	case error:
+		writeString(buf, tv.Error())
+	case []interface{}:
+		writeArrayHeader(buf, len(tv))
+		for _, e := range tv {
+			writeValue(buf, e)
+		}
+	case map[string]interface{}:
+		writeMapHeader(buf, len(tv))
+		for _, k := range gournal.SortKeys(tv) {
+			writeString(buf, k)
+			writeValue(buf, tv[k])
+		}
+	default:
+		writeValue(buf, jsonValue(v))
+	}
+}
+
+// jsonValue returns the value of the JSON representation of v, or the
+// string representation of v if it cannot be marshaled to JSON.
+func jsonValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var jv interface{}
+	if err := d.Decode(&jv); err != nil {
+		return fmt.Sprint(v)
+	}
+	return jsonNumbers(jv)
+}
+
+// jsonNumbers replaces the json.Number values in v with integers or
+// floats.
+func jsonNumbers(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case json.Number:
+		if i, err := tv.Int64(); err == nil {
+			return i
+		}
+		f, _ := tv.Float64()
+		return f
+	case []interface{}:
+		for i, e := range tv {
+			tv[i] = jsonNumbers(e)
+		}
+	case map[string]interface{}:
+		for k, e := range tv {
+			tv[k] = jsonNumbers(e)
+		}
+	}
+	return v
+}
+
+func writeInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0:
+		writeUint(buf, uint64(i))
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		writeBE(buf, uint64(i), 2)
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		writeBE(buf, uint64(i), 4)
+	default:
+		buf.WriteByte(0xd3)
+		writeBE(buf, uint64(i), 8)
+	}
+}
+
+func writeUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 0x7f:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		writeBE(buf, u, 2)
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		writeBE(buf, u, 4)
+	default:
+		buf.WriteByte(0xcf)
+		writeBE(buf, u, 8)
+	}
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		writeBE(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xdb)
+		writeBE(buf, uint64(n), 4)
+	}
+	buf.WriteString(s)
+}
+
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xc4)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xc5)
+		writeBE(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xc6)
+		writeBE(buf, uint64(n), 4)
+	}
+	buf.Write(b)
+}
+
+func writeArrayHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xdc)
+		writeBE(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xdd)
+		writeBE(buf, uint64(n), 4)
+	}
+}
+
+func writeMapHeader(buf *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xde)
+		writeBE(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xdf)
+		writeBE(buf, uint64(n), 4)
+	}
+}
+
+// writeTime writes the time with the timestamp extension type using the
+// smallest of its three formats that can represent the time.
+func writeTime(buf *bytes.Buffer, t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case nsec == 0 && sec >= 0 && sec <= math.MaxUint32:
+		buf.Write([]byte{0xd6, 0xff})
+		writeBE(buf, uint64(sec), 4)
+	case sec >= 0 && sec < 1<<34:
+		buf.Write([]byte{0xd7, 0xff})
+		writeBE(buf, nsec<<34|uint64(sec), 8)
+	default:
+		buf.Write([]byte{0xc7, 12, 0xff})
+		writeBE(buf, nsec, 4)
+		writeBE(buf, uint64(sec), 8)
+	}
+}
+
+func writeBE(buf *bytes.Buffer, u uint64, n int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], u)
+	buf.Write(b[8-n:])
+}
@@ -0,0 +1,235 @@
+package msgpack
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// ErrInvalid is returned by a Decoder when the stream does not contain a
+// valid MessagePack record.
+var ErrInvalid = errors.New("msgpack: invalid record")
+
+// Decoder decodes Records written by a Formatter from a stream.
+type Decoder struct {
+	r *bufio.Reader
+}
+
+// NewDecoder returns a Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode returns the next Record from the stream or io.EOF when there are
+// no more Records. Integer field values are decoded as int64 or uint64
+// values, maps as map[string]interface{} values, arrays as []interface{}
+// values, and timestamps as time.Time values in UTC.
+func (d *Decoder) Decode() (*gournal.Record, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	v, err := decode(d.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalid
+	}
+
+	rec := &gournal.Record{}
+	if rec.Time, ok = m["time"].(time.Time); !ok && m["time"] != nil {
+		return nil, ErrInvalid
+	}
+	lvl, _ := m["level"].(string)
+	rec.Level = gournal.ParseLevel(lvl)
+	rec.Message, _ = m["msg"].(string)
+	if f, ok := m["fields"]; ok {
+		if rec.Fields, ok = f.(map[string]interface{}); !ok {
+			return nil, ErrInvalid
+		}
+	}
+	return rec, nil
+}
+
+// decode reads a MessagePack value.
+func decode(r *bufio.Reader) (interface{}, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c < 0x80:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return decodeMap(r, int(c&0x0f))
+	case c&0xf0 == 0x90:
+		return decodeArray(r, int(c&0x0f))
+	case c&0xe0 == 0xa0:
+		return decodeString(r, int(c&0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readLength(r, c-0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return readN(r, n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := readLength(r, c-0xc7)
+		if err != nil {
+			return nil, err
+		}
+		return decodeExt(r, n)
+	case 0xca:
+		u, err := readUint(r, 4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := readUint(r, 8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := readUint(r, 1<<(c-0xcc))
+		if err == nil && u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, err
+	case 0xd0:
+		u, err := readUint(r, 1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := readUint(r, 2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := readUint(r, 4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := readUint(r, 8)
+		return int64(u), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return decodeExt(r, 1<<(c-0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := readLength(r, c-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return decodeString(r, n)
+	case 0xdc, 0xdd:
+		n, err := readLength(r, c-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return decodeArray(r, n)
+	case 0xde, 0xdf:
+		n, err := readLength(r, c-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMap(r, n)
+	}
+	return nil, ErrInvalid
+}
+
+// decodeExt reads an extension type with n bytes of data. Timestamps are
+// decoded as time.Time values and other types as their data.
+func decodeExt(r *bufio.Reader, n int) (interface{}, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	buf, err := readN(r, n)
+	if err != nil || int8(typ) != -1 {
+		return buf, err
+	}
+	switch n {
+	case 4:
+		sec := binary.BigEndian.Uint32(buf)
+		return time.Unix(int64(sec), 0).UTC(), nil
+	case 8:
+		u := binary.BigEndian.Uint64(buf)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)).UTC(), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(buf)
+		sec := binary.BigEndian.Uint64(buf[4:])
+		return time.Unix(int64(sec), int64(nsec)).UTC(), nil
+	}
+	return nil, ErrInvalid
+}
+
+// readLength reads a length of 1, 2, or 4 bytes for a size class of 0, 1,
+// or 2.
+func readLength(r *bufio.Reader, class byte) (int, error) {
+	u, err := readUint(r, 1<<class)
+	return int(u), err
+}
+
+func readUint(r *bufio.Reader, size int) (uint64, error) {
+	buf, err := readN(r, size)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, b := range buf {
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+func readN(r *bufio.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+func decodeString(r *bufio.Reader, n int) (interface{}, error) {
+	buf, err := readN(r, n)
+	return string(buf), err
+}
+
+func decodeArray(r *bufio.Reader, n int) (interface{}, error) {
+	a := make([]interface{}, n)
+	for i := range a {
+		v, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = v
+	}
+	return a, nil
+}
+
+func decodeMap(r *bufio.Reader, n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		ks, ok := k.(string)
+		if !ok {
+			ks = fmt.Sprint(k)
+		}
+		if m[ks], err = decode(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
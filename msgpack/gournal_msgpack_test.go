@@ -0,0 +1,189 @@
+package msgpack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/channel"
+	"github.com/akutz/gournal/replay"
+	"github.com/akutz/gournal/socket"
+)
+
+func TestFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, Formatter{}.Format(buf, &gournal.Record{
+		Time:    time.Unix(1, 0),
+		Level:   gournal.InfoLevel,
+		Message: "Hi",
+		Fields:  map[string]interface{}{"n": -1},
+	}))
+	assert.Equal(t, []byte{
+		0x84,
+		0xa4, 't', 'i', 'm', 'e', 0xd6, 0xff, 0, 0, 0, 1,
+		0xa5, 'l', 'e', 'v', 'e', 'l', 0xa4, 'I', 'N', 'F', 'O',
+		0xa3, 'm', 's', 'g', 0xa2, 'H', 'i',
+		0xa6, 'f', 'i', 'e', 'l', 'd', 's', 0x81, 0xa1, 'n', 0xff,
+	}, buf.Bytes())
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestRoundTrip(t *testing.T) {
+	when := time.Date(2017, 10, 1, 12, 0, 0, 123, time.UTC)
+	buf := &bytes.Buffer{}
+	f := Formatter{}
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    when,
+		Level:   gournal.WarnLevel,
+		Message: "Hello Bob",
+		Fields: map[string]interface{}{
+			"nil":     nil,
+			"bool":    true,
+			"int8":    int8(-100),
+			"int16":   int16(-1000),
+			"int32":   int32(-100000),
+			"int64":   int64(math.MinInt64),
+			"uint8":   uint8(200),
+			"uint16":  uint16(60000),
+			"uint32":  uint32(4000000000),
+			"uint64":  uint64(math.MaxUint64),
+			"float32": float32(1.5),
+			"float64": 2.5,
+			"string":  string(bytes.Repeat([]byte{'a'}, 300)),
+			"bytes":   []byte{1, 2},
+			"time":    time.Date(1900, 1, 1, 0, 0, 0, 1, time.UTC),
+			"error":   errors.New("EOF"),
+			"array":   []interface{}{"a", 1},
+			"map":     map[string]interface{}{"k": "v"},
+			"struct":  point{1, 2},
+			"dur":     time.Second,
+			"chan":    make(chan int),
+		},
+	}))
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Level:   gournal.ErrorLevel,
+		Message: "Hello Alice",
+	}))
+
+	d := NewDecoder(buf)
+	rec, err := d.Decode()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, when, rec.Time)
+	assert.Equal(t, gournal.WarnLevel, rec.Level)
+	assert.Equal(t, "Hello Bob", rec.Message)
+	assert.Regexp(t, "^0x[0-9a-f]+$", rec.Fields["chan"])
+	delete(rec.Fields, "chan")
+	assert.Equal(t, map[string]interface{}{
+		"nil":     nil,
+		"bool":    true,
+		"int8":    int64(-100),
+		"int16":   int64(-1000),
+		"int32":   int64(-100000),
+		"int64":   int64(math.MinInt64),
+		"uint8":   int64(200),
+		"uint16":  int64(60000),
+		"uint32":  int64(4000000000),
+		"uint64":  uint64(math.MaxUint64),
+		"float32": 1.5,
+		"float64": 2.5,
+		"string":  string(bytes.Repeat([]byte{'a'}, 300)),
+		"bytes":   []byte{1, 2},
+		"time":    time.Date(1900, 1, 1, 0, 0, 0, 1, time.UTC),
+		"error":   "EOF",
+		"array":   []interface{}{"a", int64(1)},
+		"map":     map[string]interface{}{"k": "v"},
+		"struct":  map[string]interface{}{"X": int64(1), "Y": int64(2)},
+		"dur":     int64(time.Second),
+	}, rec.Fields)
+
+	rec, err = d.Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, &gournal.Record{
+		Level:   gournal.ErrorLevel,
+		Message: "Hello Alice",
+	}, rec)
+
+	_, err = d.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecodeError(t *testing.T) {
+	for _, b := range [][]byte{
+		{0xc1},
+		{0x01},
+		{0x81, 0xa4, 't', 'i', 'm', 'e', 0x01},
+		{0x81, 0xa6, 'f', 'i', 'e', 'l', 'd', 's', 0x01},
+	} {
+		_, err := NewDecoder(bytes.NewReader(b)).Decode()
+		assert.Equal(t, ErrInvalid, err)
+	}
+	_, err := NewDecoder(bytes.NewReader([]byte{0x82, 0xa1})).Decode()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReplay(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(),
+		gournal.NewAppenderWithFormatter(buf, Formatter{}))
+	ctx = gournal.WithTime(ctx, time.Unix(0, 1))
+	gournal.WithField("size", 1).Info(ctx, "Hello Bob")
+
+	ch := make(chan gournal.Record, 1)
+	n, err := replay.Replay(nil, NewDecoder(buf), channel.New(ch))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, gournal.Record{
+		Time:    time.Unix(0, 1).UTC(),
+		Level:   gournal.InfoLevel,
+		Message: "Hello Bob",
+		Fields:  map[string]interface{}{"size": int64(1)},
+	}, <-ch)
+}
+
+func TestSocket(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer l.Close()
+
+	a := socket.NewWithOptions(
+		"tcp", l.Addr().String(), nil, Formatter{}, socket.Raw, 10)
+	ctx := context.WithValue(
+		context.Background(), gournal.AppenderKey(), a)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	gournal.WithField("n", 10).Info(ctx, "Hello Bob")
+	gournal.Warn(ctx, "Hello Alice")
+
+	conn, err := l.Accept()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.Close()
+	d := NewDecoder(conn)
+	rec, err := d.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Hello Bob", rec.Message)
+		assert.Equal(t, map[string]interface{}{"n": int64(10)}, rec.Fields)
+	}
+	rec, err = d.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Hello Alice", rec.Message)
+	}
+	assert.NoError(t, a.Close())
+}
@@ -0,0 +1,12 @@
+// Package noop provides a Gournal Appender that discards every entry. It is
+// useful for benchmarks and for libraries that must be silent by default.
+package noop
+
+import "github.com/akutz/gournal"
+
+// New returns an Appender whose Append function does nothing. The returned
+// Appender is gournal.Discard, so it does not exit the program or panic when
+// it receives FATAL or PANIC entries.
+func New() gournal.Appender {
+	return gournal.Discard
+}
@@ -0,0 +1,24 @@
+package noop
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestAppender(t *testing.T) {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), New())
+
+	gournal.Info(ctx, "Hello Bob")
+	gournal.Fatal(ctx, "Hello Bob")
+	gournal.Panic(ctx, "Hello Bob")
+
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		gournal.Info(ctx, "Hello Bob")
+	}))
+}
@@ -0,0 +1,173 @@
+// Package nsq provides a Gournal Appender that publishes entries as JSON
+// gournal.Record objects to an NSQ topic, failing over between nsqd
+// instances.
+//
+// This package does not import an NSQ client. The Publisher interface is
+// satisfied by *nsq.Producer from github.com/nsqio/go-nsq, one of which is
+// created for each nsqd instance:
+//
+//	var pubs []nsq.Publisher
+//	for _, addr := range []string{"nsqd-1:4150", "nsqd-2:4150"} {
+//		p, err := gonsq.NewProducer(addr, gonsq.NewConfig())
+//		if err != nil {
+//			return err
+//		}
+//		pubs = append(pubs, p)
+//	}
+//	a := nsq.New(pubs, "logs")
+package nsq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// MinBackoff is the amount of time a Publisher returned by New is
+	// skipped after it first fails to publish an entry.
+	MinBackoff = 100 * time.Millisecond
+
+	// MaxBackoff is the maximum amount of time a Publisher returned by New
+	// is skipped after consecutive failures.
+	MaxBackoff = 10 * time.Second
+
+	// ErrUnavailable is the error returned when every Publisher is backing
+	// off after a failure.
+	ErrUnavailable = errors.New("nsq: no publisher available")
+)
+
+// now is replaced by tests
+var now = time.Now
+
+// Publisher publishes a message to an NSQ topic on an nsqd instance.
+type Publisher interface {
+	Publish(topic string, body []byte) error
+}
+
+// New returns an Appender that publishes entries to the provided topic
+// using MinBackoff and MaxBackoff.
+func New(pubs []Publisher, topic string) gournal.Appender {
+	return NewWithOptions(pubs, topic, MinBackoff, MaxBackoff)
+}
+
+// NewWithOptions returns an Appender that publishes each entry to the
+// provided topic with the first of the Publishers that is not backing off.
+// When a Publisher fails, the entry is published with the next Publisher
+// and the failed Publisher is skipped for a backoff that starts at
+// minBackoff and doubles with each consecutive failure up to maxBackoff.
+//
+// When no Publisher is able to publish an entry, the last error, or
+// ErrUnavailable if every Publisher is backing off, is reported with
+// gournal.HandleError. The returned Appender also implements
+// gournal.ErrorAppender, which returns the error instead.
+func NewWithOptions(
+	pubs []Publisher,
+	topic string,
+	minBackoff, maxBackoff time.Duration) gournal.Appender {
+
+	a := &appender{
+		topic:      topic,
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		pubs:       make([]*publisher, len(pubs)),
+	}
+	for i, p := range pubs {
+		a.pubs[i] = &publisher{Publisher: p}
+	}
+	return a
+}
+
+type appender struct {
+	topic      string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	// mu protects the backoff state of the publishers
+	mu   sync.Mutex
+	pubs []*publisher
+}
+
+type publisher struct {
+	Publisher
+	backoff time.Duration
+	until   time.Time
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+func (a *appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	body, err := json.Marshal(&gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = ErrUnavailable
+	for _, p := range a.pubs {
+		if !a.available(p) {
+			continue
+		}
+		if err = p.Publish(a.topic, body); err == nil {
+			a.succeeded(p)
+			return nil
+		}
+		a.failed(p)
+	}
+	return err
+}
+
+func (a *appender) available(p *publisher) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return !now().Before(p.until)
+}
+
+func (a *appender) succeeded(p *publisher) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p.backoff = 0
+}
+
+func (a *appender) failed(p *publisher) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if p.backoff == 0 {
+		p.backoff = a.minBackoff
+	} else if p.backoff *= 2; p.backoff > a.maxBackoff {
+		p.backoff = a.maxBackoff
+	}
+	p.until = now().Add(p.backoff)
+}
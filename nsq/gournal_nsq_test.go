@@ -0,0 +1,83 @@
+package nsq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type testPublisher struct {
+	bodies []string
+	err    error
+}
+
+func (p *testPublisher) Publish(topic string, body []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.bodies = append(p.bodies, topic+" "+string(body))
+	return nil
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(),
+		time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC))
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestNSQAppender(t *testing.T) {
+	p := &testPublisher{}
+	a := New([]Publisher{p}, "logs")
+	gournal.WithField("size", 1).Info(newContext(a), "Hello %s", "Bob")
+	assert.Equal(t, []string{
+		`logs {"time":"2017-10-01T12:00:00Z","level":"INFO",` +
+			`"msg":"Hello Bob","fields":{"size":1}}`,
+	}, p.bodies)
+}
+
+func TestNSQAppenderFailover(t *testing.T) {
+	clock := time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return clock }
+
+	p1 := &testPublisher{err: errors.New("down")}
+	p2 := &testPublisher{}
+	a := NewWithOptions([]Publisher{p1, p2}, "logs", time.Second, 3*time.Second)
+	ctx := newContext(a)
+	try := func() error {
+		return gournal.TryAppend(a, ctx, gournal.InfoLevel, nil, "Hello")
+	}
+
+	assert.NoError(t, try())
+	assert.Len(t, p2.bodies, 1)
+	assert.Equal(t, time.Second, a.(*appender).pubs[0].backoff)
+
+	// p1 is skipped while backing off
+	p1.err = nil
+	assert.NoError(t, try())
+	assert.Len(t, p1.bodies, 0)
+	assert.Len(t, p2.bodies, 2)
+
+	// p1 is used again once the backoff elapses, and its backoff resets
+	clock = clock.Add(time.Second)
+	assert.NoError(t, try())
+	assert.Len(t, p1.bodies, 1)
+	assert.Equal(t, time.Duration(0), a.(*appender).pubs[0].backoff)
+
+	// consecutive failures double the backoff up to the maximum
+	p1.err, p2.err = errors.New("down"), errors.New("gone")
+	assert.EqualError(t, try(), "gone")
+	assert.EqualError(t, try(), ErrUnavailable.Error())
+	for _, d := range []time.Duration{2, 3, 3} {
+		clock = clock.Add(time.Minute)
+		assert.EqualError(t, try(), "gone")
+		assert.Equal(t, d*time.Second, a.(*appender).pubs[0].backoff)
+	}
+}
@@ -0,0 +1,98 @@
+// Package opentracing provides a Gournal Appender that tags every entry with
+// the IDs of the OpenTracing span present in the entry's Context and
+// optionally mirrors entries onto the span itself.
+//
+// This package does not import the OpenTracing API. An opentracing.Span
+// satisfies the Span interface as-is, and the SpanFunc and IDsFunc are
+// typically small closures around opentracing.SpanFromContext and the
+// tracer's span context type, ex. jaeger.SpanContext.
+package opentracing
+
+import (
+	"context"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// TraceIDKey is the name of the field that contains the trace ID.
+	TraceIDKey = "trace_id"
+
+	// SpanIDKey is the name of the field that contains the span ID.
+	SpanIDKey = "span_id"
+)
+
+// Span is the subset of the opentracing.Span interface used by the Appender.
+type Span interface {
+
+	// LogKV records key:value logging data about the span.
+	LogKV(alternatingKeyValues ...interface{})
+}
+
+// SpanFunc returns the active span in the provided Context or nil if there
+// is no active span.
+type SpanFunc func(ctx context.Context) Span
+
+// IDsFunc returns the trace and span IDs of the provided span and a flag
+// indicating whether the IDs could be determined.
+type IDsFunc func(span Span) (traceID, spanID string, ok bool)
+
+// New returns an Appender that tags entries with the trace and span IDs of
+// the active span before delegating to the next Appender.
+func New(next gournal.Appender, spanFn SpanFunc, idsFn IDsFunc) gournal.Appender {
+	return NewWithOptions(next, spanFn, idsFn, gournal.UnknownLevel)
+}
+
+// NewWithOptions returns an Appender that tags entries with the trace and span
+// IDs of the active span before delegating to the next Appender. Entries at
+// the mirror level or a more severe level are also logged to the span with
+// LogKV. A mirror level of UnknownLevel disables mirroring.
+func NewWithOptions(
+	next gournal.Appender,
+	spanFn SpanFunc,
+	idsFn IDsFunc,
+	mirror gournal.Level) gournal.Appender {
+
+	return &appender{next: next, spanFn: spanFn, idsFn: idsFn, mirror: mirror}
+}
+
+type appender struct {
+	next   gournal.Appender
+	spanFn SpanFunc
+	idsFn  IDsFunc
+	mirror gournal.Level
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	span := a.spanFn(ctx)
+	if span == nil {
+		a.next.Append(ctx, lvl, fields, msg)
+		return
+	}
+
+	if lvl <= a.mirror {
+		kvs := make([]interface{}, 0, 4+len(fields)*2)
+		kvs = append(kvs, "event", msg, "level", lvl.String())
+		for k, v := range fields {
+			kvs = append(kvs, k, v)
+		}
+		span.LogKV(kvs...)
+	}
+
+	if traceID, spanID, ok := a.idsFn(span); ok {
+		tagged := make(map[string]interface{}, len(fields)+2)
+		for k, v := range fields {
+			tagged[k] = v
+		}
+		tagged[TraceIDKey] = traceID
+		tagged[SpanIDKey] = spanID
+		fields = tagged
+	}
+
+	a.next.Append(ctx, lvl, fields, msg)
+}
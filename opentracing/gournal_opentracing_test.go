@@ -0,0 +1,70 @@
+package opentracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type testSpan struct {
+	traceID string
+	spanID  string
+	logs    [][]interface{}
+}
+
+func (s *testSpan) LogKV(alternatingKeyValues ...interface{}) {
+	s.logs = append(s.logs, alternatingKeyValues)
+}
+
+type spanKey struct{}
+
+func spanFromContext(ctx context.Context) Span {
+	if s, ok := ctx.Value(spanKey{}).(*testSpan); ok {
+		return s
+	}
+	return nil
+}
+
+func spanIDs(span Span) (string, string, bool) {
+	s := span.(*testSpan)
+	return s.traceID, s.spanID, true
+}
+
+func TestAppender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	span := &testSpan{traceID: "abc", spanID: "def"}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), NewWithOptions(
+		gournal.NewAppenderWithOptions(buf),
+		spanFromContext,
+		spanIDs,
+		gournal.WarnLevel))
+
+	gournal.Info(ctx, "Hello Bob")
+	assert.Equal(t, "[INFO] Hello Bob\n", buf.String())
+	buf.Reset()
+
+	ctx = context.WithValue(ctx, spanKey{}, span)
+	gournal.Info(ctx, "Hello Alice")
+	assert.Equal(
+		t, "[INFO] Hello Alice map[span_id:def trace_id:abc]\n", buf.String())
+	assert.Empty(t, span.logs)
+	buf.Reset()
+
+	gournal.WithField("size", 1).Warn(ctx, "Hello Mary")
+	assert.Equal(
+		t,
+		"[WARN] Hello Mary map[size:1 span_id:def trace_id:abc]\n",
+		buf.String())
+	assert.Equal(
+		t,
+		[][]interface{}{
+			{"event", "Hello Mary", "level", "WARN", "size", 1},
+		},
+		span.logs)
+}
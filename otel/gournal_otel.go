@@ -0,0 +1,82 @@
+// Package otel provides a Gournal Appender that enriches every entry with the
+// OpenTelemetry trace correlation fields of the span present in the entry's
+// Context, and an Appender that records entries as events on that span.
+//
+// This package does not import the OpenTelemetry API. Instead, callers
+// provide a SpanContextFunc that extracts the span context, for example:
+//
+//	func(ctx context.Context) (otel.SpanContext, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return otel.SpanContext{
+//			TraceID:    sc.TraceID().String(),
+//			SpanID:     sc.SpanID().String(),
+//			TraceFlags: byte(sc.TraceFlags()),
+//		}, sc.IsValid()
+//	}
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// TraceIDKey is the name of the field that contains the trace ID.
+	TraceIDKey = "trace_id"
+
+	// SpanIDKey is the name of the field that contains the span ID.
+	SpanIDKey = "span_id"
+
+	// TraceFlagsKey is the name of the field that contains the trace flags.
+	TraceFlagsKey = "trace_flags"
+)
+
+// SpanContext is the correlation data of a span.
+type SpanContext struct {
+	// TraceID is the hex-encoded trace ID.
+	TraceID string
+
+	// SpanID is the hex-encoded span ID.
+	SpanID string
+
+	// TraceFlags are the W3C trace flags, ex. 0x01 for sampled.
+	TraceFlags byte
+}
+
+// SpanContextFunc returns the SpanContext of the span present in the provided
+// Context and a flag indicating whether a valid span was present.
+type SpanContextFunc func(ctx context.Context) (SpanContext, bool)
+
+// New returns an Appender that adds the trace correlation fields returned by
+// the provided function to every entry before delegating to the next
+// Appender.
+func New(next gournal.Appender, fn SpanContextFunc) gournal.Appender {
+	return &appender{next: next, fn: fn}
+}
+
+type appender struct {
+	next gournal.Appender
+	fn   SpanContextFunc
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if sc, ok := a.fn(ctx); ok {
+		enriched := make(map[string]interface{}, len(fields)+3)
+		for k, v := range fields {
+			enriched[k] = v
+		}
+		enriched[TraceIDKey] = sc.TraceID
+		enriched[SpanIDKey] = sc.SpanID
+		enriched[TraceFlagsKey] = fmt.Sprintf("%02x", sc.TraceFlags)
+		fields = enriched
+	}
+
+	a.next.Append(ctx, lvl, fields, msg)
+}
@@ -0,0 +1,82 @@
+package otel
+
+import (
+	"context"
+
+	"github.com/akutz/gournal"
+)
+
+// LevelAttributeKey is the name of the span event attribute that contains
+// the level of the entry.
+var LevelAttributeKey = "level"
+
+// Span is the subset of an OpenTelemetry span used to record entries as
+// span events, for example:
+//
+//	type span struct{ trace.Span }
+//
+//	func (s span) AddEvent(name string, attrs map[string]interface{}) {
+//		kvs := make([]attribute.KeyValue, 0, len(attrs))
+//		for k, v := range attrs {
+//			kvs = append(kvs, attribute.String(k, fmt.Sprint(v)))
+//		}
+//		s.Span.AddEvent(name, trace.WithAttributes(kvs...))
+//	}
+//
+//	func (s span) SetError(description string) {
+//		s.Span.SetStatus(codes.Error, description)
+//	}
+type Span interface {
+
+	// AddEvent adds an event with the provided name and attributes.
+	AddEvent(name string, attrs map[string]interface{})
+
+	// SetError sets the status of the span to an error with the provided
+	// description.
+	SetError(description string)
+}
+
+// SpanFunc returns the recording span present in the provided Context and
+// a flag indicating whether such a span was present.
+type SpanFunc func(ctx context.Context) (Span, bool)
+
+// NewSpanEvents returns an Appender that adds every entry as an event on
+// the span returned by the provided function, so entries appear inline on
+// traces without a log backend. The event is named after the entry's
+// message and its attributes are the entry's fields and level. ERROR,
+// FATAL, and PANIC entries also set the status of the span to an error.
+//
+// Entries are then delivered to next. If next is nil, entries are only
+// recorded as span events.
+func NewSpanEvents(next gournal.Appender, fn SpanFunc) gournal.Appender {
+	if next == nil {
+		next = gournal.Discard
+	}
+	return &eventAppender{next: next, fn: fn}
+}
+
+type eventAppender struct {
+	next gournal.Appender
+	fn   SpanFunc
+}
+
+func (a *eventAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if span, ok := a.fn(ctx); ok {
+		attrs := make(map[string]interface{}, len(fields)+1)
+		for k, v := range fields {
+			attrs[k] = v
+		}
+		attrs[LevelAttributeKey] = lvl.String()
+		span.AddEvent(msg, attrs)
+		if lvl <= gournal.ErrorLevel {
+			span.SetError(msg)
+		}
+	}
+
+	a.next.Append(ctx, lvl, fields, msg)
+}
@@ -0,0 +1,118 @@
+package otel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/akutz/gournal"
+)
+
+// Resource is the resource with which a Formatter returned by NewFormatter
+// describes the entity producing entries, ex. {"service.name": "api"}.
+var Resource map[string]interface{}
+
+// Formatter formats entries as newline-delimited JSON objects that follow
+// the OpenTelemetry Log Data Model, so collectors can ingest them without
+// transformation:
+//
+//	{"Timestamp":"1506859200000000000","SeverityText":"INFO",
+//	 "SeverityNumber":9,"Body":"Hello Bob","Attributes":{"size":1},
+//	 "Resource":{"service.name":"api"},
+//	 "TraceId":"4bf92f3577b34da6a3ce929d0e0e4736",
+//	 "SpanId":"00f067aa0ba902b7","TraceFlags":1}
+//
+// The trace context is read from the fields added by the Appender returned
+// by New, and the remaining fields become the entry's attributes. Attribute
+// values that cannot be marshaled to JSON are written as strings.
+type Formatter struct {
+	resource map[string]interface{}
+}
+
+// NewFormatter returns a Formatter that describes entries with Resource.
+func NewFormatter() *Formatter {
+	return NewFormatterWithOptions(Resource)
+}
+
+// NewFormatterWithOptions returns a Formatter that describes entries with
+// the provided resource, which may be nil.
+func NewFormatterWithOptions(resource map[string]interface{}) *Formatter {
+	return &Formatter{resource: resource}
+}
+
+type logRecord struct {
+	Timestamp      string                 `json:"Timestamp,omitempty"`
+	SeverityText   string                 `json:"SeverityText"`
+	SeverityNumber int                    `json:"SeverityNumber"`
+	Body           string                 `json:"Body"`
+	Attributes     gournal.OrderedFields  `json:"Attributes,omitempty"`
+	Resource       map[string]interface{} `json:"Resource,omitempty"`
+	TraceID        string                 `json:"TraceId,omitempty"`
+	SpanID         string                 `json:"SpanId,omitempty"`
+	TraceFlags     *uint64                `json:"TraceFlags,omitempty"`
+}
+
+// Format writes the Record to the buffer.
+func (f *Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	lr := &logRecord{
+		SeverityText:   rec.Level.String(),
+		SeverityNumber: SeverityNumber(rec.Level),
+		Body:           rec.Message,
+		Resource:       f.resource,
+	}
+	if !rec.Time.IsZero() {
+		lr.Timestamp = strconv.FormatInt(rec.Time.UnixNano(), 10)
+	}
+
+	for k, v := range rec.Fields {
+		switch k {
+		case TraceIDKey:
+			lr.TraceID = fmt.Sprint(v)
+			continue
+		case SpanIDKey:
+			lr.SpanID = fmt.Sprint(v)
+			continue
+		case TraceFlagsKey:
+			flags, err := strconv.ParseUint(fmt.Sprint(v), 16, 8)
+			if err == nil {
+				lr.TraceFlags = &flags
+				continue
+			}
+		}
+		if _, err := json.Marshal(v); err != nil {
+			v = fmt.Sprint(v)
+		}
+		if lr.Attributes == nil {
+			lr.Attributes = make(gournal.OrderedFields, len(rec.Fields))
+		}
+		lr.Attributes[k] = v
+	}
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(lr)
+}
+
+// SeverityNumber returns the OpenTelemetry severity number of the level,
+// or the one returned by gournal.SeverityMapper for the "otel" backend.
+func SeverityNumber(lvl gournal.Level) int {
+	if n, ok := gournal.MapSeverityInt("otel", lvl); ok {
+		return n
+	}
+	switch lvl {
+	case gournal.DebugLevel:
+		return 5
+	case gournal.InfoLevel:
+		return 9
+	case gournal.WarnLevel:
+		return 13
+	case gournal.ErrorLevel:
+		return 17
+	case gournal.FatalLevel:
+		return 21
+	case gournal.PanicLevel:
+		return 22
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type spanKey struct{}
+
+func spanContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanKey{}).(SpanContext)
+	return sc, ok
+}
+
+func TestAppender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), New(
+		gournal.NewAppenderWithOptions(buf), spanContext))
+
+	gournal.Info(ctx, "Hello Bob")
+	assert.Equal(t, "[INFO] Hello Bob\n", buf.String())
+	buf.Reset()
+
+	ctx = context.WithValue(ctx, spanKey{}, SpanContext{
+		TraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:     "00f067aa0ba902b7",
+		TraceFlags: 1,
+	})
+	gournal.WithField("size", 1).Info(ctx, "Hello Mary")
+	assert.Equal(
+		t,
+		"[INFO] Hello Mary map[size:1 span_id:00f067aa0ba902b7 "+
+			"trace_flags:01 trace_id:4bf92f3577b34da6a3ce929d0e0e4736]\n",
+		buf.String())
+}
+
+type event struct {
+	name  string
+	attrs map[string]interface{}
+}
+
+type testSpan struct {
+	events []event
+	err    string
+}
+
+func (s *testSpan) AddEvent(name string, attrs map[string]interface{}) {
+	s.events = append(s.events, event{name, attrs})
+}
+
+func (s *testSpan) SetError(description string) {
+	s.err = description
+}
+
+func TestSpanEvents(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fn := func(ctx context.Context) (Span, bool) {
+		s, ok := ctx.Value(spanKey{}).(*testSpan)
+		return s, ok
+	}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), NewSpanEvents(
+		gournal.NewAppenderWithOptions(buf), fn))
+
+	gournal.Info(ctx, "Hello Bob")
+	assert.Equal(t, "[INFO] Hello Bob\n", buf.String())
+
+	span := &testSpan{}
+	ctx = context.WithValue(ctx, spanKey{}, span)
+	gournal.WithField("size", 1).Info(ctx, "Hello Mary")
+	assert.Empty(t, span.err)
+	gournal.Error(ctx, "Hello %s", "Alice")
+
+	assert.Equal(t, []event{
+		{"Hello Mary", map[string]interface{}{"size": 1, "level": "INFO"}},
+		{"Hello Alice", map[string]interface{}{"level": "ERROR"}},
+	}, span.events)
+	assert.Equal(t, "Hello Alice", span.err)
+}
+
+func TestFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := NewFormatterWithOptions(map[string]interface{}{"service.name": "api"})
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		Level:   gournal.InfoLevel,
+		Message: "Hello <Bob>",
+		Fields: map[string]interface{}{
+			"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":     "00f067aa0ba902b7",
+			"trace_flags": "01",
+			"size":        1,
+			"ch":          make(chan int),
+		},
+	}))
+	assert.Regexp(t,
+		`^{"Timestamp":"1506859200000000000","SeverityText":"INFO",`+
+			`"SeverityNumber":9,"Body":"Hello <Bob>",`+
+			`"Attributes":{"ch":"0x[0-9a-f]+","size":1},`+
+			`"Resource":{"service.name":"api"},`+
+			`"TraceId":"4bf92f3577b34da6a3ce929d0e0e4736",`+
+			`"SpanId":"00f067aa0ba902b7","TraceFlags":1}\n$`,
+		buf.String())
+
+	buf.Reset()
+	assert.NoError(t, NewFormatter().Format(buf, &gournal.Record{
+		Level:   gournal.PanicLevel,
+		Message: "Hi",
+	}))
+	assert.Equal(t,
+		`{"SeverityText":"PANIC","SeverityNumber":22,"Body":"Hi"}`+"\n",
+		buf.String())
+}
+
+func TestFormatterWithAppender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), New(
+		gournal.NewAppenderWithFormatter(buf, NewFormatter()), spanContext))
+	ctx = context.WithValue(ctx, spanKey{}, SpanContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+	})
+	ctx = gournal.WithTime(ctx, time.Unix(0, 1))
+
+	gournal.Error(ctx, "Hello Bob")
+	assert.Equal(t,
+		`{"Timestamp":"1","SeverityText":"ERROR","SeverityNumber":17,`+
+			`"Body":"Hello Bob",`+
+			`"TraceId":"4bf92f3577b34da6a3ce929d0e0e4736",`+
+			`"SpanId":"00f067aa0ba902b7","TraceFlags":0}`+"\n",
+		buf.String())
+}
+
+func TestSeverityNumber(t *testing.T) {
+	assert.Equal(t, 5, SeverityNumber(gournal.DebugLevel))
+	assert.Equal(t, 13, SeverityNumber(gournal.WarnLevel))
+	assert.Equal(t, 21, SeverityNumber(gournal.FatalLevel))
+	assert.Equal(t, 0, SeverityNumber(gournal.UnknownLevel))
+}
@@ -0,0 +1,207 @@
+// Package propagation parses and injects W3C Trace Context and B3 headers so
+// that log entries may be correlated across services without a full tracing
+// stack.
+//
+// Handler extracts the trace context from incoming requests, storing it in
+// the request's Context and pushing its IDs onto the Context's field stack.
+// Transport injects the trace context from an outbound request's Context
+// into the request's headers before delegating to gournal.Transport.
+package propagation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// TraceIDKey is the name of the field that contains the trace ID.
+	TraceIDKey = "trace_id"
+
+	// SpanIDKey is the name of the field that contains the span ID.
+	SpanIDKey = "span_id"
+)
+
+const (
+	traceparentHeader = "Traceparent"
+	b3Header          = "B3"
+	b3TraceIDHeader   = "X-B3-Traceid"
+	b3SpanIDHeader    = "X-B3-Spanid"
+	b3SampledHeader   = "X-B3-Sampled"
+)
+
+// TraceContext is the correlation data propagated between services.
+type TraceContext struct {
+
+	// TraceID is the hex-encoded, 16-byte trace ID.
+	TraceID string
+
+	// SpanID is the hex-encoded, 8-byte span ID.
+	SpanID string
+
+	// Sampled indicates whether the trace is sampled.
+	Sampled bool
+}
+
+type contextKey struct{}
+
+// New returns a TraceContext with a randomly generated trace and span ID.
+func New() TraceContext {
+	return TraceContext{TraceID: randomHex(16), SpanID: randomHex(8)}
+}
+
+// FromContext returns the TraceContext stored in the provided Context.
+func FromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(contextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// WithTraceContext returns a Context that stores the provided TraceContext
+// and the function that pops its IDs from the Context's field stack.
+func WithTraceContext(
+	ctx context.Context, tc TraceContext) (context.Context, func()) {
+
+	ctx = context.WithValue(ctx, contextKey{}, tc)
+	return gournal.PushFields(ctx, map[string]interface{}{
+		TraceIDKey: tc.TraceID,
+		SpanIDKey:  tc.SpanID,
+	})
+}
+
+// Extract parses a TraceContext from the provided headers. The W3C
+// traceparent header is preferred, followed by the single B3 header and
+// finally the multiple X-B3-* headers.
+func Extract(h http.Header) (TraceContext, bool) {
+	if tc, ok := parseTraceparent(h.Get(traceparentHeader)); ok {
+		return tc, true
+	}
+	if tc, ok := parseB3(h.Get(b3Header)); ok {
+		return tc, true
+	}
+	tc := TraceContext{
+		TraceID: strings.ToLower(h.Get(b3TraceIDHeader)),
+		SpanID:  strings.ToLower(h.Get(b3SpanIDHeader)),
+		Sampled: h.Get(b3SampledHeader) == "1",
+	}
+	if len(tc.TraceID) == 16 {
+		tc.TraceID = "0000000000000000" + tc.TraceID
+	}
+	if !isHex(tc.TraceID, 32) || !isHex(tc.SpanID, 16) {
+		return TraceContext{}, false
+	}
+	return tc, true
+}
+
+// Inject writes the provided TraceContext to the headers as both a W3C
+// traceparent header and a single B3 header.
+func Inject(h http.Header, tc TraceContext) {
+	flags, sampled := "00", "0"
+	if tc.Sampled {
+		flags, sampled = "01", "1"
+	}
+	h.Set(traceparentHeader, "00-"+tc.TraceID+"-"+tc.SpanID+"-"+flags)
+	h.Set(b3Header, tc.TraceID+"-"+tc.SpanID+"-"+sampled)
+}
+
+// Handler returns an http.Handler that extracts the TraceContext from every
+// request, or generates a new one if none is present, and stores it in the
+// request's Context before invoking the next handler.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc, ok := Extract(r.Header)
+		if !ok {
+			tc = New()
+		}
+		ctx, pop := WithTraceContext(r.Context(), tc)
+		defer pop()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Transport returns an http.RoundTripper that injects the TraceContext
+// stored in each request's Context into the request's headers and then
+// delegates to gournal.Transport(next).
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{gournal.Transport(next)}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if tc, ok := FromContext(req.Context()); ok {
+		// a RoundTripper must not modify the provided request
+		r := new(http.Request)
+		*r = *req
+		r.Header = make(http.Header, len(req.Header)+2)
+		for k, v := range req.Header {
+			r.Header[k] = v
+		}
+		Inject(r.Header, tc)
+		req = r
+	}
+	return t.next.RoundTrip(req)
+}
+
+func parseTraceparent(s string) (TraceContext, bool) {
+	// version-traceid-parentid-flags
+	parts := strings.Split(strings.ToLower(s), "-")
+	if len(parts) < 4 || !isHex(parts[0], 2) || parts[0] == "ff" {
+		return TraceContext{}, false
+	}
+	if !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return TraceContext{}, false
+	}
+	if parts[1] == strings.Repeat("0", 32) ||
+		parts[2] == strings.Repeat("0", 16) {
+		return TraceContext{}, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return TraceContext{
+		TraceID: parts[1],
+		SpanID:  parts[2],
+		Sampled: flags[0]&0x01 == 0x01,
+	}, true
+}
+
+func parseB3(s string) (TraceContext, bool) {
+	// traceid-spanid[-sampled[-parentspanid]]
+	parts := strings.Split(strings.ToLower(s), "-")
+	if len(parts) < 2 {
+		return TraceContext{}, false
+	}
+	tc := TraceContext{TraceID: parts[0], SpanID: parts[1]}
+	if len(tc.TraceID) == 16 {
+		tc.TraceID = "0000000000000000" + tc.TraceID
+	}
+	if !isHex(tc.TraceID, 32) || !isHex(tc.SpanID, 16) {
+		return TraceContext{}, false
+	}
+	if len(parts) > 2 {
+		tc.Sampled = parts[2] == "1" || parts[2] == "d"
+	}
+	return tc, true
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,87 @@
+package propagation
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestExtractTraceparent(t *testing.T) {
+	h := http.Header{}
+	h.Set("traceparent", "00-"+testTraceID+"-"+testSpanID+"-01")
+	tc, ok := Extract(h)
+	assert.True(t, ok)
+	assert.Equal(t, TraceContext{testTraceID, testSpanID, true}, tc)
+
+	h.Set("traceparent", "00-"+testTraceID+"-0000000000000000-01")
+	_, ok = Extract(h)
+	assert.False(t, ok)
+}
+
+func TestExtractB3(t *testing.T) {
+	h := http.Header{}
+	h.Set("b3", "a3ce929d0e0e4736-"+testSpanID+"-1")
+	tc, ok := Extract(h)
+	assert.True(t, ok)
+	assert.Equal(t, TraceContext{
+		"0000000000000000a3ce929d0e0e4736", testSpanID, true}, tc)
+
+	h = http.Header{}
+	h.Set("X-B3-TraceId", testTraceID)
+	h.Set("X-B3-SpanId", testSpanID)
+	tc, ok = Extract(h)
+	assert.True(t, ok)
+	assert.Equal(t, TraceContext{testTraceID, testSpanID, false}, tc)
+}
+
+func TestHandlerAndTransport(t *testing.T) {
+	var outbound http.Header
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			outbound = r.Header
+		}))
+	defer srv.Close()
+
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(
+		ctx, gournal.AppenderKey(), gournal.NewAppenderWithOptions(buf))
+
+	h := Handler(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gournal.Info(r.Context(), "Hello Bob")
+			req, _ := http.NewRequest("GET", srv.URL, nil)
+			client := &http.Client{Transport: Transport(nil)}
+			res, err := client.Do(req.WithContext(r.Context()))
+			if assert.NoError(t, err) {
+				res.Body.Close()
+			}
+		}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-"+testTraceID+"-"+testSpanID+"-01")
+	h.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	assert.Contains(
+		t,
+		buf.String(),
+		"[INFO] Hello Bob map[span_id:"+testSpanID+" trace_id:"+testTraceID+"]")
+	assert.Contains(t, buf.String(), "[INFO] outbound request")
+	assert.Equal(
+		t,
+		"00-"+testTraceID+"-"+testSpanID+"-01",
+		outbound.Get("traceparent"))
+	assert.Equal(t, testTraceID+"-"+testSpanID+"-1", outbound.Get("b3"))
+}
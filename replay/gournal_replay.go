@@ -0,0 +1,126 @@
+// Package replay decodes log entries from a stored stream and replays them
+// through a Gournal Appender. This makes it possible to backfill entries to a
+// new sink and to test Appender pipelines deterministically.
+//
+// Entries are re-emitted with a Context created by gournal.WithTime so
+// Appenders that use gournal.TimeFrom record each entry's original time,
+// and with the name of the logger that emitted them stored with
+// gournal.NameKey.
+// Please note that replaying FATAL or PANIC entries causes most Appenders to
+// exit or panic.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/akutz/gournal"
+)
+
+// Decoder decodes Records from a stream.
+type Decoder interface {
+
+	// Decode returns the next Record from the stream or io.EOF when there
+	// are no more Records.
+	Decode() (*gournal.Record, error)
+}
+
+// NewJSONDecoder returns a Decoder that reads newline-delimited JSON Records
+// as written by an Appender returned by NewRecorder.
+func NewJSONDecoder(r io.Reader) Decoder {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return &jsonDecoder{d}
+}
+
+type jsonDecoder struct {
+	d *json.Decoder
+}
+
+func (d *jsonDecoder) Decode() (*gournal.Record, error) {
+	var rec gournal.Record
+	if err := d.d.Decode(&rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Replay decodes every Record from the Decoder and appends it to the
+// provided Appender using a Context derived from ctx. The number of Records
+// replayed is returned along with the first decoding error other than
+// io.EOF.
+func Replay(ctx context.Context, d Decoder, a gournal.Appender) (int, error) {
+	if ctx == nil {
+		ctx = gournal.DefaultContext
+	}
+	n := 0
+	for {
+		rec, err := d.Decode()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		a.Append(recordContext(ctx, rec), rec.Level, rec.Fields, rec.Message)
+		n++
+	}
+}
+
+// recordContext returns a Context derived from ctx with the Record's time
+// and logger name.
+func recordContext(ctx context.Context, rec *gournal.Record) context.Context {
+	ctx = gournal.WithTime(ctx, rec.Time)
+	if rec.Name != "" {
+		ctx = context.WithValue(ctx, gournal.NameKey(), rec.Name)
+	}
+	return ctx
+}
+
+// NewRecorder returns an Appender that writes every entry it receives to the
+// provided writer as a newline-delimited JSON Record that may be decoded with
+// NewJSONDecoder. If the entry's fields cannot be marshaled, their string
+// forms are written instead so the entry is not lost.
+func NewRecorder(w io.Writer) gournal.Appender {
+	return &recorder{enc: json.NewEncoder(w)}
+}
+
+type recorder struct {
+	sync.Mutex
+	enc *json.Encoder
+}
+
+func (r *recorder) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	rec := &gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	// nothing is written if the Record cannot be marshaled, so the string
+	// form of the fields is written instead
+	if err := r.enc.Encode(rec); err == nil {
+		return
+	}
+	rec.Fields = make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		rec.Fields[k] = fmt.Sprint(v)
+	}
+	if err := r.enc.Encode(rec); err != nil {
+		gournal.HandleError(err)
+	}
+}
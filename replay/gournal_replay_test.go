@@ -0,0 +1,98 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type testAppender struct {
+	records []gournal.Record
+}
+
+func (a *testAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.records = append(a.records, gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+	})
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	when := time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
+	defer func() { gournal.Clock = time.Now }()
+	gournal.Clock = func() time.Time { return when }
+
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), NewRecorder(buf))
+
+	gournal.Info(ctx, "Hello Bob")
+	gournal.WithField("size", 2).Warn(ctx, "Hello Alice")
+	gournal.Debug(ctx, "Hello Mary")
+
+	assert.Equal(
+		t,
+		`{"time":"2017-10-01T12:00:00Z","level":"INFO","msg":"Hello Bob"}`+
+			"\n"+
+			`{"time":"2017-10-01T12:00:00Z","level":"WARN",`+
+			`"msg":"Hello Alice","fields":{"size":2}}`+"\n",
+		buf.String())
+
+	gournal.Clock = time.Now
+	a := &testAppender{}
+	n, err := Replay(nil, NewJSONDecoder(buf), a)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []gournal.Record{
+		{Time: when, Level: gournal.InfoLevel, Message: "Hello Bob"},
+		{
+			Time:    when,
+			Level:   gournal.WarnLevel,
+			Message: "Hello Alice",
+			Fields:  map[string]interface{}{"size": json.Number("2")},
+		},
+	}, a.records)
+}
+
+func TestReplayName(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), NewRecorder(buf))
+	ctx = context.WithValue(ctx, gournal.NameKey(), "db")
+	gournal.Info(ctx, "Hello Bob")
+	assert.Contains(t, buf.String(), `"logger":"db"`)
+
+	a := &testAppender{}
+	n, err := Replay(nil, NewJSONDecoder(buf), a)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, n) {
+		assert.Equal(t, "db", a.records[0].Name)
+	}
+}
+
+func TestReplayDecodeError(t *testing.T) {
+	a := &testAppender{}
+	n, err := Replay(
+		nil,
+		NewJSONDecoder(strings.NewReader(`{"level":"INFO","msg":"Hi"}{`)),
+		a)
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+}
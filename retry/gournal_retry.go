@@ -0,0 +1,130 @@
+// Package retry provides a Gournal Appender that retries entries that an
+// Appender fails to deliver, using exponential backoff with jitter.
+//
+// Delivery failures are detected with gournal.TryAppend, so only Appenders
+// that implement gournal.ErrorAppender are retried. Retries block the
+// caller; wrap the returned Appender with the async package to retry in the
+// background.
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// MaxAttempts is the maximum number of attempts to deliver an entry
+	// made by an Appender returned by New.
+	MaxAttempts = 5
+
+	// MinBackoff is the delay before the first retry made by an Appender
+	// returned by New.
+	MinBackoff = 100 * time.Millisecond
+
+	// MaxBackoff is the maximum delay between retries made by an Appender
+	// returned by New.
+	MaxBackoff = 10 * time.Second
+)
+
+// sleep waits for the duration d or until ctx is done, and returns false
+// if ctx is done first. It is replaced by tests.
+var sleep = func(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+var (
+	rnd   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	rndMu sync.Mutex
+)
+
+// New returns an Appender that retries entries next fails to deliver using
+// MaxAttempts, MinBackoff, and MaxBackoff.
+func New(next gournal.Appender) gournal.Appender {
+	return NewWithOptions(next, MaxAttempts, MinBackoff, MaxBackoff)
+}
+
+// NewWithOptions returns an Appender that makes up to maxAttempts attempts
+// to deliver each entry to next. The delay before each retry is a random
+// duration between zero and the backoff, which starts at minBackoff and
+// doubles after each retry up to maxBackoff.
+//
+// No more retries are made once the entry's context is done.
+//
+// When an entry cannot be delivered after maxAttempts, or before its context
+// is done, the last error is reported with gournal.HandleError. The
+// returned Appender also implements gournal.ErrorAppender, which returns
+// the error instead.
+func NewWithOptions(
+	next gournal.Appender,
+	maxAttempts int,
+	minBackoff, maxBackoff time.Duration) gournal.Appender {
+
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &appender{
+		next:        next,
+		maxAttempts: maxAttempts,
+		minBackoff:  minBackoff,
+		maxBackoff:  maxBackoff,
+	}
+}
+
+type appender struct {
+	next        gournal.Appender
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+func (a *appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	backoff := a.minBackoff
+	for attempt := 1; ; attempt++ {
+		err := gournal.TryAppend(a.next, ctx, lvl, fields, msg)
+		if err == nil || attempt >= a.maxAttempts {
+			return err
+		}
+		if !sleep(ctx, jitter(backoff)) {
+			return err
+		}
+		if backoff *= 2; backoff > a.maxBackoff {
+			backoff = a.maxBackoff
+		}
+	}
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	rndMu.Lock()
+	defer rndMu.Unlock()
+	return time.Duration(rnd.Int63n(int64(d) + 1))
+}
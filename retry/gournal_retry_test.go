@@ -0,0 +1,102 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type flakyAppender struct {
+	failures int
+	attempts int
+	msgs     []string
+}
+
+func (a *flakyAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+	panic("Append called instead of TryAppend")
+}
+
+func (a *flakyAppender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+	a.attempts++
+	if a.attempts <= a.failures {
+		return errors.New("unavailable")
+	}
+	a.msgs = append(a.msgs, msg)
+	return nil
+}
+
+func TestRetry(t *testing.T) {
+	var delays []time.Duration
+	defer func(f func(context.Context, time.Duration) bool) {
+		sleep = f
+	}(sleep)
+	sleep = func(ctx context.Context, d time.Duration) bool {
+		delays = append(delays, d)
+		return true
+	}
+
+	next := &flakyAppender{failures: 3}
+	ctx := context.WithValue(
+		context.Background(),
+		gournal.AppenderKey(),
+		NewWithOptions(next, 5, time.Second, 3*time.Second))
+
+	gournal.Error(ctx, "one")
+	assert.Equal(t, []string{"one"}, next.msgs)
+	assert.Equal(t, 4, next.attempts)
+	if assert.Len(t, delays, 3) {
+		assert.True(t, delays[0] <= time.Second)
+		assert.True(t, delays[1] <= 2*time.Second)
+		assert.True(t, delays[2] <= 3*time.Second)
+	}
+}
+
+func TestPermanentFailure(t *testing.T) {
+	defer func(f func(context.Context, time.Duration) bool) {
+		sleep = f
+	}(sleep)
+	sleep = func(context.Context, time.Duration) bool { return true }
+
+	var handled error
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) { handled = err }
+
+	next := &flakyAppender{failures: 10}
+	ctx := context.WithValue(
+		context.Background(), gournal.AppenderKey(), New(next))
+
+	gournal.Error(ctx, "one")
+	assert.Equal(t, MaxAttempts, next.attempts)
+	assert.EqualError(t, handled, "unavailable")
+	assert.Empty(t, next.msgs)
+}
+
+func TestCanceled(t *testing.T) {
+	next := &flakyAppender{failures: 10}
+	a := NewWithOptions(next, 5, time.Hour, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	// the retries stop once the context is done rather than blocking for
+	// the remaining backoff
+	start := time.Now()
+	err := a.(gournal.ErrorAppender).TryAppend(
+		ctx, gournal.ErrorLevel, nil, "one")
+	assert.EqualError(t, err, "unavailable")
+	assert.Equal(t, 1, next.attempts)
+	assert.True(t, time.Since(start) < time.Minute)
+}
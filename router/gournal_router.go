@@ -0,0 +1,63 @@
+// Package router provides a Gournal Appender that routes entries to other
+// Appenders based on the value of one of their fields, for example to ship
+// the entries of each tenant in a multi-tenant service to its own
+// destination.
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akutz/gournal"
+)
+
+// New returns an Appender that routes each entry to the Appender in routes
+// that is keyed by the string form of the entry's value for the provided
+// field key. Entries that do not have the field, or whose value does not
+// match a route, are routed to the default Appender. If the default
+// Appender is nil, such entries are discarded.
+//
+// The routes map must not be modified after it is provided to New.
+func New(
+	key string,
+	routes map[string]gournal.Appender,
+	def gournal.Appender) gournal.Appender {
+
+	if def == nil {
+		def = gournal.Discard
+	}
+	return &appender{key: key, routes: routes, def: def}
+}
+
+type appender struct {
+	key    string
+	routes map[string]gournal.Appender
+	def    gournal.Appender
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.route(fields).Append(ctx, lvl, fields, msg)
+}
+
+func (a *appender) route(fields map[string]interface{}) gournal.Appender {
+	v, ok := fields[a.key]
+	if !ok {
+		return a.def
+	}
+	var s string
+	switch tv := v.(type) {
+	case string:
+		s = tv
+	default:
+		s = fmt.Sprint(tv)
+	}
+	if r, ok := a.routes[s]; ok {
+		return r
+	}
+	return a.def
+}
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestAppender(t *testing.T) {
+	acme := &bytes.Buffer{}
+	tenant2 := &bytes.Buffer{}
+	def := &bytes.Buffer{}
+
+	ctx := context.WithValue(
+		context.Background(),
+		gournal.AppenderKey(),
+		New("tenant", map[string]gournal.Appender{
+			"acme": gournal.NewAppenderWithOptions(acme),
+			"2":    gournal.NewAppenderWithOptions(tenant2),
+		}, gournal.NewAppenderWithOptions(def)))
+
+	gournal.WithField("tenant", "acme").Error(ctx, "one")
+	gournal.WithField("tenant", 2).Error(ctx, "two")
+	gournal.WithField("tenant", "other").Error(ctx, "three")
+	gournal.Error(ctx, "four")
+
+	assert.Equal(t, "[ERROR] one map[tenant:acme]\n", acme.String())
+	assert.Equal(t, "[ERROR] two map[tenant:2]\n", tenant2.String())
+	assert.Equal(
+		t, "[ERROR] three map[tenant:other]\n[ERROR] four\n", def.String())
+}
+
+func TestNilDefault(t *testing.T) {
+	ctx := context.WithValue(
+		context.Background(),
+		gournal.AppenderKey(),
+		New("tenant", nil, nil))
+	gournal.Error(ctx, "one")
+}
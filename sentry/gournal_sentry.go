@@ -0,0 +1,292 @@
+// Package sentry provides a Gournal Appender that reports ERROR, FATAL, and
+// PANIC entries to Sentry as events, with the most recent less severe
+// entries attached as breadcrumbs.
+//
+// This package does not import the Sentry SDK. Instead, callers provide a
+// Client, for example one backed by github.com/getsentry/sentry-go:
+//
+//	type client struct{ *sentry.Hub }
+//
+//	func (c client) Capture(e *gsentry.Event) {
+//		ev := sentry.NewEvent()
+//		ev.Level = sentry.Level(e.Level)
+//		ev.Message = e.Message
+//		ev.Timestamp = e.Time
+//		ev.Tags = e.Tags
+//		ev.Extra = e.Extra
+//		...
+//		c.CaptureEvent(ev)
+//	}
+//
+//	func (c client) Flush(timeout time.Duration) bool {
+//		return c.Hub.Flush(timeout)
+//	}
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// Level is the least severe level of the entries reported as events by
+	// an Appender returned by New.
+	Level = gournal.ErrorLevel
+
+	// Breadcrumbs is the maximum number of breadcrumbs attached to events
+	// by an Appender returned by New.
+	Breadcrumbs = 100
+
+	// TagKeys are the names of the fields reported as tags rather than as
+	// extra data by an Appender returned by New.
+	TagKeys []string
+
+	// FlushTimeout is the maximum amount of time Close and FATAL and PANIC
+	// entries wait for the Client to deliver events.
+	FlushTimeout = 2 * time.Second
+
+	// StackKey is the name of the field that contains a stack trace, such
+	// as the one added by gournal.Recover.
+	StackKey = "stack"
+
+	// ErrFlushTimeout is returned by Close when the Client does not deliver
+	// the reported events before FlushTimeout elapses.
+	ErrFlushTimeout = errors.New("sentry: flush timed out")
+)
+
+// Client delivers events to Sentry.
+type Client interface {
+
+	// Capture enqueues the event for delivery.
+	Capture(e *Event)
+
+	// Flush waits until the enqueued events are delivered or the timeout
+	// elapses, returning false if it elapses.
+	Flush(timeout time.Duration) bool
+}
+
+// Event is a Sentry event.
+type Event struct {
+
+	// Level is the Sentry level, ex. "error".
+	Level string
+
+	// Message is the entry's message.
+	Message string
+
+	// Time is the time of the entry.
+	Time time.Time
+
+	// Error is the value of the entry's gournal.ErrorKey field, if any.
+	Error string
+
+	// Stack is the value of the entry's StackKey field, if any.
+	Stack string
+
+	// Tags are the entry's fields named by the Appender's tag keys.
+	Tags map[string]string
+
+	// Extra are the entry's remaining fields.
+	Extra map[string]interface{}
+
+	// Breadcrumbs are the entries that preceded the event, oldest first.
+	Breadcrumbs []Breadcrumb
+}
+
+// Breadcrumb is an entry that preceded an event.
+type Breadcrumb struct {
+
+	// Level is the Sentry level, ex. "info".
+	Level string
+
+	// Message is the entry's message.
+	Message string
+
+	// Time is the time of the entry.
+	Time time.Time
+
+	// Data are the entry's fields.
+	Data map[string]interface{}
+}
+
+// Appender is a Sentry Appender.
+type Appender struct {
+	next    gournal.Appender
+	c       Client
+	lvl     gournal.Level
+	tagKeys map[string]struct{}
+
+	sync.Mutex
+	ring  []Breadcrumb
+	start int
+	count int
+}
+
+// New returns an Appender that reports entries to c in front of next using
+// Level, Breadcrumbs, and TagKeys.
+func New(next gournal.Appender, c Client) *Appender {
+	return NewWithOptions(next, c, Level, Breadcrumbs, TagKeys)
+}
+
+// NewWithOptions returns an Appender that delivers every entry to next and
+// reports the entries at or above the provided level to c as events. Up to
+// breadcrumbs of the most recent less severe entries are attached to each
+// event. The fields named by tagKeys are reported as tags, formatted with
+// fmt.Sprint, and the remaining fields as extra data.
+//
+// FATAL and PANIC entries are reported and the Client is flushed before
+// they are delivered to next, which is expected to exit or panic. If next
+// is nil, entries are only reported to Sentry.
+func NewWithOptions(
+	next gournal.Appender,
+	c Client,
+	lvl gournal.Level,
+	breadcrumbs int,
+	tagKeys []string) *Appender {
+
+	if next == nil {
+		next = gournal.Discard
+	}
+	if breadcrumbs < 0 {
+		breadcrumbs = 0
+	}
+	a := &Appender{
+		next:    next,
+		c:       c,
+		lvl:     lvl,
+		tagKeys: make(map[string]struct{}, len(tagKeys)),
+		ring:    make([]Breadcrumb, breadcrumbs),
+	}
+	for _, k := range tagKeys {
+		a.tagKeys[k] = struct{}{}
+	}
+	return a
+}
+
+// Append reports the entry as an event or records it as a breadcrumb, and
+// then delivers it to the next Appender.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if lvl > a.lvl {
+		a.record(ctx, lvl, fields, msg)
+		a.next.Append(ctx, lvl, fields, msg)
+		return
+	}
+
+	e := &Event{
+		Level:       level(lvl),
+		Message:     msg,
+		Time:        gournal.TimeFrom(ctx),
+		Breadcrumbs: a.breadcrumbs(),
+	}
+	for k, v := range fields {
+		switch k {
+		case gournal.ErrorKey:
+			e.Error = fmt.Sprint(v)
+			continue
+		case StackKey:
+			e.Stack = fmt.Sprint(v)
+			continue
+		}
+		if _, ok := a.tagKeys[k]; ok {
+			if e.Tags == nil {
+				e.Tags = map[string]string{}
+			}
+			e.Tags[k] = fmt.Sprint(v)
+			continue
+		}
+		if e.Extra == nil {
+			e.Extra = map[string]interface{}{}
+		}
+		e.Extra[k] = v
+	}
+	a.c.Capture(e)
+
+	if lvl <= gournal.FatalLevel {
+		a.c.Flush(FlushTimeout)
+	}
+	a.next.Append(ctx, lvl, fields, msg)
+}
+
+// record adds a breadcrumb to the ring, overwriting the oldest breadcrumb if
+// the ring is full. The fields are copied since the breadcrumb outlives the
+// call.
+func (a *Appender) record(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if len(a.ring) == 0 {
+		return
+	}
+	b := Breadcrumb{
+		Level:   level(lvl),
+		Message: msg,
+		Time:    gournal.TimeFrom(ctx),
+	}
+	if len(fields) > 0 {
+		b.Data = make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			b.Data[k] = v
+		}
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	i := (a.start + a.count) % len(a.ring)
+	a.ring[i] = b
+	if a.count < len(a.ring) {
+		a.count++
+	} else {
+		a.start = (a.start + 1) % len(a.ring)
+	}
+}
+
+// breadcrumbs returns the recorded breadcrumbs, oldest first.
+func (a *Appender) breadcrumbs() []Breadcrumb {
+	a.Lock()
+	defer a.Unlock()
+	if a.count == 0 {
+		return nil
+	}
+	crumbs := make([]Breadcrumb, a.count)
+	for i := range crumbs {
+		crumbs[i] = a.ring[(a.start+i)%len(a.ring)]
+	}
+	return crumbs
+}
+
+// Close waits up to FlushTimeout for the Client to deliver reported events.
+func (a *Appender) Close() error {
+	if !a.c.Flush(FlushTimeout) {
+		return ErrFlushTimeout
+	}
+	return nil
+}
+
+func level(lvl gournal.Level) string {
+	if s, ok := gournal.MapSeverityString("sentry", lvl); ok {
+		return s
+	}
+	switch lvl {
+	case gournal.DebugLevel:
+		return "debug"
+	case gournal.InfoLevel:
+		return "info"
+	case gournal.WarnLevel:
+		return "warning"
+	case gournal.ErrorLevel:
+		return "error"
+	}
+	return "fatal"
+}
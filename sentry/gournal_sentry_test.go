@@ -0,0 +1,103 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/gournaltest"
+)
+
+type testClient struct {
+	events  []*Event
+	flushes int
+	ok      bool
+}
+
+func (c *testClient) Capture(e *Event) {
+	c.events = append(c.events, e)
+}
+
+func (c *testClient) Flush(timeout time.Duration) bool {
+	c.flushes++
+	return c.ok
+}
+
+func TestSentryAppender(t *testing.T) {
+	c := &testClient{ok: true}
+	next := gournaltest.New()
+	a := NewWithOptions(next, c, gournal.ErrorLevel, 2, []string{"host"})
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+
+	gournal.Debug(ctx, "Hello Bob")
+	gournal.WithField("size", 1).Info(ctx, "Hello Alice")
+	gournal.Warn(ctx, "Hello Mary")
+	gournal.WithFields(map[string]interface{}{
+		"host": "web-1",
+		"size": 2,
+	}).WithError(errors.New("boom")).Error(ctx, "failed")
+
+	assert.Len(t, next.Entries(), 4)
+	if assert.Len(t, c.events, 1) {
+		e := c.events[0]
+		assert.Equal(t, "error", e.Level)
+		assert.Equal(t, "failed", e.Message)
+		assert.Equal(t, "boom", e.Error)
+		assert.Equal(t, map[string]string{"host": "web-1"}, e.Tags)
+		assert.Equal(t, map[string]interface{}{"size": 2}, e.Extra)
+		if assert.Len(t, e.Breadcrumbs, 2) {
+			assert.Equal(t, "info", e.Breadcrumbs[0].Level)
+			assert.Equal(t, "Hello Alice", e.Breadcrumbs[0].Message)
+			assert.Equal(
+				t,
+				map[string]interface{}{"size": 1},
+				e.Breadcrumbs[0].Data)
+			assert.Equal(t, "warning", e.Breadcrumbs[1].Level)
+		}
+	}
+	assert.Equal(t, 0, c.flushes)
+
+	assert.NoError(t, a.Close())
+	c.ok = false
+	assert.Equal(t, ErrFlushTimeout, a.Close())
+}
+
+func TestSentryAppenderRecover(t *testing.T) {
+	c := &testClient{}
+	a := New(nil, c)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+
+	func() {
+		defer gournal.Recover(ctx)
+		panic("boom")
+	}()
+
+	if assert.Len(t, c.events, 1) {
+		assert.Equal(t, "recovered from panic", c.events[0].Message)
+		assert.Contains(t, c.events[0].Stack, "TestSentryAppenderRecover")
+		assert.Equal(t, "boom", c.events[0].Extra["panic"])
+	}
+}
+
+func TestSentryAppenderPanic(t *testing.T) {
+	c := &testClient{ok: true}
+	a := New(gournaltest.New(), c)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	gournal.Panic(ctx, "Hello Bob")
+
+	if assert.Len(t, c.events, 1) {
+		assert.Equal(t, "fatal", c.events[0].Level)
+	}
+	assert.Equal(t, 1, c.flushes)
+}
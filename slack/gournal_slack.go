@@ -0,0 +1,358 @@
+// Package slack provides a Gournal Appender that posts WARN and more severe
+// entries to a Slack incoming webhook, so small teams are alerted without a
+// full alerting stack.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// Level is the least severe level of the entries posted by an Appender
+	// returned by New.
+	Level = gournal.WarnLevel
+
+	// Template is the text/template with which an Appender returned by New
+	// renders the text of a message. It is executed with the entry's
+	// gournal.Record.
+	Template = "*{{.Level}}* {{.Message}}"
+
+	// Limit is the number of messages an Appender returned by New posts per
+	// Period. Messages beyond the limit are dropped.
+	Limit = 10
+
+	// Period is the amount of time over which Limit applies.
+	Period = time.Minute
+
+	// BufferSize is the number of messages that may wait to be posted
+	// before messages are dropped.
+	BufferSize = 100
+)
+
+// Appender is a Slack Appender.
+type Appender struct {
+	next    gournal.Appender
+	client  *http.Client
+	url     string
+	channel string
+	lvl     gournal.Level
+	tmpl    *template.Template
+	corrKey string
+
+	// mu protects the rate limiter's state, the queue once the Appender is
+	// closed, and threads, the timestamps of the first message posted for
+	// each correlation value
+	mu         sync.Mutex
+	closed     bool
+	limit      float64
+	period     time.Duration
+	tokens     float64
+	last       time.Time
+	suppressed int
+	threads    map[string]string
+
+	queue chan *message
+	done  chan struct{}
+}
+
+// message is a Slack message and the correlation value by which it is
+// threaded.
+type message struct {
+	Channel     string       `json:"channel,omitempty"`
+	Text        string       `json:"text"`
+	ThreadTS    string       `json:"thread_ts,omitempty"`
+	Attachments []attachment `json:"attachments,omitempty"`
+
+	corr string
+}
+
+type attachment struct {
+	Color  string  `json:"color"`
+	Fields []field `json:"fields"`
+}
+
+type field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// maxThreads is the number of threads that are remembered before the oldest
+// are forgotten.
+const maxThreads = 1000
+
+// now is replaced by tests.
+var now = time.Now
+
+// New returns an Appender that posts entries to the provided webhook URL in
+// front of next using http.DefaultClient, Level, Template, Limit, and
+// Period.
+func New(next gournal.Appender, url string) *Appender {
+	a, err := NewWithOptions(
+		next, http.DefaultClient, url, "", Level, Template, "", Limit, Period)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// NewWithOptions returns an Appender that delivers every entry to next and
+// posts the entries at or above the provided level to the provided URL with
+// the provided client. If next is nil, entries are only posted to Slack.
+//
+// The text of each message is rendered with tmpl, a text/template executed
+// with the entry's gournal.Record, and the entry's fields are rendered as
+// the fields of an attachment colored by the entry's level. If channel is
+// not empty, it overrides the webhook's channel.
+//
+// At most limit messages are posted per period. Messages beyond the limit
+// are dropped and counted with gournal.RecordDropped, and the number of
+// dropped messages is appended to the next message that is posted. Messages
+// are posted by a background goroutine, so the returned Appender must be
+// closed. FATAL and PANIC entries are posted immediately, regardless of the
+// limit, before they are delivered to next, which is expected to exit or
+// panic. Errors are reported with gournal.HandleError.
+//
+// If corrKey is not empty, messages whose entries have the same value for
+// the field named by corrKey are posted in the thread of the first of them.
+// Threading requires a URL that responds with the timestamp of the posted
+// message, such as https://slack.com/api/chat.postMessage with a client that
+// adds the token to requests. Incoming webhooks do not, so messages posted
+// to them are never threaded.
+func NewWithOptions(
+	next gournal.Appender,
+	client *http.Client,
+	url, channel string,
+	lvl gournal.Level,
+	tmpl string,
+	corrKey string,
+	limit int,
+	period time.Duration) (*Appender, error) {
+
+	t, err := template.New("slack").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		next = gournal.Discard
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	a := &Appender{
+		next:    next,
+		client:  client,
+		url:     url,
+		channel: channel,
+		lvl:     lvl,
+		tmpl:    t,
+		corrKey: corrKey,
+		limit:   float64(limit),
+		period:  period,
+		tokens:  float64(limit),
+		last:    now(),
+		threads: map[string]string{},
+		queue:   make(chan *message, BufferSize),
+		done:    make(chan struct{}),
+	}
+	go a.run()
+	return a, nil
+}
+
+// Append posts the entry if it is severe enough and then delivers it to the
+// next Appender.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if lvl <= a.lvl {
+		a.enqueue(&gournal.Record{
+			Time:    gournal.TimeFrom(ctx),
+			Level:   lvl,
+			Message: msg,
+			Fields:  fields,
+			Name:    gournal.NameFrom(ctx),
+			Caller:  gournal.CallerFrom(ctx),
+		})
+	}
+	a.next.Append(ctx, lvl, fields, msg)
+}
+
+func (a *Appender) enqueue(rec *gournal.Record) {
+	m, err := a.newMessage(rec)
+	if err != nil {
+		gournal.HandleError(err)
+		return
+	}
+
+	if rec.Level <= gournal.FatalLevel {
+		if err := a.post(m); err != nil {
+			gournal.HandleError(err)
+		}
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed || !a.allow(m) {
+		gournal.RecordDropped(1)
+		return
+	}
+	select {
+	case a.queue <- m:
+	default:
+		gournal.RecordDropped(1)
+	}
+}
+
+// allow reports whether the rate limit allows the message to be posted. If
+// it does, the number of messages that were not allowed since the last one
+// that was is appended to the message's text. The Appender must be locked.
+func (a *Appender) allow(m *message) bool {
+	if a.period > 0 {
+		t := now()
+		a.tokens += a.limit * float64(t.Sub(a.last)) / float64(a.period)
+		if a.tokens > a.limit {
+			a.tokens = a.limit
+		}
+		a.last = t
+		if a.tokens < 1 {
+			a.suppressed++
+			return false
+		}
+		a.tokens--
+	}
+	if a.suppressed > 0 {
+		m.Text += fmt.Sprintf(
+			"\n_(%d messages suppressed)_", a.suppressed)
+		a.suppressed = 0
+	}
+	return true
+}
+
+func (a *Appender) newMessage(rec *gournal.Record) (*message, error) {
+	buf := &bytes.Buffer{}
+	if err := a.tmpl.Execute(buf, rec); err != nil {
+		return nil, err
+	}
+	m := &message{Channel: a.channel, Text: buf.String()}
+
+	if len(rec.Fields) > 0 {
+		att := attachment{Color: color(rec.Level)}
+		keys := make([]string, 0, len(rec.Fields))
+		for k := range rec.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := fmt.Sprint(rec.Fields[k])
+			att.Fields = append(att.Fields, field{
+				Title: k,
+				Value: v,
+				Short: len(v) <= 40,
+			})
+		}
+		m.Attachments = []attachment{att}
+	}
+
+	if a.corrKey != "" {
+		if v, ok := rec.Fields[a.corrKey]; ok {
+			m.corr = fmt.Sprint(v)
+		}
+	}
+	return m, nil
+}
+
+func (a *Appender) run() {
+	defer close(a.done)
+	for m := range a.queue {
+		if err := a.post(m); err != nil {
+			gournal.HandleError(err)
+		}
+	}
+}
+
+// post posts the message in the thread of its correlation value, if any,
+// and records the thread if the message starts it.
+func (a *Appender) post(m *message) error {
+	if m.corr != "" {
+		a.mu.Lock()
+		m.ThreadTS = a.threads[m.corr]
+		a.mu.Unlock()
+	}
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	res, err := a.client.Post(a.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	buf, _ := ioutil.ReadAll(io.LimitReader(res.Body, 4096))
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf(
+			"slack: %s: %s", res.Status, bytes.TrimSpace(buf))
+	}
+
+	var reply struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		TS    string `json:"ts"`
+	}
+	if json.Unmarshal(buf, &reply) != nil {
+		// incoming webhooks respond with "ok"
+		return nil
+	}
+	if !reply.OK {
+		return fmt.Errorf("slack: %s", reply.Error)
+	}
+	if m.corr != "" && m.ThreadTS == "" && reply.TS != "" {
+		a.mu.Lock()
+		if len(a.threads) >= maxThreads {
+			a.threads = map[string]string{}
+		}
+		a.threads[m.corr] = reply.TS
+		a.mu.Unlock()
+	}
+	return nil
+}
+
+// Close posts the queued messages and stops the background goroutine.
+// Entries appended after Close are delivered to next but not posted, except
+// for FATAL and PANIC entries.
+func (a *Appender) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	<-a.done
+	return nil
+}
+
+func color(lvl gournal.Level) string {
+	switch lvl {
+	case gournal.DebugLevel, gournal.InfoLevel:
+		return "good"
+	case gournal.WarnLevel:
+		return "warning"
+	}
+	return "danger"
+}
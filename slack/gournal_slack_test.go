@@ -0,0 +1,157 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/gournaltest"
+)
+
+// testServer records the messages posted to it and replies with a
+// chat.postMessage response if api is true, otherwise with "ok".
+type testServer struct {
+	sync.Mutex
+	*httptest.Server
+	api  bool
+	msgs []map[string]interface{}
+}
+
+func newTestServer(api bool) *testServer {
+	s := &testServer{api: api}
+	s.Server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var m map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&m)
+			s.Lock()
+			s.msgs = append(s.msgs, m)
+			n := len(s.msgs)
+			s.Unlock()
+			if s.api {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"ok": true,
+					"ts": fmt.Sprintf("%d.000000", n),
+				})
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+	return s
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := context.WithValue(
+		context.Background(), gournal.LevelKey(), gournal.DebugLevel)
+	return context.WithValue(ctx, gournal.AppenderKey(), a)
+}
+
+func TestSlack(t *testing.T) {
+	s := newTestServer(false)
+	defer s.Close()
+
+	next := gournaltest.New()
+	a := New(next, s.URL)
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	gournal.WithFields(map[string]interface{}{
+		"size":  1,
+		"color": "red",
+	}).Warn(ctx, "Hello Alice")
+	gournal.Error(ctx, "Hello Mary")
+	assert.NoError(t, a.Close())
+
+	assert.Len(t, next.Entries(), 3)
+	if !assert.Len(t, s.msgs, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, "*WARN* Hello Alice", s.msgs[0]["text"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"color": "warning",
+			"fields": []interface{}{
+				map[string]interface{}{
+					"title": "color", "value": "red", "short": true,
+				},
+				map[string]interface{}{
+					"title": "size", "value": "1", "short": true,
+				},
+			},
+		},
+	}, s.msgs[0]["attachments"])
+	assert.Equal(t, "*ERROR* Hello Mary", s.msgs[1]["text"])
+	assert.Nil(t, s.msgs[1]["attachments"])
+}
+
+func TestSlackLimit(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	t0 := time.Now()
+	now = func() time.Time { return t0 }
+
+	s := newTestServer(false)
+	defer s.Close()
+
+	dropped := gournal.DroppedCount()
+	a, err := NewWithOptions(
+		nil, nil, s.URL, "#alerts", gournal.ErrorLevel,
+		"{{.Message}}", "", 2, time.Minute)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	for _, name := range []string{"Bob", "Alice", "Mary", "Carl"} {
+		gournal.Error(ctx, "Hello %s", name)
+	}
+	now = func() time.Time { return t0.Add(30 * time.Second) }
+	gournal.Error(ctx, "Hello Jane")
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, dropped+2,
+		gournal.DroppedCount())
+	if !assert.Len(t, s.msgs, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t, "#alerts", s.msgs[0]["channel"])
+	assert.Equal(t, "Hello Bob", s.msgs[0]["text"])
+	assert.Equal(t, "Hello Alice", s.msgs[1]["text"])
+	assert.Equal(t, "Hello Jane\n_(2 messages suppressed)_", s.msgs[2]["text"])
+}
+
+func TestSlackThreads(t *testing.T) {
+	s := newTestServer(true)
+	defer s.Close()
+
+	a, err := NewWithOptions(
+		nil, nil, s.URL, "", gournal.WarnLevel,
+		"{{.Message}}", "request", 0, 0)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	gournal.WithField("request", 1).Warn(ctx, "Hello Bob")
+	gournal.WithField("request", 2).Warn(ctx, "Hello Alice")
+	gournal.WithField("request", 1).Warn(ctx, "Goodbye Bob")
+	gournal.Warn(ctx, "Hello Mary")
+	assert.NoError(t, a.Close())
+
+	if !assert.Len(t, s.msgs, 4) {
+		t.FailNow()
+	}
+	assert.Nil(t, s.msgs[0]["thread_ts"])
+	assert.Nil(t, s.msgs[1]["thread_ts"])
+	assert.Equal(t, "1.000000", s.msgs[2]["thread_ts"])
+	assert.Nil(t, s.msgs[3]["thread_ts"])
+}
+
+func TestSlackTemplate(t *testing.T) {
+	_, err := NewWithOptions(
+		nil, nil, "", "", gournal.WarnLevel, "{{.Message", "", 0, 0)
+	assert.Error(t, err)
+}
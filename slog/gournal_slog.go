@@ -0,0 +1,95 @@
+//go:build go1.21
+// +build go1.21
+
+// Package slog provides a Gournal Appender backed by a log/slog Logger.
+package slog
+
+import (
+	"context"
+	stdslog "log/slog"
+	"os"
+	"sort"
+
+	"github.com/akutz/gournal"
+)
+
+// The slog levels used for Gournal's FATAL and PANIC levels, which slog
+// does not define.
+const (
+	LevelFatal = stdslog.LevelError + 4
+	LevelPanic = stdslog.LevelError + 8
+)
+
+// New returns a Gournal Appender that uses slog.Default().
+func New() gournal.Appender {
+	return &appender{}
+}
+
+// NewWithOptions returns a Gournal Appender that uses the provided slog
+// Logger.
+func NewWithOptions(logger *stdslog.Logger) gournal.Appender {
+	return &appender{logger}
+}
+
+type appender struct {
+	logger *stdslog.Logger
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	logger := a.logger
+	if logger == nil {
+		logger = stdslog.Default()
+	}
+
+	slvl := Level(lvl)
+	if h := logger.Handler(); h.Enabled(ctx, slvl) {
+		r := stdslog.NewRecord(gournal.TimeFrom(ctx), slvl, msg, 0)
+		if len(fields) > 0 {
+			keys := make([]string, 0, len(fields))
+			for k := range fields {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				r.AddAttrs(stdslog.Any(k, fields[k]))
+			}
+		}
+		if err := h.Handle(ctx, r); err != nil {
+			gournal.HandleError(err)
+		}
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+// Level returns the slog level for the provided Gournal level, or the one
+// returned by gournal.SeverityMapper for the "slog" backend.
+func Level(lvl gournal.Level) stdslog.Level {
+	if n, ok := gournal.MapSeverityInt("slog", lvl); ok {
+		return stdslog.Level(n)
+	}
+	switch lvl {
+	case gournal.DebugLevel:
+		return stdslog.LevelDebug
+	case gournal.InfoLevel:
+		return stdslog.LevelInfo
+	case gournal.WarnLevel:
+		return stdslog.LevelWarn
+	case gournal.ErrorLevel:
+		return stdslog.LevelError
+	case gournal.FatalLevel:
+		return LevelFatal
+	default:
+		return LevelPanic
+	}
+}
@@ -0,0 +1,180 @@
+//go:build go1.21
+// +build go1.21
+
+package slog
+
+import (
+	"context"
+	stdslog "log/slog"
+	"runtime"
+
+	"github.com/akutz/gournal"
+)
+
+// NewHandler returns a slog.Handler that emits records through Gournal
+// using the appender, level, and fields of the provided Context. This
+// allows code that logs with log/slog to share Gournal's configuration.
+//
+// The values of the Context passed to the Handler's functions by slog, for
+// example with slog.InfoContext, take precedence over those of the provided
+// Context, so request-scoped fields and appenders are used. If
+// gournal.CaptureCaller is enabled, the caller recorded by slog is used.
+// Grouped attributes become fields whose keys are joined with a period, for
+// example "req.method".
+//
+// Records at levels above slog.LevelError are emitted as ERROR entries, so
+// a library that logs at a custom level does not cause the program to exit
+// or panic. Use NewHandlerWithOptions to emit them as FATAL and PANIC
+// entries.
+func NewHandler(ctx context.Context) stdslog.Handler {
+	return NewHandlerWithOptions(ctx, false)
+}
+
+// NewHandlerWithOptions returns a slog.Handler like NewHandler. If
+// allowFatal is true, records at or above LevelFatal and LevelPanic are
+// emitted as FATAL and PANIC entries, which typically cause the program to
+// exit or panic.
+func NewHandlerWithOptions(
+	ctx context.Context, allowFatal bool) stdslog.Handler {
+
+	if ctx == nil {
+		ctx = gournal.DefaultContext
+	}
+	return &handler{ctx: ctx, allowFatal: allowFatal}
+}
+
+type handler struct {
+	ctx        context.Context
+	allowFatal bool
+	prefix     string
+	fields     map[string]interface{}
+}
+
+func (h *handler) Enabled(ctx context.Context, lvl stdslog.Level) bool {
+	return gournal.Enabled(h.context(ctx), h.level(lvl))
+}
+
+func (h *handler) Handle(ctx context.Context, r stdslog.Record) error {
+	fields := make(map[string]interface{}, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a stdslog.Attr) bool {
+		addAttr(fields, h.prefix, a)
+		return true
+	})
+
+	ctx = h.context(ctx)
+	if !r.Time.IsZero() {
+		ctx = gournal.WithTime(ctx, r.Time)
+	}
+	if gournal.CaptureCaller && r.PC != 0 {
+		fr, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ctx = context.WithValue(ctx, gournal.CallerKey(), &gournal.Caller{
+			File:     fr.File,
+			Line:     fr.Line,
+			Function: fr.Function,
+		})
+	}
+	gournal.WithFields(fields).(gournal.LevelEntry).Log(
+		ctx, h.level(r.Level), r.Message)
+	return nil
+}
+
+// context returns the Context passed to the Handler by slog, whose values
+// are looked up before those of the Handler's Context.
+func (h *handler) context(ctx context.Context) context.Context {
+	if ctx == nil {
+		return h.ctx
+	}
+	return &callContext{ctx, h.ctx}
+}
+
+// level returns the Gournal level of the slog level, which is at most
+// ERROR unless the Handler allows FATAL and PANIC entries.
+func (h *handler) level(lvl stdslog.Level) gournal.Level {
+	glvl := FromLevel(lvl)
+	if !h.allowFatal && glvl < gournal.ErrorLevel {
+		return gournal.ErrorLevel
+	}
+	return glvl
+}
+
+// callContext is the Context passed to a Handler's function whose values
+// are looked up in the Handler's Context if the Context does not have them.
+type callContext struct {
+	context.Context
+	base context.Context
+}
+
+func (c *callContext) Value(key interface{}) interface{} {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.base.Value(key)
+}
+
+func (h *handler) WithAttrs(attrs []stdslog.Attr) stdslog.Handler {
+	fields := make(map[string]interface{}, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, h.prefix, a)
+	}
+	return &handler{
+		ctx:        h.ctx,
+		allowFatal: h.allowFatal,
+		prefix:     h.prefix,
+		fields:     fields,
+	}
+}
+
+func (h *handler) WithGroup(name string) stdslog.Handler {
+	if name == "" {
+		return h
+	}
+	return &handler{
+		ctx:        h.ctx,
+		allowFatal: h.allowFatal,
+		prefix:     h.prefix + name + ".",
+		fields:     h.fields,
+	}
+}
+
+// addAttr adds the attribute to the fields, flattening groups.
+func addAttr(fields map[string]interface{}, prefix string, a stdslog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == stdslog.KindGroup {
+		// the attributes of a group without a key are inlined
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	fields[prefix+a.Key] = v.Any()
+}
+
+// FromLevel returns the Gournal level for the provided slog level.
+func FromLevel(lvl stdslog.Level) gournal.Level {
+	switch {
+	case lvl < stdslog.LevelInfo:
+		return gournal.DebugLevel
+	case lvl < stdslog.LevelWarn:
+		return gournal.InfoLevel
+	case lvl < stdslog.LevelError:
+		return gournal.WarnLevel
+	case lvl < LevelFatal:
+		return gournal.ErrorLevel
+	case lvl < LevelPanic:
+		return gournal.FatalLevel
+	default:
+		return gournal.PanicLevel
+	}
+}
@@ -0,0 +1,157 @@
+//go:build go1.21
+// +build go1.21
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	stdslog "log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func newTestContext(buf *bytes.Buffer) context.Context {
+	logger := stdslog.New(stdslog.NewTextHandler(buf, &stdslog.HandlerOptions{
+		Level: stdslog.LevelDebug,
+	}))
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), NewWithOptions(logger))
+	return gournal.WithTime(ctx, time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC))
+}
+
+func TestAppender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := newTestContext(buf)
+
+	gournal.WithFields(map[string]interface{}{
+		"size":     1,
+		"location": "Austin",
+	}).Warn(ctx, "Hello %s", "Mary")
+	assert.Equal(
+		t,
+		`time=2017-01-02T03:04:05.000Z level=WARN msg="Hello Mary" `+
+			"location=Austin size=1\n",
+		buf.String())
+}
+
+func TestAppenderPanic(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := newTestContext(buf)
+
+	defer func() {
+		assert.Equal(t, "Hello Bob", recover())
+		assert.Contains(t, buf.String(), "level=ERROR+8")
+	}()
+	gournal.Panic(ctx, "Hello %s", "Bob")
+}
+
+func TestLevel(t *testing.T) {
+	assert.Equal(t, stdslog.LevelDebug, Level(gournal.DebugLevel))
+	assert.Equal(t, stdslog.LevelInfo, Level(gournal.InfoLevel))
+	assert.Equal(t, LevelFatal, Level(gournal.FatalLevel))
+}
+
+func TestHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(
+		ctx, gournal.AppenderKey(), gournal.NewAppenderWithOptions(buf))
+
+	logger := stdslog.New(NewHandler(ctx))
+	logger.Debug("Hello Bob")
+	assert.Zero(t, buf.Len())
+
+	logger.With("size", 1).WithGroup("req").Warn(
+		"Hello Mary",
+		"method", "GET",
+		stdslog.Group("url", "path", "/"))
+	assert.Equal(
+		t,
+		"[WARN] Hello Mary map[req.method:GET req.url.path:/ size:1]\n",
+		buf.String())
+}
+
+type recordAppender struct {
+	lvl    gournal.Level
+	fields map[string]interface{}
+	caller *gournal.Caller
+}
+
+func (a *recordAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.lvl, a.fields, a.caller = lvl, fields, gournal.CallerFrom(ctx)
+}
+
+func TestHandlerContext(t *testing.T) {
+	base, req := &recordAppender{}, &recordAppender{}
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), base)
+	logger := stdslog.New(NewHandler(ctx))
+
+	// the values of the Context passed to slog take precedence, and the
+	// handler's Context is used for the others, such as the level
+	reqCtx := context.WithValue(
+		context.Background(), gournal.AppenderKey(), req)
+	reqCtx, pop := gournal.PushFields(
+		reqCtx, map[string]interface{}{"requestID": "1234"})
+	defer pop()
+	logger.DebugContext(reqCtx, "Hello Bob")
+	logger.InfoContext(reqCtx, "Hello Alice")
+	assert.Nil(t, base.fields)
+	assert.Equal(t, "1234", req.fields["requestID"])
+
+	logger.Info("Hello Mary", "size", 1)
+	assert.Equal(t, map[string]interface{}{"size": int64(1)}, base.fields)
+}
+
+func TestHandlerCaller(t *testing.T) {
+	defer func() { gournal.CaptureCaller = false }()
+	gournal.CaptureCaller = true
+
+	a := &recordAppender{}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	stdslog.New(NewHandler(ctx)).Error("Hello Bob")
+	if assert.NotNil(t, a.caller) {
+		assert.Equal(t, "gournal_slog_test.go", filepath.Base(a.caller.File))
+		assert.Contains(t, a.caller.Function, "TestHandlerCaller")
+	}
+}
+
+func TestHandlerLevels(t *testing.T) {
+	a := &recordAppender{}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	logger := stdslog.New(NewHandler(ctx))
+	logger.Log(context.Background(), LevelFatal, "Hello Bob")
+	assert.Equal(t, gournal.ErrorLevel, a.lvl)
+	logger.Log(context.Background(), LevelPanic+4, "Hello Alice")
+	assert.Equal(t, gournal.ErrorLevel, a.lvl)
+
+	logger = stdslog.New(NewHandlerWithOptions(ctx, true))
+	logger.Log(context.Background(), LevelFatal, "Hello Mary")
+	assert.Equal(t, gournal.FatalLevel, a.lvl)
+	logger.WithGroup("req").Log(context.Background(), LevelPanic, "Hi")
+	assert.Equal(t, gournal.PanicLevel, a.lvl)
+}
+
+func TestFromLevel(t *testing.T) {
+	assert.Equal(t, gournal.DebugLevel, FromLevel(stdslog.LevelDebug-4))
+	assert.Equal(t, gournal.InfoLevel, FromLevel(stdslog.LevelInfo))
+	assert.Equal(t, gournal.WarnLevel, FromLevel(stdslog.LevelWarn+1))
+	assert.Equal(t, gournal.ErrorLevel, FromLevel(stdslog.LevelError))
+	assert.Equal(t, gournal.FatalLevel, FromLevel(LevelFatal))
+	assert.Equal(t, gournal.PanicLevel, FromLevel(LevelPanic))
+}
@@ -0,0 +1,276 @@
+// Package socket provides a Gournal Appender that writes formatted entries
+// to a TCP or UDP endpoint, reconnecting with exponential backoff and
+// buffering the most recent entries in memory to bridge brief outages.
+package socket
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// Framing determines how entries are delimited on the connection.
+type Framing uint8
+
+const (
+	// Newline frames each entry with a trailing newline.
+	Newline Framing = iota
+
+	// LengthPrefix frames each entry with a preceding four byte, big
+	// endian length. Any trailing newline written by the Formatter is
+	// removed.
+	LengthPrefix
+
+	// Raw writes each entry exactly as it is formatted. This is suitable
+	// for self-delimiting encodings, such as those of the msgpack and cbor
+	// packages.
+	Raw
+)
+
+var (
+	// BufferSize is the number of entries buffered in memory by an Appender
+	// returned by New while the endpoint is unavailable.
+	BufferSize = 1024
+
+	// MinBackoff is the delay before the first attempt to reconnect after
+	// a connection fails.
+	MinBackoff = 100 * time.Millisecond
+
+	// MaxBackoff is the maximum delay between attempts to reconnect.
+	MaxBackoff = 10 * time.Second
+
+	// Timeout is the amount of time allowed to connect and to write an
+	// entry.
+	Timeout = 10 * time.Second
+)
+
+// Appender is a socket Appender.
+type Appender struct {
+	async *gournal.AsyncAppender
+	w     *writer
+}
+
+// New returns an Appender that writes newline-delimited JSON entries to the
+// provided network address, ex. "tcp" and "logs.example.com:5000", using
+// BufferSize.
+func New(network, addr string) *Appender {
+	return NewWithOptions(
+		network, addr, nil, gournal.JSONFormatter{}, Newline, BufferSize)
+}
+
+// NewWithOptions returns an Appender that writes entries formatted with f
+// to the provided network address using the provided framing. If the TLS
+// config is not nil, connections use TLS.
+//
+// Entries are written in the background. When a write fails, the
+// connection is re-established after a backoff that starts at MinBackoff
+// and doubles with each failed attempt up to MaxBackoff. Meanwhile, up to
+// bufferSize entries are buffered in memory; when the buffer is full, the
+// oldest entry is dropped and counted with gournal.RecordDropped.
+//
+// FATAL and PANIC entries are written after the buffered entries, which are
+// dropped rather than retried if the endpoint is unavailable so the exit
+// or panic is not delayed.
+//
+// The connection is established when the first entry is written.
+func NewWithOptions(
+	network, addr string,
+	tlsConfig *tls.Config,
+	f gournal.Formatter,
+	framing Framing,
+	bufferSize int) *Appender {
+
+	w := &writer{
+		network: network,
+		addr:    addr,
+		tls:     tlsConfig,
+		f:       f,
+		framing: framing,
+		done:    make(chan struct{}),
+	}
+	return &Appender{
+		async: gournal.NewAsyncAppenderWithOptions(
+			w, bufferSize, 1, gournal.OverflowDropOldest),
+		w: w,
+	}
+}
+
+// Append enqueues the entry.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	// stop retrying so the buffered entries are written or dropped
+	// promptly before the program exits or panics
+	if lvl <= gournal.FatalLevel {
+		a.w.stop()
+	}
+	a.async.Append(ctx, lvl, fields, msg)
+}
+
+// Flush blocks until every buffered entry is written or dropped.
+func (a *Appender) Flush() {
+	a.async.Flush()
+}
+
+// Close writes the buffered entries and closes the connection. If the
+// endpoint is unavailable, the remaining buffered entries are dropped
+// rather than retried.
+func (a *Appender) Close() error {
+	a.w.stop()
+	a.async.Close()
+
+	a.w.Lock()
+	defer a.w.Unlock()
+	if a.w.conn == nil {
+		return nil
+	}
+	err := a.w.conn.Close()
+	a.w.conn = nil
+	return err
+}
+
+// writer writes framed entries to a connection, reconnecting when it
+// fails.
+type writer struct {
+	network string
+	addr    string
+	tls     *tls.Config
+	f       gournal.Formatter
+	framing Framing
+
+	sync.Mutex
+	conn net.Conn
+
+	// down is set once a write fails after the writer is stopped, after
+	// which entries are dropped without attempting to write them
+	down bool
+
+	done chan struct{}
+	once sync.Once
+}
+
+// stop ends retries. Entries are still written while the connection is
+// available.
+func (w *writer) stop() {
+	w.once.Do(func() { close(w.done) })
+}
+
+func (w *writer) isDown() bool {
+	w.Lock()
+	defer w.Unlock()
+	return w.down
+}
+
+func (w *writer) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	frame, err := w.frame(&gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	})
+	if err != nil {
+		gournal.HandleError(err)
+		return
+	}
+
+	backoff := MinBackoff
+	for !w.isDown() {
+		err := w.write(frame)
+		if err == nil {
+			break
+		}
+		gournal.HandleError(err)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+			if backoff *= 2; backoff > MaxBackoff {
+				backoff = MaxBackoff
+			}
+			continue
+		case <-w.done:
+			t.Stop()
+		}
+		w.Lock()
+		w.down = true
+		w.Unlock()
+	}
+	if lvl > gournal.FatalLevel && w.isDown() {
+		gournal.RecordDropped(1)
+		return
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+func (w *writer) frame(rec *gournal.Record) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if w.framing == LengthPrefix {
+		buf.Write(make([]byte, 4))
+	}
+	if err := w.f.Format(buf, rec); err != nil {
+		return nil, err
+	}
+
+	b := buf.Bytes()
+	switch w.framing {
+	case LengthPrefix:
+		b = bytes.TrimSuffix(b, []byte{'\n'})
+		binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	case Newline:
+		if len(b) == 0 || b[len(b)-1] != '\n' {
+			b = append(b, '\n')
+		}
+	}
+	return b, nil
+}
+
+func (w *writer) write(frame []byte) error {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.conn == nil {
+		d := &net.Dialer{Timeout: Timeout}
+		var err error
+		if w.tls != nil {
+			w.conn, err = tls.DialWithDialer(d, w.network, w.addr, w.tls)
+		} else {
+			w.conn, err = d.Dial(w.network, w.addr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	err := w.conn.SetWriteDeadline(time.Now().Add(Timeout))
+	if err == nil {
+		_, err = w.conn.Write(frame)
+	}
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package socket
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestSocketAppenderNewline(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer l.Close()
+
+	a := NewWithOptions(
+		"tcp", l.Addr().String(), nil, gournal.TextFormatter{}, Newline, 10)
+	ctx := newContext(a)
+	gournal.WithField("size", 1).Info(ctx, "Hello %s", "Bob")
+	gournal.Warn(ctx, "Hello Alice")
+
+	conn, err := l.Accept()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for _, exp := range []string{
+		"[INFO] Hello Bob map[size:1]\n",
+		"[WARN] Hello Alice\n",
+	} {
+		line, err := r.ReadString('\n')
+		assert.NoError(t, err)
+		assert.Equal(t, exp, line)
+	}
+	assert.NoError(t, a.Close())
+}
+
+func TestSocketAppenderLengthPrefix(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.Close()
+
+	a := NewWithOptions("udp", conn.LocalAddr().String(), nil,
+		gournal.TextFormatter{}, LengthPrefix, 10)
+	gournal.Info(newContext(a), "Hello Bob")
+	a.Flush()
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if assert.NoError(t, err) && assert.True(t, n > 4) {
+		assert.Equal(t, uint32(n-4), binary.BigEndian.Uint32(buf))
+		assert.Equal(t, "[INFO] Hello Bob", string(buf[4:n]))
+	}
+	assert.NoError(t, a.Close())
+}
+
+func TestSocketAppenderOutage(t *testing.T) {
+	defer func(d time.Duration) { MinBackoff = d }(MinBackoff)
+	MinBackoff = time.Millisecond
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(error) {}
+
+	// reserve an address and close it so the connection is refused
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	// the ring keeps the two most recent entries
+	a := NewWithOptions(
+		"tcp", addr, nil, gournal.TextFormatter{}, LengthPrefix, 2)
+	ctx := newContext(a)
+	for _, name := range []string{"Bob", "Alice", "Mary", "Kim"} {
+		gournal.Info(ctx, "Hello %s", name)
+	}
+
+	if l, err = net.Listen("tcp", addr); err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+	a.Flush()
+	assert.NoError(t, a.Close())
+
+	conn, err := l.Accept()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.Close()
+	var msgs []string
+	for {
+		var n uint32
+		if err := binary.Read(conn, binary.BigEndian, &n); err != nil {
+			break
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			break
+		}
+		msgs = append(msgs, string(buf))
+	}
+	assert.Contains(t, msgs, "[INFO] Hello Kim")
+	assert.NotContains(t, msgs, "[INFO] Hello Alice")
+}
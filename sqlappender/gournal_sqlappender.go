@@ -0,0 +1,284 @@
+// Package sqlappender provides a Gournal Appender that inserts entries
+// into a table with any database/sql driver.
+//
+// Table and column names are not quoted, so they must be valid identifiers
+// for the database or already be quoted.
+package sqlappender
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// Columns maps the parts of an entry to the names of the columns in which
+// they are stored. Parts with empty column names are not stored.
+type Columns struct {
+
+	// Time is the column that stores the time of the entry.
+	Time string
+
+	// Level is the column that stores the level of the entry as a string,
+	// ex. "INFO".
+	Level string
+
+	// Message is the column that stores the message of the entry.
+	Message string
+
+	// Fields is the column that stores the fields of the entry, except
+	// those stored in their own columns, as a JSON object.
+	Fields string
+
+	// FieldColumns maps the names of fields to the columns in which their
+	// values are stored as-is.
+	FieldColumns map[string]string
+}
+
+// Placeholder returns the placeholder for the query parameter with the
+// provided one-based index.
+type Placeholder func(i int) string
+
+var (
+	// DefaultColumns are the columns used by an Appender returned by New.
+	DefaultColumns = Columns{
+		Time:    "time",
+		Level:   "level",
+		Message: "message",
+		Fields:  "fields",
+	}
+
+	// Question is the Placeholder used by drivers such as MySQL and SQLite.
+	Question Placeholder = func(int) string { return "?" }
+
+	// Dollar is the Placeholder used by PostgreSQL drivers.
+	Dollar Placeholder = func(i int) string { return "$" + strconv.Itoa(i) }
+
+	// BatchSize is the number of entries inserted at once by an Appender
+	// returned by New.
+	BatchSize = 100
+
+	// Interval is the amount of time after which entries buffered by an
+	// Appender returned by New are inserted.
+	Interval = time.Second
+)
+
+// Appender is a database/sql Appender.
+type Appender struct {
+	db      *sql.DB
+	stmt    *sql.Stmt
+	cols    Columns
+	extra   []string
+	batcher *gournal.Batcher
+}
+
+// New returns an Appender that inserts entries into the provided table
+// using DefaultColumns, the Question placeholder, BatchSize, and Interval.
+func New(db *sql.DB, table string) (*Appender, error) {
+	return NewWithOptions(
+		db, table, DefaultColumns, Question, BatchSize, Interval)
+}
+
+// NewWithOptions returns an Appender that inserts entries into the provided
+// table using the provided columns and placeholder. The insert statement is
+// prepared once; an error is returned if it cannot be prepared.
+//
+// If batchSize is greater than one, entries are buffered and inserted in a
+// transaction once batchSize entries are buffered or, if the interval is
+// greater than zero, each time the interval elapses. Buffered entries are
+// inserted before FATAL and PANIC entries. Batches that cannot be inserted
+// are reported with gournal.HandleError and their entries are counted with
+// gournal.RecordDropped.
+//
+// Otherwise each entry is inserted synchronously. Errors are reported with
+// gournal.HandleError, or returned by TryAppend.
+func NewWithOptions(
+	db *sql.DB,
+	table string,
+	cols Columns,
+	placeholder Placeholder,
+	batchSize int,
+	interval time.Duration) (*Appender, error) {
+
+	a := &Appender{db: db, cols: cols}
+
+	var names []string
+	for _, name := range []string{
+		cols.Time, cols.Level, cols.Message, cols.Fields} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	for k := range cols.FieldColumns {
+		a.extra = append(a.extra, k)
+	}
+	sort.Strings(a.extra)
+	for _, k := range a.extra {
+		names = append(names, cols.FieldColumns[k])
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("sqlappender: no columns")
+	}
+
+	params := make([]string, len(names))
+	for i := range params {
+		params[i] = placeholder(i + 1)
+	}
+	stmt, err := db.Prepare(fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(names, ", "), strings.Join(params, ", ")))
+	if err != nil {
+		return nil, err
+	}
+	a.stmt = stmt
+
+	if batchSize > 1 {
+		a.batcher = gournal.NewBatcher(
+			batchInserter{a}, batchSize, interval)
+	}
+	return a, nil
+}
+
+// Append inserts the entry or, if the Appender is batching, buffers it.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if a.batcher != nil {
+		a.batcher.Append(ctx, lvl, fields, msg)
+		return
+	}
+
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+// TryAppend inserts the entry and returns any error that occurs. If the
+// Appender is batching, the entry is buffered and nil is returned.
+func (a *Appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	if a.batcher != nil {
+		a.batcher.Append(ctx, lvl, fields, msg)
+		return nil
+	}
+
+	args, err := a.args(&gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = a.stmt.Exec(args...)
+	return err
+}
+
+// args returns the values of the insert statement's parameters.
+func (a *Appender) args(rec *gournal.Record) ([]interface{}, error) {
+	var args []interface{}
+	if a.cols.Time != "" {
+		args = append(args, rec.Time)
+	}
+	if a.cols.Level != "" {
+		args = append(args, rec.Level.String())
+	}
+	if a.cols.Message != "" {
+		args = append(args, rec.Message)
+	}
+	if a.cols.Fields != "" {
+		fields := make(map[string]interface{}, len(rec.Fields))
+		for k, v := range rec.Fields {
+			if _, ok := a.cols.FieldColumns[k]; !ok {
+				fields[k] = v
+			}
+		}
+		buf, err := json.Marshal(fields)
+		if err != nil {
+			// store the string form of fields that cannot be marshaled
+			for k, v := range fields {
+				fields[k] = fmt.Sprint(v)
+			}
+			if buf, err = json.Marshal(fields); err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, string(buf))
+	}
+	for _, k := range a.extra {
+		args = append(args, rec.Fields[k])
+	}
+	return args, nil
+}
+
+// Flush inserts any buffered entries.
+func (a *Appender) Flush() {
+	if a.batcher != nil {
+		a.batcher.Flush()
+	}
+}
+
+// Close inserts any buffered entries and closes the prepared statement. The
+// *sql.DB is not closed.
+func (a *Appender) Close() error {
+	if a.batcher != nil {
+		a.batcher.Close()
+	}
+	return a.stmt.Close()
+}
+
+// batchInserter implements gournal.BatchAppender for an Appender's Batcher
+// without exporting AppendBatch from the Appender.
+type batchInserter struct {
+	a *Appender
+}
+
+func (b batchInserter) AppendBatch(recs []gournal.Record) {
+	if err := b.insert(recs); err != nil {
+		gournal.HandleError(err)
+		gournal.RecordDropped(len(recs))
+	}
+}
+
+func (b batchInserter) insert(recs []gournal.Record) error {
+	tx, err := b.a.db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt := tx.Stmt(b.a.stmt)
+	for i := range recs {
+		args, err := b.a.args(&recs[i])
+		if err == nil {
+			_, err = stmt.Exec(args...)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
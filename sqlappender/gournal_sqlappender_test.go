@@ -0,0 +1,194 @@
+package sqlappender
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+var testTime = time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// testDriver records the queries prepared with it and the arguments with
+// which they are executed.
+type testDriver struct {
+	sync.Mutex
+	queries []string
+	execs   [][]driver.Value
+	commits int
+	err     error
+}
+
+var theDriver = &testDriver{}
+
+func init() {
+	sql.Register("gournal-sqlappender-test", theDriver)
+}
+
+func (d *testDriver) reset() {
+	d.Lock()
+	defer d.Unlock()
+	d.queries, d.execs, d.commits, d.err = nil, nil, 0, nil
+}
+
+func (d *testDriver) Open(string) (driver.Conn, error) {
+	return testConn{d}, nil
+}
+
+type testConn struct {
+	d *testDriver
+}
+
+func (c testConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.Lock()
+	defer c.d.Unlock()
+	c.d.queries = append(c.d.queries, query)
+	return testStmt{c.d}, nil
+}
+
+func (c testConn) Close() error {
+	return nil
+}
+
+func (c testConn) Begin() (driver.Tx, error) {
+	return testTx{c.d}, nil
+}
+
+type testTx struct {
+	d *testDriver
+}
+
+func (t testTx) Commit() error {
+	t.d.Lock()
+	defer t.d.Unlock()
+	t.d.commits++
+	return nil
+}
+
+func (t testTx) Rollback() error {
+	return nil
+}
+
+type testStmt struct {
+	d *testDriver
+}
+
+func (s testStmt) Close() error {
+	return nil
+}
+
+func (s testStmt) NumInput() int {
+	return -1
+}
+
+func (s testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.Lock()
+	defer s.d.Unlock()
+	if s.d.err != nil {
+		return nil, s.d.err
+	}
+	s.d.execs = append(s.d.execs, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, driver.ErrSkip
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(), testTime)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func openDB(t *testing.T) *sql.DB {
+	theDriver.reset()
+	db, err := sql.Open("gournal-sqlappender-test", "")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return db
+}
+
+func TestSQLAppender(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	cols := DefaultColumns
+	cols.FieldColumns = map[string]string{"tenant": "tenant_id"}
+	a, err := NewWithOptions(db, "logs", cols, Dollar, 1, 0)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	gournal.WithFields(map[string]interface{}{
+		"tenant": "acme",
+		"size":   1,
+	}).Info(newContext(a), "Hello %s", "Bob")
+	assert.NoError(t, a.Close())
+
+	assert.Equal(t, []string{
+		"INSERT INTO logs (time, level, message, fields, tenant_id) " +
+			"VALUES ($1, $2, $3, $4, $5)",
+	}, theDriver.queries)
+	assert.Equal(t, [][]driver.Value{
+		{testTime, "INFO", "Hello Bob", `{"size":1}`, "acme"},
+	}, theDriver.execs)
+}
+
+func TestSQLAppenderTryAppend(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	a, err := NewWithOptions(db, "logs", Columns{Message: "msg"}, Question, 1, 0)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	assert.Equal(
+		t,
+		[]string{"INSERT INTO logs (msg) VALUES (?)"},
+		theDriver.queries)
+
+	theDriver.err = errors.New("disk full")
+	err = gournal.TryAppend(
+		a, newContext(a), gournal.InfoLevel, nil, "Hello Bob")
+	assert.EqualError(t, err, "disk full")
+}
+
+func TestSQLAppenderNoColumns(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := NewWithOptions(db, "logs", Columns{}, Question, 1, 0)
+	assert.EqualError(t, err, "sqlappender: no columns")
+}
+
+func TestSQLAppenderBatch(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	a, err := NewWithOptions(db, "logs", DefaultColumns, Question, 2, 0)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := newContext(a)
+	gournal.Info(ctx, "Hello Bob")
+	assert.Len(t, theDriver.execs, 0)
+	gournal.Info(ctx, "Hello Alice")
+	assert.Len(t, theDriver.execs, 2)
+	assert.Equal(t, 1, theDriver.commits)
+
+	gournal.Info(ctx, "Hello Mary")
+	assert.NoError(t, a.Close())
+	assert.Len(t, theDriver.execs, 3)
+	assert.Equal(t, 2, theDriver.commits)
+}
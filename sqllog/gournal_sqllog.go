@@ -0,0 +1,447 @@
+//go:build go1.10
+// +build go1.10
+
+// Package sqllog provides a database/sql driver.Connector that logs queries
+// using Gournal.
+//
+// Queries are logged at the DEBUG level along with their arguments, the
+// number of rows returned or affected, and their duration. Queries that take
+// longer than a configurable threshold are logged at the WARN level, and
+// failed queries are logged at the ERROR level. The Context provided to the
+// database/sql package is used to resolve the appender and level.
+//
+// This package requires Go 1.10 or later.
+package sqllog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// Redactor transforms a query's arguments before they are logged. A Redactor
+// may be used to remove sensitive data such as passwords from log entries.
+type Redactor func(query string, args []driver.NamedValue) []interface{}
+
+// New returns a driver.Connector that logs all queries executed with
+// connections from the provided connector.
+func New(c driver.Connector) driver.Connector {
+	return NewWithOptions(c, 0, nil)
+}
+
+// NewWithOptions returns a driver.Connector that logs all queries executed
+// with connections from the provided connector. Queries that take at least as
+// long as the slow threshold are logged at the WARN level. A threshold of zero
+// disables slow query warnings. If redact is nil then query arguments are
+// logged as-is.
+func NewWithOptions(
+	c driver.Connector,
+	slow time.Duration,
+	redact Redactor) driver.Connector {
+
+	if redact == nil {
+		redact = noRedact
+	}
+	return &connector{c: c, l: &logger{slow: slow, redact: redact}}
+}
+
+func noRedact(query string, args []driver.NamedValue) []interface{} {
+	vals := make([]interface{}, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	return vals
+}
+
+type logger struct {
+	slow   time.Duration
+	redact Redactor
+}
+
+func (l *logger) log(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+	rows int64,
+	d time.Duration,
+	err error) {
+
+	if err == driver.ErrSkip {
+		return
+	}
+
+	var (
+		lvl gournal.Level
+		msg string
+	)
+	switch {
+	case err != nil && err != io.EOF:
+		lvl, msg = gournal.ErrorLevel, "query failed"
+	case l.slow > 0 && d >= l.slow:
+		lvl, msg = gournal.WarnLevel, "slow query"
+	default:
+		lvl, msg = gournal.DebugLevel, "query"
+	}
+
+	// the arguments are not redacted unless the entry is logged
+	if !gournal.Enabled(ctx, lvl) {
+		return
+	}
+
+	fields := map[string]interface{}{
+		"query":    query,
+		"duration": d,
+	}
+	if len(args) > 0 {
+		fields["args"] = l.redact(query, args)
+	}
+	if rows >= 0 {
+		fields["rows"] = rows
+	}
+	if lvl == gournal.ErrorLevel {
+		fields[gournal.ErrorKey] = err.Error()
+	}
+	gournal.WithFields(fields).(gournal.LevelEntry).Log(ctx, lvl, msg)
+}
+
+type connector struct {
+	c driver.Connector
+	l *logger
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.c.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, l: c.l}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.c.Driver()
+}
+
+type conn struct {
+	driver.Conn
+	l *logger
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	s, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return newStmt(s, query, c.l), nil
+}
+
+func (c *conn) PrepareContext(
+	ctx context.Context, query string) (driver.Stmt, error) {
+
+	cpc, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	s, err := cpc.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return newStmt(s, query, c.l), nil
+}
+
+func (c *conn) BeginTx(
+	ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+
+	if cbt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return cbt.BeginTx(ctx, opts)
+	}
+
+	// the options are rejected the same way the database/sql package
+	// rejects them for drivers that do not implement driver.ConnBeginTx
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New(
+			"sqllog: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New(
+			"sqllog: driver does not support read-only transactions")
+	}
+	tx, err := c.Conn.Begin()
+	if err == nil {
+		select {
+		case <-ctx.Done():
+			tx.Rollback()
+			return nil, ctx.Err()
+		default:
+		}
+	}
+	return tx, err
+}
+
+func (c *conn) ExecContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue) (driver.Result, error) {
+
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := ec.ExecContext(ctx, query, args)
+	c.l.log(ctx, query, args, rowsAffected(res), time.Since(start), err)
+	return res, err
+}
+
+func (c *conn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue) (driver.Rows, error) {
+
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	r, err := qc.QueryContext(ctx, query, args)
+	d := time.Since(start)
+	if err != nil {
+		c.l.log(ctx, query, args, -1, d, err)
+		return nil, err
+	}
+	return &rows{Rows: r, ctx: ctx, query: query, args: args, d: d, l: c.l}, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	driver.Stmt
+	query string
+	l     *logger
+}
+
+// newStmt returns a stmt that implements driver.ColumnConverter and
+// driver.NamedValueChecker only if the provided statement does, since the
+// database/sql package converts arguments differently when a statement
+// implements them.
+func newStmt(s driver.Stmt, query string, l *logger) driver.Stmt {
+	st := &stmt{Stmt: s, query: query, l: l}
+	_, cc := s.(driver.ColumnConverter)
+	_, nvc := s.(driver.NamedValueChecker)
+	switch {
+	case cc && nvc:
+		return &ccNVCStmt{ccStmt{st}}
+	case cc:
+		return &ccStmt{st}
+	case nvc:
+		return &nvcStmt{st}
+	}
+	return st
+}
+
+// ccStmt is a stmt whose statement implements driver.ColumnConverter.
+type ccStmt struct {
+	*stmt
+}
+
+func (s *ccStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return s.Stmt.(driver.ColumnConverter).ColumnConverter(idx)
+}
+
+// nvcStmt is a stmt whose statement implements driver.NamedValueChecker.
+type nvcStmt struct {
+	*stmt
+}
+
+func (s *nvcStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.Stmt.(driver.NamedValueChecker).CheckNamedValue(nv)
+}
+
+// ccNVCStmt is a stmt whose statement implements both
+// driver.ColumnConverter and driver.NamedValueChecker.
+type ccNVCStmt struct {
+	ccStmt
+}
+
+func (s *ccNVCStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return s.Stmt.(driver.NamedValueChecker).CheckNamedValue(nv)
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(
+	ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+
+	start := time.Now()
+	var (
+		res driver.Result
+		err error
+	)
+	if sec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = sec.ExecContext(ctx, args)
+	} else {
+		res, err = s.Stmt.Exec(values(args))
+	}
+	s.l.log(ctx, s.query, args, rowsAffected(res), time.Since(start), err)
+	return res, err
+}
+
+func (s *stmt) QueryContext(
+	ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+
+	start := time.Now()
+	var (
+		r   driver.Rows
+		err error
+	)
+	if sqc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		r, err = sqc.QueryContext(ctx, args)
+	} else {
+		r, err = s.Stmt.Query(values(args))
+	}
+	d := time.Since(start)
+	if err != nil {
+		s.l.log(ctx, s.query, args, -1, d, err)
+		return nil, err
+	}
+	return &rows{Rows: r, ctx: ctx, query: s.query, args: args, d: d, l: s.l}, nil
+}
+
+// rows counts the number of rows read and logs the query when closed.
+type rows struct {
+	driver.Rows
+	ctx   context.Context
+	query string
+	args  []driver.NamedValue
+	d     time.Duration
+	n     int64
+	err   error
+	l     *logger
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.n++
+	} else if err != io.EOF {
+		r.err = err
+	}
+	return err
+}
+
+// The database/sql package only uses the following functions if the rows
+// implement them, and uses the values they return when the wrapped rows do
+// not implement them otherwise, so the behavior of the wrapped rows is
+// preserved.
+
+func (r *rows) HasNextResultSet() bool {
+	if nrs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return nrs.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *rows) NextResultSet() error {
+	if nrs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return nrs.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if st, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return st.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if tn, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return tn.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *rows) ColumnTypeLength(index int) (int64, bool) {
+	if l, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return l.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *rows) ColumnTypeNullable(index int) (bool, bool) {
+	if n, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return n.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if ps, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return ps.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
+
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	r.l.log(r.ctx, r.query, r.args, r.n, r.d, r.err)
+	return err
+}
+
+func rowsAffected(res driver.Result) int64 {
+	if res == nil {
+		return -1
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return nvs
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	return vals
+}
@@ -0,0 +1,7 @@
+//go:build !go1.10
+// +build !go1.10
+
+// Package sqllog provides a database/sql driver.Connector that logs queries
+// using Gournal. It requires Go 1.10 or later, so with older versions of Go
+// the package is empty.
+package sqllog
@@ -0,0 +1,343 @@
+//go:build go1.10
+// +build go1.10
+
+package sqllog
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestQueryAndExec(t *testing.T) {
+	a := &testAppender{}
+	db := sql.OpenDB(NewWithOptions(
+		&testConnector{},
+		time.Hour,
+		func(query string, args []driver.NamedValue) []interface{} {
+			return []interface{}{"***"}
+		}))
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx(a), "SELECT name FROM planets", "secret")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+
+	_, err = db.ExecContext(ctx(a), "DELETE FROM planets")
+	assert.NoError(t, err)
+
+	if !assert.Len(t, a.entries, 2) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, gournal.DebugLevel, a.entries[0].lvl)
+	assert.Equal(t, "SELECT name FROM planets", a.entries[0].fields["query"])
+	assert.Equal(t, []interface{}{"***"}, a.entries[0].fields["args"])
+	assert.EqualValues(t, 3, a.entries[0].fields["rows"])
+
+	assert.Equal(t, "DELETE FROM planets", a.entries[1].fields["query"])
+	assert.EqualValues(t, 9, a.entries[1].fields["rows"])
+}
+
+func TestSlowQuery(t *testing.T) {
+	a := &testAppender{}
+	db := sql.OpenDB(NewWithOptions(&testConnector{}, time.Nanosecond, nil))
+	defer db.Close()
+
+	_, err := db.ExecContext(ctx(a), "DELETE FROM planets")
+	assert.NoError(t, err)
+	if assert.Len(t, a.entries, 1) {
+		assert.Equal(t, gournal.WarnLevel, a.entries[0].lvl)
+		assert.Equal(t, "slow query", a.entries[0].msg)
+	}
+}
+
+func TestStmtConverters(t *testing.T) {
+	cc := &testCCStmt{}
+	nvc := &testNVCStmt{}
+	both := &testCCNVCStmt{}
+
+	for _, s := range []driver.Stmt{&testStmt{}, cc, nvc, both} {
+		st := newStmt(s, "DELETE FROM planets", &logger{redact: noRedact})
+		_, ok := st.(driver.ColumnConverter)
+		_, sok := s.(driver.ColumnConverter)
+		assert.Equal(t, sok, ok)
+		_, ok = st.(driver.NamedValueChecker)
+		_, sok = s.(driver.NamedValueChecker)
+		assert.Equal(t, sok, ok)
+	}
+
+	for _, s := range []driver.Stmt{cc, nvc, both} {
+		a := &testAppender{}
+		db := sql.OpenDB(New(&testConnector{stmt: s}))
+		_, err := db.ExecContext(ctx(a), "DELETE FROM planets", 1)
+		assert.NoError(t, err)
+		assert.NoError(t, db.Close())
+		assert.Len(t, a.entries, 1)
+	}
+	assert.Equal(t, 1, cc.converted)
+	assert.Equal(t, 1, nvc.checked)
+	assert.Equal(t, 1, both.checked)
+	assert.Equal(t, 0, both.converted)
+}
+
+func TestDisabled(t *testing.T) {
+	redacted := 0
+	db := sql.OpenDB(NewWithOptions(
+		&testConnector{},
+		0,
+		func(query string, args []driver.NamedValue) []interface{} {
+			redacted++
+			return nil
+		}))
+	defer db.Close()
+
+	a := &testAppender{}
+	ctx := context.WithValue(ctx(a), gournal.LevelKey(), gournal.InfoLevel)
+	_, err := db.ExecContext(ctx, "DELETE FROM planets", "secret")
+	assert.NoError(t, err)
+	assert.Empty(t, a.entries)
+	assert.Zero(t, redacted)
+}
+
+func TestBeginTxOptions(t *testing.T) {
+	db := sql.OpenDB(New(&testConnector{}))
+	defer db.Close()
+
+	_, err := db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+	assert.EqualError(
+		t, err, "sqllog: driver does not support read-only transactions")
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+	})
+	assert.EqualError(
+		t, err, "sqllog: driver does not support non-default isolation level")
+}
+
+func TestRowsResultSetsAndColumnTypes(t *testing.T) {
+	a := &testAppender{}
+	db := sql.OpenDB(New(&testConnector{stmt: &testMultiStmt{}}))
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx(a), "SELECT name FROM planets")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cts, err := rows.ColumnTypes()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "TEXT", cts[0].DatabaseTypeName())
+		nullable, ok := cts[0].Nullable()
+		assert.True(t, ok)
+		assert.True(t, nullable)
+	}
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	assert.True(t, rows.NextResultSet())
+	for rows.Next() {
+		n++
+	}
+	assert.False(t, rows.NextResultSet())
+	assert.NoError(t, rows.Err())
+	assert.Equal(t, 5, n)
+	rows.Close()
+	if assert.Len(t, a.entries, 1) {
+		assert.EqualValues(t, 5, a.entries[0].fields["rows"])
+	}
+
+	// rows that do not implement the interfaces behave as if they were not
+	// wrapped
+	rows, err = db.QueryContext(ctx(a), "SELECT name FROM planets", 1)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	cts, err = rows.ColumnTypes()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "", cts[0].DatabaseTypeName())
+		_, ok := cts[0].Nullable()
+		assert.False(t, ok)
+	}
+	for rows.Next() {
+	}
+	assert.False(t, rows.NextResultSet())
+	rows.Close()
+}
+
+func ctx(a gournal.Appender) context.Context {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+type testAppender struct {
+	entries []testEntry
+}
+
+type testEntry struct {
+	lvl    gournal.Level
+	fields map[string]interface{}
+	msg    string
+}
+
+func (a *testAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	a.entries = append(a.entries, testEntry{lvl, fields, msg})
+}
+
+type testConnector struct {
+	stmt driver.Stmt
+}
+
+func (c *testConnector) Connect(context.Context) (driver.Conn, error) {
+	return &testConn{stmt: c.stmt}, nil
+}
+
+func (c *testConnector) Driver() driver.Driver {
+	return nil
+}
+
+type testConn struct {
+	stmt driver.Stmt
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	if c.stmt != nil {
+		return c.stmt, nil
+	}
+	return &testStmt{}, nil
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+type testStmt struct{}
+
+func (s *testStmt) Close() error {
+	return nil
+}
+
+func (s *testStmt) NumInput() int {
+	return -1
+}
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(9), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &testRows{}, nil
+}
+
+type testRows struct {
+	n int
+}
+
+func (r *testRows) Columns() []string {
+	return []string{"name"}
+}
+
+func (r *testRows) Close() error {
+	return nil
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.n == 3 {
+		return io.EOF
+	}
+	r.n++
+	dest[0] = "Venus"
+	return nil
+}
+
+type testCCStmt struct {
+	testStmt
+	converted int
+}
+
+func (s *testCCStmt) NumInput() int {
+	return 1
+}
+
+func (s *testCCStmt) ColumnConverter(idx int) driver.ValueConverter {
+	s.converted++
+	return driver.DefaultParameterConverter
+}
+
+type testNVCStmt struct {
+	testStmt
+	checked int
+}
+
+func (s *testNVCStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	s.checked++
+	return nil
+}
+
+type testCCNVCStmt struct {
+	testCCStmt
+	checked int
+}
+
+func (s *testCCNVCStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	s.checked++
+	return nil
+}
+
+type testMultiStmt struct {
+	testStmt
+}
+
+func (s *testMultiStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if len(args) > 0 {
+		return &testRows{}, nil
+	}
+	return &testMultiRows{}, nil
+}
+
+// testMultiRows returns two result sets of three and two rows.
+type testMultiRows struct {
+	testRows
+	set int
+}
+
+func (r *testMultiRows) HasNextResultSet() bool {
+	return r.set == 0
+}
+
+func (r *testMultiRows) NextResultSet() error {
+	if r.set > 0 {
+		return io.EOF
+	}
+	r.set, r.n = 1, 1
+	return nil
+}
+
+func (r *testMultiRows) ColumnTypeDatabaseTypeName(index int) string {
+	return "TEXT"
+}
+
+func (r *testMultiRows) ColumnTypeNullable(index int) (bool, bool) {
+	return true, true
+}
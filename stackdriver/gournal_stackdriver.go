@@ -0,0 +1,175 @@
+// Package stackdriver provides a Gournal Formatter that formats entries as
+// the JSON objects the logging agents of Google Kubernetes Engine and other
+// Google Cloud runtimes parse from standard output, so entries are leveled
+// and correlated with traces in Cloud Logging without custom parsers:
+//
+//	{"severity":"ERROR","time":"2017-10-01T12:00:00Z","message":"Hello Bob",
+//	 "logging.googleapis.com/trace":"projects/my-project/traces/4bf9...",
+//	 "logging.googleapis.com/spanId":"00f067aa0ba902b7",
+//	 "logging.googleapis.com/sourceLocation":{"file":"main.go","line":"42",
+//	 "function":"main.main"},"size":1}
+//
+// The trace and span IDs are read from the fields added by the propagation
+// and otel packages, and the remaining fields become the entry's JSON
+// payload.
+package stackdriver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+const (
+	traceKey          = "logging.googleapis.com/trace"
+	spanIDKey         = "logging.googleapis.com/spanId"
+	traceSampledKey   = "logging.googleapis.com/trace_sampled"
+	sourceLocationKey = "logging.googleapis.com/sourceLocation"
+)
+
+var (
+	// ProjectID is the Google Cloud project ID with which a Formatter
+	// returned by NewFormatter qualifies trace IDs. The default value is
+	// read from the GOOGLE_CLOUD_PROJECT environment variable.
+	ProjectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+
+	// TraceIDKey is the name of the field that contains the trace ID.
+	TraceIDKey = "trace_id"
+
+	// SpanIDKey is the name of the field that contains the span ID.
+	SpanIDKey = "span_id"
+
+	// TraceFlagsKey is the name of the field that contains the hex-encoded
+	// W3C trace flags, from which the trace's sampling decision is read.
+	TraceFlagsKey = "trace_flags"
+)
+
+// Formatter is a Cloud Logging Formatter.
+type Formatter struct {
+	projectID      string
+	sourceLocation bool
+}
+
+// New returns an Appender that writes entries to w with a Formatter
+// returned by NewFormatter. Entries should be written to os.Stdout to be
+// collected by the logging agent.
+func New(w io.Writer) gournal.Appender {
+	return gournal.NewAppenderWithFormatter(w, NewFormatter())
+}
+
+// NewFormatter returns a Formatter that qualifies trace IDs with ProjectID
+// and writes source locations.
+func NewFormatter() *Formatter {
+	return NewFormatterWithOptions(ProjectID, true)
+}
+
+// NewFormatterWithOptions returns a Formatter that qualifies trace IDs with
+// the provided project ID, if it is not empty, and writes the source
+// location of the log function call if sourceLocation is true.
+//
+// The source location is only available when the Formatter is used by an
+// Appender that formats entries on the goroutine that emitted them, so it
+// is not written for entries delivered by asynchronous Appenders.
+func NewFormatterWithOptions(
+	projectID string, sourceLocation bool) *Formatter {
+
+	return &Formatter{projectID: projectID, sourceLocation: sourceLocation}
+}
+
+// Format writes the Record to the buffer.
+func (f *Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	m := make(gournal.OrderedFields, len(rec.Fields)+6)
+	for k, v := range rec.Fields {
+		switch k {
+		case TraceIDKey, SpanIDKey, TraceFlagsKey:
+			continue
+		}
+		if _, err := json.Marshal(v); err != nil {
+			v = fmt.Sprint(v)
+		}
+		m[k] = v
+	}
+	m["severity"] = Severity(rec.Level)
+	m["message"] = rec.Message
+	if !rec.Time.IsZero() {
+		m["time"] = rec.Time.UTC().Format(time.RFC3339Nano)
+	}
+
+	if v, ok := rec.Fields[TraceIDKey]; ok {
+		if id := fmt.Sprint(v); f.projectID != "" {
+			m[traceKey] = "projects/" + f.projectID + "/traces/" + id
+		} else {
+			m[traceKey] = id
+		}
+	}
+	if v, ok := rec.Fields[SpanIDKey]; ok {
+		m[spanIDKey] = fmt.Sprint(v)
+	}
+	if v, ok := rec.Fields[TraceFlagsKey]; ok {
+		flags, err := strconv.ParseUint(fmt.Sprint(v), 16, 8)
+		m[traceSampledKey] = err == nil && flags&1 == 1
+	}
+
+	if f.sourceLocation {
+		if fr, ok := caller(); ok {
+			m[sourceLocationKey] = map[string]string{
+				"file":     fr.File,
+				"line":     strconv.Itoa(fr.Line),
+				"function": fr.Function,
+			}
+		}
+	}
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(m)
+}
+
+// Severity returns the Cloud Logging severity of the level, or the one
+// returned by gournal.SeverityMapper for the "stackdriver" backend.
+func Severity(lvl gournal.Level) string {
+	if s, ok := gournal.MapSeverityString("stackdriver", lvl); ok {
+		return s
+	}
+	switch lvl {
+	case gournal.DebugLevel:
+		return "DEBUG"
+	case gournal.InfoLevel:
+		return "INFO"
+	case gournal.WarnLevel:
+		return "WARNING"
+	case gournal.ErrorLevel:
+		return "ERROR"
+	case gournal.FatalLevel:
+		return "CRITICAL"
+	case gournal.PanicLevel:
+		return "ALERT"
+	}
+	return "DEFAULT"
+}
+
+// caller returns the frame of the first function on the stack outside of
+// Gournal, or of the first function in a test file.
+func caller() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		fr, more := frames.Next()
+		if !strings.HasPrefix(fr.Function, "github.com/akutz/gournal") ||
+			strings.HasSuffix(fr.File, "_test.go") {
+			return fr, fr.Function != ""
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
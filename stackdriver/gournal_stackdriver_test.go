@@ -0,0 +1,90 @@
+package stackdriver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestFormatter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, NewFormatterWithOptions("proj", false).Format(
+		buf, &gournal.Record{
+			Time:    time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+			Level:   gournal.WarnLevel,
+			Message: "Hello <Bob>",
+			Fields: map[string]interface{}{
+				"trace_id":    "abc",
+				"span_id":     "def",
+				"trace_flags": "01",
+				"size":        1,
+			},
+		}))
+	assert.Equal(t,
+		`{"logging.googleapis.com/spanId":"def",`+
+			`"logging.googleapis.com/trace":"projects/proj/traces/abc",`+
+			`"logging.googleapis.com/trace_sampled":true,`+
+			`"message":"Hello <Bob>","severity":"WARNING","size":1,`+
+			`"time":"2017-10-01T12:00:00Z"}`+"\n",
+		buf.String())
+
+	buf.Reset()
+	assert.NoError(t, NewFormatterWithOptions("", false).Format(
+		buf, &gournal.Record{
+			Level:   gournal.FatalLevel,
+			Message: "Hi",
+			Fields: map[string]interface{}{
+				"trace_id":    "abc",
+				"trace_flags": "00",
+			},
+		}))
+	assert.Equal(t,
+		`{"logging.googleapis.com/trace":"abc",`+
+			`"logging.googleapis.com/trace_sampled":false,`+
+			`"message":"Hi","severity":"CRITICAL"}`+"\n",
+		buf.String())
+}
+
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, "DEBUG", Severity(gournal.DebugLevel))
+	assert.Equal(t, "INFO", Severity(gournal.InfoLevel))
+	assert.Equal(t, "ERROR", Severity(gournal.ErrorLevel))
+	assert.Equal(t, "ALERT", Severity(gournal.PanicLevel))
+	assert.Equal(t, "DEFAULT", Severity(gournal.Level(255)))
+}
+
+func TestNew(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := New(buf)
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	ctx = gournal.WithTime(ctx, time.Unix(0, 0))
+	gournal.WithField("ch", make(chan int)).Error(ctx, "Hello Bob")
+
+	var doc struct {
+		Severity string `json:"severity"`
+		Message  string `json:"message"`
+		Ch       string `json:"ch"`
+		Source   struct {
+			File     string `json:"file"`
+			Line     string `json:"line"`
+			Function string `json:"function"`
+		} `json:"logging.googleapis.com/sourceLocation"`
+	}
+	if !assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc)) {
+		t.FailNow()
+	}
+	assert.Equal(t, "ERROR", doc.Severity)
+	assert.Equal(t, "Hello Bob", doc.Message)
+	assert.Regexp(t, "^0x[0-9a-f]+$", doc.Ch)
+	assert.Regexp(t, `gournal_stackdriver_test\.go$`, doc.Source.File)
+	assert.NotEmpty(t, doc.Source.Line)
+	assert.Equal(t,
+		"github.com/akutz/gournal/stackdriver.TestNew",
+		doc.Source.Function)
+}
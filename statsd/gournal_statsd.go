@@ -0,0 +1,232 @@
+// Package statsd provides a Gournal Appender that emits metrics about log
+// activity to statsd or DogStatsD, so log volume and error rates show up in
+// dashboards before entries reach a log backend.
+//
+// The Appender does not write entries anywhere. It is meant to be used
+// alongside an Appender that does, so it does not exit or panic for FATAL
+// and PANIC entries either.
+package statsd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// Flavor is the statsd dialect used to name and tag metrics.
+type Flavor int
+
+const (
+	// Statsd is the original statsd dialect, which does not support tags.
+	// The level and tag fields are appended to the names of counters, ex.
+	// "gournal.entries.error.component.db".
+	Statsd Flavor = iota
+
+	// DogStatsD is the Datadog dialect, which reports the level and tag
+	// fields as tags, ex. "gournal.entries:1|c|#level:error,component:db".
+	DogStatsD
+)
+
+var (
+	// DefaultFlavor is the Flavor used by an Appender returned by New.
+	DefaultFlavor = DogStatsD
+
+	// Prefix is prepended to the names of the metrics emitted by an
+	// Appender returned by New.
+	Prefix = "gournal."
+
+	// TagKeys are the names of the fields by which an Appender returned by
+	// New tags the entry counters, ex. "component".
+	TagKeys []string
+
+	// TimingKeys are the names of the fields recorded as timings by an
+	// Appender returned by New.
+	TimingKeys []string
+)
+
+// Appender is a statsd Appender.
+type Appender struct {
+	conn       net.Conn
+	flavor     Flavor
+	prefix     string
+	tagKeys    []string
+	timingKeys []string
+}
+
+// New returns an Appender that emits metrics to the statsd server at the
+// provided UDP address using DefaultFlavor, Prefix, TagKeys, and
+// TimingKeys.
+func New(addr string) (*Appender, error) {
+	return NewWithOptions(
+		addr, DefaultFlavor, Prefix, TagKeys, TimingKeys)
+}
+
+// NewWithOptions returns an Appender that emits metrics to the statsd
+// server at the provided UDP address. For each entry, the Appender
+// increments the "entries" counter by level and by the values of the fields
+// named by tagKeys, and records the values of the fields named by
+// timingKeys as timings named after the fields, by the same level and
+// values. Timing values may be
+// time.Durations, strings parsed with time.ParseDuration, or numbers of
+// milliseconds.
+//
+// The metrics of each entry are sent in a single packet. Errors are
+// reported with gournal.HandleError, or returned by TryAppend.
+func NewWithOptions(
+	addr string,
+	flavor Flavor,
+	prefix string,
+	tagKeys, timingKeys []string) (*Appender, error) {
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Appender{
+		conn:       conn,
+		flavor:     flavor,
+		prefix:     prefix,
+		tagKeys:    tagKeys,
+		timingKeys: timingKeys,
+	}, nil
+}
+
+// Append emits the entry's metrics.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if err := a.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+// TryAppend is like Append but returns the error, if any, from sending the
+// entry's metrics.
+func (a *Appender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	_, err := a.conn.Write(a.encode(lvl, fields))
+	return err
+}
+
+// encode returns the packet of the entry's metrics.
+func (a *Appender) encode(
+	lvl gournal.Level, fields map[string]interface{}) []byte {
+
+	tags := []string{"level", strings.ToLower(lvl.String())}
+	for _, k := range a.tagKeys {
+		if v, ok := fields[k]; ok {
+			tags = append(tags, k, fmt.Sprint(v))
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	a.write(buf, "entries", tags, "1|c")
+
+	var timings []string
+	for _, k := range a.timingKeys {
+		if _, ok := fields[k]; ok {
+			timings = append(timings, k)
+		}
+	}
+	sort.Strings(timings)
+	for _, k := range timings {
+		ms, ok := millis(fields[k])
+		if !ok {
+			continue
+		}
+		buf.WriteByte('\n')
+		a.write(buf, k, tags, strconv.FormatFloat(ms, 'f', -1, 64)+"|ms")
+	}
+	return buf.Bytes()
+}
+
+// write writes a metric. Tags are pairs of names and values.
+func (a *Appender) write(
+	buf *bytes.Buffer, name string, tags []string, value string) {
+
+	buf.WriteString(a.prefix)
+	buf.WriteString(sanitize(name))
+	if a.flavor == Statsd {
+		for _, t := range tags[1:] {
+			buf.WriteByte('.')
+			buf.WriteString(strings.Replace(sanitize(t), ".", "_", -1))
+		}
+		buf.WriteByte(':')
+		buf.WriteString(value)
+		return
+	}
+
+	buf.WriteByte(':')
+	buf.WriteString(value)
+	buf.WriteString("|#")
+	for i := 0; i < len(tags); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(sanitize(tags[i]))
+		buf.WriteByte(':')
+		buf.WriteString(sanitize(tags[i+1]))
+	}
+}
+
+// Close closes the connection to the statsd server.
+func (a *Appender) Close() error {
+	return a.conn.Close()
+}
+
+// millis returns the number of milliseconds represented by the value.
+func millis(v interface{}) (float64, bool) {
+	switch tv := v.(type) {
+	case time.Duration:
+		return float64(tv) / float64(time.Millisecond), true
+	case string:
+		d, err := time.ParseDuration(tv)
+		if err != nil {
+			return 0, false
+		}
+		return float64(d) / float64(time.Millisecond), true
+	case int:
+		return float64(tv), true
+	case int32:
+		return float64(tv), true
+	case int64:
+		return float64(tv), true
+	case uint:
+		return float64(tv), true
+	case uint32:
+		return float64(tv), true
+	case uint64:
+		return float64(tv), true
+	case float32:
+		return float64(tv), true
+	case float64:
+		return tv, true
+	}
+	return 0, false
+}
+
+// sanitize replaces the characters that have special meaning in the statsd
+// protocols with underscores.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', '\n', ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,94 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func listen(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return conn
+}
+
+func read(t *testing.T, conn net.PacketConn) string {
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return string(buf[:n])
+}
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := context.WithValue(
+		context.Background(), gournal.LevelKey(), gournal.DebugLevel)
+	return context.WithValue(ctx, gournal.AppenderKey(), a)
+}
+
+func TestStatsd(t *testing.T) {
+	conn := listen(t)
+	defer conn.Close()
+
+	a, err := New(conn.LocalAddr().String())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	gournal.Info(newContext(a), "Hello Bob")
+	assert.Equal(t, "gournal.entries:1|c|#level:info", read(t, conn))
+}
+
+func TestStatsdTagsAndTimings(t *testing.T) {
+	conn := listen(t)
+	defer conn.Close()
+
+	a, err := NewWithOptions(
+		conn.LocalAddr().String(), DogStatsD, "app.",
+		[]string{"component"}, []string{"latency", "elapsed", "size"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	gournal.WithFields(map[string]interface{}{
+		"component": "db:main",
+		"latency":   1500 * time.Microsecond,
+		"elapsed":   "2s",
+		"size":      "large",
+	}).Error(newContext(a), "Hello Bob")
+	assert.Equal(t,
+		"app.entries:1|c|#level:error,component:db_main\n"+
+			"app.elapsed:2000|ms|#level:error,component:db_main\n"+
+			"app.latency:1.5|ms|#level:error,component:db_main",
+		read(t, conn))
+}
+
+func TestStatsdFlavor(t *testing.T) {
+	conn := listen(t)
+	defer conn.Close()
+
+	a, err := NewWithOptions(
+		conn.LocalAddr().String(), Statsd, "app.",
+		[]string{"component"}, []string{"latency"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	gournal.WithFields(map[string]interface{}{
+		"component": "db.main",
+		"latency":   25,
+	}).Warn(newContext(a), "Hello Bob")
+	assert.Equal(t,
+		"app.entries.warn.component.db_main:1|c\n"+
+			"app.latency.warn.component.db_main:25|ms",
+		read(t, conn))
+}
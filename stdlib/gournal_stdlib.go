@@ -5,28 +5,105 @@ package stdlib
 import (
 	"context"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
+	"runtime"
+	"strings"
+	"sync"
 
 	"github.com/akutz/gournal"
 )
 
+// TimeLayout is the layout with which the %time% prefix token is expanded.
+var TimeLayout = "2006/01/02 15:04:05"
+
 // New returns a stdlib logger that implements the Gournal Appender interface.
 func New() gournal.Appender {
 	return &appender{
-		log.New(os.Stdout, "",
+		def: newLogger(os.Stdout, "",
 			log.Ldate|log.Ltime|log.Lmicroseconds|log.Lshortfile),
 	}
 }
 
 // NewWithOptions returns a stdlib logger that implements the Gournal Appender
 // interface.
+//
+// The prefix may contain the following tokens, which are expanded for each
+// entry:
+//
+//	%lvl%     the entry's level, ex. INFO
+//	%time%    the entry's time formatted with TimeLayout
+//	%caller%  the entry's caller formatted with gournal.FormatCaller
+//
+// For example, a prefix of "%time% [%lvl%] %caller%: " with flags of zero
+// writes stdlib-style lines that include the level.
 func NewWithOptions(out io.Writer, prefix string, flags int) gournal.Appender {
-	return &appender{log.New(out, prefix, flags)}
+	return &appender{def: newLogger(out, prefix, flags)}
+}
+
+// NewWithLevels returns a stdlib logger that implements the Gournal Appender
+// interface and is configured like one returned by NewWithOptions, except
+// that entries of the levels in the prefixes map are written with that
+// level's prefix instead, ex. "WARN: ", which may also contain tokens, and
+// entries of the levels in the writers map are written to that level's
+// io.Writer instead of out. A nil io.Writer discards the entries of its
+// level. Either map may be nil.
+func NewWithLevels(
+	out io.Writer,
+	prefix string,
+	flags int,
+	prefixes map[gournal.Level]string,
+	writers map[gournal.Level]io.Writer) gournal.Appender {
+
+	a := &appender{
+		def:    newLogger(out, prefix, flags),
+		levels: map[gournal.Level]*logger{},
+	}
+	for lvl := range prefixes {
+		a.levels[lvl] = nil
+	}
+	for lvl := range writers {
+		a.levels[lvl] = nil
+	}
+	for lvl := range a.levels {
+		w, p := out, prefix
+		if lw, ok := writers[lvl]; ok {
+			if w = lw; w == nil {
+				w = ioutil.Discard
+			}
+		}
+		if lp, ok := prefixes[lvl]; ok {
+			p = lp
+		}
+		a.levels[lvl] = newLogger(w, p, flags)
+	}
+	return a
 }
 
 type appender struct {
-	logger *log.Logger
+	sync.Mutex
+	def *logger
+
+	// levels are the loggers of levels with their own prefix or writer
+	levels map[gournal.Level]*logger
+}
+
+// logger is a stdlib logger and its prefix.
+type logger struct {
+	*log.Logger
+
+	// prefix, if not empty, is the prefix with tokens that are expanded
+	// for each entry
+	prefix string
+}
+
+func newLogger(w io.Writer, prefix string, flags int) *logger {
+	l := &logger{Logger: log.New(w, prefix, flags)}
+	if strings.Contains(prefix, "%") {
+		l.prefix = prefix
+	}
+	return l
 }
 
 func (a *appender) Append(
@@ -35,15 +112,25 @@ func (a *appender) Append(
 	fields map[string]interface{},
 	msg string) {
 
+	l := a.def
+	if ll, ok := a.levels[lvl]; ok {
+		l = ll
+	}
+	if l.prefix != "" {
+		a.Lock()
+		defer a.Unlock()
+		l.SetPrefix(l.expand(ctx, lvl))
+	}
+
 	var logf func(string, ...interface{})
 
 	switch lvl {
 	case gournal.PanicLevel:
-		logf = a.logger.Panicf
+		logf = l.Panicf
 	case gournal.FatalLevel:
-		logf = a.logger.Fatalf
+		logf = l.Fatalf
 	default:
-		logf = a.logger.Printf
+		logf = l.Printf
 	}
 
 	if len(fields) == 0 {
@@ -53,3 +140,41 @@ func (a *appender) Append(
 
 	logf(msg, fields)
 }
+
+// expand returns the prefix with its tokens expanded for the entry.
+func (l *logger) expand(ctx context.Context, lvl gournal.Level) string {
+	p := strings.Replace(l.prefix, "%lvl%", lvl.String(), -1)
+	if strings.Contains(p, "%time%") {
+		p = strings.Replace(
+			p, "%time%", gournal.TimeFrom(ctx).Format(TimeLayout), -1)
+	}
+	if strings.Contains(p, "%caller%") {
+		c := gournal.CallerFrom(ctx)
+		if c == nil {
+			c = caller()
+		}
+		p = strings.Replace(p, "%caller%", gournal.FormatCaller(c), -1)
+	}
+	return p
+}
+
+// caller returns the source location of the first function on the stack
+// outside of Gournal, or of the first function in a test file.
+func caller() *gournal.Caller {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		fr, more := frames.Next()
+		if !strings.HasPrefix(fr.Function, "github.com/akutz/gournal") ||
+			strings.HasSuffix(fr.File, "_test.go") {
+			if fr.Function == "" {
+				return nil
+			}
+			return &gournal.Caller{File: fr.File, Line: fr.Line}
+		}
+		if !more {
+			return nil
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package stdlib
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -42,3 +47,53 @@ func ctx() context.Context {
 	ctx = context.WithValue(ctx, gournal.AppenderKey(), New())
 	return ctx
 }
+
+func TestStdLibAppenderPrefixTokens(t *testing.T) {
+	defer func() { gournal.Clock = time.Now }()
+	gournal.Clock = func() time.Time {
+		return time.Date(2017, 10, 1, 12, 0, 0, 0, time.Local)
+	}
+
+	buf := &bytes.Buffer{}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(),
+		NewWithOptions(buf, "%time% [%lvl%] %caller%: ", 0))
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	gournal.Warn(ctx, "Hello Bob")
+	_, _, line, _ := runtime.Caller(0)
+	assert.Equal(t, fmt.Sprintf(
+		"2017/10/01 12:00:00 [WARN] stdlib/gournal_stdlib_test.go:%d: "+
+			"Hello Bob\n", line-1), buf.String())
+}
+
+func TestStdLibAppenderLevels(t *testing.T) {
+	buf, errBuf := &bytes.Buffer{}, &bytes.Buffer{}
+	a := NewWithLevels(buf, "", 0,
+		map[gournal.Level]string{
+			gournal.WarnLevel:  "WARN: ",
+			gournal.ErrorLevel: "%lvl%: ",
+		},
+		map[gournal.Level]io.Writer{
+			gournal.ErrorLevel: errBuf,
+		})
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+
+	gournal.Info(ctx, "Hello Bob")
+	gournal.Warn(ctx, "Hello Mary")
+	gournal.Error(ctx, "Goodbye Bob")
+	assert.Equal(t, "Hello Bob\nWARN: Hello Mary\n", buf.String())
+	assert.Equal(t, "ERROR: Goodbye Bob\n", errBuf.String())
+}
+
+func TestStdLibAppenderNilLevelWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a := NewWithLevels(buf, "", 0, nil, map[gournal.Level]io.Writer{
+		gournal.DebugLevel: nil,
+	})
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+
+	gournal.Debug(ctx, "Hello Alice")
+	gournal.Info(ctx, "Hello Bob")
+	assert.Equal(t, "Hello Bob\n", buf.String())
+}
@@ -0,0 +1,445 @@
+// Package syslog provides a Gournal Appender that writes entries to a local
+// or remote syslog daemon in either the RFC 3164 (BSD) or RFC 5424 format,
+// and a Formatter that formats entries in the RFC 5424 format.
+//
+// Unlike the standard library's log/syslog package, the RFC 5424 format
+// emits an entry's fields as structured data, so daemons such as rsyslog
+// and syslog-ng can index them.
+package syslog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+// Format is a syslog message format.
+type Format uint8
+
+const (
+	// RFC3164 is the BSD syslog format. Fields are appended to the message.
+	RFC3164 Format = iota
+
+	// RFC5424 is the IETF syslog format. Fields are emitted as structured
+	// data.
+	RFC5424
+)
+
+// Facility is a syslog facility.
+type Facility uint8
+
+// These are the syslog facilities.
+const (
+	Kern Facility = iota
+	User
+	Mail
+	Daemon
+	Auth
+	Syslog
+	LPR
+	News
+	UUCP
+	Cron
+	AuthPriv
+	FTP
+	_
+	_
+	_
+	_
+	Local0
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+var (
+	// DefaultFormat is the format used by an Appender returned by New.
+	DefaultFormat = RFC3164
+
+	// DefaultFacility is the facility used by an Appender returned by New.
+	DefaultFacility = User
+
+	// AppName is the application name used by an Appender returned by
+	// New. The default value is the base name of the program.
+	AppName = filepath.Base(os.Args[0])
+
+	// StructuredDataID is the SD-ID of the structured data element that
+	// contains an entry's fields in the RFC 5424 format.
+	StructuredDataID = "gournal@32473"
+
+	// MessageIDKey is the name of the field whose value is the MSGID of an
+	// entry in the RFC 5424 format. The field is not included in the
+	// structured data.
+	MessageIDKey = "msgid"
+
+	// AppNameKey is the name of the field whose value, if any, is the
+	// APP-NAME of an entry in the RFC 5424 format, or its tag in the RFC
+	// 3164 format, instead of the application name, ex. "component". The
+	// field is not included in the structured data. If AppNameKey is empty,
+	// the application name is always used.
+	AppNameKey = ""
+
+	// NamedFacilities are the facilities of entries emitted by named
+	// loggers, keyed by the name stored in the Context with
+	// gournal.NameKey.
+	NamedFacilities map[string]Facility
+
+	// LevelFacilities are the facilities of entries of each level that are
+	// not emitted by a logger in NamedFacilities.
+	LevelFacilities map[gournal.Level]Facility
+
+	// ErrNoLocalSyslog is returned by New when a local syslog daemon is not
+	// found.
+	ErrNoLocalSyslog = errors.New("syslog: local syslog not found")
+)
+
+// localPaths are the paths of the sockets on which local syslog daemons
+// listen.
+var localPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// Appender is a syslog Appender.
+type Appender struct {
+	network  string
+	addr     string
+	format   Format
+	facility Facility
+	appName  string
+	hostname string
+	pid      int
+	sdID     string
+	msgIDKey string
+
+	appNameKey      string
+	namedFacilities map[string]Facility
+	levelFacilities map[gournal.Level]Facility
+
+	sync.Mutex
+	conn net.Conn
+}
+
+// New returns an Appender that writes to the local syslog daemon using
+// DefaultFormat, DefaultFacility, AppName, StructuredDataID, and
+// MessageIDKey.
+//
+// The AppNameKey, NamedFacilities, and LevelFacilities at the time an
+// Appender or Formatter is created apply to all of the package's Appenders
+// and Formatters. Entries of loggers and levels without a facility use the
+// facility with which the Appender or Formatter was created.
+func New() (*Appender, error) {
+	return NewWithOptions(
+		"", "", DefaultFormat, DefaultFacility, AppName)
+}
+
+// NewWithOptions returns an Appender that writes to the syslog daemon at the
+// provided address, for example "udp" and "logs.example.com:514", using
+// StructuredDataID and MessageIDKey. If the network is empty, the local
+// syslog daemon's Unix socket is used.
+//
+// If a write fails, the connection is re-established and the write is
+// attempted again once. Errors are reported with gournal.HandleError.
+func NewWithOptions(
+	network, addr string,
+	format Format,
+	facility Facility,
+	appName string) (*Appender, error) {
+
+	return NewWithStructuredData(
+		network, addr, format, facility, appName,
+		StructuredDataID, MessageIDKey)
+}
+
+// NewWithStructuredData is like NewWithOptions but, in the RFC 5424
+// format, emits an entry's fields in a structured data element with the
+// provided SD-ID, and the value of the field named by msgIDKey, if any, as
+// the entry's MSGID.
+func NewWithStructuredData(
+	network, addr string,
+	format Format,
+	facility Facility,
+	appName string,
+	sdID string,
+	msgIDKey string) (*Appender, error) {
+
+	a := newAppender(format, facility, appName, sdID, msgIDKey)
+	a.network = network
+	a.addr = addr
+	if err := a.connect(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func newAppender(
+	format Format,
+	facility Facility,
+	appName string,
+	sdID string,
+	msgIDKey string) *Appender {
+
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	a := &Appender{
+		format:          format,
+		facility:        facility,
+		appName:         appName,
+		hostname:        hostname,
+		pid:             os.Getpid(),
+		sdID:            paramName(sdID),
+		msgIDKey:        msgIDKey,
+		appNameKey:      AppNameKey,
+		namedFacilities: map[string]Facility{},
+		levelFacilities: map[gournal.Level]Facility{},
+	}
+	for k, v := range NamedFacilities {
+		a.namedFacilities[k] = v
+	}
+	for k, v := range LevelFacilities {
+		a.levelFacilities[k] = v
+	}
+	return a
+}
+
+func (a *Appender) connect() error {
+	if a.network != "" {
+		conn, err := net.Dial(a.network, a.addr)
+		if err != nil {
+			return err
+		}
+		a.conn = conn
+		return nil
+	}
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range localPaths {
+			if conn, err := net.Dial(network, path); err == nil {
+				a.conn = conn
+				return nil
+			}
+		}
+	}
+	return ErrNoLocalSyslog
+}
+
+// Append writes the entry to the syslog daemon.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	buf := a.encode(
+		gournal.TimeFrom(ctx), gournal.NameFrom(ctx), lvl, fields, msg)
+	if err := a.write(buf); err != nil {
+		gournal.HandleError(err)
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+func (a *Appender) write(buf []byte) error {
+	a.Lock()
+	defer a.Unlock()
+
+	if a.conn != nil {
+		if _, err := a.conn.Write(buf); err == nil {
+			return nil
+		}
+		a.conn.Close()
+		a.conn = nil
+	}
+	if err := a.connect(); err != nil {
+		return err
+	}
+	_, err := a.conn.Write(buf)
+	return err
+}
+
+// Close closes the connection to the syslog daemon.
+func (a *Appender) Close() error {
+	a.Lock()
+	defer a.Unlock()
+	if a.conn == nil {
+		return nil
+	}
+	err := a.conn.Close()
+	a.conn = nil
+	return err
+}
+
+func (a *Appender) encode(
+	t time.Time,
+	name string,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) []byte {
+
+	buf := &bytes.Buffer{}
+	pri := int(a.facilityOf(name, lvl))*8 + severity(lvl)
+
+	appName := a.appName
+	if v, ok := fields[a.appNameKey]; ok && a.appNameKey != "" {
+		appName = headerValue(fmt.Sprint(v))
+	}
+
+	if a.format == RFC5424 {
+		msgID := "-"
+		if v, ok := fields[a.msgIDKey]; ok {
+			msgID = headerValue(fmt.Sprint(v))
+		}
+		fmt.Fprintf(buf, "<%d>1 %s %s %s %d %s ",
+			pri,
+			t.Format("2006-01-02T15:04:05.000000Z07:00"),
+			a.hostname, nilValue(appName), a.pid, msgID)
+		a.writeStructuredData(buf, fields)
+		buf.WriteByte(' ')
+		buf.WriteString(msg)
+	} else {
+		fmt.Fprintf(buf, "<%d>%s %s %s[%d]: %s",
+			pri, t.Format(time.Stamp), a.hostname, appName, a.pid, msg)
+		if len(fields) > 0 {
+			buf.WriteString(" map[")
+			for i, k := range gournal.SortKeys(fields) {
+				if i > 0 {
+					buf.WriteByte(' ')
+				}
+				fmt.Fprintf(buf, "%s:%v", k, fields[k])
+			}
+			buf.WriteByte(']')
+		}
+	}
+
+	if !strings.HasPrefix(a.network, "tcp") {
+		return buf.Bytes()
+	}
+
+	// messages sent over TCP are framed with octet counting, as described
+	// in RFC 6587
+	return append(
+		[]byte(strconv.Itoa(buf.Len())+" "), buf.Bytes()...)
+}
+
+// facilityOf returns the facility of the named logger, if any, otherwise
+// that of the level, if any, otherwise the Appender's facility.
+func (a *Appender) facilityOf(name string, lvl gournal.Level) Facility {
+	if f, ok := a.namedFacilities[name]; ok && name != "" {
+		return f
+	}
+	if f, ok := a.levelFacilities[lvl]; ok {
+		return f
+	}
+	return a.facility
+}
+
+// writeStructuredData writes the fields, other than the MSGID and APP-NAME,
+// as an RFC 5424 structured data element, or the nil value if there are
+// none. Values are escaped as required by the RFC.
+func (a *Appender) writeStructuredData(
+	buf *bytes.Buffer, fields map[string]interface{}) {
+
+	keys := gournal.SortKeys(fields)
+	for i := 0; i < len(keys); i++ {
+		if keys[i] == a.msgIDKey ||
+			(keys[i] == a.appNameKey && a.appNameKey != "") {
+			keys = append(keys[:i], keys[i+1:]...)
+			i--
+		}
+	}
+	if len(keys) == 0 {
+		buf.WriteByte('-')
+		return
+	}
+
+	buf.WriteByte('[')
+	buf.WriteString(a.sdID)
+	for _, k := range keys {
+		buf.WriteByte(' ')
+		buf.WriteString(paramName(k))
+		buf.WriteString(`="`)
+		for _, c := range fmt.Sprint(fields[k]) {
+			switch c {
+			case '"', '\\', ']':
+				buf.WriteByte('\\')
+			}
+			buf.WriteRune(c)
+		}
+		buf.WriteByte('"')
+	}
+	buf.WriteByte(']')
+}
+
+// paramName returns a valid SD-NAME, which is at most 32 printable ASCII
+// characters other than '=', ' ', ']', and '"'.
+func paramName(s string) string {
+	b := []byte(s)
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	for i, c := range b {
+		if c <= ' ' || c > '~' || c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// headerValue returns a valid header field, such as a MSGID, which is at
+// most 32 printable ASCII characters other than ' ', or the nil value if s
+// is empty.
+func headerValue(s string) string {
+	b := []byte(s)
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+	return nilValue(string(b))
+}
+
+func nilValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func severity(lvl gournal.Level) int {
+	if n, ok := gournal.MapSeverityInt("syslog", lvl); ok {
+		return n
+	}
+	switch lvl {
+	case gournal.PanicLevel:
+		return 1
+	case gournal.FatalLevel:
+		return 2
+	case gournal.ErrorLevel:
+		return 3
+	case gournal.WarnLevel:
+		return 4
+	case gournal.InfoLevel:
+		return 6
+	}
+	return 7
+}
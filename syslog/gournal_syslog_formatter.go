@@ -0,0 +1,45 @@
+package syslog
+
+import (
+	"bytes"
+
+	"github.com/akutz/gournal"
+)
+
+// Formatter formats entries as RFC 5424 syslog messages, one per line, so
+// they may be written by Appenders other than the syslog Appender, such as
+// the one returned by gournal.NewAppenderWithFormatter.
+type Formatter struct {
+	a *Appender
+}
+
+// NewFormatter returns a Formatter that uses DefaultFacility, AppName,
+// StructuredDataID, and MessageIDKey.
+func NewFormatter() *Formatter {
+	return NewFormatterWithOptions(
+		DefaultFacility, AppName, StructuredDataID, MessageIDKey)
+}
+
+// NewFormatterWithOptions returns a Formatter that formats entries with the
+// provided facility and application name, subject to AppNameKey,
+// NamedFacilities, and LevelFacilities, emits their fields in a
+// structured data element with the provided SD-ID, and emits the value of
+// the field named by msgIDKey, if any, as their MSGID.
+func NewFormatterWithOptions(
+	facility Facility,
+	appName string,
+	sdID string,
+	msgIDKey string) *Formatter {
+
+	return &Formatter{
+		a: newAppender(RFC5424, facility, appName, sdID, msgIDKey),
+	}
+}
+
+// Format writes the Record to the buffer.
+func (f *Formatter) Format(buf *bytes.Buffer, rec *gournal.Record) error {
+	buf.Write(f.a.encode(
+		rec.Time, rec.Name, rec.Level, rec.Fields, rec.Message))
+	buf.WriteByte('\n')
+	return nil
+}
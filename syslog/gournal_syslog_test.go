@@ -0,0 +1,236 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+var testTime = time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := gournal.WithTime(context.Background(), testTime)
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func TestSyslogEncode(t *testing.T) {
+	a := &Appender{
+		facility: Local0,
+		appName:  "app",
+		hostname: "host",
+		pid:      42,
+		sdID:     "gournal@32473",
+		msgIDKey: "msgid",
+	}
+	fields := map[string]interface{}{
+		"size":    1,
+		"a b=\"]": `x"y]\`,
+	}
+
+	assert.Equal(
+		t,
+		"<132>Oct  1 12:00:00 host app[42]: Hello Bob "+
+			"map[a b=\"]:x\"y]\\ size:1]",
+		string(a.encode(
+			testTime, "", gournal.WarnLevel, fields, "Hello Bob")))
+
+	a.format = RFC5424
+	assert.Equal(
+		t,
+		`<131>1 2017-10-01T12:00:00.000000Z host app 42 - `+
+			`[gournal@32473 a_b___="x\"y\]\\" size="1"] Hello Bob`,
+		string(a.encode(
+			testTime, "", gournal.ErrorLevel, fields, "Hello Bob")))
+
+	fields["msgid"] = "volume attach"
+	assert.Equal(
+		t,
+		`<131>1 2017-10-01T12:00:00.000000Z host app 42 volume_attach `+
+			`[gournal@32473 a_b___="x\"y\]\\" size="1"] Hello Bob`,
+		string(a.encode(
+			testTime, "", gournal.ErrorLevel, fields, "Hello Bob")))
+
+	a.network = "tcp"
+	assert.Equal(
+		t,
+		"56 <134>1 2017-10-01T12:00:00.000000Z host app 42 - - Hello",
+		string(a.encode(testTime, "", gournal.InfoLevel, nil, "Hello")))
+}
+
+func TestSyslogAppenderUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer conn.Close()
+
+	a, err := NewWithOptions(
+		"udp", conn.LocalAddr().String(), RFC5424, Daemon, "app")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+
+	gournal.WithField("size", 1).Info(newContext(a), "Hello %s", "Bob")
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if assert.NoError(t, err) {
+		msg := string(buf[:n])
+		assert.True(t, strings.HasPrefix(msg, "<30>1 2017-10-01T12:00:00"))
+		assert.True(t, strings.HasSuffix(
+			msg, ` app `+strconv.Itoa(a.pid)+
+				` - [gournal@32473 size="1"] Hello Bob`))
+	}
+}
+
+func TestSyslogAppenderTCPReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer l.Close()
+
+	a, err := NewWithOptions(
+		"tcp", l.Addr().String(), RFC3164, User, "app")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	ctx := newContext(a)
+
+	// the server closes the first connection, so the second entry is
+	// written on a new one
+	conn, err := l.Accept()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	conn.Close()
+
+	done := make(chan string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			close(done)
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString(']')
+		done <- line
+	}()
+
+	// writes to a closed connection may not fail until the peer's reset
+	// is received, so keep writing until an entry arrives
+	for i := 0; i < 100; i++ {
+		gournal.Info(ctx, "Hello Bob")
+		select {
+		case line := <-done:
+			assert.Contains(t, line, "<14>Oct  1 12:00:00")
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("entry was not written after reconnecting")
+}
+
+func TestSyslogFormatter(t *testing.T) {
+	f := NewFormatterWithOptions(Local7, "app", "app@32473", "event")
+	f.a.hostname = "host"
+	f.a.pid = 42
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    testTime,
+		Level:   gournal.InfoLevel,
+		Message: "Hello Bob",
+		Fields:  map[string]interface{}{"event": "attach"},
+	}))
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    testTime,
+		Level:   gournal.WarnLevel,
+		Message: "Hello Alice",
+		Fields:  map[string]interface{}{"size": 1},
+	}))
+	assert.Equal(t,
+		"<190>1 2017-10-01T12:00:00.000000Z host app 42 attach - "+
+			"Hello Bob\n"+
+			"<188>1 2017-10-01T12:00:00.000000Z host app 42 - "+
+			"[app@32473 size=\"1\"] Hello Alice\n",
+		buf.String())
+}
+
+func TestSyslogFacilityMapping(t *testing.T) {
+	defer func() {
+		AppNameKey, NamedFacilities, LevelFacilities = "", nil, nil
+	}()
+	AppNameKey = "component"
+	NamedFacilities = map[string]Facility{"db": Local1}
+	LevelFacilities = map[gournal.Level]Facility{gournal.ErrorLevel: Local2}
+
+	f := NewFormatterWithOptions(Local7, "app", "app@32473", "event")
+	f.a.hostname = "host"
+	f.a.pid = 42
+	AppNameKey, NamedFacilities, LevelFacilities = "", nil, nil
+
+	fields := map[string]interface{}{"component": "api server", "size": 1}
+	buf := &bytes.Buffer{}
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    testTime,
+		Level:   gournal.ErrorLevel,
+		Message: "Hello Bob",
+		Fields:  fields,
+	}))
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    testTime,
+		Level:   gournal.ErrorLevel,
+		Message: "Hello Alice",
+		Name:    "db",
+	}))
+	assert.NoError(t, f.Format(buf, &gournal.Record{
+		Time:    testTime,
+		Level:   gournal.InfoLevel,
+		Message: "Hello Mary",
+	}))
+	assert.Equal(t,
+		"<147>1 2017-10-01T12:00:00.000000Z host api_server 42 - "+
+			"[app@32473 size=\"1\"] Hello Bob\n"+
+			"<139>1 2017-10-01T12:00:00.000000Z host app 42 - - "+
+			"Hello Alice\n"+
+			"<190>1 2017-10-01T12:00:00.000000Z host app 42 - - "+
+			"Hello Mary\n",
+		buf.String())
+
+	f.a.format = RFC3164
+	assert.Equal(t,
+		"<147>Oct  1 12:00:00 host api_server[42]: Hello Bob "+
+			"map[component:api server size:1]",
+		string(f.a.encode(
+			testTime, "", gournal.ErrorLevel, fields, "Hello Bob")))
+}
+
+func TestSyslogSeverityMapper(t *testing.T) {
+	defer func() { gournal.SeverityMapper = nil }()
+	gournal.SeverityMapper = gournal.SeverityTable{
+		"syslog": {gournal.InfoLevel: 5},
+	}.Severity
+
+	a := &Appender{facility: User, appName: "app", hostname: "host", pid: 42}
+	assert.Equal(t,
+		"<13>Oct  1 12:00:00 host app[42]: Hello",
+		string(a.encode(testTime, "", gournal.InfoLevel, nil, "Hello")))
+	assert.Equal(t,
+		"<12>Oct  1 12:00:00 host app[42]: Hello",
+		string(a.encode(testTime, "", gournal.WarnLevel, nil, "Hello")))
+}
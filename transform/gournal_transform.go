@@ -0,0 +1,96 @@
+// Package transform provides a Gournal Appender that rewrites the fields of
+// entries before delegating them to another Appender. This keeps enrichment
+// and cleaning in one composable place rather than in each Appender.
+package transform
+
+import (
+	"context"
+
+	"github.com/akutz/gournal"
+)
+
+// Func rewrites an entry's fields in place. The fields map is owned by the
+// Appender and is never nil.
+type Func func(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string)
+
+// New returns an Appender that applies the provided functions, in order, to
+// a copy of each entry's fields before delegating the entry to next. If the
+// resulting fields are empty, next receives a nil map.
+func New(next gournal.Appender, funcs ...Func) gournal.Appender {
+	return &appender{next: next, funcs: funcs}
+}
+
+type appender struct {
+	next  gournal.Appender
+	funcs []Func
+}
+
+func (a *appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	// the fields may be shared with the caller, so they are copied
+	m := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		m[k] = v
+	}
+	for _, f := range a.funcs {
+		f(ctx, lvl, m, msg)
+	}
+	if len(m) == 0 {
+		m = nil
+	}
+	a.next.Append(ctx, lvl, m, msg)
+}
+
+// Rename returns a Func that renames the field with the old key, if any, to
+// the new key.
+func Rename(oldKey, newKey string) Func {
+	return func(
+		ctx context.Context,
+		lvl gournal.Level,
+		fields map[string]interface{},
+		msg string) {
+
+		if v, ok := fields[oldKey]; ok {
+			delete(fields, oldKey)
+			fields[newKey] = v
+		}
+	}
+}
+
+// Drop returns a Func that removes the fields with the provided keys.
+func Drop(keys ...string) Func {
+	return func(
+		ctx context.Context,
+		lvl gournal.Level,
+		fields map[string]interface{},
+		msg string) {
+
+		for _, k := range keys {
+			delete(fields, k)
+		}
+	}
+}
+
+// Set returns a Func that sets the field with the provided key to the value
+// returned by fn.
+func Set(
+	key string,
+	fn func(ctx context.Context, lvl gournal.Level) interface{}) Func {
+
+	return func(
+		ctx context.Context,
+		lvl gournal.Level,
+		fields map[string]interface{},
+		msg string) {
+
+		fields[key] = fn(ctx, lvl)
+	}
+}
@@ -0,0 +1,51 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+func TestAppender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.WithValue(
+		context.Background(),
+		gournal.AppenderKey(),
+		New(
+			gournal.NewAppenderWithOptions(buf),
+			Rename("usr", "user"),
+			Drop("password", "token"),
+			Set("level", func(
+				ctx context.Context, lvl gournal.Level) interface{} {
+				return lvl.String()
+			})))
+
+	fields := map[string]interface{}{
+		"usr":      "bob",
+		"password": "secret",
+	}
+	gournal.WithFields(fields).Error(ctx, "Hello")
+	assert.Equal(
+		t, "[ERROR] Hello map[level:ERROR user:bob]\n", buf.String())
+
+	// the caller's fields are not modified
+	assert.Equal(t, map[string]interface{}{
+		"usr":      "bob",
+		"password": "secret",
+	}, fields)
+}
+
+func TestEmptyFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ctx := context.WithValue(
+		context.Background(),
+		gournal.AppenderKey(),
+		New(gournal.NewAppenderWithOptions(buf), Drop("token")))
+
+	gournal.WithField("token", "abc").Error(ctx, "Hello")
+	assert.Equal(t, "[ERROR] Hello\n", buf.String())
+}
@@ -0,0 +1,461 @@
+// Package wal provides a Gournal Appender that persists entries to an
+// on-disk write-ahead log and asynchronously delivers them to another
+// Appender. Entries that have not been delivered survive process restarts
+// and are delivered once the Appender is created again with the same
+// directory, so logs are not lost during network partitions.
+//
+// Entries are persisted as newline-delimited JSON gournal.Record objects.
+// They are delivered with a Context created by gournal.WithTime, so the
+// values of the Context with which an entry was emitted are not available
+// to the delegate Appender, but its original time and the name of the
+// logger that emitted it are.
+//
+// FATAL and PANIC entries are never persisted. They are delivered directly
+// to the delegate Appender, which is expected to exit or panic.
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/akutz/gournal"
+)
+
+var (
+	// RetryInterval is the amount of time to wait before again attempting
+	// to deliver an entry that the delegate Appender failed to deliver.
+	RetryInterval = time.Second
+
+	// OffsetBatchSize is the number of delivered entries after which the
+	// offset of the next entry to deliver is committed to disk. The offset
+	// is also committed when every entry in the log is delivered and when
+	// the Appender is closed. A larger batch commits the offset less often,
+	// but more entries may be delivered again after a crash.
+	OffsetBatchSize = 100
+
+	// Sync is the SyncPolicy of Appenders created with New.
+	Sync = SyncAlways
+
+	// MaxSize is the maximum size, in bytes, of the logs of Appenders
+	// created with New.
+	MaxSize int64 = 64 * 1024 * 1024
+
+	// ErrFull is reported with gournal.HandleError when an entry is dropped
+	// because the log has reached its maximum size.
+	ErrFull = errors.New("wal: log is full")
+)
+
+// SyncPolicy determines when the log is synced to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways syncs the log after each entry is written and before
+	// Append returns, so an appended entry survives a crash of the host.
+	SyncAlways SyncPolicy = iota
+
+	// SyncNever leaves syncing the log to the operating system, except
+	// when the Appender is closed. Appended entries survive a crash of the
+	// process, but not necessarily of the host.
+	SyncNever
+)
+
+const (
+	logFileName    = "wal.log"
+	offsetFileName = "wal.offset"
+)
+
+// Appender is an Appender backed by a write-ahead log.
+type Appender struct {
+	next       gournal.Appender
+	logPath    string
+	offsetPath string
+	sync       SyncPolicy
+	maxSize    int64
+
+	// mu protects w and size, the size of the log
+	mu   sync.Mutex
+	w    *os.File
+	size int64
+
+	r      *os.File
+	offset int64
+
+	// committed is the offset that was last committed to disk
+	committed int64
+
+	notify chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// New returns an Appender that persists entries to a write-ahead log in the
+// provided directory, which is created if it does not exist, and delivers
+// them to next. Delivery resumes with the first entry that was not
+// delivered by a previous Appender that used the same directory.
+//
+// Delivery failures are detected with gournal.TryAppend. An entry that
+// cannot be delivered is reported with gournal.HandleError and retried after
+// RetryInterval, and subsequent entries are not delivered until it is.
+//
+// The log is synced according to Sync and is limited to MaxSize bytes.
+func New(dir string, next gournal.Appender) (*Appender, error) {
+	return NewWithOptions(dir, next, Sync, MaxSize)
+}
+
+// NewWithOptions returns an Appender like New whose log is synced according
+// to the provided SyncPolicy and is limited to maxSize bytes if maxSize is
+// greater than zero.
+//
+// Entries that would grow the log beyond maxSize are dropped, counted with
+// gournal.RecordDropped, and reported with ErrFull, so the log is bounded
+// while the delegate Appender is unable to deliver entries. Delivered
+// entries are removed from the log once half of maxSize bytes of entries
+// are delivered, as well as whenever every entry is delivered.
+func NewWithOptions(
+	dir string,
+	next gournal.Appender,
+	sync SyncPolicy,
+	maxSize int64) (*Appender, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	logPath := filepath.Join(dir, logFileName)
+	w, err := os.OpenFile(
+		logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := w.Stat()
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	r, err := os.Open(logPath)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	a := &Appender{
+		next:       next,
+		logPath:    logPath,
+		offsetPath: filepath.Join(dir, offsetFileName),
+		sync:       sync,
+		maxSize:    maxSize,
+		w:          w,
+		size:       info.Size(),
+		r:          r,
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+
+	if buf, err := ioutil.ReadFile(a.offsetPath); err == nil {
+		a.offset, _ = strconv.ParseInt(string(bytes.TrimSpace(buf)), 10, 64)
+	}
+	if a.offset < 0 || a.offset > a.size {
+		a.offset = 0
+	}
+	a.committed = a.offset
+
+	a.wg.Add(1)
+	go a.deliver()
+	return a, nil
+}
+
+// Append persists the entry to the write-ahead log. Errors are reported with
+// gournal.HandleError.
+func (a *Appender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if lvl <= gournal.FatalLevel {
+		a.next.Append(ctx, lvl, fields, msg)
+		return
+	}
+
+	rec := &gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	}
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		// persist the string form of fields that cannot be marshaled
+		rec.Fields = make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			rec.Fields[k] = fmt.Sprint(v)
+		}
+		if buf, err = json.Marshal(rec); err != nil {
+			gournal.HandleError(err)
+			return
+		}
+	}
+	buf = append(buf, '\n')
+
+	a.mu.Lock()
+	if a.maxSize > 0 && a.size+int64(len(buf)) > a.maxSize {
+		a.mu.Unlock()
+		gournal.RecordDropped(1)
+		gournal.HandleError(ErrFull)
+		return
+	}
+	n, err := a.w.Write(buf)
+	a.size += int64(n)
+	if err == nil && a.sync == SyncAlways {
+		err = a.w.Sync()
+	}
+	a.mu.Unlock()
+	if err != nil {
+		gournal.HandleError(err)
+		return
+	}
+
+	select {
+	case a.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops delivering entries and closes the write-ahead log. Entries
+// that have not been delivered are delivered by the next Appender created
+// with the same directory.
+func (a *Appender) Close() error {
+	a.once.Do(func() {
+		close(a.done)
+	})
+	a.wg.Wait()
+	a.r.Close()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	err := a.w.Sync()
+	if cerr := a.w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// deliver reads entries from the log and delivers them to the delegate
+// Appender until the Appender is closed.
+func (a *Appender) deliver() {
+	defer a.wg.Done()
+	defer a.commitOffset()
+
+	if _, err := a.r.Seek(a.offset, io.SeekStart); err != nil {
+		gournal.HandleError(err)
+	}
+	br := bufio.NewReader(a.r)
+
+	// delivered is the number of entries delivered since the offset was
+	// committed
+	delivered := 0
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// a partial line is read again once it is complete
+			if len(line) == 0 {
+				a.compact()
+			}
+			a.commitOffset()
+			if _, err := a.r.Seek(a.offset, io.SeekStart); err != nil {
+				gournal.HandleError(err)
+			}
+			br.Reset(a.r)
+			select {
+			case <-a.notify:
+				continue
+			case <-a.done:
+				return
+			}
+		}
+		if err != nil {
+			gournal.HandleError(err)
+			return
+		}
+
+		if !a.deliverLine(line) {
+			return
+		}
+		a.offset += int64(len(line))
+		if delivered++; delivered >= OffsetBatchSize {
+			a.commitOffset()
+			delivered = 0
+		}
+		if a.maxSize > 0 && a.offset >= a.maxSize/2 {
+			a.rewrite()
+			delivered = 0
+			if _, err := a.r.Seek(a.offset, io.SeekStart); err != nil {
+				gournal.HandleError(err)
+			}
+			br.Reset(a.r)
+		}
+	}
+}
+
+// deliverLine delivers the entry in the line to the delegate Appender,
+// retrying until it succeeds. A flag is returned that is false if the
+// Appender was closed before the entry was delivered.
+func (a *Appender) deliverLine(line []byte) bool {
+	var rec gournal.Record
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&rec); err != nil {
+		// a corrupt entry, for example one that was partially written
+		// before a crash, is skipped
+		gournal.HandleError(err)
+		return true
+	}
+
+	ctx := gournal.WithTime(context.Background(), rec.Time)
+	if rec.Name != "" {
+		ctx = context.WithValue(ctx, gournal.NameKey(), rec.Name)
+	}
+	for {
+		err := gournal.TryAppend(
+			a.next, ctx, rec.Level, rec.Fields, rec.Message)
+		if err == nil {
+			return true
+		}
+		gournal.HandleError(err)
+
+		t := time.NewTimer(RetryInterval)
+		select {
+		case <-t.C:
+		case <-a.done:
+			t.Stop()
+			return false
+		}
+	}
+}
+
+// compact truncates the log once every entry in it is delivered.
+func (a *Appender) compact() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.offset == 0 || a.offset != a.size {
+		return
+	}
+	if err := a.w.Truncate(0); err != nil {
+		gournal.HandleError(err)
+		return
+	}
+	a.size, a.offset = 0, 0
+	a.commitOffset()
+}
+
+// rewrite removes the delivered entries from the log by copying the entries
+// that were not delivered to a new log that replaces it. Errors are reported
+// with gournal.HandleError, in which case the log is not changed.
+func (a *Appender) rewrite() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.rewriteLog(); err != nil {
+		gournal.HandleError(err)
+	}
+}
+
+func (a *Appender) rewriteLog() error {
+	tmp := a.logPath + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, io.NewSectionReader(a.r, a.offset, a.size-a.offset))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// the offset is reset before the new log replaces the old one, so a
+	// crash in between causes entries to be delivered again rather than
+	// skipped
+	offset := a.offset
+	a.offset = 0
+	if err := a.writeOffset(); err != nil {
+		a.offset = offset
+		os.Remove(tmp)
+		return err
+	}
+	a.committed = 0
+	if err := os.Rename(tmp, a.logPath); err != nil {
+		a.offset = offset
+		a.commitOffset()
+		os.Remove(tmp)
+		return err
+	}
+
+	w, err := os.OpenFile(a.logPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	r, err := os.Open(a.logPath)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	a.w.Close()
+	a.r.Close()
+	a.w, a.r, a.size = w, r, n
+	return nil
+}
+
+// commitOffset writes the offset to disk if it changed since it was last
+// committed. Errors are reported with gournal.HandleError.
+func (a *Appender) commitOffset() {
+	if a.offset == a.committed {
+		return
+	}
+	if err := a.writeOffset(); err != nil {
+		gournal.HandleError(err)
+		return
+	}
+	a.committed = a.offset
+}
+
+// writeOffset writes the offset to a temporary file that is synced and
+// renamed over the offset file, so a crash does not leave a partially
+// written offset.
+func (a *Appender) writeOffset() error {
+	tmp := a.offsetPath + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(strconv.FormatInt(a.offset, 10))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, a.offsetPath)
+}
@@ -0,0 +1,291 @@
+package wal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akutz/gournal"
+)
+
+type testAppender struct {
+	sync.Mutex
+	err  error
+	msgs []string
+
+	// failMsg is the message of entries that fail to be delivered
+	failMsg string
+
+	recs []gournal.Record
+}
+
+func (a *testAppender) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+	panic("Append called instead of TryAppend")
+}
+
+func (a *testAppender) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+	a.Lock()
+	defer a.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	if msg == a.failMsg {
+		return errors.New("failed")
+	}
+	a.msgs = append(a.msgs, msg)
+	a.recs = append(a.recs, gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+	})
+	return nil
+}
+
+func (a *testAppender) setErr(err error) {
+	a.Lock()
+	defer a.Unlock()
+	a.err = err
+}
+
+func (a *testAppender) waitFor(n int) []string {
+	for i := 0; i < 1000; i++ {
+		a.Lock()
+		if len(a.msgs) >= n {
+			msgs := append([]string(nil), a.msgs...)
+			a.Unlock()
+			return msgs
+		}
+		a.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	a.Lock()
+	defer a.Unlock()
+	return append([]string(nil), a.msgs...)
+}
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gournal-wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestAppender(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	next := &testAppender{}
+	a, err := New(dir, next)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	tm := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	gournal.WithField("size", 1).Error(gournal.WithTime(ctx, tm), "one")
+	gournal.Error(ctx, "two")
+	assert.Equal(t, []string{"one", "two"}, next.waitFor(2))
+
+	next.Lock()
+	assert.True(t, tm.Equal(next.recs[0].Time))
+	assert.Equal(t, "1", next.recs[0].Fields["size"].(fmt.Stringer).String())
+	next.Unlock()
+
+	assert.NoError(t, a.Close())
+
+	// every entry was delivered, so the log is compacted
+	info, err := os.Stat(filepath.Join(dir, logFileName))
+	if assert.NoError(t, err) {
+		assert.Zero(t, info.Size())
+	}
+}
+
+func TestAppenderRestart(t *testing.T) {
+	defer func(d time.Duration) { RetryInterval = d }(RetryInterval)
+	RetryInterval = time.Millisecond
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(error) {}
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	next := &testAppender{}
+	next.setErr(errors.New("partitioned"))
+	a, err := New(dir, next)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	gournal.Error(ctx, "one")
+	gournal.Error(ctx, "two")
+	time.Sleep(time.Millisecond * 10)
+	assert.NoError(t, a.Close())
+	assert.Empty(t, next.msgs)
+
+	next.setErr(nil)
+	a, err = New(dir, next)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	assert.Equal(t, []string{"one", "two"}, next.waitFor(2))
+}
+
+func TestAppenderOffsetBatch(t *testing.T) {
+	defer func(d time.Duration) { RetryInterval = d }(RetryInterval)
+	RetryInterval = time.Millisecond
+	defer func(n int) { OffsetBatchSize = n }(OffsetBatchSize)
+	OffsetBatchSize = 2
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(error) {}
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	next := &testAppender{failMsg: "four"}
+	a, err := New(dir, next)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		gournal.Error(ctx, msg)
+	}
+	assert.Equal(t, []string{"one", "two", "three"}, next.waitFor(3))
+
+	// the offset is committed after the first two entries, but not after
+	// the third until the Appender is closed
+	buf, err := ioutil.ReadFile(filepath.Join(dir, logFileName))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	lines := bytes.SplitAfter(buf, []byte("\n"))
+	offset := func() string {
+		buf, _ := ioutil.ReadFile(filepath.Join(dir, offsetFileName))
+		return string(buf)
+	}
+	assert.Equal(t, strconv.Itoa(len(lines[0])+len(lines[1])), offset())
+
+	assert.NoError(t, a.Close())
+	assert.Equal(t,
+		strconv.Itoa(len(lines[0])+len(lines[1])+len(lines[2])), offset())
+	_, err = os.Stat(filepath.Join(dir, offsetFileName+".tmp"))
+	assert.True(t, os.IsNotExist(err))
+
+	// only the entry that was not delivered is delivered after a restart
+	next = &testAppender{}
+	a, err = New(dir, next)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	assert.Equal(t, []string{"four"}, next.waitFor(1))
+}
+
+func TestAppenderMaxSize(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		handled []error
+	)
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, err)
+	}
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	next := &testAppender{}
+	next.setErr(errors.New("partitioned"))
+	a, err := NewWithOptions(dir, next, SyncNever, 256)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+
+	dropped := gournal.DroppedCount()
+	for i := 0; i < 10; i++ {
+		gournal.Error(ctx, "entry")
+	}
+	assert.NoError(t, a.Close())
+
+	// the entries that do not fit are dropped rather than written
+	info, err := os.Stat(filepath.Join(dir, logFileName))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.True(t, info.Size() > 0)
+	assert.True(t, info.Size() <= 256)
+	assert.True(t, gournal.DroppedCount() > dropped)
+	mu.Lock()
+	assert.Contains(t, handled, ErrFull)
+	mu.Unlock()
+}
+
+func TestAppenderRewrite(t *testing.T) {
+	defer func(d time.Duration) { RetryInterval = d }(RetryInterval)
+	RetryInterval = time.Millisecond
+	defer func(h func(error)) { gournal.ErrorHandler = h }(
+		gournal.ErrorHandler)
+	gournal.ErrorHandler = func(error) {}
+
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	next := &testAppender{failMsg: "entry-99"}
+	a, err := NewWithOptions(dir, next, SyncAlways, 64*1024)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.WithValue(context.Background(), gournal.AppenderKey(), a)
+	pad := string(bytes.Repeat([]byte("x"), 512))
+	for i := 0; i < 100; i++ {
+		gournal.WithField("pad", pad).Error(ctx, fmt.Sprintf("entry-%02d", i))
+	}
+	assert.Len(t, next.waitFor(99), 99)
+
+	// the entries delivered before the log reached half of its maximum
+	// size are removed, but the entry that was not delivered is kept
+	buf, err := ioutil.ReadFile(filepath.Join(dir, logFileName))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NotContains(t, string(buf), "entry-00")
+	assert.Contains(t, string(buf), "entry-99")
+	assert.NoError(t, a.Close())
+	_, err = os.Stat(filepath.Join(dir, logFileName+".tmp"))
+	assert.True(t, os.IsNotExist(err))
+
+	next = &testAppender{}
+	a, err = New(dir, next)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer a.Close()
+	assert.Equal(t, []string{"entry-99"}, next.waitFor(1))
+}
@@ -0,0 +1,275 @@
+// Package websocket provides Gournal Appenders that stream entries as JSON
+// gournal.Record messages over WebSockets, for live views of a program's
+// logs such as a "tail -f" page in an administrative UI.
+//
+// A Hub is an Appender and an http.Handler that broadcasts entries to the
+// WebSocket clients connected to it. A Remote is an Appender that sends
+// entries to a remote WebSocket endpoint.
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	xws "golang.org/x/net/websocket"
+
+	"github.com/akutz/gournal"
+)
+
+// ClientBuffer is the number of messages buffered for each client of a Hub
+// returned by NewHub.
+var ClientBuffer = 256
+
+// LevelParam is the name of the query parameter with which a client of a
+// Hub selects the least severe level of the entries it receives.
+const LevelParam = "level"
+
+// Hub is an Appender that broadcasts entries to WebSocket clients.
+//
+// A Hub accepts WebSocket connections from any origin, so it should be
+// served behind the same authentication as the rest of an administrative
+// UI.
+type Hub struct {
+	next gournal.Appender
+	size int
+
+	sync.RWMutex
+	clients map[*client]struct{}
+}
+
+type client struct {
+	lvl  gournal.Level
+	msgs chan []byte
+	conn *xws.Conn
+}
+
+// NewHub returns a Hub that delivers entries to next after broadcasting
+// them, using ClientBuffer. If next is nil, entries are only broadcast.
+func NewHub(next gournal.Appender) *Hub {
+	return NewHubWithOptions(next, ClientBuffer)
+}
+
+// NewHubWithOptions returns a Hub that delivers entries to next after
+// broadcasting them and buffers up to size messages for each client. When
+// a client does not keep up and its buffer is full, entries are dropped
+// for that client and counted with gournal.RecordDropped, so slow clients
+// never block the program. If next is nil, entries are only broadcast.
+func NewHubWithOptions(next gournal.Appender, size int) *Hub {
+	if next == nil {
+		next = gournal.Discard
+	}
+	if size < 1 {
+		size = 1
+	}
+	return &Hub{next: next, size: size, clients: map[*client]struct{}{}}
+}
+
+// Append broadcasts the entry to the clients whose level includes it and
+// then delivers it to the next Appender.
+func (h *Hub) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	h.RLock()
+	if len(h.clients) > 0 {
+		buf, err := encode(ctx, lvl, fields, msg)
+		if err != nil {
+			gournal.HandleError(err)
+		}
+		for c := range h.clients {
+			if err != nil || lvl > c.lvl {
+				continue
+			}
+			select {
+			case c.msgs <- buf:
+			default:
+				gournal.RecordDropped(1)
+			}
+		}
+	}
+	h.RUnlock()
+
+	h.next.Append(ctx, lvl, fields, msg)
+}
+
+// Len returns the number of connected clients.
+func (h *Hub) Len() int {
+	h.RLock()
+	defer h.RUnlock()
+	return len(h.clients)
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection and streams
+// entries to it until the client disconnects. The level query parameter,
+// ex. ?level=warn, selects the least severe level of the entries the
+// client receives. By default a client receives every entry.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lvl := gournal.DebugLevel
+	if v := r.URL.Query().Get(LevelParam); v != "" {
+		if lvl = gournal.ParseLevel(v); lvl == gournal.UnknownLevel {
+			http.Error(
+				w, fmt.Sprintf("invalid level: %s", v), http.StatusBadRequest)
+			return
+		}
+	}
+	xws.Server{Handler: func(conn *xws.Conn) {
+		h.serve(conn, lvl)
+	}}.ServeHTTP(w, r)
+}
+
+func (h *Hub) serve(conn *xws.Conn, lvl gournal.Level) {
+	c := &client{lvl: lvl, msgs: make(chan []byte, h.size), conn: conn}
+	h.Lock()
+	h.clients[c] = struct{}{}
+	h.Unlock()
+
+	defer func() {
+		h.Lock()
+		delete(h.clients, c)
+		h.Unlock()
+		conn.Close()
+	}()
+
+	// the client does not send messages, so reading only detects when it
+	// disconnects
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		close(closed)
+	}()
+
+	for {
+		select {
+		case buf := <-c.msgs:
+			if err := xws.Message.Send(conn, string(buf)); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// Close disconnects every client.
+func (h *Hub) Close() error {
+	h.RLock()
+	defer h.RUnlock()
+	for c := range h.clients {
+		c.conn.Close()
+	}
+	return nil
+}
+
+// Remote is an Appender that sends entries to a remote WebSocket endpoint.
+type Remote struct {
+	url    string
+	origin string
+
+	sync.Mutex
+	conn *xws.Conn
+}
+
+// NewRemote returns a Remote that sends entries to the WebSocket endpoint at
+// the provided URL, ex. "wss://logs.example.com/ingest", with the provided
+// origin. The connection is established when the first entry is sent.
+func NewRemote(url, origin string) *Remote {
+	return &Remote{url: url, origin: origin}
+}
+
+// Append sends the entry. Errors are reported with gournal.HandleError.
+func (r *Remote) Append(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) {
+
+	if err := r.TryAppend(ctx, lvl, fields, msg); err != nil {
+		gournal.HandleError(err)
+	}
+
+	switch lvl {
+	case gournal.FatalLevel:
+		os.Exit(1)
+	case gournal.PanicLevel:
+		panic(msg)
+	}
+}
+
+// TryAppend sends the entry and returns any error that occurs. If the
+// connection fails, it is re-established and the entry is sent again once.
+func (r *Remote) TryAppend(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) error {
+
+	buf, err := encode(ctx, lvl, fields, msg)
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	for attempt := 0; ; attempt++ {
+		if r.conn == nil {
+			if r.conn, err = xws.Dial(r.url, "", r.origin); err != nil {
+				return err
+			}
+		}
+		if err = xws.Message.Send(r.conn, string(buf)); err == nil {
+			return nil
+		}
+		r.conn.Close()
+		r.conn = nil
+		if attempt > 0 {
+			return err
+		}
+	}
+}
+
+// Close closes the connection to the remote endpoint.
+func (r *Remote) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+func encode(
+	ctx context.Context,
+	lvl gournal.Level,
+	fields map[string]interface{},
+	msg string) ([]byte, error) {
+
+	rec := &gournal.Record{
+		Time:    gournal.TimeFrom(ctx),
+		Level:   lvl,
+		Message: msg,
+		Fields:  fields,
+		Name:    gournal.NameFrom(ctx),
+		Caller:  gournal.CallerFrom(ctx),
+	}
+	buf, err := json.Marshal(rec)
+	if err == nil {
+		return buf, nil
+	}
+
+	// use the string form of fields that cannot be marshaled
+	rec.Fields = make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		rec.Fields[k] = fmt.Sprint(v)
+	}
+	return json.Marshal(rec)
+}
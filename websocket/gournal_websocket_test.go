@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	xws "golang.org/x/net/websocket"
+
+	"github.com/akutz/gournal"
+	"github.com/akutz/gournal/gournaltest"
+)
+
+func newContext(a gournal.Appender) context.Context {
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.DebugLevel)
+	ctx = context.WithValue(ctx, gournal.AppenderKey(), a)
+	return ctx
+}
+
+func dial(t *testing.T, srv *httptest.Server, query string) *xws.Conn {
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/" + query
+	conn, err := xws.Dial(url, "", "http://localhost/")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return conn
+}
+
+func receive(t *testing.T, conn *xws.Conn) *gournal.Record {
+	var buf string
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if !assert.NoError(t, xws.Message.Receive(conn, &buf)) {
+		t.FailNow()
+	}
+	rec := &gournal.Record{}
+	assert.NoError(t, json.Unmarshal([]byte(buf), rec))
+	return rec
+}
+
+func waitForClients(h *Hub, n int) {
+	for i := 0; i < 100 && h.Len() != n; i++ {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHub(t *testing.T) {
+	next := gournaltest.New()
+	h := NewHub(next)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	all := dial(t, srv, "")
+	defer all.Close()
+	warn := dial(t, srv, "?level=warn")
+	defer warn.Close()
+	waitForClients(h, 2)
+
+	ctx := newContext(h)
+	gournal.WithField("size", 1).Info(ctx, "Hello %s", "Bob")
+	gournal.Warn(ctx, "Hello Alice")
+
+	rec := receive(t, all)
+	assert.Equal(t, "Hello Bob", rec.Message)
+	assert.Equal(t, gournal.InfoLevel, rec.Level)
+	assert.Equal(t, "Hello Alice", receive(t, all).Message)
+	assert.Equal(t, "Hello Alice", receive(t, warn).Message)
+	assert.Len(t, next.Entries(), 2)
+
+	all.Close()
+	waitForClients(h, 1)
+	assert.Equal(t, 1, h.Len())
+
+	assert.NoError(t, h.Close())
+	waitForClients(h, 0)
+	assert.Equal(t, 0, h.Len())
+}
+
+func TestHubInvalidLevel(t *testing.T) {
+	srv := httptest.NewServer(NewHub(nil))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/?level=loud")
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	}
+}
+
+func TestHubSlowClient(t *testing.T) {
+	h := NewHubWithOptions(nil, 1)
+	c := &client{lvl: gournal.DebugLevel, msgs: make(chan []byte, 1)}
+	h.clients[c] = struct{}{}
+
+	ctx := newContext(h)
+	gournal.Info(ctx, "Hello Bob")
+	gournal.Info(ctx, "Hello Alice")
+	assert.Len(t, c.msgs, 1)
+	assert.Contains(t, string(<-c.msgs), "Hello Bob")
+}
+
+func TestRemote(t *testing.T) {
+	msgs := make(chan string, 10)
+	srv := httptest.NewServer(xws.Handler(func(conn *xws.Conn) {
+		for {
+			var buf string
+			if err := xws.Message.Receive(conn, &buf); err != nil {
+				return
+			}
+			msgs <- buf
+		}
+	}))
+	defer srv.Close()
+
+	r := NewRemote(
+		"ws"+strings.TrimPrefix(srv.URL, "http"), "http://localhost/")
+	defer r.Close()
+	gournal.Info(newContext(r), "Hello Bob")
+
+	select {
+	case buf := <-msgs:
+		assert.Contains(t, buf, `"msg":"Hello Bob"`)
+	case <-time.After(time.Second):
+		t.Fatal("entry was not sent")
+	}
+}
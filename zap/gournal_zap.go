@@ -5,26 +5,65 @@ package zap
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
 	"time"
 
-	"github.com/uber-go/zap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	"github.com/akutz/gournal"
 )
 
 type appender struct {
-	logger zap.Logger
+	logger *zap.Logger
 }
 
-// New returns a logrus logger that implements the Gournal Appender interface.
+// New returns a zap logger that implements the Gournal Appender interface
+// and writes entries to os.Stdout as JSON with zap's production encoder
+// configuration. Entries are not filtered by zap, so every entry that
+// passes Gournal's level check is written.
 func New() gournal.Appender {
-	return &appender{zap.New(zap.NewJSONEncoder())}
+	return NewWithOptions(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), os.Stdout)
 }
 
 // NewWithOptions returns a zap logger that implements the Gournal Appender
-// interface.
-func NewWithOptions(enc zap.Encoder, opts ...zap.Option) gournal.Appender {
-	return &appender{zap.New(enc, opts...)}
+// interface and writes entries encoded with the provided encoder to the
+// provided writer. The options, such as zap.AddCaller and Sampling, are
+// applied to the zap logger.
+func NewWithOptions(
+	enc zapcore.Encoder,
+	w io.Writer,
+	opts ...zap.Option) gournal.Appender {
+
+	return NewWithCore(
+		zapcore.NewCore(enc, zapcore.AddSync(w), zapcore.DebugLevel),
+		opts...)
+}
+
+// NewWithCore returns a zap logger that implements the Gournal Appender
+// interface and writes entries to the provided core.
+func NewWithCore(core zapcore.Core, opts ...zap.Option) gournal.Appender {
+	return NewWithLogger(zap.New(core, opts...))
+}
+
+// NewWithLogger returns an Appender that writes entries to the provided zap
+// logger.
+func NewWithLogger(logger *zap.Logger) gournal.Appender {
+	return &appender{logger}
+}
+
+// Sampling returns a zap option that samples entries the same way as
+// zapcore.NewSampler: of the entries with the same level and message
+// written during each tick, the first ones are written, and after that
+// every thereafter entry.
+func Sampling(tick time.Duration, first, thereafter int) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewSampler(core, tick, first, thereafter)
+	})
 }
 
 func (a *appender) Append(
@@ -33,59 +72,118 @@ func (a *appender) Append(
 	fields map[string]interface{},
 	msg string) {
 
-	zapLvl := lvlTranslator[lvl]
-
-	if len(fields) == 0 {
-		a.logger.Log(zapLvl, msg)
+	ce := a.logger.Check(lvlTranslator[lvl], msg)
+	if ce == nil {
 		return
 	}
 
-	zapFields := make([]zap.Field, len(fields))
-
-	i := 0
-	for k, v := range fields {
-		switch tv := v.(type) {
-		case zap.LogMarshaler:
-			zapFields[i] = zap.Marshaler(k, tv)
-		case bool:
-			zapFields[i] = zap.Bool(k, tv)
-		case []byte:
-			zapFields[i] = zap.Base64(k, tv)
-		case float64:
-			zapFields[i] = zap.Float64(k, tv)
-		case int:
-			zapFields[i] = zap.Int(k, tv)
-		case int64:
-			zapFields[i] = zap.Int64(k, tv)
-		case uint:
-			zapFields[i] = zap.Uint(k, tv)
-		case uint64:
-			zapFields[i] = zap.Uint64(k, tv)
-		case string:
-			zapFields[i] = zap.String(k, tv)
-		case fmt.Stringer:
-			zapFields[i] = zap.String(k, tv.String())
-		case time.Time:
-			zapFields[i] = zap.Time(k, tv)
-		case error:
-			zapFields[i] = zap.Error(tv)
-		case time.Duration:
-			zapFields[i] = zap.Duration(k, tv)
-		default:
-			zapFields[i] = zap.Object(k, tv)
+	ce.Entry.Time = gournal.TimeFrom(ctx)
+	if name := gournal.NameFrom(ctx); name != "" {
+		if ce.Entry.LoggerName == "" {
+			ce.Entry.LoggerName = name
+		} else {
+			ce.Entry.LoggerName += "." + name
 		}
+	}
 
-		i++
+	// the caller found by zap.AddCaller is a frame inside Gournal, so it
+	// is replaced with the caller captured by Gournal or the first frame
+	// outside of it
+	if c := gournal.CallerFrom(ctx); c != nil {
+		ce.Entry.Caller = zapcore.EntryCaller{
+			Defined: true, File: c.File, Line: c.Line}
+	} else if ce.Entry.Caller.Defined {
+		ce.Entry.Caller = caller()
 	}
 
-	a.logger.Log(zapLvl, msg, zapFields...)
+	var zapFields []zapcore.Field
+	if len(fields) > 0 {
+		zapFields = make([]zapcore.Field, 0, len(fields))
+		for _, k := range gournal.SortKeys(fields) {
+			zapFields = append(zapFields, toField(k, fields[k]))
+		}
+	}
+
+	ce.Write(zapFields...)
+}
+
+// toField returns a zap field of the type that matches the value's, so the
+// encoder does not have to fall back to reflection.
+func toField(k string, v interface{}) zapcore.Field {
+	switch tv := v.(type) {
+	case zapcore.ObjectMarshaler:
+		return zap.Object(k, tv)
+	case zapcore.ArrayMarshaler:
+		return zap.Array(k, tv)
+	case bool:
+		return zap.Bool(k, tv)
+	case []byte:
+		return zap.Binary(k, tv)
+	case float32:
+		return zap.Float32(k, tv)
+	case float64:
+		return zap.Float64(k, tv)
+	case int:
+		return zap.Int(k, tv)
+	case int8:
+		return zap.Int8(k, tv)
+	case int16:
+		return zap.Int16(k, tv)
+	case int32:
+		return zap.Int32(k, tv)
+	case int64:
+		return zap.Int64(k, tv)
+	case uint:
+		return zap.Uint(k, tv)
+	case uint8:
+		return zap.Uint8(k, tv)
+	case uint16:
+		return zap.Uint16(k, tv)
+	case uint32:
+		return zap.Uint32(k, tv)
+	case uint64:
+		return zap.Uint64(k, tv)
+	case string:
+		return zap.String(k, tv)
+	case time.Time:
+		return zap.Time(k, tv)
+	case time.Duration:
+		return zap.Duration(k, tv)
+	case error:
+		return zap.NamedError(k, tv)
+	case fmt.Stringer:
+		return zap.Stringer(k, tv)
+	}
+	return zap.Any(k, v)
+}
+
+// caller returns the source location of the first function on the stack
+// outside of Gournal, or of the first function in a test file.
+func caller() zapcore.EntryCaller {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		fr, more := frames.Next()
+		if !strings.HasPrefix(fr.Function, "github.com/akutz/gournal") ||
+			strings.HasSuffix(fr.File, "_test.go") {
+			if fr.Function == "" {
+				return zapcore.EntryCaller{}
+			}
+			return zapcore.EntryCaller{
+				Defined: true, File: fr.File, Line: fr.Line}
+		}
+		if !more {
+			return zapcore.EntryCaller{}
+		}
+	}
 }
 
-var lvlTranslator = map[gournal.Level]zap.Level{
-	gournal.DebugLevel: zap.DebugLevel,
-	gournal.InfoLevel:  zap.InfoLevel,
-	gournal.WarnLevel:  zap.WarnLevel,
-	gournal.ErrorLevel: zap.ErrorLevel,
-	gournal.FatalLevel: zap.FatalLevel,
-	gournal.PanicLevel: zap.PanicLevel,
+var lvlTranslator = map[gournal.Level]zapcore.Level{
+	gournal.DebugLevel: zapcore.DebugLevel,
+	gournal.InfoLevel:  zapcore.InfoLevel,
+	gournal.WarnLevel:  zapcore.WarnLevel,
+	gournal.ErrorLevel: zapcore.ErrorLevel,
+	gournal.FatalLevel: zapcore.FatalLevel,
+	gournal.PanicLevel: zapcore.PanicLevel,
 }
@@ -2,9 +2,16 @@ package zap
 
 import (
 	"context"
+	"errors"
+	"net/url"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/akutz/gournal"
 )
@@ -36,6 +43,128 @@ func TestZapAppenderPanic(t *testing.T) {
 	gournal.Panic(ctx(), "Hello %s", "Bob")
 }
 
+func TestZapAppenderFieldTypes(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := context.WithValue(ctx(), gournal.AppenderKey(), NewWithCore(core))
+
+	u, _ := url.Parse("https://localhost/")
+	gournal.WithFields(map[string]interface{}{
+		"bool":     true,
+		"bytes":    []byte("hi"),
+		"duration": time.Second,
+		"error":    errors.New("oops"),
+		"float":    1.5,
+		"int":      1,
+		"string":   "hi",
+		"stringer": u,
+		"time":     time.Unix(0, 0),
+		"uint":     uint(1),
+		"other":    map[string]int{"a": 1},
+	}).Info(ctx, "Hello %s", "Bob")
+
+	entries := logs.AllUntimed()
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Hello Bob", entries[0].Message)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+
+	types := map[string]zapcore.FieldType{}
+	for _, f := range entries[0].Context {
+		types[f.Key] = f.Type
+	}
+	assert.Equal(t, map[string]zapcore.FieldType{
+		"bool":     zapcore.BoolType,
+		"bytes":    zapcore.BinaryType,
+		"duration": zapcore.DurationType,
+		"error":    zapcore.ErrorType,
+		"float":    zapcore.Float64Type,
+		"int":      zapcore.Int64Type,
+		"string":   zapcore.StringType,
+		"stringer": zapcore.StringerType,
+		"time":     zapcore.TimeType,
+		"uint":     zapcore.Uint64Type,
+		"other":    zapcore.ReflectType,
+	}, types)
+}
+
+func TestZapAppenderTime(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := context.WithValue(ctx(), gournal.AppenderKey(), NewWithCore(core))
+
+	ts := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	gournal.Info(gournal.WithTime(ctx, ts), "Hello %s", "Bob")
+
+	entries := logs.All()
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	assert.True(t, ts.Equal(entries[0].Time))
+}
+
+func TestZapAppenderSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := context.WithValue(ctx(), gournal.AppenderKey(),
+		NewWithCore(core, Sampling(time.Minute, 2, 3)))
+
+	for i := 0; i < 8; i++ {
+		gournal.Info(ctx, "Hello %s", "Bob")
+	}
+	gournal.Info(ctx, "Hello %s", "Alice")
+
+	// the 1st, 2nd, 5th, and 8th entries are written
+	assert.Equal(t, 4, logs.FilterMessage("Hello Bob").Len())
+	assert.Equal(t, 1, logs.FilterMessage("Hello Alice").Len())
+}
+
+func TestZapAppenderCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := context.WithValue(ctx(), gournal.AppenderKey(),
+		NewWithCore(core, zap.AddCaller()))
+
+	gournal.WithField("size", 2).Info(ctx, "Hello %s", "Bob")
+	_, file, line, _ := runtime.Caller(0)
+
+	entries := logs.All()
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	assert.True(t, entries[0].Caller.Defined)
+	assert.Equal(t, file, entries[0].Caller.File)
+	assert.Equal(t, line-1, entries[0].Caller.Line)
+}
+
+func TestZapAppenderNoCaller(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := context.WithValue(ctx(), gournal.AppenderKey(), NewWithCore(core))
+
+	gournal.Info(ctx, "Hello %s", "Bob")
+
+	entries := logs.All()
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	assert.False(t, entries[0].Caller.Defined)
+}
+
+func TestZapAppenderCapturedCaller(t *testing.T) {
+	gournal.CaptureCaller = true
+	defer func() { gournal.CaptureCaller = false }()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := context.WithValue(ctx(), gournal.AppenderKey(), NewWithCore(core))
+
+	gournal.Info(ctx, "Hello %s", "Bob")
+	_, file, line, _ := runtime.Caller(0)
+
+	entries := logs.All()
+	if !assert.Len(t, entries, 1) {
+		t.FailNow()
+	}
+	assert.Equal(t, file, entries[0].Caller.File)
+	assert.Equal(t, line-1, entries[0].Caller.Line)
+}
+
 func ctx() context.Context {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, gournal.LevelKey(), gournal.InfoLevel)